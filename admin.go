@@ -0,0 +1,149 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// AdminOptions configures the admin endpoints.
+type AdminOptions struct {
+	// Prefix is the path under which the endpoints are mounted, e.g. "/admin".
+	Prefix string
+	// Authorize reports whether the request may use the admin endpoints.
+	// All requests are refused when it is nil.
+	Authorize func(req *http.Request) bool
+	// Audit is optional middleware recording the admin requests, including
+	// the refused ones, typically created with Router.Audit.
+	Audit func(http.HandlerFunc) http.HandlerFunc
+}
+
+// adminRouteRequest is the body of the enable and disable endpoints.
+type adminRouteRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Status is the status of disabled routes, 404 or 503 (the default).
+	Status int `json:"status"`
+}
+
+// EnableAdmin mounts the admin endpoints for runtime route management:
+//
+//	GET  {prefix}/routes             lists the routes
+//	POST {prefix}/routes/disable     disables routes, body {"method": ..., "path": ..., "status": ...}
+//	POST {prefix}/routes/enable      enables routes, body {"method": ..., "path": ...}
+//	GET  {prefix}/limits             returns the in-flight cap
+//	PUT  {prefix}/limits             sets the in-flight cap, body {"max_in_flight": ...}
+//	GET  {prefix}/maintenance        returns the maintenance mode
+//	PUT  {prefix}/maintenance        sets the maintenance mode, body {"enabled": ...}
+//	GET  {prefix}/webhooks/failures  lists the failed webhook deliveries
+//
+// The admin endpoints stay available in maintenance mode. It returns an
+// error, adding no route, if the routes cannot be added, see AddRoute.
+func (r *Router) EnableAdmin(opts AdminOptions) error {
+	guard := func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			if opts.Authorize == nil || !opts.Authorize(req) {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			handler(w, req)
+		}
+	}
+	var keys []routeKey
+	var handlers []http.HandlerFunc
+	add := func(method string, path string, handler http.HandlerFunc) {
+		keys = append(keys, routeKey{method, opts.Prefix + path})
+		handlers = append(handlers, guard(handler))
+	}
+
+	add("GET", "/routes", func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, r.Routes())
+	})
+	add("POST", "/routes/disable", r.adminSetRoute(func(body adminRouteRequest) (int, error) {
+		if body.Status == 0 {
+			body.Status = http.StatusServiceUnavailable
+		}
+		return r.DisableWithStatus(body.Method, body.Path, body.Status)
+	}))
+	add("POST", "/routes/enable", r.adminSetRoute(func(body adminRouteRequest) (int, error) {
+		return r.Enable(body.Method, body.Path), nil
+	}))
+
+	add("GET", "/limits", func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]int{"max_in_flight": r.maxInFlight(), "in_flight": r.InFlight()})
+	})
+	add("PUT", "/limits", func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			MaxInFlight *int `json:"max_in_flight"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil || body.MaxInFlight == nil || *body.MaxInFlight < 0 {
+			http.Error(w, "invalid max_in_flight", http.StatusBadRequest)
+			return
+		}
+		r.SetMaxInFlight(*body.MaxInFlight)
+		writeJSON(w, http.StatusOK, map[string]int{"max_in_flight": r.maxInFlight()})
+	})
+
+	add("GET", "/maintenance", func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]bool{"enabled": r.Maintenance()})
+	})
+	add("PUT", "/maintenance", func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil || body.Enabled == nil {
+			http.Error(w, "invalid enabled", http.StatusBadRequest)
+			return
+		}
+		r.SetMaintenance(*body.Enabled)
+		writeJSON(w, http.StatusOK, map[string]bool{"enabled": r.Maintenance()})
+	})
+
+	add("GET", "/webhooks/failures", func(w http.ResponseWriter, req *http.Request) {
+		failures := []WebhookDelivery{}
+		if wh, ok := r.webhooks.Load().(*Webhooks); ok {
+			failures = wh.Failures()
+		}
+		writeJSON(w, http.StatusOK, failures)
+	})
+
+	if err := r.validateRoutes(keys...); err != nil {
+		return err
+	}
+	for i, key := range keys {
+		route := r.MustAddRoute(key.method, key.path, handlers[i])
+		route.admin = true
+		if opts.Audit != nil {
+			route.Use(opts.Audit)
+		}
+	}
+	return nil
+}
+
+// adminSetRoute returns a handler enabling or disabling the routes named in
+// the request body.
+func (r *Router) adminSetRoute(set func(body adminRouteRequest) (int, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var body adminRouteRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil || body.Method == "" || body.Path == "" {
+			http.Error(w, "invalid route", http.StatusBadRequest)
+			return
+		}
+		n, err := set(body)
+		if err != nil {
+			http.Error(w, "invalid status", http.StatusBadRequest)
+			return
+		}
+		if n == 0 {
+			http.Error(w, "route not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]int{"routes": n})
+	}
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
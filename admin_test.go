@@ -0,0 +1,173 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAdmin(t *testing.T) {
+	router := NewRouter()
+	router.MustAddRoute("GET", "/hello", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("Hello, World!"))
+	})
+	router.MustAddRoute("GET", "/beta/search", func(w http.ResponseWriter, req *http.Request) {})
+	router.MustAddRoute("POST", "/beta/items/:id", func(w http.ResponseWriter, req *http.Request) {})
+	router.EnableAdmin(AdminOptions{
+		Prefix: "/admin",
+		Authorize: func(req *http.Request) bool {
+			return req.Header.Get("Authorization") == "Bearer secret"
+		},
+	})
+
+	serve := func(method string, path string, body string, authorized bool) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if authorized {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		rr := serve("GET", "/admin/routes", "", false)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status code %d, but got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+
+	t.Run("List routes", func(t *testing.T) {
+		rr := serve("GET", "/admin/routes", "", true)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+
+		var routes []RouteInfo
+		if err := json.Unmarshal(rr.Body.Bytes(), &routes); err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, route := range routes {
+			if route.Method == "GET" && route.Path == "/hello" && route.Enabled {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected GET /hello in the route list, but got %v", routes)
+		}
+	})
+
+	t.Run("Disable and enable route", func(t *testing.T) {
+		rr := serve("POST", "/admin/routes/disable", `{"method": "GET", "path": "/hello"}`, true)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+		if rr := serve("GET", "/hello", "", false); rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status code %d, but got %d", http.StatusServiceUnavailable, rr.Code)
+		}
+
+		serve("POST", "/admin/routes/enable", `{"method": "GET", "path": "/hello"}`, true)
+		if rr := serve("GET", "/hello", "", false); rr.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+
+		rr = serve("POST", "/admin/routes/disable", `{"method": "GET", "path": "/missing"}`, true)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d, but got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+
+	t.Run("Disable routes by pattern", func(t *testing.T) {
+		rr := serve("POST", "/admin/routes/disable", `{"method": "*", "path": "/beta/*", "status": 404}`, true)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+
+		// Check the number of routes disabled
+		if body := strings.TrimSpace(rr.Body.String()); body != `{"routes":2}` {
+			t.Errorf("Expected response body %q, but got %q", `{"routes":2}`, body)
+		}
+		if rr := serve("POST", "/beta/items/7", "", false); rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d, but got %d", http.StatusNotFound, rr.Code)
+		}
+		if rr := serve("GET", "/hello", "", false); rr.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+
+		// Admin routes cannot be disabled
+		if n := router.Disable("*", "/admin/*"); n != 0 {
+			t.Errorf("Expected no admin routes disabled, but got %d", n)
+		}
+
+		rr = serve("POST", "/admin/routes/disable", `{"method": "*", "path": "/beta/*", "status": 500}`, true)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, but got %d", http.StatusBadRequest, rr.Code)
+		}
+
+		serve("POST", "/admin/routes/enable", `{"method": "*", "path": "/beta/*"}`, true)
+		if rr := serve("GET", "/beta/search", "", false); rr.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+	})
+
+	t.Run("Disabled route served as not found", func(t *testing.T) {
+		router := NewRouter()
+		router.MustAddRoute("GET", "/hidden", func(w http.ResponseWriter, req *http.Request) {})
+		router.SetNotFoundHandler(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("custom not found"))
+		})
+		if _, err := router.DisableWithStatus("GET", "/hidden", http.StatusNotFound); err != nil {
+			t.Fatal(err)
+		}
+
+		// Check that the disabled route looks like a missing one
+		for _, path := range []string{"/hidden", "/missing"} {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+			if rr.Code != http.StatusNotFound {
+				t.Errorf("Expected status code %d, but got %d", http.StatusNotFound, rr.Code)
+			}
+			if rr.Body.String() != "custom not found" {
+				t.Errorf("Expected response body %q for %s, but got %q", "custom not found", path, rr.Body.String())
+			}
+		}
+	})
+
+	t.Run("Limits", func(t *testing.T) {
+		rr := serve("PUT", "/admin/limits", `{"max_in_flight": 100}`, true)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+		if limit := router.maxInFlight(); limit != 100 {
+			t.Errorf("Expected an in-flight cap of %d, but got %d", 100, limit)
+		}
+
+		rr = serve("PUT", "/admin/limits", `{"max_in_flight": -1}`, true)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, but got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("Maintenance mode", func(t *testing.T) {
+		serve("PUT", "/admin/maintenance", `{"enabled": true}`, true)
+		if rr := serve("GET", "/hello", "", false); rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status code %d, but got %d", http.StatusServiceUnavailable, rr.Code)
+		}
+
+		// The admin endpoints stay available in maintenance mode
+		rr := serve("PUT", "/admin/maintenance", `{"enabled": false}`, true)
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+		if rr := serve("GET", "/hello", "", false); rr.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+	})
+}
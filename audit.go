@@ -0,0 +1,267 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditRecord records a request to an audited route. Records are chained
+// by hash so that altering, removing or reordering them can be detected
+// with VerifyAuditTrail.
+type AuditRecord struct {
+	Time          time.Time         `json:"time"`
+	CorrelationID string            `json:"correlation_id"`
+	Principal     string            `json:"principal"`
+	Method        string            `json:"method"`
+	Route         string            `json:"route"`
+	Path          string            `json:"path"`
+	PathParams    map[string]string `json:"path_params,omitempty"`
+	Query         url.Values        `json:"query,omitempty"`
+	Status        int               `json:"status"`
+	Outcome       string            `json:"outcome"`
+	PrevHash      string            `json:"prev_hash"`
+	Hash          string            `json:"hash"`
+}
+
+// AuditSink stores audit records, e.g. in a file, a Kafka topic or a
+// remote collector.
+type AuditSink interface {
+	WriteAudit(record AuditRecord) error
+}
+
+// AuditSinkFunc adapts a function to the AuditSink interface.
+type AuditSinkFunc func(record AuditRecord) error
+
+// WriteAudit calls f(record).
+func (f AuditSinkFunc) WriteAudit(record AuditRecord) error {
+	return f(record)
+}
+
+// AuditTrailSink is implemented by sinks that can read back the hash of
+// the last record they stored, so that the chain continues across process
+// restarts instead of starting over.
+type AuditTrailSink interface {
+	AuditSink
+	LastAuditHash() (string, error)
+}
+
+// AuditOptions configures the audit middleware.
+type AuditOptions struct {
+	// Sink stores the records. When it implements AuditTrailSink, the
+	// chain continues from its last record.
+	Sink AuditSink
+	// Principal identifies who made the request, e.g. from a verified
+	// token set in the context by authentication middleware.
+	Principal func(req *http.Request) string
+	// QueueSize is the number of records waiting to be stored before
+	// requests wait for the sink, 1000 by default.
+	QueueSize int
+}
+
+// Audit returns middleware recording who called the routes it is applied
+// to, with which parameters and with what outcome, including requests whose
+// handler panicked. Query parameters are redacted with the router's
+// redactor. Records are chained and stored in the background, in order, so
+// that requests do not wait for the sink; records failing to be stored are
+// logged. Shutdown waits for the queued records, see FlushAudit.
+func (r *Router) Audit(opts AuditOptions) func(http.HandlerFunc) http.HandlerFunc {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1000
+	}
+	queue := make(chan AuditRecord, opts.QueueSize)
+	var once sync.Once
+	start := func() { go r.writeAudit(opts.Sink, queue) }
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			rw := newResponseWriter(w)
+			panicked := true
+			defer func() {
+				record := AuditRecord{
+					Time:          time.Now().UTC(),
+					CorrelationID: r.GetCorrelationID(req),
+					Method:        req.Method,
+					Path:          req.URL.Path,
+					PathParams:    r.GetPathParams(req),
+					Status:        rw.Status(),
+					Outcome:       "success",
+				}
+				if panicked {
+					record.Status = http.StatusInternalServerError
+				}
+				if opts.Principal != nil {
+					record.Principal = opts.Principal(req)
+				}
+				if route := r.GetRoute(req); route != nil {
+					record.Route = route.Path
+				}
+				if query := r.GetQueryParams(req); len(query) > 0 {
+					record.Query = r.Redactor().Query(query)
+				}
+				if record.Status >= 400 {
+					record.Outcome = "failure"
+				}
+
+				once.Do(start)
+				r.auditPending.Add(1)
+				queue <- record
+			}()
+			next(rw, req)
+			panicked = false
+		}
+	}
+}
+
+// writeAudit chains the queued records and stores them, continuing the
+// chain of the sink if it is an AuditTrailSink.
+func (r *Router) writeAudit(sink AuditSink, queue <-chan AuditRecord) {
+	prevHash := ""
+	if trail, ok := sink.(AuditTrailSink); ok {
+		hash, err := trail.LastAuditHash()
+		if err != nil {
+			r.logger.Errorf("Failed to read the last audit record: %v", err)
+		}
+		prevHash = hash
+	}
+
+	for record := range queue {
+		record.PrevHash = prevHash
+		record.Hash = record.computeHash()
+		if err := sink.WriteAudit(record); err != nil {
+			r.logger.Errorf("Failed to write audit record for %s %s: %v", record.Method, record.Path, err)
+		} else {
+			prevHash = record.Hash
+		}
+		r.auditPending.Done()
+	}
+}
+
+// FlushAudit waits until the audit records queued by the Audit middleware
+// are stored, or the context is done.
+func (r *Router) FlushAudit(ctx context.Context) error {
+	flushed := make(chan struct{})
+	go func() {
+		r.auditPending.Wait()
+		close(flushed)
+	}()
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// computeHash returns the hash of the record chained to the previous one.
+func (record AuditRecord) computeHash() string {
+	record.Hash = ""
+	data, _ := json.Marshal(record)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyAuditTrail checks that the records form an unbroken hash chain, in
+// order.
+func VerifyAuditTrail(records []AuditRecord) error {
+	for i, record := range records {
+		if i > 0 && record.PrevHash != records[i-1].Hash {
+			return fmt.Errorf("router: audit record %d is not chained to the previous record", i)
+		}
+		if record.computeHash() != record.Hash {
+			return fmt.Errorf("router: audit record %d has been altered", i)
+		}
+	}
+	return nil
+}
+
+// FileAuditSink appends the records to a file as JSON lines. It is an
+// AuditTrailSink.
+type FileAuditSink struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewFileAuditSink opens the file for appending, creating it if needed.
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileAuditSink{path: path, file: file}, nil
+}
+
+// LastAuditHash returns the hash of the last record of the file, or "" if
+// it is empty.
+func (s *FileAuditSink) LastAuditHash() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return "", err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return "", nil
+	}
+	var record AuditRecord
+	if err := json.Unmarshal(data[bytes.LastIndexByte(data, '\n')+1:], &record); err != nil {
+		return "", fmt.Errorf("router: invalid audit record in %s: %v", s.path, err)
+	}
+	return record.Hash, nil
+}
+
+// WriteAudit appends the record and syncs the file.
+func (s *FileAuditSink) WriteAudit(record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Close closes the file.
+func (s *FileAuditSink) Close() error {
+	return s.file.Close()
+}
+
+// HTTPAuditSink posts the records as JSON to a collector.
+type HTTPAuditSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// WriteAudit posts the record.
+func (s *HTTPAuditSink) WriteAudit(record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Post(s.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	var mu sync.Mutex
+	var records []AuditRecord
+	router := NewRouter()
+	audit := router.Audit(AuditOptions{
+		Sink: AuditSinkFunc(func(record AuditRecord) error {
+			mu.Lock()
+			defer mu.Unlock()
+			records = append(records, record)
+			return nil
+		}),
+		Principal: func(req *http.Request) string {
+			return req.Header.Get("X-User")
+		},
+	})
+	router.EnableAdmin(AdminOptions{
+		Prefix:    "/admin",
+		Authorize: func(req *http.Request) bool { return req.Header.Get("X-User") == "root" },
+		Audit:     audit,
+	})
+	router.MustAddRoute("DELETE", "/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}).Use(audit)
+	router.MustAddRoute("GET", "/public", func(w http.ResponseWriter, req *http.Request) {})
+	router.MustAddRoute("POST", "/panic", func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	}).Use(audit)
+	router.EnableErrorPages(false)
+
+	requests := []struct {
+		method string
+		path   string
+		user   string
+	}{
+		{"DELETE", "/users/42?password=hunter2", "alice"},
+		{"GET", "/public", "alice"},
+		{"PUT", "/admin/maintenance", "mallory"},
+		{"POST", "/panic", "alice"},
+	}
+	for _, r := range requests {
+		req, err := http.NewRequest(r.method, r.path, strings.NewReader(`{"enabled":true}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-User", r.user)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if err := router.FlushAudit(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Records", func(t *testing.T) {
+		if len(records) != 3 {
+			t.Fatalf("Expected 3 audit records, but got %d", len(records))
+		}
+
+		record := records[0]
+		if record.Principal != "alice" || record.Route != "/users/:id" || record.PathParams["id"] != "42" {
+			t.Errorf("Expected alice deleting user 42, but got %+v", record)
+		}
+		if record.Status != http.StatusNoContent || record.Outcome != "success" {
+			t.Errorf("Expected a successful outcome, but got %+v", record)
+		}
+		if record.Query.Get("password") != redacted {
+			t.Errorf("Expected the password to be redacted, but got %q", record.Query.Get("password"))
+		}
+		if record.CorrelationID == "" {
+			t.Errorf("Expected a correlation ID")
+		}
+
+		refused := records[1]
+		if refused.Principal != "mallory" || refused.Status != http.StatusUnauthorized || refused.Outcome != "failure" {
+			t.Errorf("Expected a refused admin request, but got %+v", refused)
+		}
+
+		// Check that panics are recorded as failures
+		if panicked := records[2]; panicked.Status != http.StatusInternalServerError || panicked.Outcome != "failure" {
+			t.Errorf("Expected a failed request, but got %+v", panicked)
+		}
+	})
+
+	t.Run("Tamper evidence", func(t *testing.T) {
+		if err := VerifyAuditTrail(records); err != nil {
+			t.Fatalf("Expected a valid audit trail, but got %v", err)
+		}
+
+		altered := append([]AuditRecord(nil), records...)
+		altered[0].Principal = "bob"
+		if err := VerifyAuditTrail(altered); err == nil {
+			t.Errorf("Expected an error for an altered record")
+		}
+		if err := VerifyAuditTrail(records[1:]); err != nil {
+			t.Errorf("Expected a suffix of the trail to verify, but got %v", err)
+		}
+		if err := VerifyAuditTrail([]AuditRecord{records[1], records[0], records[2]}); err == nil {
+			t.Errorf("Expected an error for reordered records")
+		}
+	})
+
+	t.Run("File sink", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "audit.log")
+		sink, err := NewFileAuditSink(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, record := range records {
+			if err := sink.WriteAudit(record); err != nil {
+				t.Fatal(err)
+			}
+		}
+		sink.Close()
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var read []AuditRecord
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var record AuditRecord
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatal(err)
+			}
+			read = append(read, record)
+		}
+		if err := VerifyAuditTrail(read); err != nil {
+			t.Errorf("Expected the stored trail to verify, but got %v", err)
+		}
+	})
+
+	t.Run("Restarted process", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "audit.log")
+		serve := func() {
+			sink, err := NewFileAuditSink(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sink.Close()
+			router := NewRouter()
+			router.MustAddRoute("DELETE", "/users/:id", func(w http.ResponseWriter, req *http.Request) {}).Use(router.Audit(AuditOptions{Sink: sink}))
+			req, err := http.NewRequest("DELETE", "/users/42", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+			if err := router.FlushAudit(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		read := func() []AuditRecord {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var read []AuditRecord
+			for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+				var record AuditRecord
+				if err := json.Unmarshal([]byte(line), &record); err != nil {
+					t.Fatal(err)
+				}
+				read = append(read, record)
+			}
+			return read
+		}
+
+		serve()
+		serve()
+
+		// Check that the second process continued the chain of the first
+		records := read()
+		if len(records) != 2 {
+			t.Fatalf("Expected 2 audit records, but got %d", len(records))
+		}
+		if err := VerifyAuditTrail(records); err != nil {
+			t.Errorf("Expected the trail to verify across restarts, but got %v", err)
+		}
+	})
+}
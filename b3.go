@@ -0,0 +1,95 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// parseB3Headers parses the X-B3-* headers.
+func parseB3Headers(header http.Header) (*TraceContext, bool) {
+	traceID := strings.ToLower(header.Get("X-B3-TraceId"))
+	spanID := strings.ToLower(header.Get("X-B3-SpanId"))
+	if !validB3ID(traceID, true) || !validB3ID(spanID, false) {
+		return nil, false
+	}
+	tc := &TraceContext{TraceID: traceID, ParentID: spanID, SpanID: newSpanID()}
+	switch {
+	case header.Get("X-B3-Flags") == "1":
+		tc.Flags = 1
+	case header.Get("X-B3-Sampled") == "1" || header.Get("X-B3-Sampled") == "true":
+		tc.Flags = 1
+	case header.Get("X-B3-Sampled") == "":
+		tc.deferred = true
+	}
+	return tc, true
+}
+
+// parseB3 parses a b3 header value of the form
+// "{TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}", where the last two
+// fields are optional.
+func parseB3(value string) (*TraceContext, bool) {
+	fields := strings.Split(strings.ToLower(strings.TrimSpace(value)), "-")
+	if len(fields) < 2 || len(fields) > 4 {
+		return nil, false
+	}
+	if !validB3ID(fields[0], true) || !validB3ID(fields[1], false) {
+		return nil, false
+	}
+	tc := &TraceContext{TraceID: fields[0], ParentID: fields[1], SpanID: newSpanID(), deferred: true}
+	if len(fields) > 2 {
+		switch fields[2] {
+		case "1", "d":
+			tc.Flags = 1
+		case "0":
+		default:
+			return nil, false
+		}
+		tc.deferred = false
+	}
+	return tc, true
+}
+
+// validB3ID reports whether id is a valid B3 span ID, or trace ID, which
+// may also be 32 hex digits long.
+func validB3ID(id string, trace bool) bool {
+	if len(id) != 16 && !(trace && len(id) == 32) {
+		return false
+	}
+	return isLowerHex(id) && strings.Trim(id, "0") != ""
+}
+
+// setB3Headers sets the X-B3-* headers of an outgoing request.
+func (tc *TraceContext) setB3Headers(header http.Header) {
+	header.Set("X-B3-TraceId", tc.TraceID)
+	header.Set("X-B3-SpanId", tc.SpanID)
+	if tc.ParentID != "" {
+		header.Set("X-B3-ParentSpanId", tc.ParentID)
+	} else {
+		header.Del("X-B3-ParentSpanId")
+	}
+	header.Del("X-B3-Flags")
+	if tc.deferred {
+		header.Del("X-B3-Sampled")
+	} else if tc.Sampled() {
+		header.Set("X-B3-Sampled", "1")
+	} else {
+		header.Set("X-B3-Sampled", "0")
+	}
+}
+
+// b3 returns the b3 header value of an outgoing request.
+func (tc *TraceContext) b3() string {
+	value := tc.TraceID + "-" + tc.SpanID
+	if tc.deferred {
+		return value
+	}
+	if tc.Sampled() {
+		value += "-1"
+	} else {
+		value += "-0"
+	}
+	if tc.ParentID != "" {
+		value += "-" + tc.ParentID
+	}
+	return value
+}
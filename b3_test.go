@@ -0,0 +1,105 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestB3Propagation(t *testing.T) {
+	const traceID = "80f198ee56343ba864fe8b2a57d3eff7"
+	const spanID = "e457b5a2e4d86bd1"
+
+	tests := []struct {
+		name    string
+		formats []TraceFormat
+		header  http.Header
+		traceID string
+		sampled bool
+	}{
+		{"Multi headers", []TraceFormat{TraceB3}, http.Header{"X-B3-Traceid": {traceID}, "X-B3-Spanid": {spanID}, "X-B3-Sampled": {"1"}}, traceID, true},
+		{"Single header", []TraceFormat{TraceB3Single}, http.Header{"B3": {traceID + "-" + spanID + "-1"}}, traceID, true},
+		{"64-bit trace ID", []TraceFormat{TraceB3Single}, http.Header{"B3": {"a3ce929d0e0e4736-" + spanID + "-0"}}, "a3ce929d0e0e4736", false},
+		{"W3C first", []TraceFormat{TraceW3C, TraceB3Single}, http.Header{
+			"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			"B3":          {traceID + "-" + spanID + "-1"},
+		}, "4bf92f3577b34da6a3ce929d0e0e4736", true},
+		{"B3 fallback", []TraceFormat{TraceW3C, TraceB3Single}, http.Header{"B3": {traceID + "-" + spanID}}, traceID, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router := NewRouter()
+			router.SetTracePropagation(test.formats...)
+			var tc *TraceContext
+			router.MustAddRoute("GET", "/users", func(w http.ResponseWriter, req *http.Request) {
+				tc = router.TraceContext(req)
+			})
+
+			req, err := http.NewRequest("GET", "/users", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header = test.header
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tc == nil {
+				t.Fatal("Expected a trace context")
+			}
+			if tc.TraceID != test.traceID {
+				t.Errorf("Expected trace ID %q, but got %q", test.traceID, tc.TraceID)
+			}
+			if tc.Sampled() != test.sampled {
+				t.Errorf("Expected sampled %v, but got %v", test.sampled, tc.Sampled())
+			}
+		})
+	}
+
+	t.Run("Generated and forwarded", func(t *testing.T) {
+		var header http.Header
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			header = req.Header.Clone()
+		}))
+		defer upstream.Close()
+
+		target, err := url.Parse(upstream.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		router := NewRouter()
+		router.SetTracePropagation(TraceB3, TraceB3Single)
+		var tc *TraceContext
+		router.Use(func(next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, req *http.Request) {
+				tc = router.TraceContext(req)
+				next(w, req)
+			}
+		})
+		router.Proxy("GET", "/users", target)
+
+		req, err := http.NewRequest("GET", "/users", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		// Check that a trace is started
+		if tc == nil || len(tc.TraceID) != 32 || len(tc.SpanID) != 16 {
+			t.Fatalf("Expected a new trace, but got %+v", tc)
+		}
+
+		// Check the forwarded headers, leaving the sampling decision open
+		if header.Get("X-B3-TraceId") != tc.TraceID || header.Get("X-B3-SpanId") != tc.SpanID {
+			t.Errorf("Unexpected B3 headers %v", header)
+		}
+		if sampled := header.Get("X-B3-Sampled"); sampled != "" {
+			t.Errorf("Expected no sampling decision, but got %q", sampled)
+		}
+		if expected := tc.TraceID + "-" + tc.SpanID; header.Get("B3") != expected {
+			t.Errorf("Expected b3 header %q, but got %q", expected, header.Get("B3"))
+		}
+		if header.Get("Traceparent") != "" {
+			t.Errorf("Expected no traceparent header, but got %q", header.Get("Traceparent"))
+		}
+	})
+}
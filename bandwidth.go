@@ -0,0 +1,171 @@
+package router
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// BandwidthOptions configures the Bandwidth middleware.
+type BandwidthOptions struct {
+	// BytesPerSecond is the maximum response rate.
+	BytesPerSecond int
+	// Key groups requests sharing one bandwidth budget, e.g. by client IP.
+	// Each request is throttled independently when Key is nil.
+	Key func(req *http.Request) string
+}
+
+// Bandwidth returns middleware throttling the rate at which response
+// bodies are written, so large downloads cannot saturate the network.
+func Bandwidth(opts BandwidthOptions) func(http.HandlerFunc) http.HandlerFunc {
+	buckets := &bucketSet{buckets: make(map[string]*sharedBucket)}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			if opts.BytesPerSecond <= 0 {
+				next(w, req)
+				return
+			}
+
+			var bucket *tokenBucket
+			if opts.Key == nil {
+				bucket = newTokenBucket(opts.BytesPerSecond)
+			} else {
+				key := opts.Key(req)
+				bucket = buckets.acquire(key, opts.BytesPerSecond)
+				defer buckets.release(key)
+			}
+
+			next(&throttledWriter{ResponseWriter: w, bucket: bucket, req: req}, req)
+		}
+	}
+}
+
+// tokenBucket is a token bucket refilled at a constant rate of bytes per second.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket creates a token bucket allowing bursts of a tenth of a second.
+func newTokenBucket(bytesPerSecond int) *tokenBucket {
+	burst := bytesPerSecond / 10
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   float64(bytesPerSecond),
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve takes n tokens and returns how long to wait before using them.
+func (b *tokenBucket) reserve(n int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > float64(b.burst) {
+		b.tokens = float64(b.burst)
+	}
+	b.last = now
+
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// sharedBucket is a token bucket shared by the requests with the same key.
+type sharedBucket struct {
+	bucket *tokenBucket
+	refs   int
+}
+
+// bucketSet holds the token buckets of the requests in flight by key.
+type bucketSet struct {
+	mu      sync.Mutex
+	buckets map[string]*sharedBucket
+}
+
+// acquire returns the bucket for the key, creating it if needed.
+func (s *bucketSet) acquire(key string, bytesPerSecond int) *tokenBucket {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shared, ok := s.buckets[key]
+	if !ok {
+		shared = &sharedBucket{bucket: newTokenBucket(bytesPerSecond)}
+		s.buckets[key] = shared
+	}
+	shared.refs++
+	return shared.bucket
+}
+
+// release drops the bucket for the key once no request uses it.
+func (s *bucketSet) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shared := s.buckets[key]
+	shared.refs--
+	if shared.refs == 0 {
+		delete(s.buckets, key)
+	}
+}
+
+// throttledWriter writes the response body at the rate of its token bucket.
+type throttledWriter struct {
+	http.ResponseWriter
+	bucket *tokenBucket
+	req    *http.Request
+}
+
+// Write writes b in chunks no larger than the bucket burst, waiting for
+// tokens before each chunk.
+func (w *throttledWriter) Write(b []byte) (int, error) {
+	written := 0
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > w.bucket.burst {
+			chunk = chunk[:w.bucket.burst]
+		}
+
+		if delay := w.bucket.reserve(len(chunk)); delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-w.req.Context().Done():
+				timer.Stop()
+				return written, w.req.Context().Err()
+			}
+		}
+
+		n, err := w.ResponseWriter.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		b = b[len(chunk):]
+	}
+	return written, nil
+}
+
+// Flush implements http.Flusher if the wrapped writer supports it.
+func (w *throttledWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter.
+func (w *throttledWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,43 @@
+package router
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBandwidth(t *testing.T) {
+	router := NewRouter()
+
+	body := bytes.Repeat([]byte("x"), 3000)
+	router.MustAddRoute("GET", "/download", func(w http.ResponseWriter, req *http.Request) {
+		w.Write(body)
+	}).Use(Bandwidth(BandwidthOptions{
+		BytesPerSecond: 10000,
+		Key: func(req *http.Request) string {
+			return req.RemoteAddr
+		},
+	}))
+
+	req, err := http.NewRequest("GET", "/download", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	elapsed := time.Since(start)
+
+	// Check the full body was written
+	if !bytes.Equal(rr.Body.Bytes(), body) {
+		t.Errorf("Expected a response body of %d bytes, but got %d", len(body), rr.Body.Len())
+	}
+
+	// 3000 bytes at 10000 bytes/s with a 1000 byte burst take about 200ms
+	if elapsed < 150*time.Millisecond {
+		t.Errorf("Expected the response to be throttled, but it took %v", elapsed)
+	}
+}
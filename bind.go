@@ -0,0 +1,290 @@
+package router
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedMediaType is returned by Bind for request bodies whose
+// Content-Type cannot be decoded. WriteParamError answers it with 415.
+var ErrUnsupportedMediaType = errors.New("router: unsupported media type")
+
+// bindSources lists the struct tags read by Bind, in the order their
+// values are applied.
+var bindSources = []string{"path", "query", "header", "cookie"}
+
+// Bind fills the struct pointed to by dst from the request. The body is
+// decoded first with the binder of its Content-Type, see RegisterBinder,
+// then fields tagged with path, query, header or cookie are set from the
+// path parameters, query parameters, headers and cookies:
+//
+//	type listOrders struct {
+//		ID     int      `path:"id"`
+//		Page   int      `query:"page"`
+//		Tags   []string `query:"tag"`
+//		Tenant string   `header:"X-Tenant"`
+//		Note   string   `json:"note"`
+//	}
+//
+// Absent and empty values leave fields unchanged, so defaults can be set
+// before calling Bind. Fields may be strings, numbers, booleans, slices of
+// them, pointers to them, or implement encoding.TextUnmarshaler, such as
+// time.Time and uuid.UUID. Invalid values are reported together as
+// ParamErrors, see WriteParamError.
+func (r *Router) Bind(req *http.Request, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("router: Bind requires a pointer to a struct, not %T", dst)
+	}
+	if err := r.bindBody(req, dst); err != nil {
+		return err
+	}
+
+	var errs ParamErrors
+	r.bindFields(req, v.Elem(), &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Binder decodes a request body into dst, a pointer to a struct. It may
+// return ParamErrors for invalid fields.
+type Binder func(body io.Reader, dst interface{}) error
+
+// registeredBinder is a binder and the media type it decodes.
+type registeredBinder struct {
+	mediaType string
+	binder    Binder
+}
+
+// defaultBinders are the binders used unless overridden.
+var defaultBinders = []registeredBinder{
+	{"application/json", func(body io.Reader, dst interface{}) error {
+		return json.NewDecoder(body).Decode(dst)
+	}},
+	{"application/cbor", cborBinder},
+	{"application/msgpack", msgpackBinder},
+	{"application/x-msgpack", msgpackBinder},
+	{"application/vnd.msgpack", msgpackBinder},
+}
+
+// RegisterBinder registers the binder Bind uses for request bodies of the
+// media type, e.g. "application/xml" or "application/x-protobuf". The media
+// type may use a "*" wildcard, and media types with a structured syntax
+// suffix such as "application/vnd.api+xml" fall back to the binder of
+// "application/xml". Binders registered later take precedence over earlier
+// ones and over the built-in JSON, CBOR and MessagePack binders.
+func (r *Router) RegisterBinder(mediaType string, binder Binder) {
+	r.binders = append(r.binders, registeredBinder{strings.ToLower(mediaType), binder})
+}
+
+// binder returns the binder of the media type, or nil if there is none.
+func (r *Router) binder(mediaType string) Binder {
+	binders := append(append([]registeredBinder(nil), defaultBinders...), r.binders...)
+	candidates := []string{mediaType}
+	if i := strings.LastIndexByte(mediaType, '+'); i >= 0 {
+		if j := strings.IndexByte(mediaType, '/'); j >= 0 && j < i {
+			candidates = append(candidates, mediaType[:j+1]+mediaType[i+1:])
+		}
+	}
+	for _, candidate := range candidates {
+		for i := len(binders) - 1; i >= 0; i-- {
+			if binders[i].mediaType == candidate {
+				return binders[i].binder
+			}
+		}
+	}
+	for i := len(binders) - 1; i >= 0; i-- {
+		if mediaTypeMatches(binders[i].mediaType, mediaType) {
+			return binders[i].binder
+		}
+	}
+	return nil
+}
+
+// bindBody decodes the request body into dst with the binder of its
+// Content-Type.
+func (r *Router) bindBody(req *http.Request, dst interface{}) error {
+	if req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0 {
+		return nil
+	}
+	contentType := req.Header.Get("Content-Type")
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("%w %q", ErrUnsupportedMediaType, contentType)
+	}
+	binder := r.binder(mediaType)
+	if binder == nil {
+		return fmt.Errorf("%w %q", ErrUnsupportedMediaType, contentType)
+	}
+
+	err = binder(req.Body, dst)
+	var errs ParamErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil || err == io.EOF:
+		return nil
+	case errors.As(err, &errs):
+		return errs
+	case errors.As(err, &typeErr):
+		return ParamErrors{{Source: "body", Name: typeErr.Field, Value: typeErr.Value, Type: typeName(typeErr.Type), Err: err}}
+	}
+	return fmt.Errorf("router: invalid request body: %w", err)
+}
+
+// bindFields sets the tagged fields of the struct, including those of
+// embedded structs, appending invalid values to errs.
+func (r *Router) bindFields(req *http.Request, v reflect.Value, errs *ParamErrors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			r.bindFields(req, v.Field(i), errs)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		for _, source := range bindSources {
+			name := field.Tag.Get(source)
+			if name == "" || name == "-" {
+				continue
+			}
+			values := r.bindValues(req, source, name)
+			if len(values) == 0 {
+				continue
+			}
+			if err := bindField(v.Field(i), values); err != nil {
+				if source == "header" {
+					name = http.CanonicalHeaderKey(name)
+				}
+				*errs = append(*errs, &ParamError{Source: source, Name: name, Value: strings.Join(values, ","), Type: typeName(field.Type), Err: err})
+			}
+		}
+	}
+}
+
+// bindValues returns the non-empty values of the named parameter.
+func (r *Router) bindValues(req *http.Request, source string, name string) []string {
+	var value string
+	switch source {
+	case "path":
+		value = r.GetPathParam(req, name)
+	case "query":
+		return r.QueryStringSlice(req, name, nil)
+	case "header":
+		value = r.HeaderOr(req, name, "")
+	case "cookie":
+		value, _ = cookieValue(req, name)
+	}
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+// bindField sets the field from the values. Fields other than slices are
+// set from the last value.
+func bindField(v reflect.Value, values []string) error {
+	if v.Kind() == reflect.Slice && !isTextUnmarshaler(v) {
+		s := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := bindValue(s.Index(i), value); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	return bindValue(v, values[len(values)-1])
+}
+
+// isTextUnmarshaler reports whether a pointer to the value implements
+// encoding.TextUnmarshaler.
+func isTextUnmarshaler(v reflect.Value) bool {
+	return reflect.PtrTo(v.Type()).Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem())
+}
+
+// bindValue parses the value into v.
+func bindValue(v reflect.Value, value string) error {
+	if v.Kind() == reflect.Ptr {
+		elem := reflect.New(v.Type().Elem())
+		if err := bindValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	if isTextUnmarshaler(v) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("router: cannot bind a value to a field of type %s", v.Type())
+	}
+	return nil
+}
+
+// typeName names the type of a field in parameter errors.
+func typeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	switch t.String() {
+	case "time.Time":
+		return "time"
+	case "uuid.UUID":
+		return "UUID"
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	}
+	return t.String()
+}
@@ -0,0 +1,178 @@
+package router
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type bindPage struct {
+	Page  int `query:"page"`
+	Limit int `query:"limit"`
+}
+
+type bindOrder struct {
+	bindPage
+	ID      int        `path:"id"`
+	Ref     uuid.UUID  `query:"ref"`
+	Tags    []string   `query:"tag"`
+	Since   *time.Time `query:"since"`
+	Tenant  string     `header:"X-Tenant"`
+	Express bool       `cookie:"express"`
+	Note    string     `json:"note"`
+	Total   float64    `json:"total"`
+}
+
+func TestBind(t *testing.T) {
+	router := NewRouter()
+	var order bindOrder
+	var bindErr error
+	router.MustAddRoute("POST", "/orders/:id", func(w http.ResponseWriter, req *http.Request) {
+		order = bindOrder{bindPage: bindPage{Limit: 20}}
+		if bindErr = router.Bind(req, &order); bindErr != nil {
+			WriteParamError(w, bindErr)
+		}
+	})
+
+	serve := func(target string, contentType string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", target, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("X-Tenant", "acme")
+		req.AddCookie(&http.Cookie{Name: "express", Value: "true"})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("All sources", func(t *testing.T) {
+		const ref = "1b4e28ba-2fa1-11d2-883f-0016d3cca427"
+		rr := serve("/orders/42?page=3&ref="+ref+"&tag=a,b&since=2024-05-17T00:00:00Z", "application/json", `{"note":"gift","total":9.5}`)
+
+		// Check the response status code
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("Expected status code %d, but got %d: %s", http.StatusOK, status, rr.Body.String())
+		}
+
+		// Check the bound fields
+		if order.ID != 42 || order.Page != 3 || order.Limit != 20 || order.Ref.String() != ref ||
+			strings.Join(order.Tags, ",") != "a,b" || order.Since == nil || order.Since.Year() != 2024 ||
+			order.Tenant != "acme" || !order.Express || order.Note != "gift" || order.Total != 9.5 {
+			t.Errorf("Expected all fields to be bound, but got %+v", order)
+		}
+	})
+
+	t.Run("Invalid values", func(t *testing.T) {
+		rr := serve("/orders/abc?page=two", "", "")
+
+		// Check the response status code
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Fatalf("Expected status code %d, but got %d", http.StatusBadRequest, status)
+		}
+
+		// Check that every invalid value is listed
+		var body struct {
+			Error  string       `json:"error"`
+			Params []ParamError `json:"params"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if len(body.Params) != 2 || body.Params[0].Name != "page" || body.Params[1].Name != "id" || body.Params[1].Type != "integer" {
+			t.Errorf("Expected errors for page and id, but got %s", rr.Body.String())
+		}
+	})
+
+	t.Run("Invalid body", func(t *testing.T) {
+		rr := serve("/orders/1", "application/json", `{"total":"free"}`)
+
+		// Check the response status code
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Fatalf("Expected status code %d, but got %d", http.StatusBadRequest, status)
+		}
+		errs, ok := bindErr.(ParamErrors)
+		if !ok || errs[0].Source != "body" || errs[0].Name != "total" {
+			t.Errorf("Expected a body field error, but got %v", bindErr)
+		}
+	})
+
+	t.Run("Unsupported media type", func(t *testing.T) {
+		rr := serve("/orders/1", "text/csv", "a,b")
+
+		// Check the response status code
+		if status := rr.Code; status != http.StatusUnsupportedMediaType {
+			t.Errorf("Expected status code %d, but got %d", http.StatusUnsupportedMediaType, status)
+		}
+	})
+
+	t.Run("Invalid destination", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		if err := router.Bind(req, order); err == nil {
+			t.Errorf("Expected an error for a non-pointer destination")
+		}
+	})
+}
+
+func TestRegisterBinder(t *testing.T) {
+	type item struct {
+		ID   int    `path:"id" xml:"id"`
+		Name string `xml:"name"`
+	}
+	router := NewRouter()
+	router.RegisterBinder("application/xml", func(body io.Reader, dst interface{}) error {
+		return xml.NewDecoder(body).Decode(dst)
+	})
+	router.RegisterBinder("text/*", func(body io.Reader, dst interface{}) error {
+		b, err := io.ReadAll(body)
+		dst.(*item).Name = strings.ToUpper(string(b))
+		return err
+	})
+	var got item
+	router.MustAddRoute("PUT", "/items/:id", func(w http.ResponseWriter, req *http.Request) {
+		got = item{}
+		if err := router.Bind(req, &got); err != nil {
+			WriteParamError(w, err)
+		}
+	})
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		item        item
+	}{
+		{"Registered type", "application/xml", "<item><id>9</id><name>lamp</name></item>", http.StatusOK, item{ID: 7, Name: "lamp"}},
+		{"Structured syntax suffix", "application/vnd.shop+xml; charset=utf-8", "<item><name>desk</name></item>", http.StatusOK, item{ID: 7, Name: "desk"}},
+		{"Wildcard", "text/plain", "chair", http.StatusOK, item{ID: 7, Name: "CHAIR"}},
+		{"Built-in JSON", "application/json", `{"Name":"sofa"}`, http.StatusOK, item{ID: 7, Name: "sofa"}},
+		{"Unregistered type", "application/x-protobuf", "\x0a\x04lamp", http.StatusUnsupportedMediaType, item{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", "/items/7", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if status := rr.Code; status != tt.status {
+				t.Fatalf("Expected status code %d, but got %d: %s", tt.status, status, rr.Body.String())
+			}
+
+			// Check the bound item
+			if got != tt.item {
+				t.Errorf("Expected %+v, but got %+v", tt.item, got)
+			}
+		})
+	}
+}
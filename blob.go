@@ -0,0 +1,118 @@
+package router
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// BlobInfo describes a blob served by ServeBlob.
+type BlobInfo struct {
+	// Name is the file name of the blob. It determines the Content-Type
+	// when ContentType is empty, and the name of downloads.
+	Name string
+	// ContentType is the media type of the blob.
+	ContentType string
+	// ETag is the entity tag of the blob, such as the ETag of an object
+	// store. It is quoted if needed.
+	ETag string
+	// ModTime is the modification time of the blob, if known.
+	ModTime time.Time
+	// Download makes browsers save the blob as a file named Name.
+	Download bool
+}
+
+// ServeBlob answers the request with the blob read from content, like
+// http.ServeContent: Range requests are answered with the requested byte
+// ranges, and conditional requests with If-None-Match, If-Range and
+// If-Modified-Since are answered from the ETag and ModTime, so that
+// interrupted downloads can be resumed.
+func (r *Router) ServeBlob(w http.ResponseWriter, req *http.Request, content io.ReadSeeker, info BlobInfo) {
+	header := w.Header()
+	if info.ETag != "" {
+		etag := info.ETag
+		if !strings.HasSuffix(etag, `"`) {
+			etag = `"` + etag + `"`
+		}
+		header.Set("ETag", etag)
+	}
+	if info.ContentType != "" {
+		header.Set("Content-Type", info.ContentType)
+	}
+	if info.Download && info.Name != "" {
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": info.Name}))
+	}
+	header.Set("Accept-Ranges", "bytes")
+	http.ServeContent(w, req, info.Name, info.ModTime, content)
+}
+
+// RangeReader returns an io.ReadSeeker over a blob of the given size that
+// is read with open, which returns a reader starting at the offset, e.g. a
+// ranged GET from an object store. Readers are only opened when reading,
+// so seeking is free. Close closes the open reader.
+func RangeReader(size int64, open func(offset int64) (io.ReadCloser, error)) io.ReadSeekCloser {
+	return &rangeReader{size: size, open: open}
+}
+
+// rangeReader reads a blob with ranged reads.
+type rangeReader struct {
+	size   int64
+	open   func(offset int64) (io.ReadCloser, error)
+	offset int64
+	body   io.ReadCloser
+}
+
+// Read reads from the current offset, opening a reader if needed.
+func (r *rangeReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, err := r.open(r.offset)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+	if remaining := r.size - r.offset; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	if err == io.EOF && r.offset < r.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// Seek sets the offset of the next read, closing the open reader when it
+// moves.
+func (r *rangeReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	}
+	if offset < 0 {
+		return 0, errors.New("router: negative position")
+	}
+	if offset != r.offset {
+		r.Close()
+		r.offset = offset
+	}
+	return offset, nil
+}
+
+// Close closes the open reader.
+func (r *rangeReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
@@ -0,0 +1,76 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServeBlob(t *testing.T) {
+	const blob = "0123456789abcdefghij"
+	var offsets []int64
+	router := NewRouter()
+	router.MustAddRoute("GET", "/media/clip.mp4", func(w http.ResponseWriter, req *http.Request) {
+		content := RangeReader(int64(len(blob)), func(offset int64) (io.ReadCloser, error) {
+			offsets = append(offsets, offset)
+			return io.NopCloser(strings.NewReader(blob[offset:])), nil
+		})
+		defer content.Close()
+		router.ServeBlob(w, req, content, BlobInfo{Name: "clip.mp4", ETag: "v1", Download: true})
+	})
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+		body    string
+		offsets []int64
+	}{
+		{"Whole blob", nil, http.StatusOK, blob, []int64{0}},
+		{"Byte range", map[string]string{"Range": "bytes=10-14"}, http.StatusPartialContent, "abcde", []int64{10}},
+		{"Resumed download", map[string]string{"Range": "bytes=15-", "If-Range": `"v1"`}, http.StatusPartialContent, "fghij", []int64{15}},
+		{"Changed blob", map[string]string{"Range": "bytes=15-", "If-Range": `"v0"`}, http.StatusOK, blob, []int64{0}},
+		{"Not modified", map[string]string{"If-None-Match": `"v1"`}, http.StatusNotModified, "", nil},
+		{"Unsatisfiable range", map[string]string{"Range": "bytes=50-"}, http.StatusRequestedRangeNotSatisfiable, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offsets = nil
+			req := httptest.NewRequest("GET", "/media/clip.mp4", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if status := rr.Code; status != tt.status {
+				t.Fatalf("Expected status code %d, but got %d", tt.status, status)
+			}
+
+			// Check the served bytes and the ranged reads
+			if tt.status != http.StatusRequestedRangeNotSatisfiable && rr.Body.String() != tt.body {
+				t.Errorf("Expected response body %q, but got %q", tt.body, rr.Body.String())
+			}
+			if len(offsets) != len(tt.offsets) || (len(offsets) > 0 && offsets[0] != tt.offsets[0]) {
+				t.Errorf("Expected reads at %v, but got %v", tt.offsets, offsets)
+			}
+
+			// Check the headers
+			if etag := rr.Header().Get("ETag"); etag != `"v1"` {
+				t.Errorf("Expected ETag %q, but got %q", `"v1"`, etag)
+			}
+			if tt.status == http.StatusOK {
+				if contentType := rr.Header().Get("Content-Type"); contentType != "video/mp4" {
+					t.Errorf("Expected content type %q, but got %q", "video/mp4", contentType)
+				}
+				if disposition := rr.Header().Get("Content-Disposition"); disposition != "attachment; filename=clip.mp4" {
+					t.Errorf("Expected an attachment, but got %q", disposition)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+)
+
+// BufferBodyOptions configures the BufferBody middleware.
+type BufferBodyOptions struct {
+	// MaxMemory is the number of bytes kept in memory. Larger bodies are
+	// spilled to a temporary file. It defaults to 1 MB.
+	MaxMemory int64
+	// MaxSize is the maximum size of the body. Larger bodies are answered
+	// with 413. Zero means no limit.
+	MaxSize int64
+	// TempDir is the directory of the temporary files. It defaults to
+	// os.TempDir.
+	TempDir string
+}
+
+// BufferBody returns middleware reading the request body up front so that
+// it can be read any number of times, e.g. by signature verification,
+// logging and binding: req.GetBody and Router.ReplayBody return readers
+// from the start of the body. Temporary files are removed once the
+// handler returns.
+func BufferBody(opts BufferBodyOptions) func(http.HandlerFunc) http.HandlerFunc {
+	if opts.MaxMemory <= 0 {
+		opts.MaxMemory = 1 << 20
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			if req.Body == nil || req.Body == http.NoBody {
+				next(w, req)
+				return
+			}
+			if _, ok := req.Context().Value("bufferedBody").(*bufferedBody); ok {
+				next(w, req)
+				return
+			}
+
+			body, err := readBufferedBody(req.Body, opts)
+			req.Body.Close()
+			if err == ErrBodyTooLarge {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+			defer body.close()
+
+			req = req.WithContext(context.WithValue(req.Context(), "bufferedBody", body))
+			req.Body = body.open()
+			req.ContentLength = body.size
+			req.GetBody = func() (io.ReadCloser, error) {
+				return body.open(), nil
+			}
+			next(w, req)
+		}
+	}
+}
+
+// ReplayBody returns a reader from the start of the request body buffered
+// by the BufferBody middleware.
+func (r *Router) ReplayBody(req *http.Request) (io.ReadCloser, error) {
+	body, ok := req.Context().Value("bufferedBody").(*bufferedBody)
+	if !ok {
+		return nil, errors.New("router: request body is not buffered")
+	}
+	return body.open(), nil
+}
+
+// ErrBodyTooLarge is returned when reading a request body larger than the
+// configured limit.
+var ErrBodyTooLarge = errors.New("router: request body too large")
+
+// bufferedBody is a request body held in memory or in a temporary file.
+type bufferedBody struct {
+	data []byte
+	file *os.File
+	size int64
+}
+
+// readBufferedBody reads the body, spilling it to a temporary file past
+// the memory limit.
+func readBufferedBody(r io.Reader, opts BufferBodyOptions) (*bufferedBody, error) {
+	limit := opts.MaxSize
+	if limit > 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(r, opts.MaxMemory+1))
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && n > limit {
+		return nil, ErrBodyTooLarge
+	}
+	if n <= opts.MaxMemory {
+		return &bufferedBody{data: buf.Bytes(), size: n}, nil
+	}
+
+	file, err := os.CreateTemp(opts.TempDir, "router-body-")
+	if err != nil {
+		return nil, err
+	}
+	body := &bufferedBody{file: file}
+	if _, err := buf.WriteTo(file); err != nil {
+		body.close()
+		return nil, err
+	}
+	rest, err := io.Copy(file, r)
+	if err != nil {
+		body.close()
+		return nil, err
+	}
+	body.size = n + rest
+	if limit > 0 && body.size > limit {
+		body.close()
+		return nil, ErrBodyTooLarge
+	}
+	return body, nil
+}
+
+// open returns a reader from the start of the body. Readers can be used
+// concurrently.
+func (b *bufferedBody) open() io.ReadCloser {
+	if b.file == nil {
+		return io.NopCloser(bytes.NewReader(b.data))
+	}
+	return io.NopCloser(io.NewSectionReader(b.file, 0, b.size))
+}
+
+// close removes the temporary file, if any.
+func (b *bufferedBody) close() {
+	if b.file != nil {
+		b.file.Close()
+		os.Remove(b.file.Name())
+	}
+}
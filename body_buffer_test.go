@@ -0,0 +1,87 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBufferBody(t *testing.T) {
+	dir := t.TempDir()
+	router := NewRouter()
+	router.Use(BufferBody(BufferBodyOptions{MaxMemory: 8, MaxSize: 64, TempDir: dir}))
+
+	// The middleware reads the body before the handler reads it again
+	var logged string
+	router.Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			body, err := router.ReplayBody(req)
+			if err == nil {
+				content, _ := io.ReadAll(body)
+				logged = string(content)
+			}
+			next(w, req)
+		}
+	})
+
+	var spilled int
+	router.MustAddRoute("POST", "/webhook", func(w http.ResponseWriter, req *http.Request) {
+		first, _ := io.ReadAll(req.Body)
+		again, err := req.GetBody()
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, _ := io.ReadAll(again)
+		files, _ := os.ReadDir(dir)
+		spilled = len(files)
+		w.Write([]byte(string(first) + "|" + string(second)))
+	})
+
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		spilled int
+	}{
+		{"In memory", "small", http.StatusOK, 0},
+		{"Spilled to file", strings.Repeat("x", 32), http.StatusOK, 1},
+		{"Too large", strings.Repeat("x", 65), http.StatusRequestEntityTooLarge, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logged, spilled = "", 0
+			req := httptest.NewRequest("POST", "/webhook", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if status := rr.Code; status != tt.status {
+				t.Errorf("Expected status code %d, but got %d", tt.status, status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			// Check that every reader saw the whole body
+			if body := rr.Body.String(); body != tt.body+"|"+tt.body {
+				t.Errorf("Expected response body %q, but got %q", tt.body+"|"+tt.body, body)
+			}
+			if logged != tt.body {
+				t.Errorf("Expected logged body %q, but got %q", tt.body, logged)
+			}
+			if spilled != tt.spilled {
+				t.Errorf("Expected %d temporary files, but got %d", tt.spilled, spilled)
+			}
+
+			// Check that temporary files are removed
+			if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+				t.Errorf("Expected no temporary files, but got %v", files)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package router
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build information injected at link time, e.g. with
+//
+//	go build -ldflags "-X github.com/sdpsagarpawar/router.Version=1.2.0"
+//
+// When left empty, they are read from the module and VCS information Go
+// embeds in the binary where available, BuildTime being the commit time.
+var (
+	Version   string
+	GitSHA    string
+	BuildTime string
+)
+
+// BuildInfo describes the running binary.
+type BuildInfo struct {
+	Version   string `json:"version,omitempty"`
+	GitSHA    string `json:"git_sha,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	Module    string `json:"module,omitempty"`
+	GoVersion string `json:"go_version"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+}
+
+// EnableBuildInfo adds a GET route at path serving the BuildInfo of the
+// binary as JSON, so that deployments can be verified. It returns an error
+// if the route cannot be added, see AddRoute.
+func (r *Router) EnableBuildInfo(path string) (*Route, error) {
+	info := readBuildInfo()
+	return r.AddRoute("GET", path, func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, info)
+	})
+}
+
+// readBuildInfo returns the injected build information completed with the
+// information embedded by Go.
+func readBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   Version,
+		GitSHA:    GitSHA,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+	embedded, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Module = embedded.Main.Path
+	if info.Version == "" && embedded.Main.Version != "(devel)" {
+		info.Version = embedded.Main.Version
+	}
+	for _, setting := range embedded.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.GitSHA == "" {
+				info.GitSHA = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
@@ -0,0 +1,48 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+)
+
+func TestBuildInfo(t *testing.T) {
+	Version, GitSHA, BuildTime = "1.2.0", "abc123", "2024-01-02T03:04:05Z"
+	defer func() { Version, GitSHA, BuildTime = "", "", "" }()
+
+	router := NewRouter()
+	router.EnableBuildInfo("/version")
+
+	req, err := http.NewRequest("GET", "/version", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	// Check the response status code
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d", http.StatusOK, status)
+	}
+
+	// Check the injected values and runtime information
+	var info BuildInfo
+	if err := json.Unmarshal(rr.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	expected := BuildInfo{
+		Version:   "1.2.0",
+		GitSHA:    "abc123",
+		BuildTime: "2024-01-02T03:04:05Z",
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+	info.Module, info.Modified = "", false
+	if info != expected {
+		t.Errorf("Expected build info %+v, but got %+v", expected, info)
+	}
+}
@@ -0,0 +1,43 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CanonicalHostOptions configures the CanonicalHost middleware.
+type CanonicalHostOptions struct {
+	// Host is the canonical host, e.g. "www.example.com".
+	Host string
+	// TrustedProxies are the proxies whose Forwarded, X-Forwarded-Host and
+	// X-Forwarded-Proto headers are honoured.
+	TrustedProxies *TrustedProxies
+	// Code is the redirect status code. It defaults to 301.
+	Code int
+}
+
+// CanonicalHost returns middleware that redirects requests made to any
+// other host to the canonical host, preserving the scheme, path and query.
+func CanonicalHost(opts CanonicalHostOptions) func(http.HandlerFunc) http.HandlerFunc {
+	code := opts.Code
+	if code == 0 {
+		code = http.StatusMovedPermanently
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			host := opts.TrustedProxies.ClientInfo(req).Host
+
+			if opts.Host == "" || strings.EqualFold(host, opts.Host) {
+				next(w, req)
+				return
+			}
+
+			scheme := "http"
+			if isHTTPS(req, opts.TrustedProxies) {
+				scheme = "https"
+			}
+			http.Redirect(w, req, scheme+"://"+opts.Host+req.URL.RequestURI(), code)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package router
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCanonicalHost(t *testing.T) {
+	router := NewRouter()
+	router.Use(CanonicalHost(CanonicalHostOptions{Host: "www.example.com"}))
+	router.MustAddRoute("GET", "/hello", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("Hello, World!"))
+	})
+
+	tests := []struct {
+		name             string
+		url              string
+		tls              bool
+		expectedCode     int
+		expectedLocation string
+	}{
+		{"Canonical host", "http://www.example.com/hello", false, http.StatusOK, ""},
+		{"Apex domain", "http://example.com/hello?x=1", false, http.StatusMovedPermanently, "http://www.example.com/hello?x=1"},
+		{"Old domain over TLS", "https://old.example.org/hello", true, http.StatusMovedPermanently, "https://www.example.com/hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, but got %d", tt.expectedCode, rr.Code)
+			}
+			if location := rr.Header().Get("Location"); location != tt.expectedLocation {
+				t.Errorf("Expected location %q, but got %q", tt.expectedLocation, location)
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// CBOR major types.
+const (
+	cborUint = iota
+	cborNegInt
+	cborBytes
+	cborText
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+// MarshalCBOR encodes v as CBOR. Values are encoded as they would be
+// encoded to JSON, following their json struct tags.
+func MarshalCBOR(v interface{}) ([]byte, error) {
+	g, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := encodeCBOR(&buf, g); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalCBOR decodes CBOR into v, as if it were decoded from JSON.
+// Byte strings decode into []byte fields, and tags are ignored.
+func UnmarshalCBOR(data []byte, v interface{}) error {
+	g, err := decodeCBOR(&binaryReader{data: data}, 0)
+	if err != nil {
+		return fmt.Errorf("router: invalid CBOR: %v", err)
+	}
+	return fromGeneric(g, v)
+}
+
+// encodeCBOR appends the generic value to the buffer.
+func encodeCBOR(buf *bytes.Buffer, g interface{}) error {
+	switch v := g.(type) {
+	case nil:
+		buf.WriteByte(0xf6)
+	case bool:
+		if v {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+	case json.Number:
+		u, i, ok := genericInt(v)
+		switch {
+		case !ok:
+			f, err := v.Float64()
+			if err != nil {
+				return err
+			}
+			buf.WriteByte(0xfb)
+			putUint(buf, math.Float64bits(f), 8)
+		case i < 0:
+			writeCBORHeader(buf, cborNegInt, uint64(-(i + 1)))
+		default:
+			writeCBORHeader(buf, cborUint, u)
+		}
+	case string:
+		writeCBORHeader(buf, cborText, uint64(len(v)))
+		buf.WriteString(v)
+	case []interface{}:
+		writeCBORHeader(buf, cborArray, uint64(len(v)))
+		for _, elem := range v {
+			if err := encodeCBOR(buf, elem); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		writeCBORHeader(buf, cborMap, uint64(len(v)))
+		for _, key := range sortedKeys(v) {
+			encodeCBOR(buf, key)
+			if err := encodeCBOR(buf, v[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("router: cannot encode %T as CBOR", g)
+	}
+	return nil
+}
+
+// writeCBORHeader writes the initial byte of a data item of the major
+// type, followed by its argument in the shortest form.
+func writeCBORHeader(buf *bytes.Buffer, major byte, arg uint64) {
+	switch {
+	case arg < 24:
+		buf.WriteByte(major<<5 | byte(arg))
+	case arg <= math.MaxUint8:
+		buf.WriteByte(major<<5 | 24)
+		putUint(buf, arg, 1)
+	case arg <= math.MaxUint16:
+		buf.WriteByte(major<<5 | 25)
+		putUint(buf, arg, 2)
+	case arg <= math.MaxUint32:
+		buf.WriteByte(major<<5 | 26)
+		putUint(buf, arg, 4)
+	default:
+		buf.WriteByte(major<<5 | 27)
+		putUint(buf, arg, 8)
+	}
+}
+
+// errCBORBreak is returned when the break code ending an indefinite-length
+// item is read.
+var errCBORBreak = fmt.Errorf("unexpected break")
+
+// decodeCBOR decodes the next CBOR data item as a generic value.
+func decodeCBOR(r *binaryReader, depth int) (interface{}, error) {
+	if depth > maxCodecDepth {
+		return nil, fmt.Errorf("nesting too deep")
+	}
+	b, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+	major, info := b[0]>>5, b[0]&0x1f
+	if b[0] == 0xff {
+		return nil, errCBORBreak
+	}
+
+	var arg uint64
+	indefinite := false
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info <= 27:
+		if arg, err = r.uint(1 << (info - 24)); err != nil {
+			return nil, err
+		}
+	case info == 31 && major >= cborBytes && major <= cborMap:
+		indefinite = true
+	default:
+		return nil, fmt.Errorf("invalid additional information %d", info)
+	}
+
+	switch major {
+	case cborUint:
+		return json.Number(strconv.FormatUint(arg, 10)), nil
+	case cborNegInt:
+		if arg > math.MaxInt64 {
+			return nil, fmt.Errorf("negative integer out of range")
+		}
+		return json.Number(strconv.FormatInt(-1-int64(arg), 10)), nil
+	case cborBytes, cborText:
+		data, err := cborString(r, major, arg, indefinite, depth)
+		if err != nil {
+			return nil, err
+		}
+		if major == cborText {
+			return string(data), nil
+		}
+		return data, nil
+	case cborArray:
+		return decodeCBORArray(r, arg, indefinite, depth)
+	case cborMap:
+		return decodeCBORMap(r, arg, indefinite, depth)
+	case cborTag:
+		return decodeCBOR(r, depth+1)
+	}
+
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		return halfFloat(uint16(arg)), nil
+	case 26:
+		return float64(math.Float32frombits(uint32(arg))), nil
+	case 27:
+		return math.Float64frombits(arg), nil
+	}
+	return nil, fmt.Errorf("unsupported simple value %d", arg)
+}
+
+// cborString reads the content of a byte or text string, concatenating the
+// chunks of an indefinite-length string.
+func cborString(r *binaryReader, major byte, n uint64, indefinite bool, depth int) ([]byte, error) {
+	if !indefinite {
+		data, err := r.next(n)
+		return append([]byte(nil), data...), err
+	}
+	var data []byte
+	for {
+		chunk, err := decodeCBOR(r, depth+1)
+		if err == errCBORBreak {
+			return data, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch c := chunk.(type) {
+		case []byte:
+			if major != cborBytes {
+				return nil, fmt.Errorf("invalid string chunk")
+			}
+			data = append(data, c...)
+		case string:
+			if major != cborText {
+				return nil, fmt.Errorf("invalid string chunk")
+			}
+			data = append(data, c...)
+		default:
+			return nil, fmt.Errorf("invalid string chunk")
+		}
+	}
+}
+
+// decodeCBORArray decodes an array of n elements, or of elements up to a
+// break code if it has an indefinite length.
+func decodeCBORArray(r *binaryReader, n uint64, indefinite bool, depth int) (interface{}, error) {
+	if indefinite {
+		a := []interface{}{}
+		for {
+			elem, err := decodeCBOR(r, depth+1)
+			if err == errCBORBreak {
+				return a, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, elem)
+		}
+	}
+	count, err := r.count(n)
+	if err != nil {
+		return nil, err
+	}
+	a := make([]interface{}, count)
+	for i := range a {
+		if a[i], err = decodeCBOR(r, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// decodeCBORMap decodes a map of n entries, or of entries up to a break
+// code if it has an indefinite length.
+func decodeCBORMap(r *binaryReader, n uint64, indefinite bool, depth int) (interface{}, error) {
+	count := 0
+	if !indefinite {
+		var err error
+		if count, err = r.count(n); err != nil {
+			return nil, err
+		}
+	}
+	m := make(map[string]interface{}, count)
+	for i := 0; indefinite || i < count; i++ {
+		k, err := decodeCBOR(r, depth+1)
+		if indefinite && err == errCBORBreak {
+			return m, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		key, err := mapKey(k)
+		if err != nil {
+			return nil, err
+		}
+		if m[key], err = decodeCBOR(r, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// halfFloat converts an IEEE 754 half-precision float.
+func halfFloat(h uint16) float64 {
+	exp, mant := int(h>>10)&0x1f, float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
+
+// cborBinder decodes CBOR request bodies.
+func cborBinder(body io.Reader, dst interface{}) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	return UnmarshalCBOR(data, dst)
+}
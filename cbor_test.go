@@ -0,0 +1,100 @@
+package router
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+)
+
+func TestCBOR(t *testing.T) {
+	t.Run("Encode", func(t *testing.T) {
+		tests := []struct {
+			value interface{}
+			hex   string
+		}{
+			{0, "00"},
+			{23, "17"},
+			{24, "1818"},
+			{1000, "1903e8"},
+			{-1, "20"},
+			{-1000, "3903e7"},
+			{uint64(18446744073709551615), "1bffffffffffffffff"},
+			{1.5, "fb3ff8000000000000"},
+			{"a", "6161"},
+			{[]int{1, 2, 3}, "83010203"},
+			{map[string]interface{}{"b": []int{2, 3}, "a": 1}, "a26161016162820203"},
+			{true, "f5"},
+			{nil, "f6"},
+		}
+		for _, tt := range tests {
+			b, err := MarshalCBOR(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(b); got != tt.hex {
+				t.Errorf("Expected %v to encode to %s, but got %s", tt.value, tt.hex, got)
+			}
+		}
+	})
+
+	t.Run("Decode", func(t *testing.T) {
+		tests := []struct {
+			hex  string
+			json string
+		}{
+			{"1903e8", `1000`},
+			{"3903e7", `-1000`},
+			{"f93c00", `1`},
+			{"f97bff", `65504`},
+			{"fa47c35000", `100000`},
+			{"6449455446", `"IETF"`},
+			{"5f42010243030405ff", `"AQIDBAU="`},
+			{"7f657374726561646d696e67ff", `"streaming"`},
+			{"9f018202039f0405ffff", `[1,[2,3],[4,5]]`},
+			{"bf61610161629f0203ffff", `{"a":1,"b":[2,3]}`},
+			{"a201020304", `{"1":2,"3":4}`},
+			{"c074323031332d30332d32315432303a30343a30305a", `"2013-03-21T20:04:00Z"`},
+			{"f7", `null`},
+		}
+		for _, tt := range tests {
+			data, _ := hex.DecodeString(tt.hex)
+			var v interface{}
+			if err := UnmarshalCBOR(data, &v); err != nil {
+				t.Fatalf("Expected %s to decode, but got %v", tt.hex, err)
+			}
+			if got, _ := json.Marshal(v); string(got) != tt.json {
+				t.Errorf("Expected %s to decode to %s, but got %s", tt.hex, tt.json, got)
+			}
+		}
+	})
+
+	t.Run("Struct round trip", func(t *testing.T) {
+		type reading struct {
+			Sensor string    `json:"sensor"`
+			Values []float64 `json:"values"`
+			Raw    []byte    `json:"raw"`
+		}
+		in := reading{Sensor: "t1", Values: []float64{20.5, -3}, Raw: []byte{1, 2}}
+		b, err := MarshalCBOR(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out reading
+		if err := UnmarshalCBOR(b, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Sensor != in.Sensor || len(out.Values) != 2 || out.Values[0] != 20.5 || out.Values[1] != -3 || string(out.Raw) != string(in.Raw) {
+			t.Errorf("Expected %+v, but got %+v", in, out)
+		}
+	})
+
+	t.Run("Invalid input", func(t *testing.T) {
+		for _, h := range []string{"", "19", "9bffffffffffffffff", "ff", "81ff", "1c", "a1a0"} {
+			data, _ := hex.DecodeString(h)
+			var v interface{}
+			if err := UnmarshalCBOR(data, &v); err == nil {
+				t.Errorf("Expected an error decoding %q", h)
+			}
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package router
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// Clone returns an independent copy of the router: routes, groups,
+// middleware, hooks, rewrites and settings can be changed on either router
+// without affecting the other, e.g. to mutate a base router per test case,
+// or to stage changes before swapping them in with a Switch.
+//
+// Handlers, middleware, the job runner and the webhook dispatcher are
+// shared, so handlers bound to the router keep using it. Other runtime
+// state is not copied: the clone has no requests in flight, no servers and
+// no recorded stub responses.
+func (r *Router) Clone() *Router {
+	c := &Router{
+		notFoundHandler: r.notFoundHandler,
+		middleware:      append([]func(http.HandlerFunc) http.HandlerFunc(nil), r.middleware...),
+		logger:          r.logger,
+		beforeHooks:     append([]BeforeHook(nil), r.beforeHooks...),
+		afterHooks:      append([]AfterHook(nil), r.afterHooks...),
+		events:          r.events.clone(),
+		traceFormats:    append([]TraceFormat(nil), r.traceFormats...),
+		errorReporters:  append([]ErrorReporter(nil), r.errorReporters...),
+		startupSummary:  r.startupSummary,
+		cookiePolicy:    r.cookiePolicy,
+		cors:            r.cors,
+		binders:         append([]registeredBinder(nil), r.binders...),
+		encoders:        append([]registeredEncoder(nil), r.encoders...),
+		forwardedTrust:  r.forwardedTrust,
+		middlewareScope: r.middlewareScope,
+		errorHandlers:   r.errorHandlers,
+		jobs:            r.jobs,
+
+		skipPreflightMiddleware: r.skipPreflightMiddleware,
+	}
+	c.maintenance = atomic.LoadInt32(&r.maintenance)
+	c.serverTiming = atomic.LoadInt32(&r.serverTiming)
+	c.errorPages = atomic.LoadInt32(&r.errorPages)
+	c.middlewareGen = atomic.LoadUint64(&r.middlewareGen)
+
+	for _, rule := range r.rewrites {
+		copied := *rule
+		c.rewrites = append(c.rewrites, &copied)
+	}
+	if r.handlers != nil {
+		c.handlers = make(map[string]http.HandlerFunc, len(r.handlers))
+		for name, handler := range r.handlers {
+			c.handlers[name] = handler
+		}
+	}
+	if r.namedMiddleware != nil {
+		c.namedMiddleware = make(map[string]func(http.HandlerFunc) http.HandlerFunc, len(r.namedMiddleware))
+		for name, middleware := range r.namedMiddleware {
+			c.namedMiddleware[name] = middleware
+		}
+	}
+
+	r.admission.mu.Lock()
+	c.admission.limit = r.admission.limit
+	c.admission.queueSize = r.admission.queueSize
+	c.admission.maxWait = r.admission.maxWait
+	r.admission.mu.Unlock()
+
+	r.server.mu.Lock()
+	c.server.drainPeriod = r.server.drainPeriod
+	c.server.proxyProtocol = r.server.proxyProtocol
+	r.server.mu.Unlock()
+
+	r.stubs.mu.Lock()
+	c.stubs.opts = r.stubs.opts
+	c.stubs.files = make(map[string]map[string]*StubResponse)
+	r.stubs.mu.Unlock()
+
+	if rd, ok := r.redactor.Load().(*Redactor); ok {
+		c.redactor.Store(rd)
+	}
+	r.configMu.Lock()
+	c.baseRedactor = r.baseRedactor
+	r.configMu.Unlock()
+	if wh, ok := r.webhooks.Load().(*Webhooks); ok {
+		c.webhooks.Store(wh)
+		c.webhooksOnce.Do(func() {})
+	}
+
+	groups := make(map[*Group]*Group, len(r.groups))
+	for _, g := range r.groups {
+		copied := &Group{
+			router:        c,
+			prefix:        g.prefix,
+			middleware:    append([]func(http.HandlerFunc) http.HandlerFunc(nil), g.middleware...),
+			notFound:      g.notFound,
+			errorHandlers: g.errorHandlers,
+		}
+		groups[g] = copied
+		c.groups = append(c.groups, copied)
+	}
+	for _, g := range r.groups {
+		if g.parent != nil {
+			groups[g].parent = groups[g.parent]
+		}
+	}
+
+	c.routes = r.routes.clone(groups)
+	if config, ok := r.config.Load().(*routeTable); ok {
+		c.config.Store(config.clone(groups))
+	}
+	return c
+}
+
+// clone returns a copy of the table with copies of its routes, whose groups
+// are replaced by their copies.
+func (t *routeTable) clone(groups map[*Group]*Group) *routeTable {
+	c := &routeTable{
+		routes:   make(map[string]map[string][]*Route, len(t.routes)),
+		patterns: make(map[string][]string, len(t.patterns)),
+		seq:      t.seq,
+	}
+	for method, byPath := range t.routes {
+		c.routes[method] = make(map[string][]*Route, len(byPath))
+		for path, candidates := range byPath {
+			for _, route := range candidates {
+				copied := route.clone(groups)
+				copied.table = c
+				c.routes[method][path] = append(c.routes[method][path], copied)
+			}
+		}
+	}
+	for method, paths := range t.patterns {
+		c.patterns[method] = append([]string(nil), paths...)
+	}
+	return c
+}
+
+// clone returns a copy of the route, without its compiled handler.
+func (route *Route) clone(groups map[*Group]*Group) *Route {
+	c := &Route{
+		Method:          route.Method,
+		Path:            route.Path,
+		HandlerFunc:     route.HandlerFunc,
+		pattern:         route.pattern,
+		matchers:        append([]func(req *http.Request) bool(nil), route.matchers...),
+		middleware:      append([]func(http.HandlerFunc) http.HandlerFunc(nil), route.middleware...),
+		queue:           route.queue,
+		disabled:        atomic.LoadInt32(&route.disabled),
+		admin:           route.admin,
+		consumes:        append([]string(nil), route.consumes...),
+		produces:        append([]string(nil), route.produces...),
+		postHooks:       append([]PostHook(nil), route.postHooks...),
+		continueChecks:  append([]ContinueCheck(nil), route.continueChecks...),
+		cors:            route.cors,
+		preflightMaxAge: route.preflightMaxAge,
+		precedence:      route.precedence,
+		seq:             route.seq,
+		requiredQuery:   append([]QueryRequirement(nil), route.requiredQuery...),
+		useCount:        route.useCount,
+	}
+	if route.meta != nil {
+		c.meta = make(map[string]string, len(route.meta))
+		for k, v := range route.meta {
+			c.meta[k] = v
+		}
+	}
+	for _, m := range route.groups {
+		c.groups = append(c.groups, groupMembership{groups[m.group], m.useCount})
+	}
+	return c
+}
+
+// clone returns a copy of the listeners.
+func (e *events) clone() events {
+	return events{
+		routeMatched: append([]EventListener(nil), e.routeMatched...),
+		notFound:     append([]EventListener(nil), e.notFound...),
+		panic:        append([]EventListener(nil), e.panic...),
+		response:     append([]EventListener(nil), e.response...),
+		clientGone:   append([]EventListener(nil), e.clientGone...),
+		reload:       append([]ReloadListener(nil), e.reload...),
+	}
+}
+
+// Switch is an http.Handler serving the requests with a router that can be
+// swapped atomically, e.g. with a clone staged with new routes. Requests
+// already being served finish on the router they started on.
+type Switch struct {
+	router atomic.Value // *Router
+}
+
+// NewSwitch creates a switch serving the requests with the router.
+func NewSwitch(r *Router) *Switch {
+	s := &Switch{}
+	s.router.Store(r)
+	return s
+}
+
+// Router returns the router currently serving the requests.
+func (s *Switch) Router() *Router {
+	return s.router.Load().(*Router)
+}
+
+// Swap serves the next requests with the router and returns the previous
+// one.
+func (s *Switch) Swap(r *Router) *Router {
+	return s.router.Swap(r).(*Router)
+}
+
+// ServeHTTP serves the request with the current router.
+func (s *Switch) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.Router().ServeHTTP(w, req)
+}
@@ -0,0 +1,125 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	text := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) { w.Write([]byte(body)) }
+	}
+	header := func(name string) func(http.HandlerFunc) http.HandlerFunc {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Add("X-Middleware", name)
+				next(w, req)
+			}
+		}
+	}
+
+	base := NewRouter()
+	base.Use(header("base"))
+	base.MustGET("/users", text("users"))
+	api := base.Group("/api")
+	api.Use(header("api"))
+	api.MustAddRoute("GET", "/items/:id", text("item"))
+
+	clone := base.Clone()
+	clone.Use(header("clone"))
+	clone.MustGET("/orders", text("orders"))
+	clone.Disable("GET", "/users")
+
+	serve := func(r http.Handler, path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Independent routes", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			router         *Router
+			path           string
+			expectedStatus int
+		}{
+			{"Base route", base, "/users", http.StatusOK},
+			{"Route added to the clone", base, "/orders", http.StatusNotFound},
+			{"Route disabled in the clone", clone, "/users", http.StatusServiceUnavailable},
+			{"Clone route", clone, "/orders", http.StatusOK},
+			{"Cloned group route", clone, "/api/items/1", http.StatusOK},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := serve(tt.router, tt.path)
+
+				// Check the response status code
+				if rr.Code != tt.expectedStatus {
+					t.Errorf("Expected status code %d, but got %d", tt.expectedStatus, rr.Code)
+				}
+			})
+		}
+	})
+
+	t.Run("Independent middleware", func(t *testing.T) {
+		// Check that the middleware added to the clone does not run on the base
+		if got := serve(base, "/api/items/1").Header()["X-Middleware"]; len(got) != 2 {
+			t.Errorf("Expected middleware %q, but got %q", []string{"base", "api"}, got)
+		}
+		if got := serve(clone, "/api/items/1").Header()["X-Middleware"]; len(got) != 3 {
+			t.Errorf("Expected middleware %q, but got %q", []string{"base", "clone", "api"}, got)
+		}
+	})
+
+	t.Run("Switch", func(t *testing.T) {
+		s := NewSwitch(base)
+		staged := base.Clone()
+		staged.MustGET("/reports", text("reports"))
+		if rr := serve(s, "/reports"); rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d, but got %d", http.StatusNotFound, rr.Code)
+		}
+
+		// Check that the staged router serves the requests once swapped in
+		if previous := s.Swap(staged); previous != base {
+			t.Errorf("Expected the base router to be returned")
+		}
+		if rr := serve(s, "/reports"); rr.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+	})
+
+	t.Run("Jobs", func(t *testing.T) {
+		base := NewRouter()
+		base.Async("POST", "/exports", func(req *http.Request) (interface{}, error) { return "done", nil })
+		clone := base.Clone()
+		clone.Async("POST", "/imports", func(req *http.Request) (interface{}, error) { return "done", nil })
+
+		req, err := http.NewRequest("POST", "/imports", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		clone.ServeHTTP(rr, req)
+
+		// Check that the clone runs jobs and serves their status
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("Expected status code %d, but got %d", http.StatusAccepted, rr.Code)
+		}
+		if rr := serve(clone, rr.Header().Get("Location")); rr.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+
+		// Check that a clone of a router without jobs mounts the job routes
+		fresh := NewRouter().Clone()
+		fresh.Async("POST", "/imports", func(req *http.Request) (interface{}, error) { return "done", nil })
+		if n := len(fresh.routes.routes["GET"]["/jobs/:id"]); n != 1 {
+			t.Errorf("Expected %d job status route, but got %d", 1, n)
+		}
+	})
+}
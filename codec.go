@@ -0,0 +1,124 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// maxCodecDepth bounds the nesting of decoded binary values.
+const maxCodecDepth = 512
+
+// toGeneric converts v to the generic values the binary codecs encode, as
+// it would be encoded to JSON: nil, bool, json.Number, float64, string,
+// []interface{} and map[string]interface{}.
+func toGeneric(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var g interface{}
+	if err := dec.Decode(&g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// fromGeneric stores the generic value decoded by a binary codec in dst, as
+// if it were decoded from JSON.
+func fromGeneric(g interface{}, dst interface{}) error {
+	b, err := json.Marshal(g)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dst)
+}
+
+// sortedKeys returns the keys of the map in order.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mapKey converts a decoded map key to a string.
+func mapKey(key interface{}) (string, error) {
+	switch k := key.(type) {
+	case string:
+		return k, nil
+	case []byte:
+		return string(k), nil
+	case json.Number:
+		return k.String(), nil
+	case bool:
+		return strconv.FormatBool(k), nil
+	case float64:
+		return strconv.FormatFloat(k, 'g', -1, 64), nil
+	}
+	return "", fmt.Errorf("unsupported map key of type %T", key)
+}
+
+// binaryReader reads the input of the binary decoders.
+type binaryReader struct {
+	data []byte
+	pos  int
+}
+
+// next returns the next n bytes.
+func (r *binaryReader) next(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return nil, fmt.Errorf("unexpected end of input")
+	}
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+// uint reads a big-endian unsigned integer of n bytes.
+func (r *binaryReader) uint(n int) (uint64, error) {
+	b, err := r.next(uint64(n))
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+// count checks that a container of n elements, each at least one byte
+// long, fits in the remaining input before it is allocated.
+func (r *binaryReader) count(n uint64) (int, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return 0, fmt.Errorf("unexpected end of input")
+	}
+	return int(n), nil
+}
+
+// putUint appends v to the buffer as a big-endian integer of n bytes.
+func putUint(buf *bytes.Buffer, v uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		buf.WriteByte(byte(v >> (8 * uint(i))))
+	}
+}
+
+// genericInt returns the value of an integer number, in u if it is not
+// negative and in i if it is, and whether the number is an integer.
+func genericInt(n json.Number) (u uint64, i int64, ok bool) {
+	var err error
+	if u, err = strconv.ParseUint(string(n), 10, 64); err == nil {
+		return u, 0, true
+	}
+	if i, err = strconv.ParseInt(string(n), 10, 64); err == nil {
+		return 0, i, true
+	}
+	return 0, 0, false
+}
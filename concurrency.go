@@ -0,0 +1,51 @@
+package router
+
+import (
+	"net/http"
+	"time"
+)
+
+// ConcurrencyLimit returns middleware allowing at most limit requests to be
+// handled concurrently. Excess requests wait up to maxWait for a slot and
+// are then rejected with 503 Service Unavailable. The same middleware can
+// be added to several routes to share the limit between them.
+func ConcurrencyLimit(limit int, maxWait time.Duration) func(http.HandlerFunc) http.HandlerFunc {
+	slots := make(chan struct{}, limit)
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			if !acquireSlot(slots, maxWait, req) {
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+			defer func() { <-slots }()
+
+			next(w, req)
+		}
+	}
+}
+
+// acquireSlot takes a slot from the semaphore, waiting up to maxWait.
+func acquireSlot(slots chan struct{}, maxWait time.Duration, req *http.Request) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+	}
+	if maxWait <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-req.Context().Done():
+		return false
+	}
+}
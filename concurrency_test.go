@@ -0,0 +1,96 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	// newLimitedRouter creates a router whose /slow and /other routes share
+	// a limit of one concurrent request.
+	newLimitedRouter := func(maxWait time.Duration, started chan struct{}, release chan struct{}) *Router {
+		router := NewRouter()
+		limit := ConcurrencyLimit(1, maxWait)
+
+		router.MustAddRoute("GET", "/slow", func(w http.ResponseWriter, req *http.Request) {
+			started <- struct{}{}
+			<-release
+			w.Write([]byte("slow"))
+		}).Use(limit)
+		router.MustAddRoute("GET", "/other", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("other"))
+		}).Use(limit)
+		router.MustAddRoute("GET", "/fast", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("fast"))
+		})
+		return router
+	}
+
+	serve := func(t *testing.T, router *Router, path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Load shedding", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		router := newLimitedRouter(0, started, release)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serve(t, router, "/slow")
+		}()
+		<-started
+		defer wg.Wait()
+		defer close(release)
+
+		// Check the shared limit rejects the second request
+		rr := serve(t, router, "/other")
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status code %d, but got %d", http.StatusServiceUnavailable, rr.Code)
+		}
+		if rr.Header().Get("Retry-After") == "" {
+			t.Errorf("Expected a Retry-After header")
+		}
+
+		// Check routes without the limit are unaffected
+		rr = serve(t, router, "/fast")
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+	})
+
+	t.Run("Queueing", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		router := newLimitedRouter(time.Second, started, release)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serve(t, router, "/slow")
+		}()
+		<-started
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			close(release)
+		}()
+
+		// Check the second request waits for the slot instead of failing
+		rr := serve(t, router, "/other")
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,291 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is a declarative routing configuration. Handlers and middleware
+// are referred to by the names they were registered under with
+// RegisterHandler and RegisterMiddleware.
+type Config struct {
+	Middleware []string         `json:"middleware"`
+	Routes     []RouteConfig    `json:"routes"`
+	Redirects  []RedirectConfig `json:"redirects"`
+	Proxies    []ProxyConfig    `json:"proxies"`
+	Plugins    []PluginConfig   `json:"plugins"`
+	// Redaction replaces the router's redactor when set. Loading a
+	// configuration without it restores the redactor in place before.
+	Redaction *Redactor `json:"redaction"`
+}
+
+// RouteConfig configures a route served by a registered handler.
+type RouteConfig struct {
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Handler    string   `json:"handler"`
+	Middleware []string `json:"middleware"`
+	// When is an optional routing expression, see Expr.
+	When string `json:"when"`
+	// Priority is the precedence of the route, see Route.Priority.
+	Priority int `json:"priority"`
+}
+
+// RedirectConfig configures a redirect route.
+type RedirectConfig struct {
+	Method string `json:"method"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Code   int    `json:"code"`
+}
+
+// ProxyConfig configures a route forwarding requests to an upstream.
+type ProxyConfig struct {
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Target     string   `json:"target"`
+	Middleware []string `json:"middleware"`
+	// When is an optional routing expression, see Expr.
+	When string `json:"when"`
+}
+
+// RegisterHandler registers a handler under a name for use in configurations.
+func (r *Router) RegisterHandler(name string, handler http.HandlerFunc) {
+	if r.handlers == nil {
+		r.handlers = make(map[string]http.HandlerFunc)
+	}
+	r.handlers[name] = handler
+}
+
+// RegisterMiddleware registers middleware under a name for use in configurations.
+func (r *Router) RegisterMiddleware(name string, middleware func(http.HandlerFunc) http.HandlerFunc) {
+	if r.namedMiddleware == nil {
+		r.namedMiddleware = make(map[string]func(http.HandlerFunc) http.HandlerFunc)
+	}
+	r.namedMiddleware[name] = middleware
+}
+
+// LoadConfigFile reads a JSON or YAML configuration file and loads it.
+func (r *Router) LoadConfigFile(path string) error {
+	cfg, err := ReadConfigFile(path)
+	if err != nil {
+		return err
+	}
+	return r.LoadConfig(cfg)
+}
+
+// ReadConfigFile reads and decodes a configuration file. Files with a .yaml
+// or .yml extension are decoded as YAML, with the same field names as JSON,
+// and other files as JSON.
+func ReadConfigFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if data, err = yamlToJSON(data); err != nil {
+			return nil, fmt.Errorf("router: invalid configuration %s: %v", path, err)
+		}
+	}
+	cfg := &Config{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("router: invalid configuration %s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// LoadConfig validates the configuration and replaces the routes loaded
+// from any previous configuration with its routes. The configuration's
+// plugins are activated, then their middleware and the middleware listed at
+// the top level of the configuration apply to all its routes. Nothing is
+// changed if the configuration is invalid.
+func (r *Router) LoadConfig(cfg *Config) error {
+	r.configMu.Lock()
+	defer r.configMu.Unlock()
+	if err := r.ValidateConfig(cfg); err != nil {
+		return err
+	}
+	ext, err := r.activatePlugins(cfg.Plugins)
+	if err != nil {
+		return err
+	}
+
+	shared := append([]func(http.HandlerFunc) http.HandlerFunc{}, ext.middleware...)
+	for _, name := range cfg.Middleware {
+		shared = append(shared, r.namedMiddleware[name])
+	}
+
+	table := newRouteTable()
+	add := func(method string, path string, handler http.HandlerFunc, middleware []string, when string, priority int) {
+		route := newRoute(method, path, handler)
+		route.useCount = len(r.middleware)
+		route.precedence = priority
+		route.Use(shared...)
+		for _, name := range middleware {
+			route.Use(r.namedMiddleware[name])
+		}
+		if when != "" {
+			route.When(MustParseExpr(when))
+		}
+		table.add(route)
+	}
+
+	for _, rc := range cfg.Routes {
+		add(rc.Method, rc.Path, r.handlers[rc.Handler], rc.Middleware, rc.When, rc.Priority)
+	}
+	for _, rc := range cfg.Redirects {
+		add(rc.Method, rc.From, r.redirectHandler(rc.To, redirectCode(rc.Code)), nil, "", 0)
+	}
+	for _, pc := range cfg.Proxies {
+		target, _ := url.Parse(pc.Target)
+		add(pc.Method, pc.Path, r.proxyHandler(target, nil), pc.Middleware, pc.When, 0)
+	}
+	for _, route := range ext.routes {
+		if err := validateRoute(route.Method, route.Path, table.routes[route.Method][route.Path]); err != nil {
+			return err
+		}
+		route.useCount = len(r.middleware)
+		route.middleware = append(append([]func(http.HandlerFunc) http.HandlerFunc{}, shared...), route.middleware...)
+		table.add(route)
+	}
+
+	if cfg.Redaction != nil {
+		if r.baseRedactor == nil {
+			r.baseRedactor = r.Redactor()
+		}
+		r.SetRedactor(cfg.Redaction)
+	} else if r.baseRedactor != nil {
+		r.redactor.Store(r.baseRedactor)
+		r.baseRedactor = nil
+	}
+	r.config.Store(table)
+	return nil
+}
+
+// ValidateConfig checks that the configuration only refers to registered
+// handlers and middleware and that its routes are complete and valid, with
+// the same rules as AddRoute.
+func (r *Router) ValidateConfig(cfg *Config) error {
+	if err := r.validateMiddleware(cfg.Middleware); err != nil {
+		return err
+	}
+	routes := make(map[routeKey][]*Route)
+	validate := func(method string, path string, when string) error {
+		if err := validateExpr(when); err != nil {
+			return err
+		}
+		key := routeKey{method, path}
+		if err := validateRoute(method, path, routes[key]); err != nil {
+			return err
+		}
+		route := newRoute(method, path, nil)
+		if when != "" {
+			route.When(MustParseExpr(when))
+		}
+		routes[key] = append(routes[key], route)
+		return nil
+	}
+	for _, rc := range cfg.Routes {
+		if rc.Method == "" || rc.Path == "" {
+			return fmt.Errorf("router: route %q %q must have a method and a path", rc.Method, rc.Path)
+		}
+		if _, ok := r.handlers[rc.Handler]; !ok {
+			return fmt.Errorf("router: unknown handler %q for route %s %s", rc.Handler, rc.Method, rc.Path)
+		}
+		if err := validate(rc.Method, rc.Path, rc.When); err != nil {
+			return err
+		}
+		if err := r.validateMiddleware(rc.Middleware); err != nil {
+			return err
+		}
+	}
+	for _, rc := range cfg.Redirects {
+		if rc.Method == "" || rc.From == "" || rc.To == "" {
+			return fmt.Errorf("router: redirect %q %q must have a method, a source and a target", rc.Method, rc.From)
+		}
+		if code := redirectCode(rc.Code); code < 300 || code > 399 {
+			return fmt.Errorf("router: invalid redirect status code %d for %s %s", rc.Code, rc.Method, rc.From)
+		}
+		if err := validate(rc.Method, rc.From, ""); err != nil {
+			return err
+		}
+	}
+	for _, pc := range cfg.Proxies {
+		if pc.Method == "" || pc.Path == "" {
+			return fmt.Errorf("router: proxy %q %q must have a method and a path", pc.Method, pc.Path)
+		}
+		target, err := url.Parse(pc.Target)
+		if err != nil || target.Scheme == "" || target.Host == "" {
+			return fmt.Errorf("router: invalid proxy target %q for %s %s", pc.Target, pc.Method, pc.Path)
+		}
+		if err := validate(pc.Method, pc.Path, pc.When); err != nil {
+			return err
+		}
+		if err := r.validateMiddleware(pc.Middleware); err != nil {
+			return err
+		}
+	}
+	for _, pc := range cfg.Plugins {
+		plugin, ok := lookupPlugin(pc.Name)
+		if !ok {
+			return fmt.Errorf("router: unknown plugin %q", pc.Name)
+		}
+		if _, err := decodePluginConfig(plugin, pc.Config); err != nil {
+			return fmt.Errorf("router: invalid configuration for plugin %q: %v", pc.Name, err)
+		}
+	}
+	if cfg.Redaction != nil {
+		if err := cfg.Redaction.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateMiddleware checks that all the middleware names are registered.
+func (r *Router) validateMiddleware(names []string) error {
+	for _, name := range names {
+		if _, ok := r.namedMiddleware[name]; !ok {
+			return fmt.Errorf("router: unknown middleware %q", name)
+		}
+	}
+	return nil
+}
+
+// validateExpr checks that the optional routing expression is valid.
+func validateExpr(src string) error {
+	if src == "" {
+		return nil
+	}
+	_, err := ParseExpr(src)
+	return err
+}
+
+// redirectCode returns the redirect status code, defaulting to 301.
+func redirectCode(code int) int {
+	if code == 0 {
+		return http.StatusMovedPermanently
+	}
+	return code
+}
+
+// yamlToJSON converts a YAML document to JSON, so that configurations are
+// decoded the same way whatever their format.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+	return json.Marshal(doc)
+}
@@ -0,0 +1,187 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	newConfiguredRouter := func() *Router {
+		router := NewRouter()
+		router.RegisterHandler("hello", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("Hello, " + router.GetPathParam(req, "name")))
+		})
+		router.RegisterMiddleware("tag", func(next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("X-Tag", "true")
+				next(w, req)
+			}
+		})
+		return router
+	}
+
+	t.Run("Load configuration file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "routes.json")
+		config := `{
+			"routes": [
+				{"method": "GET", "path": "/hello/:name", "handler": "hello", "middleware": ["tag"]}
+			],
+			"redirects": [
+				{"method": "GET", "from": "/hi/:name", "to": "/hello/:name"}
+			]
+		}`
+		if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		router := newConfiguredRouter()
+		if err := router.LoadConfigFile(path); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		req, err := http.NewRequest("GET", "/hello/John", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		expectedBody := "Hello, John"
+		if rr.Body.String() != expectedBody {
+			t.Errorf("Expected response body %q, but got %q", expectedBody, rr.Body.String())
+		}
+		if rr.Header().Get("X-Tag") != "true" {
+			t.Errorf("Expected the route middleware to run")
+		}
+
+		req, err = http.NewRequest("GET", "/hi/John", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusMovedPermanently {
+			t.Errorf("Expected status code %d, but got %d", http.StatusMovedPermanently, rr.Code)
+		}
+		if location := rr.Header().Get("Location"); location != "/hello/John" {
+			t.Errorf("Expected location %q, but got %q", "/hello/John", location)
+		}
+	})
+
+	t.Run("YAML configuration file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "routes.yaml")
+		config := `
+routes:
+  - method: GET
+    path: /hello/:name
+    handler: hello
+    middleware: [tag]
+redirects:
+  - {method: GET, from: /hi/:name, to: /hello/:name, code: 302}
+`
+		if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		router := newConfiguredRouter()
+		if err := router.LoadConfigFile(path); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		req, err := http.NewRequest("GET", "/hi/John", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Check the response status code
+		if rr.Code != http.StatusFound {
+			t.Errorf("Expected status code %d, but got %d", http.StatusFound, rr.Code)
+		}
+		if location := rr.Header().Get("Location"); location != "/hello/John" {
+			t.Errorf("Expected location %q, but got %q", "/hello/John", location)
+		}
+	})
+
+	t.Run("Invalid YAML configuration file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "routes.yml")
+		if err := os.WriteFile(path, []byte("routes: [\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadConfigFile(path); err == nil {
+			t.Error("Expected an error for an invalid YAML file")
+		}
+	})
+
+	t.Run("Conditional routes", func(t *testing.T) {
+		router := newConfiguredRouter()
+		err := router.LoadConfig(&Config{Routes: []RouteConfig{
+			{Method: "GET", Path: "/hello/:name", Handler: "hello", When: `header("X-Beta") == "1"`},
+			{Method: "GET", Path: "/hello/:name", Handler: "hello"},
+		}})
+		if err != nil {
+			t.Errorf("Expected no error for a conditional route, but got %v", err)
+		}
+	})
+
+	t.Run("Redaction reset", func(t *testing.T) {
+		router := newConfiguredRouter()
+		base := router.Redactor()
+		if err := router.LoadConfig(&Config{Redaction: &Redactor{Headers: []string{"X-Secret"}}}); err != nil {
+			t.Fatal(err)
+		}
+		if router.Redactor() == base {
+			t.Fatal("Expected the configured redactor to be used")
+		}
+
+		// Check that a configuration without redaction restores the previous redactor
+		if err := router.LoadConfig(&Config{}); err != nil {
+			t.Fatal(err)
+		}
+		if router.Redactor() != base {
+			t.Errorf("Expected the previous redactor to be restored")
+		}
+	})
+
+	t.Run("Invalid configuration", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			config *Config
+		}{
+			{"Unknown handler", &Config{Routes: []RouteConfig{{Method: "GET", Path: "/", Handler: "missing"}}}},
+			{"Unknown middleware", &Config{Middleware: []string{"missing"}}},
+			{"Invalid redirect code", &Config{Redirects: []RedirectConfig{{Method: "GET", From: "/a", To: "/b", Code: 200}}}},
+			{"Invalid proxy target", &Config{Proxies: []ProxyConfig{{Method: "GET", Path: "/", Target: "not a url"}}}},
+			{"Invalid method", &Config{Routes: []RouteConfig{{Method: "GE T", Path: "/", Handler: "hello"}}}},
+			{"Duplicate route", &Config{Routes: []RouteConfig{{Method: "GET", Path: "/hello/:name", Handler: "hello"}}}},
+			{"Duplicate redirect", &Config{Redirects: []RedirectConfig{{Method: "GET", From: "/hello/:name", To: "/"}}}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				router := newConfiguredRouter()
+				tt.config.Routes = append([]RouteConfig{{Method: "GET", Path: "/hello/:name", Handler: "hello"}}, tt.config.Routes...)
+
+				if err := router.LoadConfig(tt.config); err == nil {
+					t.Fatalf("Expected an error, but got nil")
+				}
+
+				// Check nothing was registered
+				req, err := http.NewRequest("GET", "/hello/John", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+				if rr.Code != http.StatusNotFound {
+					t.Errorf("Expected status code %d, but got %d", http.StatusNotFound, rr.Code)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// RequireContentLength returns middleware rejecting requests whose body
+// length is not declared up front: chunked or otherwise streamed bodies,
+// and POST, PUT and PATCH requests without a Content-Length header, are
+// answered with 411, and requests whose Content-Length header is invalid
+// or does not match the body length are answered with 400. Apply it with
+// Router.Use to cover all the routes.
+func RequireContentLength() func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			if status := checkContentLength(req); status != 0 {
+				http.Error(w, http.StatusText(status), status)
+				return
+			}
+			next(w, req)
+		}
+	}
+}
+
+// RequireContentLength makes the route reject requests whose body length
+// is not declared up front, see the RequireContentLength middleware.
+func (route *Route) RequireContentLength() *Route {
+	return route.Use(RequireContentLength())
+}
+
+// checkContentLength returns the status rejecting the request, or 0 if its
+// body length is declared.
+func checkContentLength(req *http.Request) int {
+	if len(req.TransferEncoding) > 0 || req.ContentLength < 0 {
+		return http.StatusLengthRequired
+	}
+	values := req.Header.Values("Content-Length")
+	if len(values) == 0 {
+		switch req.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			// Requests built in code have their length without the header
+			if req.ContentLength == 0 {
+				return http.StatusLengthRequired
+			}
+		}
+		return 0
+	}
+	for _, value := range values {
+		length, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || length != req.ContentLength {
+			return http.StatusBadRequest
+		}
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequireContentLength(t *testing.T) {
+	router := NewRouter()
+	router.MustAddRoute("POST", "/upload", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}).RequireContentLength()
+	router.MustAddRoute("GET", "/upload", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}).RequireContentLength()
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		header string
+		chunks bool
+		status int
+	}{
+		{"Declared length", "POST", "hello", "5", false, http.StatusNoContent},
+		{"Declared empty body", "POST", "", "0", false, http.StatusNoContent},
+		{"Missing length", "POST", "", "", false, http.StatusLengthRequired},
+		{"Chunked body", "POST", "hello", "", true, http.StatusLengthRequired},
+		{"Mismatched length", "POST", "hello", "3", false, http.StatusBadRequest},
+		{"Invalid length", "POST", "hello", "five", false, http.StatusBadRequest},
+		{"No body expected", "GET", "", "", false, http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/upload", strings.NewReader(tt.body))
+			if tt.header != "" {
+				req.Header.Set("Content-Length", tt.header)
+			}
+			if tt.chunks {
+				req.ContentLength = -1
+				req.TransferEncoding = []string{"chunked"}
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if status := rr.Code; status != tt.status {
+				t.Errorf("Expected status code %d, but got %d", tt.status, status)
+			}
+		})
+	}
+
+	t.Run("Real chunked request", func(t *testing.T) {
+		server := httptest.NewServer(router)
+		defer server.Close()
+
+		// A reader of unknown length is sent chunked
+		body := struct{ *strings.Reader }{strings.NewReader("hello")}
+		resp, err := http.Post(server.URL+"/upload", "text/plain", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		// Check the response status code
+		if resp.StatusCode != http.StatusLengthRequired {
+			t.Errorf("Expected status code %d, but got %d", http.StatusLengthRequired, resp.StatusCode)
+		}
+	})
+}
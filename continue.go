@@ -0,0 +1,53 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ContinueCheck inspects a request before its body is read. It returns the
+// status to reject the request with, such as 401, 413 or 417, or 0 to
+// accept it.
+type ContinueCheck func(req *http.Request) int
+
+// BeforeContinue adds checks that run before the route's middleware and
+// handler, and so before the body is read. For requests sent with
+// "Expect: 100-continue", the server only sends "100 Continue" once the
+// body is read, so a rejected client does not upload a body that would be
+// discarded. The checks run for requests without the Expect header too.
+func (route *Route) BeforeContinue(checks ...ContinueCheck) *Route {
+	route.continueChecks = append(route.continueChecks, checks...)
+	route.invalidate()
+	return route
+}
+
+// MaxContentLength returns a check rejecting requests declaring a body of
+// more than n bytes with 413.
+func MaxContentLength(n int64) ContinueCheck {
+	return func(req *http.Request) int {
+		if req.ContentLength > n {
+			return http.StatusRequestEntityTooLarge
+		}
+		return 0
+	}
+}
+
+// withContinueChecks wraps the handler to run the route's continue checks.
+func (route *Route) withContinueChecks(handler http.HandlerFunc) http.HandlerFunc {
+	if len(route.continueChecks) == 0 {
+		return handler
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		for _, check := range route.continueChecks {
+			if status := check(req); status != 0 {
+				// The unread body keeps the connection from being reused
+				if strings.EqualFold(req.Header.Get("Expect"), "100-continue") {
+					w.Header().Set("Connection", "close")
+				}
+				http.Error(w, http.StatusText(status), status)
+				return
+			}
+		}
+		handler(w, req)
+	}
+}
@@ -0,0 +1,90 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// watchedReader records whether its content was read.
+type watchedReader struct {
+	*strings.Reader
+	read int32
+}
+
+func (r *watchedReader) Read(p []byte) (int, error) {
+	atomic.StoreInt32(&r.read, 1)
+	return r.Reader.Read(p)
+}
+
+func TestBeforeContinue(t *testing.T) {
+	router := NewRouter()
+	var served int32
+	router.MustAddRoute("PUT", "/files/:name", func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&served, 1)
+		io.Copy(io.Discard, req.Body)
+		w.WriteHeader(http.StatusCreated)
+	}).BeforeContinue(func(req *http.Request) int {
+		if req.Header.Get("Authorization") != "Bearer secret" {
+			return http.StatusUnauthorized
+		}
+		return 0
+	}, MaxContentLength(16))
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+	client := &http.Client{Transport: &http.Transport{ExpectContinueTimeout: 5 * time.Second}}
+
+	put := func(auth string, content string) (*http.Response, *watchedReader) {
+		body := &watchedReader{Reader: strings.NewReader(content)}
+		req, err := http.NewRequest("PUT", server.URL+"/files/report", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.ContentLength = int64(len(content))
+		req.Header.Set("Expect", "100-continue")
+		req.Header.Set("Authorization", auth)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp, body
+	}
+
+	tests := []struct {
+		name    string
+		auth    string
+		content string
+		status  int
+	}{
+		{"Accepted", "Bearer secret", "hello", http.StatusCreated},
+		{"Unauthorized", "Bearer wrong", "hello", http.StatusUnauthorized},
+		{"Too large", "Bearer secret", strings.Repeat("x", 64), http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&served, 0)
+			resp, body := put(tt.auth, tt.content)
+
+			// Check the response status code
+			if resp.StatusCode != tt.status {
+				t.Errorf("Expected status code %d, but got %d", tt.status, resp.StatusCode)
+			}
+
+			// Check that rejected bodies are never uploaded
+			accepted := tt.status == http.StatusCreated
+			if uploaded := atomic.LoadInt32(&body.read) == 1; uploaded != accepted {
+				t.Errorf("Expected body uploaded %v, but got %v", accepted, uploaded)
+			}
+			if handled := atomic.LoadInt32(&served) == 1; handled != accepted {
+				t.Errorf("Expected handler called %v, but got %v", accepted, handled)
+			}
+		})
+	}
+}
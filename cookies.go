@@ -0,0 +1,142 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// CookiePolicy holds the attributes of the cookies set by the application.
+type CookiePolicy struct {
+	// Secure restricts cookies to HTTPS.
+	Secure bool
+	// HttpOnly hides cookies from scripts.
+	HttpOnly bool
+	// SameSite is the SameSite attribute of cookies that have none.
+	SameSite http.SameSite
+	// Domain is the domain of cookies that have none.
+	Domain string
+	// Path is the path of cookies that have none.
+	Path string
+}
+
+// SetCookiePolicy sets the cookie policy applied by SetCookie, and by the
+// router's helpers setting cookies such as experiments.
+func (r *Router) SetCookiePolicy(policy CookiePolicy) {
+	r.cookiePolicy = &policy
+}
+
+// SetCookie adds a Set-Cookie header to the response, like http.SetCookie,
+// after applying the cookie policy: the Secure and HttpOnly attributes are
+// enforced, and the SameSite, Domain and Path attributes are defaulted.
+func (r *Router) SetCookie(w http.ResponseWriter, cookie *http.Cookie) {
+	if policy := r.cookiePolicy; policy != nil {
+		c := *cookie
+		c.Secure = c.Secure || policy.Secure
+		c.HttpOnly = c.HttpOnly || policy.HttpOnly
+		if c.SameSite == 0 {
+			c.SameSite = policy.SameSite
+		}
+		if c.Domain == "" {
+			c.Domain = policy.Domain
+		}
+		if c.Path == "" {
+			c.Path = policy.Path
+		}
+		cookie = &c
+	}
+	http.SetCookie(w, cookie)
+}
+
+// LintCookies returns middleware warning about the cookies set by handlers
+// that violate the cookie policy, e.g. when they call http.SetCookie
+// instead of SetCookie. Warnings are written with warnf, which defaults to
+// the router's warning logger. Responses are not modified.
+func (r *Router) LintCookies(warnf func(format string, args ...interface{})) func(http.HandlerFunc) http.HandlerFunc {
+	if warnf == nil {
+		warnf = r.logger.Warningf
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			lw := &cookieLintWriter{ResponseWriter: w}
+			lw.lint = func() {
+				policy := r.cookiePolicy
+				if policy == nil {
+					return
+				}
+				cookies := (&http.Response{Header: w.Header()}).Cookies()
+				for _, cookie := range cookies {
+					if problems := policy.violations(cookie); len(problems) > 0 {
+						warnf("Cookie %q set by %s %s violates the cookie policy: %s",
+							cookie.Name, req.Method, req.URL.Path, strings.Join(problems, ", "))
+					}
+				}
+			}
+			next(lw, req)
+			lw.check()
+		}
+	}
+}
+
+// violations lists the ways the cookie violates the policy.
+func (p *CookiePolicy) violations(cookie *http.Cookie) []string {
+	var problems []string
+	if p.Secure && !cookie.Secure {
+		problems = append(problems, "not Secure")
+	}
+	if p.HttpOnly && !cookie.HttpOnly {
+		problems = append(problems, "not HttpOnly")
+	}
+	if p.SameSite != 0 && cookie.SameSite == 0 {
+		problems = append(problems, "no SameSite")
+	}
+	if cookie.SameSite == http.SameSiteNoneMode && !cookie.Secure {
+		problems = append(problems, "SameSite=None without Secure")
+	}
+	if p.Domain != "" && cookie.Domain != "" && !strings.EqualFold(strings.TrimPrefix(cookie.Domain, "."), strings.TrimPrefix(p.Domain, ".")) {
+		problems = append(problems, fmt.Sprintf("domain %s instead of %s", cookie.Domain, p.Domain))
+	}
+	return problems
+}
+
+// cookieLintWriter lints the cookies of the response when its header is
+// written.
+type cookieLintWriter struct {
+	http.ResponseWriter
+	lint    func()
+	checked bool
+}
+
+// check lints the cookies once.
+func (w *cookieLintWriter) check() {
+	if !w.checked {
+		w.checked = true
+		w.lint()
+	}
+}
+
+// WriteHeader lints the cookies and writes the header.
+func (w *cookieLintWriter) WriteHeader(status int) {
+	w.check()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write lints the cookies and writes the body.
+func (w *cookieLintWriter) Write(b []byte) (int, error) {
+	w.check()
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher if the wrapped writer supports it.
+func (w *cookieLintWriter) Flush() {
+	w.check()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter.
+func (w *cookieLintWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
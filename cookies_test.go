@@ -0,0 +1,84 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCookiePolicy(t *testing.T) {
+	router := NewRouter()
+	router.SetCookiePolicy(CookiePolicy{
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Domain:   "example.com",
+		Path:     "/",
+	})
+	var warnings []string
+	router.Use(router.LintCookies(func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}))
+	router.MustAddRoute("GET", "/login", func(w http.ResponseWriter, req *http.Request) {
+		router.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+	})
+	router.MustAddRoute("GET", "/legacy", func(w http.ResponseWriter, req *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "tracking", Value: "xyz", SameSite: http.SameSiteNoneMode})
+		w.Write([]byte("ok"))
+	})
+
+	t.Run("Defaults applied", func(t *testing.T) {
+		warnings = nil
+		req := httptest.NewRequest("GET", "/login", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Check the attributes of the cookie
+		cookies := rr.Result().Cookies()
+		if len(cookies) != 1 {
+			t.Fatalf("Expected 1 cookie, but got %d", len(cookies))
+		}
+		cookie := cookies[0]
+		if !cookie.Secure || !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode ||
+			cookie.Domain != "example.com" || cookie.Path != "/" {
+			t.Errorf("Expected the policy attributes, but got %q", rr.Header().Get("Set-Cookie"))
+		}
+		if len(warnings) != 0 {
+			t.Errorf("Expected no warnings, but got %v", warnings)
+		}
+	})
+
+	t.Run("Violations reported", func(t *testing.T) {
+		warnings = nil
+		req := httptest.NewRequest("GET", "/legacy", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Check the warning listing every violation
+		if len(warnings) != 1 {
+			t.Fatalf("Expected 1 warning, but got %v", warnings)
+		}
+		for _, problem := range []string{`"tracking"`, "GET /legacy", "not Secure", "not HttpOnly", "SameSite=None without Secure"} {
+			if !strings.Contains(warnings[0], problem) {
+				t.Errorf("Expected warning to contain %q, but got %q", problem, warnings[0])
+			}
+		}
+	})
+
+	t.Run("Experiment cookie", func(t *testing.T) {
+		router.AddExperiment("GET", "/home", Experiment{
+			Name:     "layout",
+			Variants: []Variant{{Name: "a", Handler: func(w http.ResponseWriter, req *http.Request) {}}},
+		})
+		req := httptest.NewRequest("GET", "/home", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Check that the experiment cookie follows the policy
+		if cookie := rr.Header().Get("Set-Cookie"); !strings.Contains(cookie, "Secure") || !strings.Contains(cookie, "SameSite=Lax") {
+			t.Errorf("Expected a Secure SameSite=Lax cookie, but got %q", cookie)
+		}
+	})
+}
@@ -0,0 +1,198 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSPolicy describes the cross-origin requests browsers may make.
+type CORSPolicy struct {
+	// AllowOrigins lists the allowed origins, such as
+	// "https://app.example.com", or "*" for any origin.
+	AllowOrigins []string
+	// AllowMethods lists the methods allowed in preflight requests. When it
+	// is empty, the method of any route is allowed.
+	AllowMethods []string
+	// AllowHeaders lists the request headers allowed in preflight
+	// requests, or "*" for any header.
+	AllowHeaders []string
+	// ExposeHeaders lists the response headers scripts can read.
+	ExposeHeaders []string
+	// AllowCredentials allows cookies and authorization headers. The
+	// origin is then echoed back even when any origin is allowed.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache preflight responses. Zero
+	// leaves it to the browser default.
+	MaxAge time.Duration
+}
+
+// CORS sets the CORS policy of the routes without a policy of their own.
+func (r *Router) CORS(policy CORSPolicy) {
+	r.cors = &policy
+}
+
+// CORS sets the CORS policy of the route, overriding the router's policy,
+// e.g. to open a public endpoint to any origin. Preflight requests are
+// answered with the policy of the route they are sent for.
+func (route *Route) CORS(policy CORSPolicy) *Route {
+	route.cors = &policy
+	return route
+}
+
+// PreflightMaxAge sets how long browsers may cache the preflight responses
+// of the route, overriding the MaxAge of its CORS policy.
+func (route *Route) PreflightMaxAge(maxAge time.Duration) *Route {
+	route.preflightMaxAge = maxAge
+	return route
+}
+
+// SkipPreflightMiddleware makes the router answer preflight requests from
+// the route table without running the router and route middleware, which
+// may hit databases or reject the unauthenticated preflights.
+func (r *Router) SkipPreflightMiddleware(skip bool) {
+	r.skipPreflightMiddleware = skip
+}
+
+// corsPolicy returns the CORS policy of the route, or nil if there is none.
+func (r *Router) corsPolicy(route *Route) *CORSPolicy {
+	if route != nil && route.cors != nil {
+		return route.cors
+	}
+	return r.cors
+}
+
+// isPreflight reports whether the request is a CORS preflight request.
+func isPreflight(req *http.Request) bool {
+	return req.Method == http.MethodOptions && req.Header.Get("Origin") != "" &&
+		req.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// lookupPreflight returns a route answering the preflight request with the
+// policy of the route the actual request would be served by, or nil if
+// there is no such route or it has no policy.
+func (r *Router) lookupPreflight(req *http.Request) *Route {
+	target := req.Clone(req.Context())
+	target.Method = req.Header.Get("Access-Control-Request-Method")
+	route, _, _ := r.lookup(target)
+	if route == nil {
+		return nil
+	}
+	policy := r.corsPolicy(route)
+	if policy == nil {
+		return nil
+	}
+	maxAge := policy.MaxAge
+	if route.preflightMaxAge != 0 {
+		maxAge = route.preflightMaxAge
+	}
+	return &Route{
+		Method: http.MethodOptions,
+		Path:   route.Path,
+		HandlerFunc: func(w http.ResponseWriter, req *http.Request) {
+			policy.preflight(w, req, maxAge)
+		},
+	}
+}
+
+// preflight answers a preflight request with 204, or with 403 if the
+// policy does not allow the origin, method or headers.
+func (p *CORSPolicy) preflight(w http.ResponseWriter, req *http.Request, maxAge time.Duration) {
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+
+	method := req.Header.Get("Access-Control-Request-Method")
+	var requested []string
+	for _, value := range req.Header.Values("Access-Control-Request-Headers") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				requested = append(requested, name)
+			}
+		}
+	}
+	origin, ok := p.allowOrigin(req.Header.Get("Origin"))
+	if !ok || !p.allowMethod(method) || !p.allowHeaders(requested) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	header.Set("Access-Control-Allow-Origin", origin)
+	if p.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	header.Set("Access-Control-Allow-Methods", method)
+	if len(requested) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+	}
+	if maxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(int(maxAge/time.Second)))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apply sets the CORS headers of an actual cross-origin request.
+func (p *CORSPolicy) apply(w http.ResponseWriter, req *http.Request) {
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	origin, ok := p.allowOrigin(req.Header.Get("Origin"))
+	if !ok {
+		return
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+	if p.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if len(p.ExposeHeaders) > 0 {
+		header.Set("Access-Control-Expose-Headers", strings.Join(p.ExposeHeaders, ", "))
+	}
+}
+
+// allowOrigin returns the value of the Access-Control-Allow-Origin header
+// for the origin, and whether the origin is allowed.
+func (p *CORSPolicy) allowOrigin(origin string) (string, bool) {
+	for _, allowed := range p.AllowOrigins {
+		if allowed == "*" {
+			if p.AllowCredentials {
+				return origin, true
+			}
+			return "*", true
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin, true
+		}
+	}
+	return "", false
+}
+
+// allowMethod reports whether the method is allowed.
+func (p *CORSPolicy) allowMethod(method string) bool {
+	if len(p.AllowMethods) == 0 {
+		return true
+	}
+	for _, allowed := range p.AllowMethods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowHeaders reports whether all the headers are allowed.
+func (p *CORSPolicy) allowHeaders(names []string) bool {
+	for _, name := range names {
+		allowed := false
+		for _, a := range p.AllowHeaders {
+			if a == "*" || strings.EqualFold(a, name) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
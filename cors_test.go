@@ -0,0 +1,126 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	router := NewRouter()
+	router.CORS(CORSPolicy{
+		AllowOrigins:     []string{"https://app.example.com"},
+		AllowHeaders:     []string{"Authorization", "Content-Type"},
+		AllowCredentials: true,
+	})
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("ok"))
+	}
+	router.MustAddRoute("POST", "/orders", handler)
+	router.MustAddRoute("GET", "/widget/:id", handler).CORS(CORSPolicy{
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{"GET"},
+		ExposeHeaders: []string{"X-Widget-Version"},
+	})
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		origin      string
+		preflight   string
+		headers     string
+		status      int
+		allowOrigin string
+	}{
+		{"Allowed origin", "POST", "/orders", "https://app.example.com", "", "", http.StatusOK, "https://app.example.com"},
+		{"Unknown origin", "POST", "/orders", "https://evil.example", "", "", http.StatusOK, ""},
+		{"Public route", "GET", "/widget/7", "https://blog.example.org", "", "", http.StatusOK, "*"},
+		{"Preflight allowed", "OPTIONS", "/orders", "https://app.example.com", "POST", "content-type", http.StatusNoContent, "https://app.example.com"},
+		{"Preflight unknown origin", "OPTIONS", "/orders", "https://evil.example", "POST", "", http.StatusForbidden, ""},
+		{"Preflight header not allowed", "OPTIONS", "/orders", "https://app.example.com", "POST", "X-Debug", http.StatusForbidden, ""},
+		{"Preflight public route", "OPTIONS", "/widget/7", "https://blog.example.org", "GET", "", http.StatusNoContent, "*"},
+		{"Preflight unrouted method", "OPTIONS", "/widget/7", "https://blog.example.org", "DELETE", "", http.StatusNotFound, ""},
+		{"Preflight unknown route", "OPTIONS", "/unknown", "https://app.example.com", "GET", "", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Origin", tt.origin)
+			if tt.preflight != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.preflight)
+			}
+			if tt.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if status := rr.Code; status != tt.status {
+				t.Errorf("Expected status code %d, but got %d", tt.status, status)
+			}
+
+			// Check the allowed origin
+			if origin := rr.Header().Get("Access-Control-Allow-Origin"); origin != tt.allowOrigin {
+				t.Errorf("Expected allowed origin %q, but got %q", tt.allowOrigin, origin)
+			}
+		})
+	}
+}
+
+func TestPreflightCaching(t *testing.T) {
+	router := NewRouter()
+	router.CORS(CORSPolicy{AllowOrigins: []string{"https://app.example.com"}, MaxAge: 10 * time.Minute})
+	var middlewareCalls int
+	router.Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			middlewareCalls++
+			next(w, req)
+		}
+	})
+	handler := func(w http.ResponseWriter, req *http.Request) {}
+	router.MustAddRoute("GET", "/orders", handler)
+	router.MustAddRoute("GET", "/prices", handler).PreflightMaxAge(24 * time.Hour)
+
+	preflight := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("OPTIONS", path, nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Max age", func(t *testing.T) {
+		// Check the policy and route max ages
+		if maxAge := preflight("/orders").Header().Get("Access-Control-Max-Age"); maxAge != "600" {
+			t.Errorf("Expected max age %q, but got %q", "600", maxAge)
+		}
+		if maxAge := preflight("/prices").Header().Get("Access-Control-Max-Age"); maxAge != "86400" {
+			t.Errorf("Expected max age %q, but got %q", "86400", maxAge)
+		}
+	})
+
+	t.Run("Skip middleware", func(t *testing.T) {
+		middlewareCalls = 0
+		preflight("/orders")
+		if middlewareCalls != 1 {
+			t.Errorf("Expected the middleware to run once, but got %d", middlewareCalls)
+		}
+
+		router.SkipPreflightMiddleware(true)
+		middlewareCalls = 0
+		rr := preflight("/orders")
+
+		// Check that the preflight is answered without the middleware
+		if status := rr.Code; status != http.StatusNoContent {
+			t.Errorf("Expected status code %d, but got %d", http.StatusNoContent, status)
+		}
+		if middlewareCalls != 0 {
+			t.Errorf("Expected the middleware not to run, but got %d calls", middlewareCalls)
+		}
+	})
+}
@@ -0,0 +1,197 @@
+package router
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// utf8BOM is the byte order mark spreadsheet applications need to read
+// CSV files as UTF-8.
+const utf8BOM = "\xef\xbb\xbf"
+
+// CSVOptions configures StreamCSV.
+type CSVOptions struct {
+	// BOM starts the response with a UTF-8 byte order mark, for Excel.
+	BOM bool
+	// Comma is the field delimiter. It defaults to ','.
+	Comma rune
+	// Filename, if set, makes browsers download the response as a file.
+	Filename string
+	// FlushInterval is how often buffered rows are flushed to the client,
+	// like NDJSONOptions.FlushInterval.
+	FlushInterval time.Duration
+	// RawFormulas writes cells starting with =, +, -, @, a tab or a carriage
+	// return as they are. By default they are escaped with a leading quote,
+	// so that spreadsheets do not run them as formulas. Numbers are never
+	// escaped.
+	RawFormulas bool
+}
+
+// CSV answers the request with the status and the rows as text/csv. Fields
+// are quoted as needed and formulas are escaped, see CSVOptions.RawFormulas.
+func CSV(w http.ResponseWriter, status int, rows [][]string) error {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(status)
+	cw := csv.NewWriter(w)
+	for _, row := range rows {
+		cw.Write(escapeCSV(row))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// escapeCSV returns the row with the cells that spreadsheets would run as
+// formulas prefixed with a quote, leaving numbers as they are.
+func escapeCSV(row []string) []string {
+	var escaped []string
+	for i, cell := range row {
+		if cell == "" || !strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+			continue
+		}
+		if _, err := strconv.ParseFloat(cell, 64); err == nil {
+			continue
+		}
+		if escaped == nil {
+			escaped = append([]string(nil), row...)
+		}
+		escaped[i] = "'" + cell
+	}
+	if escaped == nil {
+		return row
+	}
+	return escaped
+}
+
+// StreamCSV answers the request with the rows returned by next as text/csv,
+// flushing them periodically, like StreamNDJSON. next returns io.EOF after
+// the last row.
+func (r *Router) StreamCSV(w http.ResponseWriter, req *http.Request, next func() ([]string, error), opts CSVOptions) error {
+	if opts.Filename != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": opts.Filename}))
+	}
+	cw := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+	bom := opts.BOM
+	write := func(v interface{}) error {
+		if bom {
+			bom = false
+			if _, err := io.WriteString(w, utf8BOM); err != nil {
+				return err
+			}
+		}
+		row := v.([]string)
+		if !opts.RawFormulas {
+			row = escapeCSV(row)
+		}
+		cw.Write(row)
+		cw.Flush()
+		return cw.Error()
+	}
+	nextRow := func(func()) (interface{}, error) {
+		return next()
+	}
+	if err := r.stream(w, req, "text/csv; charset=utf-8", nextRow, write, opts.FlushInterval); err != nil {
+		return err
+	}
+	if bom {
+		// No row was written
+		_, err := io.WriteString(w, utf8BOM)
+		return err
+	}
+	return nil
+}
+
+// encodeCSV encodes v, rows of strings or a slice of structs, as CSV for
+// Render. The header row of structs lists the names of their fields, as
+// encoded to JSON.
+func encodeCSV(w io.Writer, v interface{}) error {
+	cw := csv.NewWriter(w)
+	if rows, ok := v.([][]string); ok {
+		for _, row := range rows {
+			cw.Write(escapeCSV(row))
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	if !isCSVEncodable(v) {
+		return fmt.Errorf("router: cannot encode %T as CSV", v)
+	}
+	rv := reflect.ValueOf(v)
+	elem := csvElem(rv.Type())
+
+	var fields []int
+	var header []string
+	for i := 0; i < elem.NumField(); i++ {
+		field := elem.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.PkgPath != "" || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, i)
+		header = append(header, name)
+	}
+	cw.Write(escapeCSV(header))
+	for i := 0; i < rv.Len(); i++ {
+		item := rv.Index(i)
+		for item.Kind() == reflect.Ptr && !item.IsNil() {
+			item = item.Elem()
+		}
+		row := make([]string, len(fields))
+		if item.Kind() == reflect.Struct {
+			for j, f := range fields {
+				row[j] = csvField(item.Field(f))
+			}
+		}
+		cw.Write(escapeCSV(row))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// isCSVEncodable reports whether encodeCSV can encode v.
+func isCSVEncodable(v interface{}) bool {
+	if _, ok := v.([][]string); ok {
+		return true
+	}
+	t := reflect.TypeOf(v)
+	return t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && csvElem(t).Kind() == reflect.Struct
+}
+
+// csvElem returns the element type of a slice, dereferencing pointers.
+func csvElem(t reflect.Type) reflect.Type {
+	elem := t.Elem()
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return elem
+}
+
+// csvField formats a struct field as a CSV field.
+func csvField(v reflect.Value) string {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch value := v.Interface().(type) {
+	case time.Time:
+		return value.Format(time.RFC3339)
+	case fmt.Stringer:
+		return value.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
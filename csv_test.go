@@ -0,0 +1,127 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCSV(t *testing.T) {
+	t.Run("Rows", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		if err := CSV(rr, http.StatusOK, [][]string{{"name", "note"}, {"Smith, J.", `said "hi"`}}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Check the content type and the quoted fields
+		if contentType := rr.Header().Get("Content-Type"); contentType != "text/csv; charset=utf-8" {
+			t.Errorf("Expected content type %q, but got %q", "text/csv; charset=utf-8", contentType)
+		}
+		if want := "name,note\n\"Smith, J.\",\"said \"\"hi\"\"\"\n"; rr.Body.String() != want {
+			t.Errorf("Expected response body %q, but got %q", want, rr.Body.String())
+		}
+	})
+
+	t.Run("Streaming", func(t *testing.T) {
+		router := NewRouter()
+		rows := [][]string{{"id", "total"}, {"1", "9.50"}, {"2", "12.00"}}
+		next := func() ([]string, error) {
+			if len(rows) == 0 {
+				return nil, io.EOF
+			}
+			row := rows[0]
+			rows = rows[1:]
+			return row, nil
+		}
+		req := httptest.NewRequest("GET", "/orders.csv", nil)
+		rr := httptest.NewRecorder()
+		if err := router.StreamCSV(rr, req, next, CSVOptions{BOM: true, Comma: ';', Filename: "orders.csv"}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Check the BOM, delimiter and download headers
+		if want := "\xef\xbb\xbfid;total\n1;9.50\n2;12.00\n"; rr.Body.String() != want {
+			t.Errorf("Expected response body %q, but got %q", want, rr.Body.String())
+		}
+		if disposition := rr.Header().Get("Content-Disposition"); disposition != `attachment; filename=orders.csv` {
+			t.Errorf("Expected an attachment, but got %q", disposition)
+		}
+	})
+
+	t.Run("Formulas", func(t *testing.T) {
+		rows := [][]string{{"=HYPERLINK(\"http://evil.example\")", "+1+1", "-2", "@SUM(A1)", "\tcmd", "safe"}}
+		stream := func(opts CSVOptions) string {
+			i := 0
+			next := func() ([]string, error) {
+				if i == len(rows) {
+					return nil, io.EOF
+				}
+				i++
+				return rows[i-1], nil
+			}
+			rr := httptest.NewRecorder()
+			if err := NewRouter().StreamCSV(rr, httptest.NewRequest("GET", "/export.csv", nil), next, opts); err != nil {
+				t.Fatal(err)
+			}
+			return rr.Body.String()
+		}
+
+		// Check that formulas are escaped, but not numbers
+		want := "\"'=HYPERLINK(\"\"http://evil.example\"\")\",'+1+1,-2,'@SUM(A1),'\tcmd,safe\n"
+		if body := stream(CSVOptions{}); body != want {
+			t.Errorf("Expected response body %q, but got %q", want, body)
+		}
+		rr := httptest.NewRecorder()
+		CSV(rr, http.StatusOK, rows)
+		if rr.Body.String() != want {
+			t.Errorf("Expected response body %q, but got %q", want, rr.Body.String())
+		}
+
+		// Check that escaping can be turned off
+		want = "\"=HYPERLINK(\"\"http://evil.example\"\")\",+1+1,-2,@SUM(A1),\"\tcmd\",safe\n"
+		if body := stream(CSVOptions{RawFormulas: true}); body != want {
+			t.Errorf("Expected response body %q, but got %q", want, body)
+		}
+	})
+
+	t.Run("Empty stream with BOM", func(t *testing.T) {
+		next := func() ([]string, error) { return nil, io.EOF }
+		rr := httptest.NewRecorder()
+		if err := NewRouter().StreamCSV(rr, httptest.NewRequest("GET", "/export.csv", nil), next, CSVOptions{BOM: true}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Check that the BOM is written without rows
+		if want := "\xef\xbb\xbf"; rr.Body.String() != want {
+			t.Errorf("Expected response body %q, but got %q", want, rr.Body.String())
+		}
+	})
+
+	t.Run("Negotiated", func(t *testing.T) {
+		type order struct {
+			ID      int       `json:"id"`
+			Placed  time.Time `json:"placed"`
+			Note    *string   `json:"note"`
+			Private string    `json:"-"`
+		}
+		router := NewRouter()
+		router.MustAddRoute("GET", "/orders", func(w http.ResponseWriter, req *http.Request) {
+			placed := time.Date(2024, 5, 17, 9, 0, 0, 0, time.UTC)
+			router.Render(w, req, http.StatusOK, []order{{ID: 1, Placed: placed, Private: "x"}})
+		})
+		req := httptest.NewRequest("GET", "/orders", nil)
+		req.Header.Set("Accept", "text/csv, application/json;q=0.9")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Check the content type and the rows
+		if contentType := rr.Header().Get("Content-Type"); contentType != "text/csv" {
+			t.Errorf("Expected content type %q, but got %q", "text/csv", contentType)
+		}
+		if want := "id,placed,note\n1,2024-05-17T09:00:00Z,\n"; rr.Body.String() != want {
+			t.Errorf("Expected response body %q, but got %q", want, rr.Body.String())
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DeadlineOptions configures the Deadline middleware.
+type DeadlineOptions struct {
+	// Header holds the time the client will wait for the response, as a
+	// number of milliseconds or a duration such as "1.5s". It defaults to
+	// X-Request-Timeout. The gRPC grpc-timeout header is honoured too.
+	Header string
+	// Default is the timeout of requests without a timeout header. Zero
+	// means no timeout.
+	Default time.Duration
+	// Max caps the timeout requested by clients. Zero means no cap.
+	Max time.Duration
+}
+
+// Deadline returns middleware deriving the deadline of the request context
+// from the timeout the client sent, so that handlers stop working on
+// requests the client has given up on. Requests whose timeout has already
+// expired are answered with 504 without calling the handler. Invalid
+// timeouts are ignored.
+func Deadline(opts DeadlineOptions) func(http.HandlerFunc) http.HandlerFunc {
+	if opts.Header == "" {
+		opts.Header = "X-Request-Timeout"
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			timeout, ok := requestTimeout(req, opts.Header)
+			if !ok {
+				timeout = opts.Default
+				if timeout == 0 {
+					next(w, req)
+					return
+				}
+			}
+			if timeout <= 0 {
+				http.Error(w, http.StatusText(http.StatusGatewayTimeout), http.StatusGatewayTimeout)
+				return
+			}
+			if opts.Max > 0 && timeout > opts.Max {
+				timeout = opts.Max
+			}
+
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			defer cancel()
+			next(w, req.WithContext(ctx))
+		}
+	}
+}
+
+// requestTimeout returns the timeout sent in the header or the grpc-timeout
+// header.
+func requestTimeout(req *http.Request, header string) (time.Duration, bool) {
+	if value := strings.TrimSpace(req.Header.Get(header)); value != "" {
+		if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.Duration(ms) * time.Millisecond, true
+		}
+		if d, err := time.ParseDuration(value); err == nil {
+			return d, true
+		}
+	}
+	if value := req.Header.Get("Grpc-Timeout"); value != "" {
+		return parseGRPCTimeout(value)
+	}
+	return 0, false
+}
+
+// grpcTimeoutUnits maps the units of the grpc-timeout header to durations.
+var grpcTimeoutUnits = map[byte]time.Duration{
+	'H': time.Hour,
+	'M': time.Minute,
+	'S': time.Second,
+	'm': time.Millisecond,
+	'u': time.Microsecond,
+	'n': time.Nanosecond,
+}
+
+// parseGRPCTimeout parses a grpc-timeout header value such as "100m": at
+// most 8 digits followed by a unit.
+func parseGRPCTimeout(value string) (time.Duration, bool) {
+	if len(value) < 2 || len(value) > 9 {
+		return 0, false
+	}
+	unit, ok := grpcTimeoutUnits[value[len(value)-1]]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(value[:len(value)-1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// propagateDeadline sets the timeout headers of an outgoing request to the
+// time left before the deadline of its context, if any.
+func propagateDeadline(req *http.Request) {
+	deadline, ok := req.Context().Deadline()
+	if !ok {
+		return
+	}
+	left := time.Until(deadline)
+	if left < 0 {
+		left = 0
+	}
+	ms := int64(left / time.Millisecond)
+	req.Header.Set("X-Request-Timeout", strconv.FormatInt(ms, 10))
+	if req.Header.Get("Grpc-Timeout") != "" {
+		if ms <= 99999999 {
+			req.Header.Set("Grpc-Timeout", strconv.FormatInt(ms, 10)+"m")
+		} else {
+			req.Header.Set("Grpc-Timeout", strconv.FormatInt(ms/1000, 10)+"S")
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDeadline(t *testing.T) {
+	var remaining time.Duration
+	var hasDeadline bool
+	router := NewRouter()
+	router.Use(Deadline(DeadlineOptions{Max: time.Minute}))
+	router.MustAddRoute("GET", "/users", func(w http.ResponseWriter, req *http.Request) {
+		var deadline time.Time
+		deadline, hasDeadline = req.Context().Deadline()
+		remaining = time.Until(deadline)
+	})
+
+	tests := []struct {
+		name        string
+		header      string
+		value       string
+		status      int
+		hasDeadline bool
+		max         time.Duration
+	}{
+		{"Milliseconds", "X-Request-Timeout", "1500", http.StatusOK, true, 1500 * time.Millisecond},
+		{"Duration", "X-Request-Timeout", "2s", http.StatusOK, true, 2 * time.Second},
+		{"gRPC timeout", "Grpc-Timeout", "100m", http.StatusOK, true, 100 * time.Millisecond},
+		{"Capped", "X-Request-Timeout", "1h", http.StatusOK, true, time.Minute},
+		{"Expired", "X-Request-Timeout", "0", http.StatusGatewayTimeout, false, 0},
+		{"Invalid", "X-Request-Timeout", "soon", http.StatusOK, false, 0},
+		{"Missing", "", "", http.StatusOK, false, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hasDeadline = false
+			req, err := http.NewRequest("GET", "/users", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.header != "" {
+				req.Header.Set(test.header, test.value)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if rr.Code != test.status {
+				t.Errorf("Expected status code %d, but got %d", test.status, rr.Code)
+			}
+
+			// Check the deadline seen by the handler
+			if hasDeadline != test.hasDeadline {
+				t.Fatalf("Expected deadline %v, but got %v", test.hasDeadline, hasDeadline)
+			}
+			if hasDeadline && (remaining > test.max || remaining < test.max-time.Second) {
+				t.Errorf("Expected about %v left, but got %v", test.max, remaining)
+			}
+		})
+	}
+
+	t.Run("Propagated to proxied requests", func(t *testing.T) {
+		var timeout string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			timeout = req.Header.Get("X-Request-Timeout")
+		}))
+		defer upstream.Close()
+
+		target, err := url.Parse(upstream.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proxy := NewRouter()
+		proxy.Use(Deadline(DeadlineOptions{}))
+		proxy.Proxy("GET", "/users", target)
+
+		req, err := http.NewRequest("GET", "/users", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Request-Timeout", "5000")
+
+		rr := httptest.NewRecorder()
+		proxy.ServeHTTP(rr, req)
+
+		ms, err := strconv.Atoi(timeout)
+		if err != nil || ms > 5000 || ms < 4000 {
+			t.Errorf("Expected the time left to be forwarded, but got %q", timeout)
+		}
+	})
+}
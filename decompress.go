@@ -0,0 +1,101 @@
+package router
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DecompressOptions configures the DecompressBody middleware.
+type DecompressOptions struct {
+	// MaxSize is the maximum size of the decompressed body. Reading past
+	// it fails with ErrBodyTooLarge. It defaults to 10 MB.
+	MaxSize int64
+	// Decoders adds decoders by content coding, e.g. "br" for a Brotli
+	// implementation. The gzip and deflate codings are built in.
+	Decoders map[string]func(io.Reader) (io.ReadCloser, error)
+}
+
+// DecompressBody returns middleware decompressing request bodies sent with
+// a Content-Encoding, so that handlers read the original content. Requests
+// with an unsupported coding are answered with 415, and requests whose
+// compressed stream is invalid with 400. The decompressed size is capped
+// to guard against compression bombs.
+func DecompressBody(opts DecompressOptions) func(http.HandlerFunc) http.HandlerFunc {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 10 << 20
+	}
+	decoders := map[string]func(io.Reader) (io.ReadCloser, error){
+		"gzip":   func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+		"x-gzip": func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+		"deflate": func(r io.Reader) (io.ReadCloser, error) {
+			return zlib.NewReader(r)
+		},
+	}
+	for coding, decoder := range opts.Decoders {
+		decoders[strings.ToLower(coding)] = decoder
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			encoding := req.Header.Get("Content-Encoding")
+			if encoding == "" || req.Body == nil || req.Body == http.NoBody {
+				next(w, req)
+				return
+			}
+
+			// Codings are listed in the order they were applied
+			codings := strings.Split(encoding, ",")
+			body := io.ReadCloser(req.Body)
+			for i := len(codings) - 1; i >= 0; i-- {
+				coding := strings.ToLower(strings.TrimSpace(codings[i]))
+				if coding == "identity" || coding == "" {
+					continue
+				}
+				decoder, ok := decoders[coding]
+				if !ok {
+					http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+					return
+				}
+				decoded, err := decoder(body)
+				if err != nil {
+					http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+					return
+				}
+				body = decoded
+			}
+
+			req.Body = &limitedBody{ReadCloser: body, remaining: opts.MaxSize}
+			req.ContentLength = -1
+			req.GetBody = nil
+			req.Header.Del("Content-Encoding")
+			req.Header.Del("Content-Length")
+			next(w, req)
+		}
+	}
+}
+
+// limitedBody fails with ErrBodyTooLarge once more than the remaining
+// bytes are read.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+// Read reads from the body, up to the limit.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrBodyTooLarge
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), ErrBodyTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,88 @@
+package router
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecompressBody(t *testing.T) {
+	router := NewRouter()
+	router.Use(DecompressBody(DecompressOptions{
+		MaxSize: 1024,
+		Decoders: map[string]func(io.Reader) (io.ReadCloser, error){
+			"reverse": func(r io.Reader) (io.ReadCloser, error) {
+				content, err := io.ReadAll(r)
+				for i, j := 0, len(content)-1; i < j; i, j = i+1, j-1 {
+					content[i], content[j] = content[j], content[i]
+				}
+				return io.NopCloser(bytes.NewReader(content)), err
+			},
+		},
+	}))
+	router.MustAddRoute("POST", "/events", func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		if err == ErrBodyTooLarge {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Write(body)
+	})
+
+	compress := func(content string, newWriter func(io.Writer) io.WriteCloser) []byte {
+		var buf bytes.Buffer
+		zw := newWriter(&buf)
+		zw.Write([]byte(content))
+		zw.Close()
+		return buf.Bytes()
+	}
+	gzipped := func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }
+	deflated := func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		status   int
+		want     string
+	}{
+		{"Gzip", "gzip", compress(`{"event":"signup"}`, gzipped), http.StatusOK, `{"event":"signup"}`},
+		{"Deflate", "deflate", compress(`{"event":"login"}`, deflated), http.StatusOK, `{"event":"login"}`},
+		{"Stacked codings", "reverse, gzip", compress("olleh", gzipped), http.StatusOK, "hello"},
+		{"Identity", "identity", []byte("plain"), http.StatusOK, "plain"},
+		{"Uncompressed", "", []byte("plain"), http.StatusOK, "plain"},
+		{"Compression bomb", "gzip", compress(strings.Repeat("0", 1<<20), gzipped), http.StatusRequestEntityTooLarge, ""},
+		{"Invalid stream", "gzip", []byte("not gzip"), http.StatusBadRequest, ""},
+		{"Unsupported coding", "br", []byte("\x0b\x02\x80hello\x03"), http.StatusUnsupportedMediaType, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/events", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if status := rr.Code; status != tt.status {
+				t.Errorf("Expected status code %d, but got %d", tt.status, status)
+			}
+
+			// Check the decompressed body
+			if tt.status == http.StatusOK && rr.Body.String() != tt.want {
+				t.Errorf("Expected response body %q, but got %q", tt.want, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// StatusClientClosedRequest is the status code reported to the response
+// listeners and after hooks for requests whose client went away before
+// the response was complete, as nginx does.
+const StatusClientClosedRequest = 499
+
+// OnClientGone subscribes a listener to clients going away while their
+// request is being handled. The listener is called as soon as the request
+// context is canceled, while the handler may still be running.
+func (r *Router) OnClientGone(listener EventListener) {
+	r.events.clientGone = append(r.events.clientGone, listener)
+}
+
+// ClientGone reports whether the client of the request went away, so that
+// handlers can tell a canceled request from a failed one and abort
+// expensive work. The request context is done in that case.
+func (r *Router) ClientGone(req *http.Request) bool {
+	return errors.Is(req.Context().Err(), context.Canceled)
+}
+
+// watchClient calls the client gone listeners if the client goes away
+// before the returned function is called.
+func (r *Router) watchClient(req *http.Request, route *Route) func() {
+	if len(r.events.clientGone) == 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-req.Context().Done():
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if r.ClientGone(req) {
+				r.events.emit(r.events.clientGone, Event{Request: req, Route: route, Status: StatusClientClosedRequest})
+			}
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,70 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientGone(t *testing.T) {
+	router := NewRouter()
+	gone := make(chan Event, 1)
+	responses := make(chan Event, 1)
+	handlerGone := make(chan bool, 1)
+	reported := make(chan ErrorReport, 1)
+	router.OnClientGone(func(event Event) { gone <- event })
+	router.OnResponse(func(event Event) { responses <- event })
+	router.AddErrorReporter(ErrorReporterFunc(func(report ErrorReport) { reported <- report }))
+
+	release := make(chan struct{})
+	router.MustAddRoute("GET", "/reports", func(w http.ResponseWriter, req *http.Request) {
+		<-release
+		handlerGone <- router.ClientGone(req)
+		http.Error(w, "aborted", http.StatusInternalServerError)
+	})
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/reports", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := http.DefaultClient.Do(req); err == nil {
+		t.Fatal("Expected the request to be canceled")
+	}
+
+	// Check that the listener is called while the handler is running
+	select {
+	case event := <-gone:
+		if event.Route == nil || event.Route.Path != "/reports" {
+			t.Errorf("Expected the matched route, but got %v", event.Route)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the client gone listener to be called")
+	}
+	close(release)
+
+	// Check that the handler can tell the client went away
+	if !<-handlerGone {
+		t.Error("Expected ClientGone to report the canceled request")
+	}
+
+	// Check that the response is reported as canceled, not as an error
+	event := <-responses
+	if event.Status != StatusClientClosedRequest {
+		t.Errorf("Expected status code %d, but got %d", StatusClientClosedRequest, event.Status)
+	}
+	select {
+	case report := <-reported:
+		t.Errorf("Expected no error report, but got %+v", report)
+	default:
+	}
+}
@@ -0,0 +1,45 @@
+package router
+
+import (
+	"context"
+	"net/url"
+)
+
+// Discoverer finds the backends of an upstream pool, e.g. from DNS, Consul,
+// etcd or the Kubernetes API, see StartDiscovery.
+type Discoverer interface {
+	// Watch calls update with the current backends, then again whenever
+	// they change, until the context is done. Failures should be retried
+	// rather than reported, keeping the last backends.
+	Watch(ctx context.Context, update func(targets []*url.URL))
+}
+
+// StaticDiscoverer is a Discoverer of a fixed list of backends.
+type StaticDiscoverer []*url.URL
+
+// Watch calls update with the backends once.
+func (d StaticDiscoverer) Watch(ctx context.Context, update func(targets []*url.URL)) {
+	update(d)
+	<-ctx.Done()
+}
+
+// StartDiscovery watches the discoverer in the background, replacing the
+// backends of the pool with those it finds. Call the returned function to
+// stop watching.
+func (p *UpstreamPool) StartDiscovery(d Discoverer) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		d.Watch(ctx, func(targets []*url.URL) {
+			if ctx.Err() == nil {
+				p.SetBackends(targets)
+			}
+		})
+	}()
+
+	return func() {
+		cancel()
+		<-stopped
+	}
+}
@@ -0,0 +1,58 @@
+package router
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+// channelDiscoverer sends the backends received on a channel.
+type channelDiscoverer chan []*url.URL
+
+func (d channelDiscoverer) Watch(ctx context.Context, update func(targets []*url.URL)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case targets := <-d:
+			update(targets)
+		}
+	}
+}
+
+func TestStartDiscovery(t *testing.T) {
+	a, _ := url.Parse("http://192.0.2.1:8080")
+	b, _ := url.Parse("http://192.0.2.2:8080")
+
+	t.Run("Static", func(t *testing.T) {
+		pool := NewUpstreamPool(UpstreamPoolOptions{})
+		stop := pool.StartDiscovery(StaticDiscoverer{a, b})
+		defer stop()
+
+		// Check that the pool has the static backends
+		waitFor(t, func() bool { return len(pool.Backends()) == 2 })
+	})
+
+	t.Run("Changes", func(t *testing.T) {
+		pool := NewUpstreamPool(UpstreamPoolOptions{})
+		changes := make(channelDiscoverer)
+		stop := pool.StartDiscovery(changes)
+
+		// Check that the pool follows the changes
+		changes <- []*url.URL{a, b}
+		waitFor(t, func() bool { return len(pool.Backends()) == 2 })
+		changes <- []*url.URL{b}
+		waitFor(t, func() bool {
+			backends := pool.Backends()
+			return len(backends) == 1 && backends[0].String() == b.String()
+		})
+
+		// Check that the pool no longer changes once stopped
+		stop()
+		select {
+		case changes <- []*url.URL{a}:
+			t.Errorf("Expected the discoverer to be stopped")
+		default:
+		}
+	})
+}
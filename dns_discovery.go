@@ -0,0 +1,124 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DNSDiscovery configures the discovery of the backends of an upstream pool
+// from DNS records, such as those of a Kubernetes headless service.
+type DNSDiscovery struct {
+	// Name is the name resolved, e.g. "api.default.svc.cluster.local", or
+	// with SRV "_http._tcp.api.default.svc.cluster.local".
+	Name string
+	// SRV resolves SRV records, which give the port of each backend, rather
+	// than A and AAAA records.
+	SRV bool
+	// Port is the port of the backends found with A and AAAA records. It
+	// defaults to 80, or 443 with the https scheme.
+	Port int
+	// Scheme is the scheme of the backend URLs. It defaults to "http".
+	Scheme string
+	// Interval is the time between resolutions. It defaults to 30 seconds.
+	Interval time.Duration
+	// Resolver resolves the name. It defaults to net.DefaultResolver.
+	Resolver *net.Resolver
+	// Logf, if set, logs failed resolutions.
+	Logf func(format string, args ...interface{})
+}
+
+// StartDNSDiscovery resolves the name periodically, replacing the backends
+// of the pool with the addresses found, see Watch. Call the returned
+// function to stop resolving.
+func (p *UpstreamPool) StartDNSDiscovery(d DNSDiscovery) (stop func()) {
+	return p.StartDiscovery(d)
+}
+
+// Watch resolves the name now and then every Interval, calling update with
+// the addresses found when they change. Failed resolutions and resolutions
+// finding no address are logged and ignored.
+func (d DNSDiscovery) Watch(ctx context.Context, update func(targets []*url.URL)) {
+	if d.Scheme == "" {
+		d.Scheme = "http"
+	}
+	if d.Port <= 0 {
+		d.Port = 80
+		if d.Scheme == "https" {
+			d.Port = 443
+		}
+	}
+	if d.Interval <= 0 {
+		d.Interval = 30 * time.Second
+	}
+	if d.Resolver == nil {
+		d.Resolver = net.DefaultResolver
+	}
+	if d.Logf == nil {
+		d.Logf = func(string, ...interface{}) {}
+	}
+
+	var last string
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		resolveCtx, cancel := context.WithTimeout(ctx, d.Interval)
+		targets, err := d.resolve(resolveCtx)
+		cancel()
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			d.Logf("Failed to resolve upstream %s: %v", d.Name, err)
+		case len(targets) == 0:
+			d.Logf("No address found for upstream %s", d.Name)
+		default:
+			if key := fmt.Sprint(targets); key != last {
+				last = key
+				update(targets)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// resolve returns the backend URLs of the DNS records, sorted so that the
+// order does not depend on the DNS server.
+func (d DNSDiscovery) resolve(ctx context.Context) ([]*url.URL, error) {
+	var hosts []string
+	if d.SRV {
+		_, records, err := d.Resolver.LookupSRV(ctx, "", "", d.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			host := strings.TrimSuffix(record.Target, ".")
+			hosts = append(hosts, net.JoinHostPort(host, strconv.Itoa(int(record.Port))))
+		}
+	} else {
+		addrs, err := d.Resolver.LookupIPAddr(ctx, d.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			hosts = append(hosts, net.JoinHostPort(addr.IP.String(), strconv.Itoa(d.Port)))
+		}
+	}
+	sort.Strings(hosts)
+
+	targets := make([]*url.URL, 0, len(hosts))
+	for _, host := range hosts {
+		targets = append(targets, &url.URL{Scheme: d.Scheme, Host: host})
+	}
+	return targets, nil
+}
@@ -0,0 +1,78 @@
+package router
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSetBackends(t *testing.T) {
+	a, _ := url.Parse("http://192.0.2.1:8080")
+	b, _ := url.Parse("http://192.0.2.2:8080")
+	c, _ := url.Parse("http://192.0.2.3:8080")
+	pool := NewUpstreamPool(UpstreamPoolOptions{}, a, b)
+	kept := pool.backends[1]
+
+	pool.SetBackends([]*url.URL{b, c, c})
+
+	// Check the backends of the pool
+	backends := pool.Backends()
+	if len(backends) != 2 || backends[0].String() != b.String() || backends[1].String() != c.String() {
+		t.Errorf("Expected backends %v, but got %v", []*url.URL{b, c}, backends)
+	}
+
+	// Check that the remaining backend kept its state
+	if pool.backends[0] != kept {
+		t.Errorf("Expected the remaining backend to be kept")
+	}
+}
+
+func TestDNSDiscovery(t *testing.T) {
+	t.Run("A records", func(t *testing.T) {
+		stale, _ := url.Parse("http://192.0.2.1:8080")
+		pool := NewUpstreamPool(UpstreamPoolOptions{}, stale)
+		stop := pool.StartDNSDiscovery(DNSDiscovery{Name: "localhost", Port: 8080, Interval: time.Hour})
+		defer stop()
+
+		// Check that the backends were replaced with the resolved addresses
+		waitFor(t, func() bool {
+			for _, backend := range pool.Backends() {
+				if backend.String() == "http://127.0.0.1:8080" {
+					return true
+				}
+			}
+			return false
+		})
+		for _, backend := range pool.Backends() {
+			if backend.String() == stale.String() {
+				t.Errorf("Expected the stale backend to be removed")
+			}
+		}
+	})
+
+	t.Run("Failed resolution", func(t *testing.T) {
+		current, _ := url.Parse("http://192.0.2.1:8080")
+		pool := NewUpstreamPool(UpstreamPoolOptions{}, current)
+		logged := make(chan string, 1)
+		stop := pool.StartDNSDiscovery(DNSDiscovery{
+			Name:     "upstream.invalid",
+			Interval: time.Hour,
+			Logf: func(format string, args ...interface{}) {
+				logged <- format
+			},
+		})
+		defer stop()
+
+		// Check that the failure was logged
+		select {
+		case <-logged:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the failure to be logged")
+		}
+
+		// Check that the backends were kept
+		if backends := pool.Backends(); len(backends) != 1 || backends[0].String() != current.String() {
+			t.Errorf("Expected backends %v, but got %v", []*url.URL{current}, backends)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+)
+
+// DumpOptions configures the dump middleware.
+type DumpOptions struct {
+	// MaxBodySize is the number of body bytes dumped, 4096 by default.
+	// Longer bodies are truncated.
+	MaxBodySize int
+	// Redactor hides secrets from the dumps. It defaults to the router's
+	// redactor.
+	Redactor *Redactor
+	// Logf writes the dumps. It defaults to the router's info logger.
+	Logf func(format string, args ...interface{})
+}
+
+// Dump returns middleware logging the full requests and responses, with
+// their bodies capped and secrets redacted, for debugging.
+func (r *Router) Dump(opts DumpOptions) func(http.HandlerFunc) http.HandlerFunc {
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = 4096
+	}
+	if opts.Logf == nil {
+		opts.Logf = r.logger.Infof
+	}
+	redactor := func() *Redactor {
+		if opts.Redactor != nil {
+			return opts.Redactor
+		}
+		return r.Redactor()
+	}
+
+	dumpBody := func(body []byte, truncated bool) string {
+		s := string(redactor().Body(body))
+		if truncated {
+			s += "... (truncated)"
+		}
+		return s
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			var body []byte
+			truncated := false
+			if req.Body != nil {
+				body, _ = io.ReadAll(io.LimitReader(req.Body, int64(opts.MaxBodySize)+1))
+				req.Body = readCloser{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+				if len(body) > opts.MaxBodySize {
+					body, truncated = body[:opts.MaxBodySize], true
+				}
+			}
+
+			dumped := req.Clone(req.Context())
+			dumped.Header = redactor().Header(req.Header)
+			dumped.URL.RawQuery = redactor().Query(dumped.URL.Query()).Encode()
+			head, _ := httputil.DumpRequest(dumped, false)
+			opts.Logf("Request dump (correlation ID %s):\n%s%s", r.GetCorrelationID(req), head, dumpBody(body, truncated))
+
+			cw := &captureWriter{ResponseWriter: w, limit: opts.MaxBodySize}
+			next(cw, req)
+
+			var respHead bytes.Buffer
+			fmt.Fprintf(&respHead, "HTTP/1.1 %d %s\r\n", cw.Status(), http.StatusText(cw.Status()))
+			redactor().Header(w.Header()).Write(&respHead)
+			opts.Logf("Response dump (correlation ID %s):\n%s\r\n%s", r.GetCorrelationID(req), respHead.String(), dumpBody(cw.body.Bytes(), cw.truncated))
+		}
+	}
+}
+
+// readCloser combines a reader with the closer of the original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// captureWriter keeps the first bytes of the response body, or all of it
+// when limit is negative.
+type captureWriter struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// WriteHeader records the status code.
+func (w *captureWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write keeps up to limit bytes of the body.
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.limit < 0 {
+		w.body.Write(b)
+	} else if room := w.limit - w.body.Len(); room > 0 {
+		if len(b) > room {
+			w.body.Write(b[:room])
+			w.truncated = true
+		} else {
+			w.body.Write(b)
+		}
+	} else if len(b) > 0 {
+		w.truncated = true
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher if the wrapped writer supports it.
+func (w *captureWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Status returns the status code, defaulting to 200.
+func (w *captureWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Unwrap returns the wrapped writer.
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
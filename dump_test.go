@@ -0,0 +1,105 @@
+package router
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	var dumps []string
+	router := NewRouter()
+	router.Use(router.Dump(DumpOptions{
+		MaxBodySize: 64,
+		Logf: func(format string, args ...interface{}) {
+			dumps = append(dumps, fmt.Sprintf(format, args...))
+		},
+	}))
+	router.MustAddRoute("POST", "/login", func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		w.Header().Set("Set-Cookie", "session=abc123")
+		w.Write([]byte(`{"user":"gopher","token":"t0k3n","echo":` + fmt.Sprint(len(body)) + `}`))
+	})
+
+	body := `{"user":"gopher","password":"hunter2","profile":{"api_key":"k3y"}}`
+	req, err := http.NewRequest("POST", "/login", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer s3cret")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	t.Run("Body passed through", func(t *testing.T) {
+		expectedBody := fmt.Sprintf(`{"user":"gopher","token":"t0k3n","echo":%d}`, len(body))
+		if rr.Body.String() != expectedBody {
+			t.Errorf("Expected response body %q, but got %q", expectedBody, rr.Body.String())
+		}
+	})
+
+	t.Run("Secrets redacted", func(t *testing.T) {
+		if len(dumps) != 2 {
+			t.Fatalf("Expected 2 dumps, but got %d", len(dumps))
+		}
+		all := strings.Join(dumps, "\n")
+		for _, secret := range []string{"s3cret", "hunter2", "k3y", "t0k3n", "abc123"} {
+			if strings.Contains(all, secret) {
+				t.Errorf("Expected %q to be redacted, but got %s", secret, all)
+			}
+		}
+		if !strings.Contains(dumps[0], "POST /login") || !strings.Contains(dumps[0], `"user":"gopher"`) {
+			t.Errorf("Expected the request to be dumped, but got %s", dumps[0])
+		}
+		if !strings.Contains(dumps[1], "HTTP/1.1 200 OK") {
+			t.Errorf("Expected the response status to be dumped, but got %s", dumps[1])
+		}
+	})
+
+	t.Run("Truncated body", func(t *testing.T) {
+		dumps = nil
+		long := `{"password":"hunter2","data":"` + strings.Repeat("x", 100) + `"}`
+		req, err := http.NewRequest("POST", "/login", strings.NewReader(long))
+		if err != nil {
+			t.Fatal(err)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		if !strings.Contains(dumps[0], "(truncated)") || strings.Contains(dumps[0], "hunter2") {
+			t.Errorf("Expected a truncated and redacted body, but got %s", dumps[0])
+		}
+	})
+}
+
+func TestDumpStreaming(t *testing.T) {
+	router := NewRouter()
+	router.Use(router.Dump(DumpOptions{Logf: func(format string, args ...interface{}) {}}))
+	rr := httptest.NewRecorder()
+	router.MustAddRoute("GET", "/events", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "first\n")
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("Expected the dumped response to support flushing")
+		}
+		flusher.Flush()
+
+		// Check that the first event reached the client before the handler returned
+		if !rr.Flushed || rr.Body.String() != "first\n" {
+			t.Errorf("Expected the first event to be flushed, but got %q", rr.Body.String())
+		}
+		io.WriteString(w, "second\n")
+	})
+
+	req, err := http.NewRequest("GET", "/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.ServeHTTP(rr, req)
+
+	if rr.Body.String() != "first\nsecond\n" {
+		t.Errorf("Expected response body %q, but got %q", "first\nsecond\n", rr.Body.String())
+	}
+}
@@ -0,0 +1,297 @@
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// Error page modes.
+const (
+	errorPagesOff int32 = iota
+	errorPagesProduction
+	errorPagesDevelopment
+)
+
+// maxErrorMessage is the maximum number of bytes of a handler's 5xx body
+// shown on development error pages.
+const maxErrorMessage = 64 << 10
+
+// EnableErrorPages makes the router answer handler panics with 500 instead
+// of propagating them, and replace the bodies of 5xx responses. In
+// development mode the pages show the error, its stack trace, a dump of
+// the request and the route table; otherwise they only show the status
+// text, so that no internal details leak to clients. Panics are still
+// passed to the panic listeners and error reporters. In development mode,
+// the default not found response also suggests the closest routes.
+func (r *Router) EnableErrorPages(dev bool) {
+	mode := errorPagesProduction
+	if dev {
+		mode = errorPagesDevelopment
+	}
+	atomic.StoreInt32(&r.errorPages, mode)
+}
+
+// DevModeFromEnv reports whether the ROUTER_ENV environment variable is
+// set to "development" or "dev".
+func DevModeFromEnv() bool {
+	env := os.Getenv("ROUTER_ENV")
+	return env == "development" || env == "dev"
+}
+
+// devMode reports whether development error pages are enabled.
+func (r *Router) devMode() bool {
+	return atomic.LoadInt32(&r.errorPages) == errorPagesDevelopment
+}
+
+// errorPageWriter holds back the bodies of 5xx responses so that they can
+// be replaced with an error page.
+type errorPageWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	intercepted bool
+	body        bytes.Buffer
+}
+
+// WriteHeader holds back 5xx responses and writes the others.
+func (w *errorPageWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+	if status >= 500 {
+		w.intercepted = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write keeps the beginning of held back bodies and writes the others.
+func (w *errorPageWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.intercepted {
+		if room := maxErrorMessage - w.body.Len(); room > 0 {
+			if len(b) > room {
+				w.body.Write(b[:room])
+			} else {
+				w.body.Write(b)
+			}
+		}
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher if the wrapped writer supports it.
+func (w *errorPageWriter) Flush() {
+	if w.intercepted {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker if the wrapped writer supports it.
+func (w *errorPageWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("router: response writer does not support hijacking")
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the wrapped http.ResponseWriter.
+func (w *errorPageWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ErrorHandler writes the response replacing a 5xx response or a panic,
+// see SetErrorHandler. err is a *PanicError for panics, and otherwise holds
+// the body written by the handler, or the status text if it wrote none.
+type ErrorHandler func(w http.ResponseWriter, req *http.Request, status int, err error)
+
+// PanicError is the error passed to error handlers for a recovered panic.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error returns the panic value.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// SetErrorHandler sets the handler writing the responses replacing 5xx
+// responses and panics, instead of the error pages. It enables the error
+// pages in production mode if they are not enabled, see EnableErrorPages.
+// Groups may override it, see Group.SetErrorHandler.
+func (r *Router) SetErrorHandler(handler ErrorHandler) {
+	r.errorHandlers.err = handler
+	atomic.CompareAndSwapInt32(&r.errorPages, errorPagesOff, errorPagesProduction)
+}
+
+// SetPanicHandler sets the handler writing the responses to panics,
+// instead of the error handler, like SetErrorHandler.
+func (r *Router) SetPanicHandler(handler ErrorHandler) {
+	r.errorHandlers.panic = handler
+	atomic.CompareAndSwapInt32(&r.errorPages, errorPagesOff, errorPagesProduction)
+}
+
+// errorHandlers are the error and panic handlers of a router or a group.
+type errorHandlers struct {
+	err   ErrorHandler
+	panic ErrorHandler
+}
+
+// get returns the panic handler for panics, falling back to the error
+// handler, or the error handler otherwise.
+func (h errorHandlers) get(panicked bool) ErrorHandler {
+	if panicked && h.panic != nil {
+		return h.panic
+	}
+	return h.err
+}
+
+// errorHandler returns the handler of a 5xx response or a panic: that of
+// the innermost group of the route, or of the request path if no route
+// matched, with a handler, else that of the router, or nil.
+func (r *Router) errorHandler(req *http.Request, panicked bool) ErrorHandler {
+	var g *Group
+	if route := r.GetRoute(req); route != nil {
+		if len(route.groups) > 0 {
+			g = route.groups[len(route.groups)-1].group
+		}
+	} else {
+		g = r.groupOf(req.URL.Path)
+	}
+	for ; g != nil; g = g.parent {
+		if handler := g.errorHandlers.get(panicked); handler != nil {
+			return handler
+		}
+	}
+	return r.errorHandlers.get(panicked)
+}
+
+// writeErrorPage writes the error page of a held back 5xx response or of a
+// panic, if the response has not been written yet.
+func (r *Router) writeErrorPage(w *errorPageWriter, req *http.Request, recovered interface{}, stack []byte) {
+	status := w.status
+	message := w.body.String()
+	if recovered != nil {
+		if w.wroteHeader && !w.intercepted {
+			return
+		}
+		status = http.StatusInternalServerError
+		message = fmt.Sprintf("panic: %v", recovered)
+	} else if !w.intercepted {
+		return
+	}
+
+	header := w.ResponseWriter.Header()
+	header.Del("Content-Length")
+	header.Del("Content-Encoding")
+	header.Set("X-Content-Type-Options", "nosniff")
+
+	if handler := r.errorHandler(req, recovered != nil); handler != nil {
+		var err error
+		if recovered != nil {
+			err = &PanicError{Value: recovered, Stack: stack}
+		} else if message = strings.TrimSpace(message); message != "" {
+			err = errors.New(message)
+		} else {
+			err = errors.New(http.StatusText(status))
+		}
+		header.Del("Content-Type")
+		handler(w.ResponseWriter, req, status, err)
+		return
+	}
+
+	if !r.devMode() {
+		header.Set("Content-Type", "text/plain; charset=utf-8")
+		w.ResponseWriter.WriteHeader(status)
+		fmt.Fprintln(w.ResponseWriter, http.StatusText(status))
+		return
+	}
+
+	headerNames := make([]string, 0, len(req.Header))
+	redactedHeader := r.Redactor().Header(req.Header)
+	for name := range redactedHeader {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+	var requestHeader [][2]string
+	for _, name := range headerNames {
+		for _, value := range redactedHeader[name] {
+			requestHeader = append(requestHeader, [2]string{name, value})
+		}
+	}
+
+	u := *req.URL
+	if u.RawQuery != "" {
+		u.RawQuery = r.Redactor().Query(u.Query()).Encode()
+	}
+
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	w.ResponseWriter.WriteHeader(status)
+	err := devErrorPage.Execute(w.ResponseWriter, map[string]interface{}{
+		"Status":        status,
+		"StatusText":    http.StatusText(status),
+		"Message":       r.Redactor().String(message),
+		"Stack":         string(stack),
+		"CorrelationID": r.GetCorrelationID(req),
+		"Method":        req.Method,
+		"URL":           u.String(),
+		"Proto":         req.Proto,
+		"RemoteAddr":    req.RemoteAddr,
+		"Header":        requestHeader,
+		"Routes":        r.Routes(),
+	})
+	if err != nil {
+		r.logger.Errorf("Failed to render error page: %v", err)
+	}
+}
+
+// devErrorPage renders development error pages.
+var devErrorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>{{.Status}} {{.StatusText}}</title>
+  <style>
+    body { font-family: sans-serif; margin: 2em; }
+    pre { background: #f4f4f4; padding: 1em; overflow: auto; }
+    td, th { padding: 0.2em 1em; text-align: left; font-family: monospace; }
+  </style>
+</head>
+<body>
+  <h1>{{.Status}} {{.StatusText}}</h1>
+  <pre>{{.Message}}</pre>
+  {{if .Stack}}<h2>Stack trace</h2>
+  <pre>{{.Stack}}</pre>{{end}}
+  <h2>Request</h2>
+  <pre>{{.Method}} {{.URL}} {{.Proto}}
+{{range .Header}}{{index . 0}}: {{index . 1}}
+{{end}}</pre>
+  <p>Remote address {{.RemoteAddr}}, correlation ID {{.CorrelationID}}</p>
+  <h2>Routes</h2>
+  <table>
+    {{range .Routes}}<tr><td>{{.Method}}</td><td>{{.Path}}</td><td>{{if not .Enabled}}disabled{{end}}</td></tr>
+    {{end}}
+  </table>
+</body>
+</html>
+`))
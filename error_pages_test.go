@@ -0,0 +1,106 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorPages(t *testing.T) {
+	newRouter := func(dev bool) *Router {
+		router := NewRouter()
+		router.EnableErrorPages(dev)
+		router.MustAddRoute("GET", "/panic", func(w http.ResponseWriter, req *http.Request) {
+			panic("nil map")
+		})
+		router.MustAddRoute("GET", "/fail", func(w http.ResponseWriter, req *http.Request) {
+			http.Error(w, "pq: password authentication failed", http.StatusInternalServerError)
+		})
+		router.MustAddRoute("GET", "/missing", func(w http.ResponseWriter, req *http.Request) {
+			http.Error(w, "user 42 not found", http.StatusNotFound)
+		})
+		return router
+	}
+	serve := func(router *Router, path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer secret")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Production", func(t *testing.T) {
+		router := newRouter(false)
+
+		tests := []struct {
+			path   string
+			status int
+			body   string
+		}{
+			{"/panic", http.StatusInternalServerError, "Internal Server Error\n"},
+			{"/fail", http.StatusInternalServerError, "Internal Server Error\n"},
+			{"/missing", http.StatusNotFound, "user 42 not found\n"},
+		}
+		for _, test := range tests {
+			rr := serve(router, test.path)
+
+			// Check the response status code
+			if rr.Code != test.status {
+				t.Errorf("Expected status code %d for %s, but got %d", test.status, test.path, rr.Code)
+			}
+
+			// Check that server errors are sanitized
+			if rr.Body.String() != test.body {
+				t.Errorf("Expected response body %q for %s, but got %q", test.body, test.path, rr.Body.String())
+			}
+		}
+	})
+
+	t.Run("Development", func(t *testing.T) {
+		router := newRouter(true)
+
+		rr := serve(router, "/panic?token=abc")
+
+		// Check the response status code
+		if rr.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status code %d, but got %d", http.StatusInternalServerError, rr.Code)
+		}
+
+		// Check the error, stack trace, request dump and route table
+		body := rr.Body.String()
+		for _, expected := range []string{"panic: nil map", "Stack trace", "GET /panic?token=%5BREDACTED%5D", "Authorization: [REDACTED]", "<td>/missing</td>"} {
+			if !strings.Contains(body, expected) {
+				t.Errorf("Expected the page to contain %q, but got %s", expected, body)
+			}
+		}
+		if strings.Contains(body, "Bearer secret") {
+			t.Error("Expected credentials to be redacted from the page")
+		}
+
+		// Check that the handler's message is shown for server errors
+		rr = serve(router, "/fail")
+		if !strings.Contains(rr.Body.String(), "pq: password authentication failed") {
+			t.Errorf("Expected the handler's message in the page, but got %s", rr.Body.String())
+		}
+	})
+
+	t.Run("Aborted response", func(t *testing.T) {
+		router := newRouter(false)
+		router.MustGET("/abort", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("partial"))
+			panic(http.ErrAbortHandler)
+		})
+
+		defer func() {
+			// Check that the abort was not turned into an error page
+			if recovered := recover(); recovered != http.ErrAbortHandler {
+				t.Errorf("Expected panic %v, but got %v", http.ErrAbortHandler, recovered)
+			}
+		}()
+		serve(router, "/abort")
+	})
+}
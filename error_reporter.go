@@ -0,0 +1,97 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrorReport describes a panic or a 5xx response.
+type ErrorReport struct {
+	Time          time.Time
+	CorrelationID string
+	Method        string
+	// Path is redacted with the router's redactor.
+	Path string
+	// Route is the path of the matched route, empty if none matched.
+	Route  string
+	Status int
+	// Header and Query are redacted with the router's redactor.
+	Header http.Header
+	Query  url.Values
+	// Err describes the error, e.g. "panic: nil map" or "500 Internal
+	// Server Error". It is the panic value itself when it is an error, unless
+	// redaction changed its message.
+	Err error
+	// Panic is the recovered panic value, nil for 5xx responses. It is
+	// replaced with the redacted message when redaction changed it.
+	Panic interface{}
+	// Stack is the stack trace of the panic, nil for 5xx responses.
+	Stack []byte
+}
+
+// ErrorReporter sends error reports to an error tracking service such as
+// Sentry or Bugsnag. ReportError is called while serving the request, so
+// implementations should send reports asynchronously.
+type ErrorReporter interface {
+	ReportError(report ErrorReport)
+}
+
+// ErrorReporterFunc adapts a function to the ErrorReporter interface.
+type ErrorReporterFunc func(report ErrorReport)
+
+// ReportError calls f(report).
+func (f ErrorReporterFunc) ReportError(report ErrorReport) {
+	f(report)
+}
+
+// AddErrorReporter adds a reporter called on handler panics and 5xx
+// responses. Panics are still propagated after being reported.
+func (r *Router) AddErrorReporter(reporter ErrorReporter) {
+	r.errorReporters = append(r.errorReporters, reporter)
+}
+
+// reportError calls the error reporters with the event of a panic or a 5xx
+// response.
+func (r *Router) reportError(event Event, stack []byte) {
+	if len(r.errorReporters) == 0 {
+		return
+	}
+	req := event.Request
+	report := ErrorReport{
+		Time:          time.Now().UTC(),
+		CorrelationID: r.GetCorrelationID(req),
+		Method:        req.Method,
+		Path:          r.Redactor().String(req.URL.Path),
+		Status:        event.Status,
+		Header:        r.Redactor().Header(req.Header),
+		Query:         r.Redactor().Query(r.GetQueryParams(req)),
+		Panic:         event.Panic,
+		Stack:         stack,
+	}
+	if event.Route != nil {
+		report.Route = event.Route.Path
+	}
+	switch err := event.Panic.(type) {
+	case nil:
+		report.Err = fmt.Errorf("%d %s", event.Status, http.StatusText(event.Status))
+	case error:
+		report.Err = err
+		if msg := r.Redactor().String(err.Error()); msg != err.Error() {
+			report.Err = errors.New(msg)
+			report.Panic = msg
+		}
+	default:
+		msg := fmt.Sprint(err)
+		if redactedMsg := r.Redactor().String(msg); redactedMsg != msg {
+			msg = redactedMsg
+			report.Panic = msg
+		}
+		report.Err = fmt.Errorf("panic: %s", msg)
+	}
+	for _, reporter := range r.errorReporters {
+		reporter.ReportError(report)
+	}
+}
@@ -0,0 +1,122 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorReporter(t *testing.T) {
+	router := NewRouter()
+	var reports []ErrorReport
+	router.AddErrorReporter(ErrorReporterFunc(func(report ErrorReport) {
+		reports = append(reports, report)
+	}))
+	router.MustAddRoute("GET", "/panic", func(w http.ResponseWriter, req *http.Request) {
+		panicInHandler()
+	})
+	router.MustAddRoute("GET", "/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+	})
+	router.MustAddRoute("GET", "/missing", func(w http.ResponseWriter, req *http.Request) {
+		http.NotFound(w, req)
+	})
+
+	serve := func(path string) {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer secret")
+		defer func() { recover() }()
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	t.Run("Panic", func(t *testing.T) {
+		reports = nil
+		serve("/panic?token=abc")
+
+		if len(reports) != 1 {
+			t.Fatalf("Expected 1 report, but got %d", len(reports))
+		}
+		report := reports[0]
+
+		// Check the error, the stack trace and the redacted metadata
+		if report.Status != http.StatusInternalServerError || report.Err.Error() != "panic: boom" || report.Panic != "boom" {
+			t.Errorf("Unexpected report %+v", report)
+		}
+		if !strings.Contains(string(report.Stack), "panicInHandler") {
+			t.Errorf("Expected the stack trace to include the panicking function, but got %s", report.Stack)
+		}
+		if report.Route != "/panic" || report.CorrelationID == "" {
+			t.Errorf("Expected the route and correlation ID, but got %q and %q", report.Route, report.CorrelationID)
+		}
+		if report.Header.Get("Authorization") != redacted || report.Query.Get("token") != redacted {
+			t.Errorf("Expected credentials to be redacted, but got %v and %v", report.Header, report.Query)
+		}
+	})
+
+	t.Run("Server error", func(t *testing.T) {
+		reports = nil
+		serve("/users/42")
+
+		if len(reports) != 1 {
+			t.Fatalf("Expected 1 report, but got %d", len(reports))
+		}
+		report := reports[0]
+		if report.Status != http.StatusServiceUnavailable || report.Route != "/users/:id" || report.Stack != nil {
+			t.Errorf("Unexpected report %+v", report)
+		}
+		if expected := "503 Service Unavailable"; report.Err.Error() != expected {
+			t.Errorf("Expected error %q, but got %q", expected, report.Err)
+		}
+	})
+
+	t.Run("Client error", func(t *testing.T) {
+		reports = nil
+		serve("/missing")
+
+		if len(reports) != 0 {
+			t.Errorf("Expected no report, but got %d", len(reports))
+		}
+	})
+}
+
+func TestErrorReporterRedaction(t *testing.T) {
+	router := NewRouter()
+	router.SetRedactor(&Redactor{Patterns: []string{`[a-z]+@example\.com`}})
+	var reports []ErrorReport
+	router.AddErrorReporter(ErrorReporterFunc(func(report ErrorReport) {
+		reports = append(reports, report)
+	}))
+	router.MustAddRoute("GET", "/users/:email", func(w http.ResponseWriter, req *http.Request) {
+		panic("no user " + router.GetPathParam(req, "email"))
+	})
+
+	req := httptest.NewRequest("GET", "/users/jane@example.com", nil)
+	func() {
+		defer func() { recover() }()
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+
+	if len(reports) != 1 {
+		t.Fatalf("Expected 1 report, but got %d", len(reports))
+	}
+	report := reports[0]
+
+	// Check that the path and the panic message are redacted
+	if expected := "/users/" + redacted; report.Path != expected {
+		t.Errorf("Expected path %q, but got %q", expected, report.Path)
+	}
+	if expected := "panic: no user " + redacted; report.Err.Error() != expected {
+		t.Errorf("Expected error %q, but got %q", expected, report.Err)
+	}
+	if expected := "no user " + redacted; report.Panic != expected {
+		t.Errorf("Expected panic value %q, but got %v", expected, report.Panic)
+	}
+}
+
+func panicInHandler() {
+	panic("boom")
+}
@@ -0,0 +1,57 @@
+package router
+
+import (
+	"net/http"
+	"time"
+)
+
+// Event describes something that happened while serving a request.
+type Event struct {
+	Request  *http.Request
+	Route    *Route // nil when no route matched
+	Status   int
+	Duration time.Duration
+	Panic    interface{}
+}
+
+// EventListener receives events emitted by the router.
+type EventListener func(Event)
+
+// events holds the listeners subscribed to each kind of event.
+type events struct {
+	routeMatched []EventListener
+	notFound     []EventListener
+	panic        []EventListener
+	response     []EventListener
+	clientGone   []EventListener
+	reload       []ReloadListener
+}
+
+// emit calls every listener with the event.
+func (e *events) emit(listeners []EventListener, event Event) {
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+// OnRouteMatched subscribes a listener to requests that matched a route.
+func (r *Router) OnRouteMatched(listener EventListener) {
+	r.events.routeMatched = append(r.events.routeMatched, listener)
+}
+
+// OnNotFound subscribes a listener to requests that matched no route.
+func (r *Router) OnNotFound(listener EventListener) {
+	r.events.notFound = append(r.events.notFound, listener)
+}
+
+// OnPanic subscribes a listener to handler panics. The panic is still
+// propagated after the listeners have run.
+func (r *Router) OnPanic(listener EventListener) {
+	r.events.panic = append(r.events.panic, listener)
+}
+
+// OnResponse subscribes a listener to completed responses. Requests whose
+// client went away are reported with StatusClientClosedRequest.
+func (r *Router) OnResponse(listener EventListener) {
+	r.events.response = append(r.events.response, listener)
+}
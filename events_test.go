@@ -0,0 +1,75 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	router := NewRouter()
+
+	var matched, notFound, responses []Event
+	var panics []Event
+
+	router.OnRouteMatched(func(e Event) { matched = append(matched, e) })
+	router.OnNotFound(func(e Event) { notFound = append(notFound, e) })
+	router.OnResponse(func(e Event) { responses = append(responses, e) })
+	// Subscribe a second listener to check that both are notified
+	router.OnResponse(func(e Event) { responses = append(responses, e) })
+	router.OnPanic(func(e Event) { panics = append(panics, e) })
+
+	router.MustAddRoute("GET", "/hello", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("Hello, World!"))
+	})
+	router.MustAddRoute("GET", "/panic", func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+
+	t.Run("Route matched", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/hello", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		if len(matched) != 1 || matched[0].Route.Path != "/hello" {
+			t.Errorf("Expected one matched event for /hello, but got %v", matched)
+		}
+		if len(responses) != 2 || responses[0].Status != http.StatusOK {
+			t.Errorf("Expected two response events with status %d, but got %v", http.StatusOK, responses)
+		}
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/unknown", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		if len(notFound) != 1 {
+			t.Errorf("Expected one not found event, but got %d", len(notFound))
+		}
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/panic", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected the panic to be propagated")
+				}
+			}()
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+
+		if len(panics) != 1 || panics[0].Panic != "boom" {
+			t.Errorf("Expected one panic event with value %q, but got %v", "boom", panics)
+		}
+	})
+}
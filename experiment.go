@@ -0,0 +1,135 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+)
+
+// Variant is one bucket of an experiment.
+type Variant struct {
+	Name    string
+	Weight  int // relative weight; variants with no weight count as 1
+	Handler http.HandlerFunc
+}
+
+// Experiment splits requests for a route between several variants.
+type Experiment struct {
+	Name     string
+	Variants []Variant
+	// UserID returns the identifier used to assign the request to a
+	// variant. When it is nil or returns an empty string, the assignment is
+	// kept in a cookie instead.
+	UserID func(req *http.Request) string
+	// Cookie is the name of the cookie holding the assignment. It defaults
+	// to "experiment_" followed by the experiment name.
+	Cookie string
+}
+
+// AddExperiment adds a route whose requests are deterministically assigned
+// to one of the experiment variants. The chosen variant is stored in the
+// request context and reported in the X-Experiment response header.
+//
+// It returns an error if the experiment has no variants, a variant has a
+// negative weight or no handler, or the route cannot be added, see
+// AddRoute.
+func (r *Router) AddExperiment(method string, path string, experiment Experiment) (*Route, error) {
+	if err := experiment.validate(); err != nil {
+		return nil, err
+	}
+	if experiment.Cookie == "" {
+		experiment.Cookie = "experiment_" + experiment.Name
+	}
+
+	return r.AddRoute(method, path, func(w http.ResponseWriter, req *http.Request) {
+		variant := experiment.assign(r, w, req)
+
+		ctx := req.Context()
+		ctx = context.WithValue(ctx, "experimentVariant", variant.Name)
+		req = req.WithContext(ctx)
+
+		w.Header().Add("X-Experiment", experiment.Name+"="+variant.Name)
+		variant.Handler(w, req)
+	})
+}
+
+// validate checks that requests can be assigned to the variants.
+func (e *Experiment) validate() error {
+	if len(e.Variants) == 0 {
+		return fmt.Errorf("router: experiment %q has no variants", e.Name)
+	}
+	for _, variant := range e.Variants {
+		if variant.Weight < 0 {
+			return fmt.Errorf("router: variant %q of experiment %q has a negative weight", variant.Name, e.Name)
+		}
+		if variant.Handler == nil {
+			return fmt.Errorf("router: variant %q of experiment %q has no handler", variant.Name, e.Name)
+		}
+	}
+	if e.totalWeight() <= 0 {
+		return fmt.Errorf("router: experiment %q has no weight", e.Name)
+	}
+	return nil
+}
+
+// assign chooses the variant for the request.
+func (e *Experiment) assign(r *Router, w http.ResponseWriter, req *http.Request) Variant {
+	if e.UserID != nil {
+		if id := e.UserID(req); id != "" {
+			h := fnv.New32a()
+			h.Write([]byte(e.Name + ":" + id))
+			return e.pick(int(h.Sum32() % uint32(e.totalWeight())))
+		}
+	}
+
+	if cookie, err := req.Cookie(e.Cookie); err == nil {
+		for _, variant := range e.Variants {
+			if variant.Name == cookie.Value {
+				return variant
+			}
+		}
+	}
+
+	variant := e.pick(rand.Intn(e.totalWeight()))
+	r.SetCookie(w, &http.Cookie{Name: e.Cookie, Value: variant.Name, Path: "/", HttpOnly: true})
+	return variant
+}
+
+// totalWeight returns the sum of the variant weights.
+func (e *Experiment) totalWeight() int {
+	total := 0
+	for _, variant := range e.Variants {
+		total += variantWeight(variant)
+	}
+	return total
+}
+
+// pick returns the variant covering the given point of the weight range.
+func (e *Experiment) pick(n int) Variant {
+	for _, variant := range e.Variants {
+		n -= variantWeight(variant)
+		if n < 0 {
+			return variant
+		}
+	}
+	return e.Variants[len(e.Variants)-1]
+}
+
+// variantWeight returns the weight of the variant.
+func variantWeight(variant Variant) int {
+	if variant.Weight <= 0 {
+		return 1
+	}
+	return variant.Weight
+}
+
+// ExperimentVariant retrieves the experiment variant the request was assigned to.
+func (r *Router) ExperimentVariant(req *http.Request) string {
+	variant, ok := req.Context().Value("experimentVariant").(string)
+	if !ok {
+		return ""
+	}
+	return variant
+}
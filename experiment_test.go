@@ -0,0 +1,91 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExperiment(t *testing.T) {
+	router := NewRouter()
+
+	variantHandler := func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(router.ExperimentVariant(req)))
+	}
+	router.AddExperiment("GET", "/checkout", Experiment{
+		Name: "checkout",
+		Variants: []Variant{
+			{Name: "control", Handler: variantHandler},
+			{Name: "redesign", Handler: variantHandler},
+		},
+		UserID: func(req *http.Request) string {
+			return req.Header.Get("X-User-ID")
+		},
+	})
+
+	serve := func(t *testing.T, setup func(req *http.Request)) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "/checkout", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		setup(req)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Hashed user ID is deterministic", func(t *testing.T) {
+		first := serve(t, func(req *http.Request) { req.Header.Set("X-User-ID", "user-42") })
+		for i := 0; i < 10; i++ {
+			rr := serve(t, func(req *http.Request) { req.Header.Set("X-User-ID", "user-42") })
+			if rr.Body.String() != first.Body.String() {
+				t.Fatalf("Expected variant %q, but got %q", first.Body.String(), rr.Body.String())
+			}
+		}
+
+		expectedHeader := "checkout=" + first.Body.String()
+		if header := first.Header().Get("X-Experiment"); header != expectedHeader {
+			t.Errorf("Expected header %q, but got %q", expectedHeader, header)
+		}
+	})
+
+	t.Run("Cookie assignment", func(t *testing.T) {
+		rr := serve(t, func(req *http.Request) {})
+		cookies := rr.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != "experiment_checkout" {
+			t.Fatalf("Expected an assignment cookie, but got %v", cookies)
+		}
+		if cookies[0].Value != rr.Body.String() {
+			t.Errorf("Expected cookie value %q, but got %q", rr.Body.String(), cookies[0].Value)
+		}
+
+		rr = serve(t, func(req *http.Request) {
+			req.AddCookie(&http.Cookie{Name: "experiment_checkout", Value: "redesign"})
+		})
+		if rr.Body.String() != "redesign" {
+			t.Errorf("Expected variant %q, but got %q", "redesign", rr.Body.String())
+		}
+	})
+
+	t.Run("Invalid experiments", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			variants []Variant
+		}{
+			{"No variants", nil},
+			{"Negative weight", []Variant{{Name: "control", Weight: -1, Handler: variantHandler}}},
+			{"No handler", []Variant{{Name: "control"}}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := router.AddExperiment("GET", "/invalid", Experiment{Name: "invalid", Variants: tt.variants})
+
+				// Check that the experiment is rejected at registration
+				if err == nil {
+					t.Error("Expected an error, but got nil")
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,381 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expr is a compiled routing expression evaluated against requests, e.g.
+//
+//	header("X-Client") == "mobile" && query("beta") == "1"
+//
+// Values are strings produced by string literals or by the functions
+// header(name), query(name), cookie(name), method(), path() and host().
+// Values can be compared with ==, != and the regular expression operators
+// =~ and !~, and a value on its own is true when it is not empty.
+// Conditions can be combined with &&, || and !, and grouped with parentheses.
+type Expr struct {
+	src  string
+	root exprNode
+}
+
+// ParseExpr compiles a routing expression.
+func ParseExpr(src string) (*Expr, error) {
+	p := &exprParser{src: src}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// MustParseExpr is like ParseExpr but panics if the expression is invalid.
+func MustParseExpr(src string) *Expr {
+	expr, err := ParseExpr(src)
+	if err != nil {
+		panic(err)
+	}
+	return expr
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates the expression against the request.
+func (e *Expr) Eval(req *http.Request) bool {
+	return e.root.eval(req) != ""
+}
+
+// When restricts the route to requests for which the expression is true.
+// Requests that do not satisfy it fall through to the other routes
+// registered for the same method and path.
+func (route *Route) When(expr *Expr) *Route {
+	route.matchers = append(route.matchers, expr.Eval)
+	return route
+}
+
+// exprNode is a node of a compiled expression. Conditions evaluate to
+// "true" or the empty string.
+type exprNode interface {
+	eval(req *http.Request) string
+}
+
+type literalNode string
+
+func (n literalNode) eval(req *http.Request) string { return string(n) }
+
+type callNode struct {
+	name string
+	arg  string
+}
+
+func (n callNode) eval(req *http.Request) string {
+	switch n.name {
+	case "header":
+		return req.Header.Get(n.arg)
+	case "query":
+		return queryValues(req).Get(n.arg)
+	case "cookie":
+		if cookie, err := req.Cookie(n.arg); err == nil {
+			return cookie.Value
+		}
+		return ""
+	case "method":
+		return req.Method
+	case "path":
+		return req.URL.Path
+	case "host":
+		return req.Host
+	}
+	return ""
+}
+
+type compareNode struct {
+	op          string
+	left, right exprNode
+	re          *regexp.Regexp
+}
+
+func (n compareNode) eval(req *http.Request) string {
+	left := n.left.eval(req)
+	var result bool
+	switch n.op {
+	case "==":
+		result = left == n.right.eval(req)
+	case "!=":
+		result = left != n.right.eval(req)
+	case "=~":
+		result = n.re.MatchString(left)
+	case "!~":
+		result = !n.re.MatchString(left)
+	}
+	return boolString(result)
+}
+
+type notNode struct{ operand exprNode }
+
+func (n notNode) eval(req *http.Request) string {
+	return boolString(n.operand.eval(req) == "")
+}
+
+type logicalNode struct {
+	and         bool
+	left, right exprNode
+}
+
+func (n logicalNode) eval(req *http.Request) string {
+	left := n.left.eval(req) != ""
+	if n.and && !left {
+		return ""
+	}
+	if !n.and && left {
+		return "true"
+	}
+	return boolString(n.right.eval(req) != "")
+}
+
+// boolString converts a condition result to its string value.
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return ""
+}
+
+// exprFunctions lists the functions and whether they take an argument.
+var exprFunctions = map[string]bool{
+	"header": true,
+	"query":  true,
+	"cookie": true,
+	"method": false,
+	"path":   false,
+	"host":   false,
+}
+
+// exprToken is a lexical token of an expression.
+type exprToken struct {
+	kind string // "string", "ident" or the operator itself
+	text string
+}
+
+// exprParser is a recursive descent parser for routing expressions.
+type exprParser struct {
+	src    string
+	tokens []exprToken
+	pos    int
+}
+
+// errorf returns a parse error.
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("router: invalid expression %q: %s", p.src, fmt.Sprintf(format, args...))
+}
+
+// tokenize splits the source into tokens.
+func (p *exprParser) tokenize() error {
+	src := p.src
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return p.errorf("unterminated string")
+			}
+			text, err := strconv.Unquote(src[i : end+1])
+			if err != nil {
+				return p.errorf("invalid string %s", src[i:end+1])
+			}
+			p.tokens = append(p.tokens, exprToken{kind: "string", text: text})
+			i = end + 1
+		case unicode.IsLetter(c) || c == '_':
+			end := i
+			for end < len(src) && (unicode.IsLetter(rune(src[end])) || unicode.IsDigit(rune(src[end])) || src[end] == '_') {
+				end++
+			}
+			p.tokens = append(p.tokens, exprToken{kind: "ident", text: src[i:end]})
+			i = end
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "=~", "!~", "&&", "||", "!", "(", ")"} {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return p.errorf("unexpected character %q", c)
+			}
+			p.tokens = append(p.tokens, exprToken{kind: op, text: op})
+			i += len(op)
+		}
+	}
+	return nil
+}
+
+// peek returns the kind of the next token, or "" at the end.
+func (p *exprParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos].kind
+}
+
+// expect consumes a token of the given kind.
+func (p *exprParser) expect(kind string) (exprToken, error) {
+	if p.peek() != kind {
+		if p.pos >= len(p.tokens) {
+			return exprToken{}, p.errorf("expected %s at end of expression", kind)
+		}
+		return exprToken{}, p.errorf("expected %s but got %q", kind, p.tokens[p.pos].text)
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	return tok, nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{and: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.peek() == "!" {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	if p.peek() == "(" {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	op := p.peek()
+	switch op {
+	case "==", "!=":
+		p.pos++
+		right, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return compareNode{op: op, left: left, right: right}, nil
+	case "=~", "!~":
+		p.pos++
+		tok, err := p.expect("string")
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(tok.text)
+		if err != nil {
+			return nil, p.errorf("invalid regular expression %q: %v", tok.text, err)
+		}
+		return compareNode{op: op, left: left, re: re}, nil
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseValue() (exprNode, error) {
+	switch p.peek() {
+	case "string":
+		tok := p.tokens[p.pos]
+		p.pos++
+		return literalNode(tok.text), nil
+	case "ident":
+		tok := p.tokens[p.pos]
+		p.pos++
+		switch tok.text {
+		case "true":
+			return literalNode("true"), nil
+		case "false":
+			return literalNode(""), nil
+		}
+
+		takesArg, ok := exprFunctions[tok.text]
+		if !ok {
+			return nil, p.errorf("unknown function %q", tok.text)
+		}
+		if _, err := p.expect("("); err != nil {
+			return nil, err
+		}
+		call := callNode{name: tok.text}
+		if takesArg {
+			arg, err := p.expect("string")
+			if err != nil {
+				return nil, err
+			}
+			call.arg = arg.text
+		}
+		if _, err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return call, nil
+	}
+	if p.pos >= len(p.tokens) {
+		return nil, p.errorf("unexpected end of expression")
+	}
+	return nil, p.errorf("unexpected %q", p.tokens[p.pos].text)
+}
@@ -0,0 +1,98 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExpr(t *testing.T) {
+	t.Run("Evaluation", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "http://api.example.com/feed?beta=1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Client", "mobile")
+		req.AddCookie(&http.Cookie{Name: "plan", Value: "pro"})
+
+		tests := []struct {
+			expr     string
+			expected bool
+		}{
+			{`header("X-Client") == "mobile" && query("beta") == "1"`, true},
+			{`header("X-Client") == "desktop" || query("beta") == "0"`, false},
+			{`!(header("X-Client") == "desktop")`, true},
+			{`header("X-Missing")`, false},
+			{`cookie("plan") != "free"`, true},
+			{`host() =~ "^api\\." && path() !~ "^/admin"`, true},
+			{`method() == "POST"`, false},
+			{`true && !false`, true},
+		}
+
+		for _, tt := range tests {
+			expr, err := ParseExpr(tt.expr)
+			if err != nil {
+				t.Errorf("Expected no error for %s, but got %v", tt.expr, err)
+				continue
+			}
+			if result := expr.Eval(req); result != tt.expected {
+				t.Errorf("Expected %s to be %v, but got %v", tt.expr, tt.expected, result)
+			}
+		}
+	})
+
+	t.Run("Invalid expressions", func(t *testing.T) {
+		tests := []string{
+			`header("X-Client") ==`,
+			`unknown("x")`,
+			`header(X-Client)`,
+			`header("X-Client") =~ "("`,
+			`(query("a")`,
+			`query("a") query("b")`,
+			`"unterminated`,
+		}
+
+		for _, src := range tests {
+			if _, err := ParseExpr(src); err == nil {
+				t.Errorf("Expected an error for %s, but got nil", src)
+			}
+		}
+	})
+
+	t.Run("Conditional routes", func(t *testing.T) {
+		router := NewRouter()
+		router.MustAddRoute("GET", "/feed", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("mobile beta feed"))
+		}).When(MustParseExpr(`header("X-Client") == "mobile" && query("beta") == "1"`))
+		router.MustAddRoute("GET", "/feed", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("default feed"))
+		})
+
+		tests := []struct {
+			name         string
+			path         string
+			client       string
+			expectedBody string
+		}{
+			{"Expression matched", "/feed?beta=1", "mobile", "mobile beta feed"},
+			{"Fall through", "/feed?beta=1", "desktop", "default feed"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req, err := http.NewRequest("GET", tt.path, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("X-Client", tt.client)
+
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+
+				if rr.Body.String() != tt.expectedBody {
+					t.Errorf("Expected response body %q, but got %q", tt.expectedBody, rr.Body.String())
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package router
+
+import (
+	"expvar"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// PublishExpvar publishes the router's counters as an expvar map under the
+// name:
+//
+//	requests    responses by route and status code, e.g.
+//	            {"GET /users/:id": {"200": 12, "404": 1}}
+//	in_flight   requests currently being handled
+//	uptime      seconds since the counters were published
+//
+// Requests matching no route are counted under "not_found". It returns an
+// error if a variable with the name is already published.
+func (r *Router) PublishExpvar(name string) error {
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("router: expvar %q is already published", name)
+	}
+	start := time.Now()
+	requests := new(expvar.Map).Init()
+	var mu sync.Mutex
+
+	vars := new(expvar.Map).Init()
+	vars.Set("requests", requests)
+	vars.Set("in_flight", expvar.Func(func() interface{} {
+		return r.InFlight()
+	}))
+	vars.Set("uptime", expvar.Func(func() interface{} {
+		return time.Since(start).Seconds()
+	}))
+	expvar.Publish(name, vars)
+
+	r.OnResponse(func(event Event) {
+		key := "not_found"
+		if event.Route != nil {
+			key = event.Route.Method + " " + event.Route.Path
+		}
+		mu.Lock()
+		statuses, ok := requests.Get(key).(*expvar.Map)
+		if !ok {
+			statuses = new(expvar.Map).Init()
+			requests.Set(key, statuses)
+		}
+		mu.Unlock()
+		statuses.Add(strconv.Itoa(event.Status), 1)
+	})
+	return nil
+}
+
+// MountExpvar adds a GET route serving the published expvar variables as
+// JSON, as the /debug/vars handler of the expvar package does. It returns
+// an error if the route cannot be added, see AddRoute.
+func (r *Router) MountExpvar(path string) (*Route, error) {
+	return r.AddRoute("GET", path, expvar.Handler().ServeHTTP)
+}
@@ -0,0 +1,82 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// expvarRuns makes the published names unique across repeated runs, since
+// expvar names cannot be unpublished.
+var expvarRuns int32
+
+func TestExpvar(t *testing.T) {
+	name := fmt.Sprintf("%s_%d", t.Name(), atomic.AddInt32(&expvarRuns, 1))
+	router := NewRouter()
+	if err := router.PublishExpvar(name); err != nil {
+		t.Fatal(err)
+	}
+	router.MustAddRoute("GET", "/users/:id", func(w http.ResponseWriter, req *http.Request) {})
+	router.MountExpvar("/debug/vars")
+
+	for _, path := range []string{"/users/1", "/users/2", "/missing"} {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	t.Run("Published counters", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/debug/vars", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Check the response status code
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("Expected status code %d, but got %d", http.StatusOK, status)
+		}
+
+		var published map[string]json.RawMessage
+		if err := json.Unmarshal(rr.Body.Bytes(), &published); err != nil {
+			t.Fatal(err)
+		}
+		var vars struct {
+			Requests map[string]map[string]int `json:"requests"`
+			InFlight int                       `json:"in_flight"`
+			Uptime   float64                   `json:"uptime"`
+		}
+		if err := json.Unmarshal(published[name], &vars); err != nil {
+			t.Fatal(err)
+		}
+
+		// Check the request counters
+		if count := vars.Requests["GET /users/:id"]["200"]; count != 2 {
+			t.Errorf("Expected 2 requests to GET /users/:id, but got %d", count)
+		}
+		if count := vars.Requests["not_found"]["404"]; count != 1 {
+			t.Errorf("Expected 1 request not found, but got %d", count)
+		}
+
+		// Check the in-flight gauge, which counts the request being served
+		if vars.InFlight != 1 {
+			t.Errorf("Expected 1 request in flight, but got %d", vars.InFlight)
+		}
+		if vars.Uptime <= 0 {
+			t.Errorf("Expected a positive uptime, but got %v", vars.Uptime)
+		}
+	})
+
+	t.Run("Duplicate name", func(t *testing.T) {
+		if err := NewRouter().PublishExpvar(name); err == nil {
+			t.Error("Expected an error for a name already published")
+		}
+	})
+}
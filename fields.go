@@ -0,0 +1,111 @@
+package router
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// SparseFieldsetsOptions configures the SparseFieldsets middleware.
+type SparseFieldsetsOptions struct {
+	// Param is the query parameter listing the fields, "fields" by default.
+	Param string
+}
+
+// SparseFieldsets returns middleware filtering successful JSON responses
+// down to the fields listed in the query, e.g. "?fields=id,name,owner.email".
+// Nested fields are separated by dots, and the fields of arrays apply to
+// each of their elements.
+func SparseFieldsets(opts SparseFieldsetsOptions) func(http.HandlerFunc) http.HandlerFunc {
+	if opts.Param == "" {
+		opts.Param = "fields"
+	}
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			fields := queryValues(req).Get(opts.Param)
+			if fields == "" {
+				next(w, req)
+				return
+			}
+
+			bw := newBufferedWriter(w)
+			next(bw, req)
+
+			body := bw.body.Bytes()
+			if bw.Status() >= 200 && bw.Status() < 300 && isJSON(bw.header.Get("Content-Type")) {
+				var v interface{}
+				if err := json.Unmarshal(body, &v); err == nil {
+					if filtered, err := json.Marshal(selectFields(v, parseFieldSet(fields))); err == nil {
+						body = filtered
+					}
+				}
+			}
+			bw.flush(body)
+		}
+	}
+}
+
+// fieldSet is a tree of selected fields. A nil fieldSet selects everything.
+type fieldSet map[string]fieldSet
+
+// parseFieldSet parses a comma-separated list of dotted field paths.
+func parseFieldSet(fields string) fieldSet {
+	set := fieldSet{}
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		node := set
+		parts := strings.Split(field, ".")
+		for i, part := range parts {
+			child, ok := node[part]
+			if i == len(parts)-1 {
+				node[part] = nil
+				break
+			}
+			if ok && child == nil {
+				// The whole field is already selected
+				break
+			}
+			if !ok {
+				child = fieldSet{}
+				node[part] = child
+			}
+			node = child
+		}
+	}
+	return set
+}
+
+// selectFields keeps the selected fields of a decoded JSON value.
+func selectFields(v interface{}, set fieldSet) interface{} {
+	if set == nil {
+		return v
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(set))
+		for name, child := range set {
+			if value, ok := v[name]; ok {
+				out[name] = selectFields(value, child)
+			}
+		}
+		return out
+	case []interface{}:
+		for i, value := range v {
+			v[i] = selectFields(value, set)
+		}
+	}
+	return v
+}
+
+// isJSON reports whether the content type is JSON.
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
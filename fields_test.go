@@ -0,0 +1,61 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSparseFieldsets(t *testing.T) {
+	router := NewRouter()
+	router.Use(SparseFieldsets(SparseFieldsetsOptions{}))
+
+	router.MustAddRoute("GET", "/users", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id":1,"name":"gopher","owner":{"email":"a@b.c","phone":"123"},"tags":["x"]}]`))
+	})
+	router.MustAddRoute("GET", "/text", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"id":1,"name":"gopher"}`))
+	})
+	router.MustAddRoute("GET", "/error", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"bad","code":1}`))
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Top-level fields", "/users?fields=id,name", http.StatusOK, `[{"id":1,"name":"gopher"}]`},
+		{"Nested fields", "/users?fields=id,owner.email", http.StatusOK, `[{"id":1,"owner":{"email":"a@b.c"}}]`},
+		{"Whole nested object", "/users?fields=owner,owner.email", http.StatusOK, `[{"owner":{"email":"a@b.c","phone":"123"}}]`},
+		{"No fields", "/users", http.StatusOK, `[{"id":1,"name":"gopher","owner":{"email":"a@b.c","phone":"123"},"tags":["x"]}]`},
+		{"Not JSON", "/text?fields=id", http.StatusOK, `{"id":1,"name":"gopher"}`},
+		{"Error response", "/error?fields=code", http.StatusBadRequest, `{"error":"bad","code":1}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", test.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if rr.Code != test.expectedStatus {
+				t.Errorf("Expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+
+			// Check the response body
+			if rr.Body.String() != test.expectedBody {
+				t.Errorf("Expected response body %q, but got %q", test.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package router
+
+import (
+	"hash/fnv"
+	"net/http"
+)
+
+// FlagProvider decides whether a feature flag is enabled for a request.
+type FlagProvider interface {
+	Enabled(flag string, req *http.Request) bool
+}
+
+// Flag gates the route behind a feature flag. When the flag is disabled
+// for the request, the route responds with disabledStatus, or does not
+// match at all when disabledStatus is 0 or 404 so the request falls
+// through to the not found handler.
+func (route *Route) Flag(provider FlagProvider, flag string, disabledStatus int) *Route {
+	if disabledStatus == 0 || disabledStatus == http.StatusNotFound {
+		route.matchers = append(route.matchers, func(req *http.Request) bool {
+			return provider.Enabled(flag, req)
+		})
+		return route
+	}
+
+	handler := route.HandlerFunc
+	route.HandlerFunc = func(w http.ResponseWriter, req *http.Request) {
+		if !provider.Enabled(flag, req) {
+			http.Error(w, http.StatusText(disabledStatus), disabledStatus)
+			return
+		}
+		handler(w, req)
+	}
+	return route
+}
+
+// FlagRule describes when a flag of a FlagRules provider is enabled.
+type FlagRule struct {
+	// Enabled turns the flag on for everyone.
+	Enabled bool
+	// Users lists the user IDs the flag is always enabled for.
+	Users []string
+	// Percentage enables the flag for a stable percentage of user IDs.
+	Percentage int
+}
+
+// FlagRules is a FlagProvider backed by static rules.
+type FlagRules struct {
+	Rules map[string]FlagRule
+	// UserID returns the user ID of the request used for user targeting
+	// and percentage rollouts.
+	UserID func(req *http.Request) string
+}
+
+// Enabled implements FlagProvider.
+func (f *FlagRules) Enabled(flag string, req *http.Request) bool {
+	rule, ok := f.Rules[flag]
+	if !ok {
+		return false
+	}
+	if rule.Enabled {
+		return true
+	}
+	if f.UserID == nil {
+		return false
+	}
+
+	userID := f.UserID(req)
+	if userID == "" {
+		return false
+	}
+	for _, user := range rule.Users {
+		if user == userID {
+			return true
+		}
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(flag + ":" + userID))
+	return int(h.Sum32()%100) < rule.Percentage
+}
@@ -0,0 +1,83 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestFlags(t *testing.T) {
+	flags := &FlagRules{
+		Rules: map[string]FlagRule{
+			"beta":   {Users: []string{"alice"}},
+			"public": {Enabled: true},
+			"off":    {},
+		},
+		UserID: func(req *http.Request) string {
+			return req.Header.Get("X-User-ID")
+		},
+	}
+
+	router := NewRouter()
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("enabled"))
+	}
+	router.MustAddRoute("GET", "/beta", handler).Flag(flags, "beta", 0)
+	router.MustAddRoute("GET", "/public", handler).Flag(flags, "public", 0)
+	router.MustAddRoute("GET", "/admin", handler).Flag(flags, "off", http.StatusForbidden)
+
+	tests := []struct {
+		name         string
+		path         string
+		userID       string
+		expectedCode int
+	}{
+		{"Targeted user", "/beta", "alice", http.StatusOK},
+		{"Other user", "/beta", "bob", http.StatusNotFound},
+		{"Enabled for everyone", "/public", "", http.StatusOK},
+		{"Disabled with forbidden status", "/admin", "alice", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("X-User-ID", tt.userID)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, but got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+
+	t.Run("Percentage rollout", func(t *testing.T) {
+		rollout := &FlagRules{
+			Rules: map[string]FlagRule{"half": {Percentage: 50}},
+			UserID: func(req *http.Request) string {
+				return req.Header.Get("X-User-ID")
+			},
+		}
+
+		enabled := 0
+		for i := 0; i < 1000; i++ {
+			req, err := http.NewRequest("GET", "/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("X-User-ID", "user-"+strconv.Itoa(i))
+			if rollout.Enabled("half", req) {
+				enabled++
+			}
+		}
+
+		if enabled < 400 || enabled > 600 {
+			t.Errorf("Expected roughly half of the users to be enabled, but got %d of 1000", enabled)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package router
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// GeoLocation is the geographical location of a client.
+type GeoLocation struct {
+	Country string // ISO 3166-1 alpha-2 country code
+	Region  string
+}
+
+// GeoResolver resolves IP addresses to locations, e.g. backed by a MaxMind
+// database.
+type GeoResolver interface {
+	Lookup(ip net.IP) (GeoLocation, error)
+}
+
+// GeoIPOptions configures the GeoIP middleware.
+type GeoIPOptions struct {
+	// Resolver resolves client addresses to locations.
+	Resolver GeoResolver
+	// TrustedProxies are the proxies whose X-Forwarded-For header is used
+	// to determine the client address.
+	TrustedProxies *TrustedProxies
+	// Allow lists the countries allowed to access the routes. All
+	// countries are allowed when empty.
+	Allow []string
+	// Deny lists the countries denied access to the routes.
+	Deny []string
+	// DeniedHandler handles denied requests. It defaults to a 403 response.
+	DeniedHandler http.HandlerFunc
+}
+
+// GeoIP returns middleware that resolves the client location, stores it in
+// the request context and enforces the allow and deny lists.
+func GeoIP(opts GeoIPOptions) func(http.HandlerFunc) http.HandlerFunc {
+	denied := opts.DeniedHandler
+	if denied == nil {
+		denied = func(w http.ResponseWriter, req *http.Request) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		}
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			location, err := opts.Resolver.Lookup(opts.TrustedProxies.ClientIP(req))
+			if err != nil {
+				location = GeoLocation{}
+			}
+
+			ctx := req.Context()
+			ctx = context.WithValue(ctx, "geoLocation", location)
+			req = req.WithContext(ctx)
+
+			if !countryAllowed(location.Country, opts.Allow, opts.Deny) {
+				denied(w, req)
+				return
+			}
+			next(w, req)
+		}
+	}
+}
+
+// countryAllowed reports whether the country passes the allow and deny lists.
+func countryAllowed(country string, allow []string, deny []string) bool {
+	for _, c := range deny {
+		if strings.EqualFold(c, country) {
+			return false
+		}
+	}
+	if len(allow) == 0 {
+		return true
+	}
+	for _, c := range allow {
+		if strings.EqualFold(c, country) {
+			return true
+		}
+	}
+	return false
+}
+
+// GeoLocation retrieves the client location resolved by the GeoIP middleware.
+func (r *Router) GeoLocation(req *http.Request) GeoLocation {
+	location, _ := req.Context().Value("geoLocation").(GeoLocation)
+	return location
+}
+
+// GeoSwitch returns a handler that dispatches to the handler registered for
+// the client's country, or to fallback when there is none. It must be used
+// behind the GeoIP middleware.
+func GeoSwitch(handlers map[string]http.HandlerFunc, fallback http.HandlerFunc) http.HandlerFunc {
+	byCountry := make(map[string]http.HandlerFunc, len(handlers))
+	for country, handler := range handlers {
+		byCountry[strings.ToUpper(country)] = handler
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		location, _ := req.Context().Value("geoLocation").(GeoLocation)
+		if handler, ok := byCountry[strings.ToUpper(location.Country)]; ok {
+			handler(w, req)
+			return
+		}
+		fallback(w, req)
+	}
+}
@@ -0,0 +1,76 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// staticGeoResolver resolves addresses from a fixed table.
+type staticGeoResolver map[string]GeoLocation
+
+func (s staticGeoResolver) Lookup(ip net.IP) (GeoLocation, error) {
+	return s[ip.String()], nil
+}
+
+func TestGeoIP(t *testing.T) {
+	proxies, err := NewTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter()
+	router.Use(GeoIP(GeoIPOptions{
+		Resolver: staticGeoResolver{
+			"192.0.2.1": {Country: "FR", Region: "IDF"},
+			"192.0.2.2": {Country: "US", Region: "CA"},
+			"192.0.2.3": {Country: "KP"},
+		},
+		TrustedProxies: proxies,
+		Deny:           []string{"KP"},
+	}))
+	router.MustAddRoute("GET", "/shop", GeoSwitch(map[string]http.HandlerFunc{
+		"fr": func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("boutique " + router.GeoLocation(req).Region))
+		},
+	}, func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("shop " + router.GeoLocation(req).Region))
+	}))
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		expectedCode int
+		expectedBody string
+	}{
+		{"Direct client", "192.0.2.1:1234", "", http.StatusOK, "boutique IDF"},
+		{"Through trusted proxy", "10.0.0.1:1234", "192.0.2.2", http.StatusOK, "shop CA"},
+		{"Spoofed header from untrusted peer", "192.0.2.1:1234", "192.0.2.2", http.StatusOK, "boutique IDF"},
+		{"Denied country", "192.0.2.3:1234", "", http.StatusForbidden, "Forbidden\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/shop", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, but got %d", tt.expectedCode, rr.Code)
+			}
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected response body %q, but got %q", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
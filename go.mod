@@ -5,4 +5,5 @@ go 1.18
 require (
 	github.com/google/uuid v1.3.0
 	github.com/sdpsagarpawar/logger v1.0.2
+	gopkg.in/yaml.v3 v3.0.1
 )
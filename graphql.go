@@ -0,0 +1,445 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GraphQLRequest is a GraphQL operation request.
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// GraphQLError is an error of a GraphQL response.
+type GraphQLError struct {
+	Message string `json:"message"`
+}
+
+// GraphQLResponse is the result of a GraphQL operation.
+type GraphQLResponse struct {
+	Data   interface{}    `json:"data,omitempty"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+// GraphQLHandler executes GraphQL operations against a schema. The context
+// is the one of the HTTP request, carrying the correlation ID and the
+// values set by middleware.
+type GraphQLHandler func(ctx context.Context, req *GraphQLRequest) *GraphQLResponse
+
+// GraphQLOptions configures a GraphQL endpoint.
+type GraphQLOptions struct {
+	// Playground serves a GraphiQL page to browsers requesting the
+	// endpoint with GET and no query.
+	Playground bool
+	// PlaygroundAssets locates the playground's scripts and stylesheet,
+	// DefaultGraphiQLAssets by default.
+	PlaygroundAssets *GraphiQLAssets
+	// Log logs each operation with its duration and correlation ID.
+	Log bool
+	// Middleware wraps the handler, e.g. GraphQLMaxDepth or
+	// GraphQLMaxComplexity. The first middleware is the outermost.
+	Middleware []func(GraphQLHandler) GraphQLHandler
+}
+
+// GraphQL mounts a GraphQL endpoint on GET and POST requests to the path
+// and returns its routes. POST requests carry the operation as a JSON body
+// or as an application/graphql body; GET requests carry it in the query,
+// variables and operationName query parameters and can only run queries.
+// It returns an error, adding no route, if the routes cannot be added, see
+// AddRoute.
+func (r *Router) GraphQL(path string, handler GraphQLHandler, opts GraphQLOptions) ([]*Route, error) {
+	if err := r.validateRoutes(routeKey{"GET", path}, routeKey{"POST", path}); err != nil {
+		return nil, err
+	}
+	for i := len(opts.Middleware) - 1; i >= 0; i-- {
+		handler = opts.Middleware[i](handler)
+	}
+	assets := opts.PlaygroundAssets
+	if assets == nil {
+		assets = &DefaultGraphiQLAssets
+	}
+
+	serve := func(w http.ResponseWriter, req *http.Request) {
+		op, err := r.graphqlRequest(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, &GraphQLResponse{Errors: []GraphQLError{{Message: err.Error()}}})
+			return
+		}
+		if kind := graphqlOperationType(op.Query, op.OperationName); req.Method == "GET" && kind != "query" {
+			w.Header().Set("Allow", "POST")
+			writeJSON(w, http.StatusMethodNotAllowed, &GraphQLResponse{Errors: []GraphQLError{{Message: kind + "s require POST"}}})
+			return
+		}
+
+		start := time.Now()
+		resp := handler(req.Context(), op)
+		if opts.Log {
+			r.logger.Infof("GraphQL %s %q completed in %v with %d errors (correlation ID %s)",
+				graphqlOperationType(op.Query, op.OperationName), op.OperationName, time.Since(start), len(resp.Errors), r.GetCorrelationID(req))
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+
+	get := func(w http.ResponseWriter, req *http.Request) {
+		if opts.Playground && req.URL.Query().Get("query") == "" && strings.Contains(req.Header.Get("Accept"), "text/html") {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			if err := graphiqlPage.Execute(w, struct {
+				Path   string
+				Assets *GraphiQLAssets
+			}{path, assets}); err != nil {
+				r.logger.Errorf("Failed to render the GraphQL playground: %v", err)
+			}
+			return
+		}
+		serve(w, req)
+	}
+
+	return []*Route{
+		r.MustAddRoute("GET", path, get),
+		r.MustAddRoute("POST", path, serve),
+	}, nil
+}
+
+// graphqlRequest reads the operation from the request.
+func (r *Router) graphqlRequest(req *http.Request) (*GraphQLRequest, error) {
+	op := &GraphQLRequest{}
+	if req.Method == "GET" {
+		query := r.GetQueryParams(req)
+		op.Query = query.Get("query")
+		op.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &op.Variables); err != nil {
+				return nil, fmt.Errorf("invalid variables: %v", err)
+			}
+		}
+	} else if strings.HasPrefix(req.Header.Get("Content-Type"), "application/graphql") {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request body: %v", err)
+		}
+		op.Query = string(body)
+	} else if err := json.NewDecoder(req.Body).Decode(op); err != nil {
+		return nil, fmt.Errorf("invalid request body: %v", err)
+	}
+
+	if strings.TrimSpace(op.Query) == "" {
+		return nil, fmt.Errorf("missing query")
+	}
+	return op, nil
+}
+
+// GraphQLMaxDepth returns middleware rejecting operations whose selection
+// sets are nested deeper than the limit.
+func GraphQLMaxDepth(limit int) func(GraphQLHandler) GraphQLHandler {
+	return func(next GraphQLHandler) GraphQLHandler {
+		return func(ctx context.Context, req *GraphQLRequest) *GraphQLResponse {
+			if depth, _ := graphqlMeasure(req.Query, req.OperationName); depth > limit {
+				return &GraphQLResponse{Errors: []GraphQLError{{
+					Message: fmt.Sprintf("query depth %d exceeds the limit of %d", depth, limit),
+				}}}
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// GraphQLMaxComplexity returns middleware rejecting operations selecting
+// more fields than the limit, each selected field counting as one.
+func GraphQLMaxComplexity(limit int) func(GraphQLHandler) GraphQLHandler {
+	return func(next GraphQLHandler) GraphQLHandler {
+		return func(ctx context.Context, req *GraphQLRequest) *GraphQLResponse {
+			if _, complexity := graphqlMeasure(req.Query, req.OperationName); complexity > limit {
+				return &GraphQLResponse{Errors: []GraphQLError{{
+					Message: fmt.Sprintf("query complexity %d exceeds the limit of %d", complexity, limit),
+				}}}
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// graphqlDefinition is an operation or fragment definition of a document.
+type graphqlDefinition struct {
+	kind      string   // "query", "mutation", "subscription" or "fragment"
+	name      string   // empty for anonymous operations
+	selection []string // tokens of the selection set, braces included
+}
+
+// graphqlDocument splits a query into its definitions.
+func graphqlDocument(query string) []graphqlDefinition {
+	tokens := graphqlTokens(query)
+	var defs []graphqlDefinition
+	for i := 0; i < len(tokens); {
+		def := graphqlDefinition{kind: "query"}
+		switch tokens[i] {
+		case "query", "mutation", "subscription", "fragment":
+			def.kind = tokens[i]
+			if i+1 < len(tokens) && graphqlName(tokens[i+1]) {
+				def.name = tokens[i+1]
+			}
+		}
+
+		// Skip to the selection set, past variable defaults and arguments
+		parens := 0
+		for ; i < len(tokens) && (tokens[i] != "{" || parens > 0); i++ {
+			if tokens[i] == "(" {
+				parens++
+			} else if tokens[i] == ")" {
+				parens--
+			}
+		}
+		start, level := i, 0
+		for ; i < len(tokens); i++ {
+			if tokens[i] == "{" {
+				level++
+			} else if tokens[i] == "}" {
+				if level--; level == 0 {
+					i++
+					break
+				}
+			}
+		}
+		if start < len(tokens) {
+			def.selection = tokens[start:i]
+			defs = append(defs, def)
+		}
+	}
+	return defs
+}
+
+// graphqlOperation returns the operation of the document named by the
+// operation name, or its only operation when the name is empty.
+func graphqlOperation(defs []graphqlDefinition, operationName string) (graphqlDefinition, bool) {
+	var found []graphqlDefinition
+	for _, def := range defs {
+		if def.kind != "fragment" && (operationName == "" || def.name == operationName) {
+			found = append(found, def)
+		}
+	}
+	if len(found) != 1 {
+		return graphqlDefinition{}, false
+	}
+	return found[0], true
+}
+
+// graphqlOperationType returns the type of the operation run by the
+// request: "query", "mutation" or "subscription". When the operation cannot
+// be resolved, it returns the type of the first operation that is not a
+// query, so that a document defining a mutation is never taken for a query.
+func graphqlOperationType(query, operationName string) string {
+	defs := graphqlDocument(query)
+	if op, ok := graphqlOperation(defs, operationName); ok {
+		return op.kind
+	}
+	for _, def := range defs {
+		if def.kind == "mutation" || def.kind == "subscription" {
+			return def.kind
+		}
+	}
+	return "query"
+}
+
+// graphqlMeasure returns the maximum selection set depth of the operation
+// run by the request and the number of fields it selects, expanding the
+// fragment spreads. When the operation cannot be resolved, the largest
+// measures of the operations are returned.
+func graphqlMeasure(query, operationName string) (depth int, fields int) {
+	defs := graphqlDocument(query)
+	m := &graphqlMeasurer{
+		fragments: make(map[string][]string),
+		measured:  make(map[string][2]int),
+		active:    make(map[string]bool),
+	}
+	for _, def := range defs {
+		if def.kind == "fragment" {
+			m.fragments[def.name] = def.selection
+		}
+	}
+	if op, ok := graphqlOperation(defs, operationName); ok {
+		return m.selection(op.selection)
+	}
+	for _, def := range defs {
+		if def.kind != "fragment" {
+			d, f := m.selection(def.selection)
+			if d > depth {
+				depth = d
+			}
+			if f > fields {
+				fields = f
+			}
+		}
+	}
+	return depth, fields
+}
+
+// graphqlMeasurer measures selection sets, measuring each fragment once.
+type graphqlMeasurer struct {
+	fragments map[string][]string
+	measured  map[string][2]int
+	active    map[string]bool // fragments being measured, to stop at cycles
+}
+
+// selection returns the depth of the selection set and the number of fields
+// it selects. Inline fragments and fragment spreads do not add depth, and
+// braces within arguments are object values, not selection sets.
+func (m *graphqlMeasurer) selection(tokens []string) (depth int, fields int) {
+	level, parens := 0, 0
+	inline := false
+	var counted []bool // whether each open selection set adds depth
+	prev := ""
+	for i, tok := range tokens {
+		switch {
+		case parens > 0:
+			if tok == "(" {
+				parens++
+			} else if tok == ")" {
+				parens--
+			}
+		case tok == "(":
+			parens++
+		case tok == "{":
+			counted = append(counted, !inline)
+			if !inline {
+				level++
+				if level > depth {
+					depth = level
+				}
+			}
+			inline = false
+		case tok == "}":
+			if n := len(counted); n > 0 {
+				if counted[n-1] {
+					level--
+				}
+				counted = counted[:n-1]
+			}
+		case tok == "...":
+			if i+1 < len(tokens) && graphqlName(tokens[i+1]) && tokens[i+1] != "on" {
+				d, f := m.fragment(tokens[i+1])
+				if d > 0 && level+d-1 > depth {
+					depth = level + d - 1
+				}
+				fields += f
+			} else {
+				inline = true
+			}
+		case graphqlName(tok):
+			if level > 0 && prev != ":" && prev != "..." && prev != "on" && prev != "@" {
+				fields++
+			}
+		}
+		prev = tok
+	}
+	return depth, fields
+}
+
+// fragment measures the named fragment. Unknown fragments and cycles, which
+// are invalid, measure nothing.
+func (m *graphqlMeasurer) fragment(name string) (depth int, fields int) {
+	if measured, ok := m.measured[name]; ok {
+		return measured[0], measured[1]
+	}
+	selection, ok := m.fragments[name]
+	if !ok || m.active[name] {
+		return 0, 0
+	}
+	m.active[name] = true
+	depth, fields = m.selection(selection)
+	delete(m.active, name)
+	m.measured[name] = [2]int{depth, fields}
+	return depth, fields
+}
+
+// graphqlName reports whether the token is a name.
+func graphqlName(tok string) bool {
+	return tok[0] == '_' || (tok[0] >= 'a' && tok[0] <= 'z') || (tok[0] >= 'A' && tok[0] <= 'Z')
+}
+
+// graphqlTokens splits a query into names, punctuators and other lexical
+// tokens, dropping strings, comments and commas.
+func graphqlTokens(query string) []string {
+	var tokens []string
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			i++
+			for i < len(query) && query[i] != '"' {
+				if query[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+			tokens = append(tokens, `""`)
+		case strings.HasPrefix(query[i:], "..."):
+			tokens = append(tokens, "...")
+			i += 3
+		case c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-':
+			end := i + 1
+			for end < len(query) && (query[end] == '_' || query[end] == '.' || (query[end] >= 'a' && query[end] <= 'z') ||
+				(query[end] >= 'A' && query[end] <= 'Z') || (query[end] >= '0' && query[end] <= '9')) {
+				end++
+			}
+			tokens = append(tokens, query[i:end])
+			i = end
+		default:
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	return tokens
+}
+
+// GraphiQLAssets locates the scripts and stylesheet loaded by the GraphQL
+// playground, e.g. to serve them from the application's own origin.
+type GraphiQLAssets struct {
+	React      string
+	ReactDOM   string
+	GraphiQL   string
+	Stylesheet string
+}
+
+// DefaultGraphiQLAssets are the pinned releases loaded by the playground
+// unless GraphQLOptions.PlaygroundAssets is set. They are the last releases
+// published as UMD builds, which the page needs.
+var DefaultGraphiQLAssets = GraphiQLAssets{
+	React:      "https://unpkg.com/react@18.2.0/umd/react.production.min.js",
+	ReactDOM:   "https://unpkg.com/react-dom@18.2.0/umd/react-dom.production.min.js",
+	GraphiQL:   "https://unpkg.com/graphiql@3.0.6/graphiql.min.js",
+	Stylesheet: "https://unpkg.com/graphiql@3.0.6/graphiql.min.css",
+}
+
+// graphiqlPage is the playground page.
+var graphiqlPage = template.Must(template.New("graphiql").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>GraphiQL</title>
+<link rel="stylesheet" href="{{.Assets.Stylesheet}}" crossorigin="anonymous">
+</head>
+<body style="margin: 0">
+<div id="graphiql" style="height: 100vh"></div>
+<script src="{{.Assets.React}}" crossorigin="anonymous"></script>
+<script src="{{.Assets.ReactDOM}}" crossorigin="anonymous"></script>
+<script src="{{.Assets.GraphiQL}}" crossorigin="anonymous"></script>
+<script>
+ReactDOM.createRoot(document.getElementById("graphiql")).render(
+  React.createElement(GraphiQL, {fetcher: GraphiQL.createFetcher({url: {{.Path}}})}));
+</script>
+</body>
+</html>
+`))
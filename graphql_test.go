@@ -0,0 +1,148 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestGraphQL(t *testing.T) {
+	router := NewRouter()
+
+	var executed *GraphQLRequest
+	router.GraphQL("/graphql", func(ctx context.Context, req *GraphQLRequest) *GraphQLResponse {
+		executed = req
+		return &GraphQLResponse{Data: map[string]string{"hello": "world"}}
+	}, GraphQLOptions{
+		Playground: true,
+		Middleware: []func(GraphQLHandler) GraphQLHandler{
+			GraphQLMaxDepth(3),
+			GraphQLMaxComplexity(4),
+		},
+	})
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"POST JSON", "POST", "/graphql", "application/json", `{"query":"{ hello }","variables":{"id":1}}`,
+			http.StatusOK, `{"data":{"hello":"world"}}`},
+		{"POST GraphQL", "POST", "/graphql", "application/graphql", `{ hello }`,
+			http.StatusOK, `{"data":{"hello":"world"}}`},
+		{"GET", "GET", "/graphql?query=" + url.QueryEscape(`query Q { hello }`), "", "",
+			http.StatusOK, `{"data":{"hello":"world"}}`},
+		{"GET mutation", "GET", "/graphql?query=" + url.QueryEscape(`mutation { delete }`), "", "",
+			http.StatusMethodNotAllowed, `{"errors":[{"message":"mutations require POST"}]}`},
+		{"GET named mutation", "GET", "/graphql?operationName=B&query=" + url.QueryEscape(`query A { x } mutation B { y }`), "", "",
+			http.StatusMethodNotAllowed, `{"errors":[{"message":"mutations require POST"}]}`},
+		{"GET named query", "GET", "/graphql?operationName=A&query=" + url.QueryEscape(`query A { x } mutation B { y }`), "", "",
+			http.StatusOK, `{"data":{"hello":"world"}}`},
+		{"GET fragment first", "GET", "/graphql?query=" + url.QueryEscape(`fragment F on T { x } mutation { y { ...F } }`), "", "",
+			http.StatusMethodNotAllowed, `{"errors":[{"message":"mutations require POST"}]}`},
+		{"GET subscription", "GET", "/graphql?query=" + url.QueryEscape(`subscription { events }`), "", "",
+			http.StatusMethodNotAllowed, `{"errors":[{"message":"subscriptions require POST"}]}`},
+		{"Missing query", "POST", "/graphql", "application/json", `{}`,
+			http.StatusBadRequest, `{"errors":[{"message":"missing query"}]}`},
+		{"Depth limit", "POST", "/graphql", "application/graphql", `{ a { b { c { d } } } }`,
+			http.StatusOK, `{"errors":[{"message":"query depth 4 exceeds the limit of 3"}]}`},
+		{"Complexity limit", "POST", "/graphql", "application/graphql", `{ a(id: "x") { b c ...F } d e: f }`,
+			http.StatusOK, `{"errors":[{"message":"query complexity 5 exceeds the limit of 4"}]}`},
+		{"Depth limit through fragments", "POST", "/graphql", "application/graphql", `{ a { ...B } } fragment B on T { b { ...C } } fragment C on T { c { d } }`,
+			http.StatusOK, `{"errors":[{"message":"query depth 4 exceeds the limit of 3"}]}`},
+		{"Complexity limit through fragments", "POST", "/graphql", "application/graphql", `{ a { ...F ...F } } fragment F on T { b c }`,
+			http.StatusOK, `{"errors":[{"message":"query complexity 5 exceeds the limit of 4"}]}`},
+		{"Fragment cycle", "POST", "/graphql", "application/graphql", `{ a { ...F } } fragment F on T { b { ...F } }`,
+			http.StatusOK, `{"data":{"hello":"world"}}`},
+		{"Object value arguments", "POST", "/graphql", "application/graphql", `{ a(where: {b: {c: {d: 1}}}) { b { c } } }`,
+			http.StatusOK, `{"data":{"hello":"world"}}`},
+		{"Inline fragments", "POST", "/graphql", "application/graphql", `{ a { ... on T { b { c } } } }`,
+			http.StatusOK, `{"data":{"hello":"world"}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(test.method, test.path, strings.NewReader(test.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.contentType != "" {
+				req.Header.Set("Content-Type", test.contentType)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if rr.Code != test.expectedStatus {
+				t.Errorf("Expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+
+			// Check the response body
+			if body := strings.TrimSpace(rr.Body.String()); body != test.expectedBody {
+				t.Errorf("Expected response body %s, but got %s", test.expectedBody, body)
+			}
+		})
+	}
+
+	t.Run("Variables", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/graphql", strings.NewReader(`{"query":"{ hello }","operationName":"Q","variables":{"id":1}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		if executed.OperationName != "Q" || executed.Variables["id"] != float64(1) {
+			t.Errorf("Expected operation Q with variable id 1, but got %+v", executed)
+		}
+	})
+
+	t.Run("Playground", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/graphql", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "text/html")
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if !strings.Contains(rr.Body.String(), "GraphiQL") {
+			t.Errorf("Expected the playground page, but got %q", rr.Body.String())
+		}
+		if !strings.Contains(rr.Body.String(), DefaultGraphiQLAssets.GraphiQL) {
+			t.Errorf("Expected the pinned GraphiQL release, but got %q", rr.Body.String())
+		}
+	})
+
+	t.Run("Playground assets", func(t *testing.T) {
+		router := NewRouter()
+		router.GraphQL("/graphql", func(ctx context.Context, req *GraphQLRequest) *GraphQLResponse {
+			return &GraphQLResponse{}
+		}, GraphQLOptions{
+			Playground:       true,
+			PlaygroundAssets: &GraphiQLAssets{React: "/assets/react.js", ReactDOM: "/assets/react-dom.js", GraphiQL: "/assets/graphiql.js", Stylesheet: "/assets/graphiql.css"},
+		})
+		req, err := http.NewRequest("GET", "/graphql", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "text/html")
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		for _, asset := range []string{"/assets/react.js", "/assets/react-dom.js", "/assets/graphiql.js", "/assets/graphiql.css"} {
+			if !strings.Contains(rr.Body.String(), asset) {
+				t.Errorf("Expected the page to load %s", asset)
+			}
+		}
+	})
+}
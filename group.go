@@ -0,0 +1,134 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Group registers routes under a common path prefix, with their own
+// middleware and not found handler, e.g. to serve an API and a web app
+// from the same router.
+type Group struct {
+	router     *Router
+	parent     *Group
+	prefix     string
+	middleware []func(http.HandlerFunc) http.HandlerFunc
+	notFound   http.HandlerFunc
+
+	errorHandlers errorHandlers
+}
+
+// groupMembership records the group of a route and the number of group
+// middleware when the route was registered, see SetMiddlewareScope.
+type groupMembership struct {
+	group    *Group
+	useCount int
+}
+
+// Group creates a group of routes under the path prefix, e.g. "/api".
+func (r *Router) Group(prefix string) *Group {
+	g := &Group{router: r, prefix: strings.TrimSuffix(prefix, "/")}
+	r.groups = append(r.groups, g)
+	return g
+}
+
+// Group creates a nested group under the path prefix of the group.
+func (g *Group) Group(prefix string) *Group {
+	nested := g.router.Group(g.prefix + prefix)
+	nested.parent = g
+	return nested
+}
+
+// Prefix returns the path prefix of the group.
+func (g *Group) Prefix() string {
+	return g.prefix
+}
+
+// Use adds middleware to the routes of the group and its nested groups. It
+// runs after the router middleware and before the route middleware.
+func (g *Group) Use(middleware ...func(http.HandlerFunc) http.HandlerFunc) {
+	g.middleware = append(g.middleware, middleware...)
+	atomic.AddUint64(&g.router.middlewareGen, 1)
+}
+
+// SetNotFoundHandler sets the handler of the requests under the prefix of
+// the group matching no route, instead of the router's. Nested groups
+// without a not found handler use the handler of their parent.
+func (g *Group) SetNotFoundHandler(handler http.HandlerFunc) {
+	g.notFound = handler
+}
+
+// SetErrorHandler sets the handler writing the responses replacing 5xx
+// responses and panics of the routes of the group and of the requests
+// under its prefix matching no route, instead of the router's, see
+// Router.SetErrorHandler. Nested groups without an error handler use the
+// handler of their parent.
+func (g *Group) SetErrorHandler(handler ErrorHandler) {
+	g.errorHandlers.err = handler
+	atomic.CompareAndSwapInt32(&g.router.errorPages, errorPagesOff, errorPagesProduction)
+}
+
+// SetPanicHandler sets the handler writing the responses to the panics of
+// the group, instead of its error handler, like SetErrorHandler.
+func (g *Group) SetPanicHandler(handler ErrorHandler) {
+	g.errorHandlers.panic = handler
+	atomic.CompareAndSwapInt32(&g.router.errorPages, errorPagesOff, errorPagesProduction)
+}
+
+// AddRoute adds a route under the prefix of the group, see Router.AddRoute.
+func (g *Group) AddRoute(method string, path string, handler http.HandlerFunc) (*Route, error) {
+	route, err := g.router.AddRoute(method, g.prefix+path, handler)
+	if err != nil {
+		return nil, err
+	}
+	route.groups = g.memberships()
+	return route, nil
+}
+
+// MustAddRoute is like AddRoute but panics if the route cannot be added.
+func (g *Group) MustAddRoute(method string, path string, handler http.HandlerFunc) *Route {
+	route, err := g.AddRoute(method, path, handler)
+	if err != nil {
+		panic(err.Error())
+	}
+	return route
+}
+
+// memberships returns the group and its parents, outermost first, with
+// their current number of middleware.
+func (g *Group) memberships() []groupMembership {
+	var memberships []groupMembership
+	for ; g != nil; g = g.parent {
+		memberships = append([]groupMembership{{g, len(g.middleware)}}, memberships...)
+	}
+	return memberships
+}
+
+// contains reports whether the path is under the prefix of the group.
+func (g *Group) contains(path string) bool {
+	return path == g.prefix || strings.HasPrefix(path, g.prefix+"/")
+}
+
+// groupOf returns the innermost group whose prefix contains the path, or
+// nil if there is none.
+func (r *Router) groupOf(path string) *Group {
+	var found *Group
+	for _, g := range r.groups {
+		if g.contains(path) && (found == nil || len(g.prefix) > len(found.prefix)) {
+			found = g
+		}
+	}
+	return found
+}
+
+// groupNotFound returns the not found route of the innermost group
+// containing the request path that has a not found handler, or nil.
+func (r *Router) groupNotFound(req *http.Request) *Route {
+	for g := r.groupOf(req.URL.Path); g != nil; g = g.parent {
+		if g.notFound != nil {
+			return &Route{HandlerFunc: g.notFound, groups: g.memberships()}
+		}
+	}
+	return nil
+}
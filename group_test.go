@@ -0,0 +1,134 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGroups(t *testing.T) {
+	router := NewRouter()
+	router.SetNotFoundHandler(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "router not found", http.StatusNotFound)
+	})
+
+	api := router.Group("/api")
+	api.Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Group", "api")
+			next(w, req)
+		}
+	})
+	api.SetNotFoundHandler(func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	})
+	api.MustAddRoute("GET", "/users", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "users")
+	})
+	v2 := api.Group("/v2")
+	v2.MustAddRoute("GET", "/users", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "users v2")
+	})
+
+	app := router.Group("/app")
+	app.SetNotFoundHandler(func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "<html>app shell</html>")
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+		expectedGroup  string
+	}{
+		{"Group route", "/api/users", http.StatusOK, "users", "api"},
+		{"Nested group route", "/api/v2/users", http.StatusOK, "users v2", "api"},
+		{"Group not found", "/api/missing", http.StatusNotFound, `{"error":"not found"}` + "\n", "api"},
+		{"Nested group not found", "/api/v2/missing", http.StatusNotFound, `{"error":"not found"}` + "\n", "api"},
+		{"Other group not found", "/app/settings", http.StatusOK, "<html>app shell</html>", ""},
+		{"Router not found", "/apiary", http.StatusNotFound, "router not found\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, but got %d", tt.expectedStatus, rr.Code)
+			}
+
+			// Check the response body
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected response body %q, but got %q", tt.expectedBody, rr.Body.String())
+			}
+
+			// Check that the group middleware ran
+			if group := rr.Header().Get("X-Group"); group != tt.expectedGroup {
+				t.Errorf("Expected group %q, but got %q", tt.expectedGroup, group)
+			}
+		})
+	}
+}
+
+func TestGroupErrorHandlers(t *testing.T) {
+	router := NewRouter()
+	router.SetErrorHandler(func(w http.ResponseWriter, req *http.Request, status int, err error) {
+		w.WriteHeader(status)
+		io.WriteString(w, "router error")
+	})
+
+	api := router.Group("/api")
+	api.SetErrorHandler(func(w http.ResponseWriter, req *http.Request, status int, err error) {
+		writeJSON(w, status, map[string]string{"error": err.Error()})
+	})
+	api.MustAddRoute("GET", "/fail", func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+	})
+
+	app := router.Group("/app")
+	app.SetPanicHandler(func(w http.ResponseWriter, req *http.Request, status int, err error) {
+		if _, ok := err.(*PanicError); !ok {
+			t.Errorf("Expected a *PanicError, but got %T", err)
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, "<html>something went wrong</html>")
+	})
+	app.MustAddRoute("GET", "/panic", func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+	app.MustAddRoute("GET", "/fail", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Group error handler", "/api/fail", http.StatusServiceUnavailable, `{"error":"database unavailable"}` + "\n"},
+		{"Group panic handler", "/app/panic", http.StatusInternalServerError, "<html>something went wrong</html>"},
+		{"Router error handler", "/app/fail", http.StatusInternalServerError, "router error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, but got %d", tt.expectedStatus, rr.Code)
+			}
+
+			// Check the response body
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected response body %q, but got %q", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
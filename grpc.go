@@ -0,0 +1,244 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// GRPCService is a gRPC service whose methods are exposed as REST routes.
+type GRPCService struct {
+	// Name is the fully qualified service name, e.g. "users.v1.Users".
+	Name string
+	// Methods maps the service methods to routes.
+	Methods []GRPCMethod
+	// Code extracts the gRPC status code from an error returned by a
+	// method, e.g. status.Code. By default errors of type *GRPCError carry
+	// their code and other errors are reported as Unknown.
+	Code func(err error) int
+}
+
+// GRPCMethod maps a gRPC method to a route. The request message is decoded
+// from the JSON body, then path and query parameters are bound to the
+// message fields of the same JSON name. The response message is encoded
+// as JSON.
+type GRPCMethod struct {
+	// Name is the method name, e.g. "GetUser".
+	Name string
+	// Method and Path are the HTTP method and path pattern of the route,
+	// e.g. "GET" and "/v1/users/:id".
+	Method string
+	Path   string
+	// New returns a new request message.
+	New func() interface{}
+	// Call invokes the method, typically through a generated client or the
+	// service implementation.
+	Call func(ctx context.Context, in interface{}) (interface{}, error)
+}
+
+// GRPCError is an error carrying a gRPC status code.
+type GRPCError struct {
+	Code    int
+	Message string
+}
+
+// Error implements the error interface.
+func (e *GRPCError) Error() string {
+	return fmt.Sprintf("rpc error: code = %d desc = %s", e.Code, e.Message)
+}
+
+// grpcStatus maps the gRPC status codes to HTTP status codes.
+var grpcStatus = []int{
+	http.StatusOK,                  // OK
+	499,                            // Canceled
+	http.StatusInternalServerError, // Unknown
+	http.StatusBadRequest,          // InvalidArgument
+	http.StatusGatewayTimeout,      // DeadlineExceeded
+	http.StatusNotFound,            // NotFound
+	http.StatusConflict,            // AlreadyExists
+	http.StatusForbidden,           // PermissionDenied
+	http.StatusTooManyRequests,     // ResourceExhausted
+	http.StatusBadRequest,          // FailedPrecondition
+	http.StatusConflict,            // Aborted
+	http.StatusBadRequest,          // OutOfRange
+	http.StatusNotImplemented,      // Unimplemented
+	http.StatusInternalServerError, // Internal
+	http.StatusServiceUnavailable,  // Unavailable
+	http.StatusInternalServerError, // DataLoss
+	http.StatusUnauthorized,        // Unauthenticated
+}
+
+// gRPC status codes reported for unrecognized errors and undecodable
+// requests.
+const (
+	grpcCodeUnknown         = 2
+	grpcCodeInvalidArgument = 3
+)
+
+// HTTPStatusFromGRPC returns the HTTP status code corresponding to a gRPC
+// status code.
+func HTTPStatusFromGRPC(code int) int {
+	if code < 0 || code >= len(grpcStatus) {
+		return http.StatusInternalServerError
+	}
+	return grpcStatus[code]
+}
+
+// RegisterGRPCService adds a route for each method of the service and
+// returns them in order. The routes go through the router's middleware
+// and the request context, including the correlation ID, is passed to the
+// methods. It returns an error, adding no route, if one of the routes
+// cannot be added, see AddRoute.
+func (r *Router) RegisterGRPCService(svc GRPCService) ([]*Route, error) {
+	keys := make([]routeKey, 0, len(svc.Methods))
+	for _, m := range svc.Methods {
+		keys = append(keys, routeKey{m.Method, m.Path})
+	}
+	if err := r.validateRoutes(keys...); err != nil {
+		return nil, err
+	}
+	routes := make([]*Route, 0, len(svc.Methods))
+	for _, m := range svc.Methods {
+		routes = append(routes, r.MustAddRoute(m.Method, m.Path, r.grpcHandler(svc, m)))
+	}
+	return routes, nil
+}
+
+// grpcHandler returns a handler transcoding requests to the method.
+func (r *Router) grpcHandler(svc GRPCService, m GRPCMethod) http.HandlerFunc {
+	name := "/" + svc.Name + "/" + m.Name
+	return func(w http.ResponseWriter, req *http.Request) {
+		in := m.New()
+		if err := r.decodeGRPCRequest(req, in); err != nil {
+			writeGRPCError(w, grpcCodeInvalidArgument, err.Error())
+			return
+		}
+
+		out, err := m.Call(req.Context(), in)
+		if err != nil {
+			code := grpcCode(svc, err)
+			if HTTPStatusFromGRPC(code) >= http.StatusInternalServerError {
+				r.logger.Errorf("Failed to call %s: %v", name, err)
+			}
+			writeGRPCError(w, code, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// grpcCode returns the gRPC status code of an error returned by a method.
+func grpcCode(svc GRPCService, err error) int {
+	if svc.Code != nil {
+		return svc.Code(err)
+	}
+	var grpcErr *GRPCError
+	if errors.As(err, &grpcErr) {
+		return grpcErr.Code
+	}
+	return grpcCodeUnknown
+}
+
+// writeGRPCError writes an error response in the gRPC-gateway format.
+func writeGRPCError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, HTTPStatusFromGRPC(code), map[string]interface{}{
+		"code":    code,
+		"message": message,
+	})
+}
+
+// decodeGRPCRequest decodes the request body and parameters into the
+// request message.
+func (r *Router) decodeGRPCRequest(req *http.Request, in interface{}) error {
+	if req.Body != nil && req.ContentLength != 0 {
+		if err := json.NewDecoder(req.Body).Decode(in); err != nil && err != io.EOF {
+			return fmt.Errorf("invalid request body: %v", err)
+		}
+	}
+	for name, values := range r.GetQueryParams(req) {
+		if err := setField(in, name, values[0]); err != nil {
+			return err
+		}
+	}
+	for name, value := range r.GetPathParams(req) {
+		if err := setField(in, name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setField sets the field of the struct pointed to by v whose JSON name is
+// name. Unknown names are ignored.
+func setField(v interface{}, name string, value string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" || jsonName(field) != name {
+			continue
+		}
+		if err := setValue(rv.Field(i), value); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %v", value, name, err)
+		}
+		return nil
+	}
+	return nil
+}
+
+// jsonName returns the JSON name of a struct field.
+func jsonName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if i := strings.IndexByte(tag, ','); i >= 0 {
+		tag = tag[:i]
+	}
+	if tag == "" {
+		return field.Name
+	}
+	return tag
+}
+
+// setValue parses the string into a value of the kind of v.
+func setValue(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
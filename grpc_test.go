@@ -0,0 +1,112 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type getUserRequest struct {
+	ID      int64  `json:"id"`
+	Verbose bool   `json:"verbose"`
+	Name    string `json:"name"`
+}
+
+type getUserResponse struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Verbose bool   `json:"verbose"`
+}
+
+func TestGRPCService(t *testing.T) {
+	router := NewRouter()
+
+	router.RegisterGRPCService(GRPCService{
+		Name: "users.v1.Users",
+		Methods: []GRPCMethod{
+			{
+				Name:   "GetUser",
+				Method: "GET",
+				Path:   "/v1/users/:id",
+				New:    func() interface{} { return &getUserRequest{} },
+				Call: func(ctx context.Context, in interface{}) (interface{}, error) {
+					req := in.(*getUserRequest)
+					if req.ID == 0 {
+						return nil, &GRPCError{Code: 5, Message: "user not found"}
+					}
+					return &getUserResponse{ID: req.ID, Name: "gopher", Verbose: req.Verbose}, nil
+				},
+			},
+			{
+				Name:   "UpdateUser",
+				Method: "PUT",
+				Path:   "/v1/users/:id",
+				New:    func() interface{} { return &getUserRequest{} },
+				Call: func(ctx context.Context, in interface{}) (interface{}, error) {
+					req := in.(*getUserRequest)
+					return &getUserResponse{ID: req.ID, Name: req.Name}, nil
+				},
+			},
+		},
+	})
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Path and query parameters", "GET", "/v1/users/42?verbose=true", "", http.StatusOK, `{"id":42,"name":"gopher","verbose":true}`},
+		{"Request body", "PUT", "/v1/users/7", `{"name":"gordon"}`, http.StatusOK, `{"id":7,"name":"gordon","verbose":false}`},
+		{"Error mapping", "GET", "/v1/users/0", "", http.StatusNotFound, `{"code":5,"message":"rpc error: code = 5 desc = user not found"}`},
+		{"Invalid parameter", "GET", "/v1/users/abc", "", http.StatusBadRequest, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(test.method, test.path, strings.NewReader(test.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if rr.Code != test.expectedStatus {
+				t.Errorf("Expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+
+			// Check the response body
+			if test.expectedBody != "" {
+				var got, expected interface{}
+				json.Unmarshal(rr.Body.Bytes(), &got)
+				json.Unmarshal([]byte(test.expectedBody), &expected)
+				if !jsonEqual(got, expected) {
+					t.Errorf("Expected response body %s, but got %s", test.expectedBody, rr.Body.String())
+				}
+			}
+		})
+	}
+
+	t.Run("Status mapping", func(t *testing.T) {
+		if status := HTTPStatusFromGRPC(16); status != http.StatusUnauthorized {
+			t.Errorf("Expected status code %d, but got %d", http.StatusUnauthorized, status)
+		}
+		if status := HTTPStatusFromGRPC(99); status != http.StatusInternalServerError {
+			t.Errorf("Expected status code %d, but got %d", http.StatusInternalServerError, status)
+		}
+	})
+}
+
+// jsonEqual reports whether two decoded JSON values are equal.
+func jsonEqual(a, b interface{}) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return string(x) == string(y)
+}
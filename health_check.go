@@ -0,0 +1,145 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthCheck configures the active health checks of an upstream pool.
+type HealthCheck struct {
+	// Path is the path probed with GET requests, e.g. "/healthz". Backends
+	// answering with a 2xx or 3xx status are healthy. When Path is empty,
+	// backends are probed by opening a TCP connection.
+	Path string
+	// Interval is the time between probes. It defaults to 10 seconds.
+	Interval time.Duration
+	// Timeout is the timeout of a probe. It defaults to 2 seconds.
+	Timeout time.Duration
+	// UnhealthyThreshold is the number of consecutive failed probes
+	// ejecting a backend. It defaults to 3.
+	UnhealthyThreshold int
+	// HealthyThreshold is the number of consecutive successful probes
+	// readmitting an ejected backend. It defaults to 2.
+	HealthyThreshold int
+	// Logf, if set, logs the ejected and readmitted backends.
+	Logf func(format string, args ...interface{})
+}
+
+// StartHealthChecks probes the backends of the pool periodically, ejecting
+// those failing UnhealthyThreshold probes in a row and readmitting them
+// after HealthyThreshold successful probes. Ejected backends receive no
+// requests. Call the returned function to stop probing.
+func (p *UpstreamPool) StartHealthChecks(check HealthCheck) (stop func()) {
+	if check.Interval <= 0 {
+		check.Interval = 10 * time.Second
+	}
+	if check.Timeout <= 0 {
+		check.Timeout = 2 * time.Second
+	}
+	if check.UnhealthyThreshold <= 0 {
+		check.UnhealthyThreshold = 3
+	}
+	if check.HealthyThreshold <= 0 {
+		check.HealthyThreshold = 2
+	}
+	if check.Logf == nil {
+		check.Logf = func(string, ...interface{}) {}
+	}
+	client := &http.Client{
+		Transport: p.opts.Transport,
+		Timeout:   check.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(check.Interval)
+		defer ticker.Stop()
+		for {
+			p.probe(client, check)
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
+
+// probe probes all the backends concurrently and updates their state.
+func (p *UpstreamPool) probe(client *http.Client, check HealthCheck) {
+	p.mu.RLock()
+	backends := append([]*backend(nil), p.backends...)
+	p.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, b := range backends {
+		wg.Add(1)
+		go func(b *backend) {
+			defer wg.Done()
+			// Outlier ejections are left to run their course
+			down := atomic.LoadInt32(&b.down) == 1
+			healthy := probeBackend(client, b, check)
+			if healthy {
+				b.successes, b.failures = b.successes+1, 0
+				if down && b.successes >= check.HealthyThreshold {
+					atomic.StoreInt32(&b.down, 0)
+					check.Logf("Upstream %s is healthy again", b.url)
+				}
+				return
+			}
+			b.successes, b.failures = 0, b.failures+1
+			if !down && b.failures >= check.UnhealthyThreshold {
+				atomic.StoreInt32(&b.down, 1)
+				check.Logf("Upstream %s failed %d health checks and was ejected", b.url, b.failures)
+			}
+		}(b)
+	}
+	wg.Wait()
+}
+
+// probeBackend reports whether the backend passed the probe.
+func probeBackend(client *http.Client, b *backend, check HealthCheck) bool {
+	if check.Path == "" {
+		host := b.url.Host
+		if b.url.Port() == "" {
+			port := "80"
+			if b.url.Scheme == "https" {
+				port = "443"
+			}
+			host = net.JoinHostPort(b.url.Hostname(), port)
+		}
+		conn, err := net.DialTimeout("tcp", host, check.Timeout)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}
+
+	u := *b.url
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.TrimPrefix(check.Path, "/")
+	u.RawQuery = ""
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 400
+}
@@ -0,0 +1,119 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor polls the condition until it holds or a second has passed.
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHealthChecks(t *testing.T) {
+	var failing int32
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "healthy")
+	}))
+	defer healthy.Close()
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v1/healthz" && atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "flaky")
+	}))
+	defer flaky.Close()
+	healthyURL, _ := url.Parse(healthy.URL + "/v1")
+	flakyURL, _ := url.Parse(flaky.URL + "/v1")
+
+	router := NewRouter()
+	pool := NewUpstreamPool(UpstreamPoolOptions{}, healthyURL, flakyURL)
+	router.ProxyPool("GET", "/app", pool)
+	stop := pool.StartHealthChecks(HealthCheck{Path: "/healthz", Interval: 5 * time.Millisecond, UnhealthyThreshold: 2, HealthyThreshold: 2})
+	defer stop()
+
+	served := func() map[string]int {
+		counts := map[string]int{}
+		for i := 0; i < 4; i++ {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", "/app", nil))
+			counts[rr.Body.String()]++
+		}
+		return counts
+	}
+
+	t.Run("Failing backend ejected", func(t *testing.T) {
+		atomic.StoreInt32(&failing, 1)
+		waitFor(t, func() bool { return !pool.backends[1].available() })
+
+		// Check that only the healthy backend receives requests
+		if counts := served(); counts["healthy"] != 4 {
+			t.Errorf("Expected all requests on the healthy backend, but got %v", counts)
+		}
+	})
+
+	t.Run("Recovered backend readmitted", func(t *testing.T) {
+		atomic.StoreInt32(&failing, 0)
+		waitFor(t, func() bool { return pool.backends[1].available() })
+
+		// Check that both backends receive requests
+		if counts := served(); counts["healthy"] != 2 || counts["flaky"] != 2 {
+			t.Errorf("Expected requests on both backends, but got %v", counts)
+		}
+	})
+
+	t.Run("TCP probe", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closedURL, _ := url.Parse(closed.URL)
+		closed.Close()
+		tcpPool := NewUpstreamPool(UpstreamPoolOptions{}, healthyURL, closedURL)
+		stop := tcpPool.StartHealthChecks(HealthCheck{Interval: 5 * time.Millisecond, UnhealthyThreshold: 1})
+		defer stop()
+
+		// Check that the unreachable backend is ejected and the other kept
+		waitFor(t, func() bool { return !tcpPool.backends[1].available() })
+		if !tcpPool.backends[0].available() {
+			t.Errorf("Expected the reachable backend to stay available")
+		}
+	})
+
+	t.Run("Outlier ejection kept", func(t *testing.T) {
+		var logged, probed int32
+		checked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			atomic.AddInt32(&probed, 1)
+		}))
+		defer checked.Close()
+		checkedURL, _ := url.Parse(checked.URL)
+		outlierPool := NewUpstreamPool(UpstreamPoolOptions{}, checkedURL)
+		atomic.StoreInt64(&outlierPool.backends[0].ejectedUntil, time.Now().Add(time.Hour).UnixNano())
+		stop := outlierPool.StartHealthChecks(HealthCheck{
+			Path:             "/healthz",
+			Interval:         5 * time.Millisecond,
+			HealthyThreshold: 1,
+			Logf:             func(format string, args ...interface{}) { atomic.AddInt32(&logged, 1) },
+		})
+		defer stop()
+
+		// Check that passing probes do not cut the ejection short
+		waitFor(t, func() bool { return atomic.LoadInt32(&probed) >= 3 })
+		if outlierPool.backends[0].available() {
+			t.Errorf("Expected the outlier to stay ejected")
+		}
+		if n := atomic.LoadInt32(&logged); n != 0 {
+			t.Errorf("Expected no health change to be logged, but got %d", n)
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HedgePolicy configures the hedged requests of an upstream pool: when a
+// backend has not responded after a percentile of the recent response
+// times, a second attempt is sent to another backend, the first response
+// wins and the other attempt is cancelled. Only requests that can be retried
+// are hedged, see RetryPolicy.
+type HedgePolicy struct {
+	// Percentile is the percentile of the recent response times after which
+	// requests are hedged. It defaults to 95.
+	Percentile float64
+	// MinDelay is the minimum time before hedging, so that fast backends are
+	// not hedged needlessly.
+	MinDelay time.Duration
+	// MinSamples is the number of response times measured before requests
+	// are hedged. It defaults to 20.
+	MinSamples int
+}
+
+// latencySamples is the number of recent response times kept by a pool.
+const latencySamples = 256
+
+// latencies holds the recent response times of a pool.
+type latencies struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+// add records a response time, replacing the oldest one when full.
+func (l *latencies) add(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.samples) < latencySamples {
+		l.samples = append(l.samples, d)
+		return
+	}
+	l.samples[l.next] = d
+	l.next = (l.next + 1) % latencySamples
+}
+
+// percentile returns the percentile of the response times, or false if
+// fewer than minSamples were recorded.
+func (l *latencies) percentile(p float64, minSamples int) (time.Duration, bool) {
+	l.mu.Lock()
+	if len(l.samples) < minSamples {
+		l.mu.Unlock()
+		return 0, false
+	}
+	sorted := append([]time.Duration(nil), l.samples...)
+	l.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i], true
+}
+
+// hedge forwards the request to b, and to another backend if b has not
+// responded after the hedging delay. The first response wins, unless it is
+// a server error while the other attempt is still running. When all the
+// attempts fail, the response of the last one to finish is written.
+func (p *UpstreamPool) hedge(r *Router, w http.ResponseWriter, req *http.Request, b *backend, tried *[]*backend) {
+	rc := &race{w: w, latencies: &p.latencies}
+	var wg sync.WaitGroup
+	launch := func(b *backend, req *http.Request) {
+		ctx, cancel := context.WithCancel(req.Context())
+		rr := &racer{race: rc, header: http.Header{}, cancel: cancel, start: time.Now()}
+		rc.racers = append(rc.racers, rr)
+		rc.running++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer cancel()
+			b.serve(r, rr, req.WithContext(ctx))
+			rc.mu.Lock()
+			rc.running--
+			cancelled := rr.cancelled
+			// When every attempt failed, the last one to finish wins
+			last := rc.winner == nil && rc.running == 0 && !cancelled && rr.status != 0
+			if last {
+				rc.winner = rr
+				rr.won = true
+			}
+			rc.mu.Unlock()
+			if last {
+				copyHeader(rc.w.Header(), rr.header)
+				rc.w.WriteHeader(rr.status)
+				rc.w.Write(rr.body.Bytes())
+			}
+			if !cancelled && p.opts.Outliers != nil {
+				p.record(b, rr.status, time.Since(rr.start))
+			}
+		}()
+	}
+
+	rc.mu.Lock()
+	launch(b, req)
+	rc.mu.Unlock()
+
+	if delay, ok := p.latencies.percentile(p.opts.Hedge.Percentile, p.opts.Hedge.MinSamples); ok {
+		if delay < p.opts.Hedge.MinDelay {
+			delay = p.opts.Hedge.MinDelay
+		}
+		timer := time.AfterFunc(delay, func() {
+			rc.mu.Lock()
+			defer rc.mu.Unlock()
+			if rc.winner != nil || rc.running == 0 || req.Context().Err() != nil {
+				return
+			}
+			second := p.pick(req, *tried)
+			if second == nil || containsBackend(*tried, second) {
+				return
+			}
+			hedged, err := replay(req)
+			if err != nil {
+				return
+			}
+			*tried = append(*tried, second)
+			launch(second, hedged)
+		})
+		defer timer.Stop()
+	}
+	wg.Wait()
+}
+
+// race holds the attempts of a hedged request.
+type race struct {
+	w         http.ResponseWriter
+	latencies *latencies
+	mu        sync.Mutex
+	racers    []*racer
+	winner    *racer
+	running   int
+}
+
+// racer is the http.ResponseWriter of an attempt of a hedged request. Only
+// the response of the winner is written to the race.
+type racer struct {
+	race      *race
+	header    http.Header
+	cancel    context.CancelFunc
+	start     time.Time
+	status    int
+	body      bytes.Buffer // of a server error held back while others run
+	won       bool
+	cancelled bool // guarded by race.mu
+}
+
+// Header returns the header of the attempt, or of the response once the
+// attempt has won.
+func (w *racer) Header() http.Header {
+	if w.won {
+		return w.race.w.Header()
+	}
+	return w.header
+}
+
+// WriteHeader writes the response header if the attempt wins the race,
+// cancelling the other attempts.
+func (w *racer) WriteHeader(status int) {
+	if w.status != 0 || status < 200 {
+		return
+	}
+	w.status = status
+	rc := w.race
+	rc.mu.Lock()
+	if w.cancelled {
+		rc.mu.Unlock()
+		return
+	}
+	rc.latencies.add(time.Since(w.start))
+	if rc.winner == nil && (status < 500 || rc.running == 1) {
+		rc.winner = w
+		for _, other := range rc.racers {
+			if other != w {
+				other.cancelled = true
+				other.cancel()
+			}
+		}
+	}
+	w.won = rc.winner == w
+	rc.mu.Unlock()
+	if !w.won {
+		return
+	}
+
+	copyHeader(rc.w.Header(), w.header)
+	rc.w.WriteHeader(status)
+}
+
+// Write writes the body of the winning attempt.
+func (w *racer) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.won {
+		if w.status >= 500 {
+			w.body.Write(b)
+		}
+		return len(b), nil
+	}
+	return w.race.w.Write(b)
+}
+
+// Flush implements http.Flusher if the wrapped writer supports it.
+func (w *racer) Flush() {
+	if !w.won {
+		return
+	}
+	if f, ok := w.race.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,118 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestProxyPoolHedging(t *testing.T) {
+	cancelled := make(chan struct{}, 1)
+	startBackend := func(handler http.HandlerFunc) *url.URL {
+		backend := httptest.NewServer(handler)
+		t.Cleanup(backend.Close)
+		u, _ := url.Parse(backend.URL)
+		return u
+	}
+	fast := startBackend(func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "fast")
+	})
+	slow := startBackend(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-time.After(500 * time.Millisecond):
+			io.WriteString(w, "slow")
+		case <-req.Context().Done():
+			cancelled <- struct{}{}
+		}
+	})
+
+	serve := func(router *Router) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/app", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Slow backend", func(t *testing.T) {
+		router := NewRouter()
+		hedge := &HedgePolicy{Percentile: 50, MinSamples: 1}
+		router.ProxyPool("GET", "/app", NewUpstreamPool(UpstreamPoolOptions{Hedge: hedge}, fast, slow))
+
+		// Measure the response time of the fast backend
+		if rr := serve(router); rr.Body.String() != "fast" {
+			t.Fatalf("Expected body %q, but got %q", "fast", rr.Body.String())
+		}
+
+		start := time.Now()
+		rr := serve(router)
+
+		// Check that the request was hedged to the fast backend
+		if rr.Code != http.StatusOK || rr.Body.String() != "fast" {
+			t.Errorf("Expected status code %d and body %q, but got %d and %q", http.StatusOK, "fast", rr.Code, rr.Body.String())
+		}
+		if elapsed := time.Since(start); elapsed >= 500*time.Millisecond {
+			t.Errorf("Expected the hedged response before the slow one, but it took %s", elapsed)
+		}
+
+		// Check that the slow attempt was cancelled
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			t.Errorf("Expected the slow attempt to be cancelled")
+		}
+	})
+
+	t.Run("All attempts fail", func(t *testing.T) {
+		// The backends send their headers after the hedging delay, then take a
+		// while to send their bodies, so both attempts fail while running
+		failing := func(w http.ResponseWriter, req *http.Request) {
+			time.Sleep(30 * time.Millisecond)
+			w.WriteHeader(http.StatusBadGateway)
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+			io.WriteString(w, "bad gateway")
+		}
+		router := NewRouter()
+		pool := NewUpstreamPool(UpstreamPoolOptions{Hedge: &HedgePolicy{MinSamples: 1}}, startBackend(failing), startBackend(failing))
+		pool.latencies.add(10 * time.Millisecond)
+		router.ProxyPool("GET", "/app", pool)
+
+		rr := serve(router)
+
+		// Check that the failure is passed on
+		if rr.Code != http.StatusBadGateway || rr.Body.String() != "bad gateway" {
+			t.Errorf("Expected status code %d and body %q, but got %d and %q", http.StatusBadGateway, "bad gateway", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("Not enough samples", func(t *testing.T) {
+		router := NewRouter()
+		hedge := &HedgePolicy{MinSamples: 100}
+		router.ProxyPool("GET", "/app", NewUpstreamPool(UpstreamPoolOptions{Hedge: hedge}, slow, fast))
+
+		rr := serve(router)
+
+		// Check that the request was not hedged
+		if rr.Body.String() != "slow" {
+			t.Errorf("Expected body %q, but got %q", "slow", rr.Body.String())
+		}
+	})
+}
+
+func TestLatencyPercentile(t *testing.T) {
+	var l latencies
+	for i := 1; i <= 100; i++ {
+		l.add(time.Duration(i) * time.Millisecond)
+	}
+
+	// Check the percentile of the response times
+	if d, ok := l.percentile(95, 20); !ok || d != 95*time.Millisecond {
+		t.Errorf("Expected percentile %s, but got %s", 95*time.Millisecond, d)
+	}
+	if _, ok := l.percentile(95, 200); ok {
+		t.Errorf("Expected no percentile without enough samples")
+	}
+}
@@ -0,0 +1,106 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// RequestInfo describes the outcome of a request passed to after hooks.
+type RequestInfo struct {
+	Status   int
+	Bytes    int64
+	Duration time.Duration
+	Err      error
+}
+
+// ResponseInfo describes a response passed to route post-hooks.
+type ResponseInfo struct {
+	Status   int
+	Header   http.Header // a copy of the response header
+	Bytes    int64
+	Duration time.Duration
+	// Trailer holds trailers the hooks add to the response. Trailers are
+	// only sent with responses without a Content-Length.
+	Trailer http.Header
+}
+
+// PostHook is called once a route's handler has written its response. It
+// can observe the response and add trailers, but not modify it.
+type PostHook func(req *http.Request, resp *ResponseInfo)
+
+// BeforeHook is called when a request is received, before routing.
+type BeforeHook func(req *http.Request)
+
+// AfterHook is called once the handler has completed.
+type AfterHook func(req *http.Request, info RequestInfo)
+
+// Before adds hooks that run for every request before it is routed.
+// Hooks run independently of the middleware chain.
+func (r *Router) Before(hooks ...BeforeHook) {
+	r.beforeHooks = append(r.beforeHooks, hooks...)
+}
+
+// After adds hooks that run for every request after the handler completes.
+// If the handler panics, the hooks receive the panic as Err and the panic
+// is then propagated. If the client went away, they receive
+// StatusClientClosedRequest and context.Canceled as Err.
+func (r *Router) After(hooks ...AfterHook) {
+	r.afterHooks = append(r.afterHooks, hooks...)
+}
+
+// runAfterHooks calls the after hooks with the captured request outcome.
+func (r *Router) runAfterHooks(req *http.Request, rw *responseWriter, start time.Time, recovered interface{}) {
+	info := RequestInfo{
+		Status:   rw.Status(),
+		Bytes:    rw.written,
+		Duration: time.Since(start),
+	}
+	if r.ClientGone(req) {
+		info.Status = StatusClientClosedRequest
+		info.Err = req.Context().Err()
+	}
+	if recovered != nil {
+		info.Status = http.StatusInternalServerError
+		info.Err = fmt.Errorf("panic: %v", recovered)
+	}
+	for _, hook := range r.afterHooks {
+		hook(req, info)
+	}
+}
+
+// After adds post-hooks that run once the route's handler has written its
+// response, in the order they were added.
+func (route *Route) After(hooks ...PostHook) *Route {
+	route.postHooks = append(route.postHooks, hooks...)
+	route.invalidate()
+	return route
+}
+
+// withPostHooks wraps the handler to run the route's post-hooks.
+func (route *Route) withPostHooks(handler http.HandlerFunc) http.HandlerFunc {
+	if len(route.postHooks) == 0 {
+		return handler
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		rw := newResponseWriter(w)
+		handler(rw, req)
+
+		info := &ResponseInfo{
+			Status:   rw.Status(),
+			Header:   w.Header().Clone(),
+			Bytes:    rw.written,
+			Duration: time.Since(start),
+			Trailer:  http.Header{},
+		}
+		for _, hook := range route.postHooks {
+			hook(req, info)
+		}
+		for name, values := range info.Trailer {
+			for _, value := range values {
+				w.Header().Add(http.TrailerPrefix+name, value)
+			}
+		}
+	}
+}
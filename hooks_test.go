@@ -0,0 +1,165 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	t.Run("Before and after hooks", func(t *testing.T) {
+		router := NewRouter()
+
+		var calls []string
+		var info RequestInfo
+
+		router.Before(func(req *http.Request) {
+			calls = append(calls, "before")
+		})
+		router.After(func(req *http.Request, i RequestInfo) {
+			calls = append(calls, "after")
+			info = i
+		})
+		router.MustAddRoute("POST", "/users", func(w http.ResponseWriter, req *http.Request) {
+			calls = append(calls, "handler")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("User created"))
+		})
+
+		req, err := http.NewRequest("POST", "/users", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Check the order in which hooks and handler ran
+		expectedCalls := []string{"before", "handler", "after"}
+		if len(calls) != len(expectedCalls) {
+			t.Fatalf("Expected calls %v, but got %v", expectedCalls, calls)
+		}
+		for i := range expectedCalls {
+			if calls[i] != expectedCalls[i] {
+				t.Errorf("Expected calls %v, but got %v", expectedCalls, calls)
+				break
+			}
+		}
+
+		// Check the captured request outcome
+		if info.Status != http.StatusCreated {
+			t.Errorf("Expected status code %d, but got %d", http.StatusCreated, info.Status)
+		}
+		if info.Bytes != int64(len("User created")) {
+			t.Errorf("Expected %d bytes, but got %d", len("User created"), info.Bytes)
+		}
+		if info.Err != nil {
+			t.Errorf("Expected no error, but got %v", info.Err)
+		}
+	})
+
+	t.Run("After hook on panic", func(t *testing.T) {
+		router := NewRouter()
+
+		var info RequestInfo
+		router.After(func(req *http.Request, i RequestInfo) {
+			info = i
+		})
+		router.MustAddRoute("GET", "/panic", func(w http.ResponseWriter, req *http.Request) {
+			panic("boom")
+		})
+
+		req, err := http.NewRequest("GET", "/panic", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected the panic to be propagated")
+				}
+			}()
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+
+		// Check the panic was reported to the hook
+		if info.Err == nil {
+			t.Errorf("Expected an error for the panic, but got nil")
+		}
+		if info.Status != http.StatusInternalServerError {
+			t.Errorf("Expected status code %d, but got %d", http.StatusInternalServerError, info.Status)
+		}
+	})
+}
+
+func TestPostHooks(t *testing.T) {
+	router := NewRouter()
+
+	var info ResponseInfo
+	router.MustAddRoute("GET", "/report", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("report body"))
+	}).After(func(req *http.Request, resp *ResponseInfo) {
+		info = *resp
+		resp.Trailer.Set("X-Checksum", "abc")
+	})
+	router.MustAddRoute("GET", "/legacy", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("legacy"))
+	})
+
+	var legacyStatus int
+	router.SetResponse("GET", "/legacy", func(req *http.Request, resp *ResponseInfo) {
+		legacyStatus = resp.Status
+	})
+
+	t.Run("Observed response", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/report", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Check the response was not modified
+		if rr.Body.String() != "report body" {
+			t.Errorf("Expected response body %q, but got %q", "report body", rr.Body.String())
+		}
+
+		// Check the captured response
+		if info.Status != http.StatusAccepted {
+			t.Errorf("Expected status code %d, but got %d", http.StatusAccepted, info.Status)
+		}
+		if info.Bytes != int64(len("report body")) {
+			t.Errorf("Expected %d bytes, but got %d", len("report body"), info.Bytes)
+		}
+		if info.Header.Get("Content-Type") != "text/plain" {
+			t.Errorf("Expected content type %q, but got %q", "text/plain", info.Header.Get("Content-Type"))
+		}
+
+		// Check the trailer was added
+		if trailer := rr.Result().Trailer.Get("X-Checksum"); trailer != "abc" {
+			t.Errorf("Expected trailer %q, but got %q", "abc", trailer)
+		}
+	})
+
+	t.Run("SetResponse", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/legacy", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// The hook must not write to the response
+		if rr.Body.String() != "legacy" {
+			t.Errorf("Expected response body %q, but got %q", "legacy", rr.Body.String())
+		}
+		if legacyStatus != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, legacyStatus)
+		}
+	})
+}
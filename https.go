@@ -0,0 +1,52 @@
+package router
+
+import (
+	"net/http"
+)
+
+// HTTPSRedirectOptions configures the HTTPSRedirect middleware.
+type HTTPSRedirectOptions struct {
+	// Host is the canonical host to redirect to. The request host is used
+	// when empty.
+	Host string
+	// TrustedProxies are the proxies whose Forwarded or X-Forwarded-Proto
+	// header is honoured when deciding whether the request was made over
+	// HTTPS.
+	TrustedProxies *TrustedProxies
+	// Code is the redirect status code. It defaults to 301 for GET and HEAD
+	// requests and 308 for other methods so the method is preserved.
+	Code int
+}
+
+// HTTPSRedirect returns middleware that redirects plaintext requests to HTTPS.
+func HTTPSRedirect(opts HTTPSRedirectOptions) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			if isHTTPS(req, opts.TrustedProxies) {
+				next(w, req)
+				return
+			}
+
+			host := opts.Host
+			if host == "" {
+				host = req.Host
+			}
+
+			code := opts.Code
+			if code == 0 {
+				code = http.StatusPermanentRedirect
+				if req.Method == http.MethodGet || req.Method == http.MethodHead {
+					code = http.StatusMovedPermanently
+				}
+			}
+
+			http.Redirect(w, req, "https://"+host+req.URL.RequestURI(), code)
+		}
+	}
+}
+
+// isHTTPS reports whether the request was made over HTTPS, either directly
+// or through a trusted proxy.
+func isHTTPS(req *http.Request, proxies *TrustedProxies) bool {
+	return req.TLS != nil || proxies.ClientInfo(req).Proto == "https"
+}
@@ -0,0 +1,70 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPSRedirect(t *testing.T) {
+	proxies, err := NewTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter()
+	router.Use(HTTPSRedirect(HTTPSRedirectOptions{
+		Host:           "example.com",
+		TrustedProxies: proxies,
+	}))
+	router.MustAddRoute("GET", "/hello", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("Hello, World!"))
+	})
+	router.MustAddRoute("POST", "/users", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		remoteAddr       string
+		forwardedProto   string
+		expectedCode     int
+		expectedLocation string
+	}{
+		{"Plaintext GET", "GET", "/hello?x=1", "192.0.2.1:1234", "", http.StatusMovedPermanently, "https://example.com/hello?x=1"},
+		{"Plaintext POST", "POST", "/users", "192.0.2.1:1234", "", http.StatusPermanentRedirect, "https://example.com/users"},
+		{"Trusted proxy HTTPS", "GET", "/hello", "10.1.2.3:1234", "https", http.StatusOK, ""},
+		{"Untrusted proxy header", "GET", "/hello", "192.0.2.1:1234", "https", http.StatusMovedPermanently, "https://example.com/hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedProto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.forwardedProto)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, but got %d", tt.expectedCode, rr.Code)
+			}
+			if location := rr.Header().Get("Location"); location != tt.expectedLocation {
+				t.Errorf("Expected location %q, but got %q", tt.expectedLocation, location)
+			}
+		})
+	}
+
+	t.Run("Invalid trusted proxy", func(t *testing.T) {
+		if _, err := NewTrustedProxies("not-an-ip"); err == nil {
+			t.Errorf("Expected an error for an invalid address, but got nil")
+		}
+	})
+}
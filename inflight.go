@@ -0,0 +1,169 @@
+package router
+
+import (
+	"container/heap"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SetMaxInFlight caps the number of requests handled concurrently by the
+// router. Requests beyond the cap are rejected immediately with 503 Service
+// Unavailable and a Retry-After header, unless queueing is enabled with
+// SetRequestQueue. A limit of 0 disables the cap.
+func (r *Router) SetMaxInFlight(limit int) {
+	r.admission.mu.Lock()
+	defer r.admission.mu.Unlock()
+	r.admission.limit = limit
+}
+
+// SetRequestQueue lets up to size requests wait for at most maxWait when
+// the in-flight cap is reached. Waiting requests are admitted by descending
+// route queue priority, then in arrival order.
+func (r *Router) SetRequestQueue(size int, maxWait time.Duration) {
+	r.admission.mu.Lock()
+	defer r.admission.mu.Unlock()
+	r.admission.queueSize = size
+	r.admission.maxWait = maxWait
+}
+
+// InFlight returns the number of requests currently being handled.
+func (r *Router) InFlight() int {
+	r.admission.mu.Lock()
+	defer r.admission.mu.Unlock()
+	return r.admission.inFlight
+}
+
+// QueuePriority sets the priority of the route's requests when they are
+// queued because the in-flight cap is reached. Higher priorities are
+// admitted first; the default is 0.
+func (route *Route) QueuePriority(priority int) *Route {
+	route.queue = priority
+	return route
+}
+
+// priority returns the queue priority of the route, which may be nil.
+func (route *Route) priority() int {
+	if route == nil {
+		return 0
+	}
+	return route.queue
+}
+
+// admission limits the number of requests in flight and queues the excess.
+type admission struct {
+	mu        sync.Mutex
+	limit     int
+	inFlight  int
+	queueSize int
+	maxWait   time.Duration
+	waiters   waiters
+	seq       uint64
+}
+
+// admit counts the request as in flight, waiting in the queue if the cap
+// is reached. It returns false if the request must be rejected. Admitted
+// requests must be released.
+func (a *admission) admit(req *http.Request, priority int) bool {
+	a.mu.Lock()
+	if a.limit <= 0 || a.inFlight < a.limit {
+		a.inFlight++
+		a.mu.Unlock()
+		return true
+	}
+	if a.maxWait <= 0 || len(a.waiters) >= a.queueSize {
+		a.mu.Unlock()
+		return false
+	}
+
+	a.seq++
+	w := &waiter{priority: priority, seq: a.seq, ready: make(chan struct{})}
+	heap.Push(&a.waiters, w)
+	maxWait := a.maxWait
+	a.mu.Unlock()
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+
+	select {
+	case <-w.ready:
+		return true
+	case <-timer.C:
+	case <-req.Context().Done():
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if w.index < 0 {
+		// The slot was handed over while giving up
+		return true
+	}
+	heap.Remove(&a.waiters, w.index)
+	return false
+}
+
+// release marks an admitted request as done, handing its slot to the
+// highest priority waiter if there is one.
+func (a *admission) release() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.releaseLocked()
+}
+
+// releaseLocked is release with a.mu held.
+func (a *admission) releaseLocked() {
+	if len(a.waiters) > 0 && (a.limit <= 0 || a.inFlight <= a.limit) {
+		w := heap.Pop(&a.waiters).(*waiter)
+		close(w.ready)
+		return
+	}
+	a.inFlight--
+}
+
+// waiter is a request waiting for an in-flight slot.
+type waiter struct {
+	priority int
+	seq      uint64
+	index    int
+	ready    chan struct{}
+}
+
+// waiters is a priority queue of waiting requests implementing heap.Interface.
+type waiters []*waiter
+
+func (q waiters) Len() int { return len(q) }
+
+func (q waiters) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waiters) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waiters) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waiters) Pop() interface{} {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	w.index = -1
+	*q = old[:len(old)-1]
+	return w
+}
+
+// maxInFlight returns the in-flight cap.
+func (r *Router) maxInFlight() int {
+	r.admission.mu.Lock()
+	defer r.admission.mu.Unlock()
+	return r.admission.limit
+}
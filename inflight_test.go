@@ -0,0 +1,149 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMaxInFlight(t *testing.T) {
+	router := NewRouter()
+	router.SetMaxInFlight(1)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	router.MustAddRoute("GET", "/slow", func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+	})
+	router.MustAddRoute("GET", "/fast", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("fast"))
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serve("/slow")
+	}()
+	<-started
+
+	// Check the gauge counts the in-flight request
+	if inFlight := router.InFlight(); inFlight != 1 {
+		t.Errorf("Expected %d requests in flight, but got %d", 1, inFlight)
+	}
+
+	// Check requests beyond the cap are rejected
+	rr := serve("/fast")
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status code %d, but got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected a Retry-After header")
+	}
+
+	close(release)
+	<-done
+
+	// Check requests are admitted again once capacity is available
+	rr = serve("/fast")
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+	}
+	if inFlight := router.InFlight(); inFlight != 0 {
+		t.Errorf("Expected %d requests in flight, but got %d", 0, inFlight)
+	}
+}
+
+func TestRequestQueue(t *testing.T) {
+	router := NewRouter()
+	router.SetMaxInFlight(1)
+	router.SetRequestQueue(2, time.Second)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+
+	record := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}
+	}
+	router.MustAddRoute("GET", "/slow", func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+	})
+	router.MustAddRoute("GET", "/export", record("export"))
+	router.MustAddRoute("GET", "/health", record("health")).QueuePriority(10)
+
+	var wg sync.WaitGroup
+	serve := func(path string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, err := http.NewRequest("GET", path, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+	}
+
+	// waitQueued waits until n requests are queued
+	waitQueued := func(n int) {
+		for {
+			router.admission.mu.Lock()
+			queued := len(router.admission.waiters)
+			router.admission.mu.Unlock()
+			if queued == n {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	serve("/slow")
+	<-started
+	serve("/export")
+	waitQueued(1)
+	serve("/health")
+	waitQueued(2)
+
+	// Check a third waiter is rejected once the queue is full
+	req, err := http.NewRequest("GET", "/export", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status code %d, but got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+
+	close(release)
+	wg.Wait()
+
+	// Check the higher priority request was admitted first
+	expectedOrder := []string{"health", "export"}
+	if len(order) != len(expectedOrder) || order[0] != expectedOrder[0] || order[1] != expectedOrder[1] {
+		t.Errorf("Expected order %v, but got %v", expectedOrder, order)
+	}
+	if inFlight := router.InFlight(); inFlight != 0 {
+		t.Errorf("Expected %d requests in flight, but got %d", 0, inFlight)
+	}
+}
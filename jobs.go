@@ -0,0 +1,289 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Job states.
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// AsyncFunc performs the work of an asynchronous request. The request has
+// its body buffered and a context that outlives the HTTP request while
+// keeping its values, such as the correlation ID and path parameters. The
+// result is encoded as JSON.
+type AsyncFunc func(req *http.Request) (interface{}, error)
+
+// JobOptions configures the asynchronous jobs.
+type JobOptions struct {
+	// Path is the prefix of the job status routes, "/jobs" by default.
+	Path string
+	// Workers is the number of jobs run concurrently, 4 by default.
+	Workers int
+	// QueueSize is the number of jobs that can wait for a worker, 100 by
+	// default. Requests are answered with 503 when the queue is full.
+	QueueSize int
+	// Retention is how long finished jobs are kept, one hour by default.
+	Retention time.Duration
+	// MaxBodySize is the size limit of the request bodies kept for the jobs,
+	// 1 MB by default. Larger requests are answered with 413.
+	MaxBodySize int64
+}
+
+// Job is the status of an asynchronous job.
+type Job struct {
+	ID            string      `json:"id"`
+	Status        string      `json:"status"`
+	Result        interface{} `json:"result,omitempty"`
+	Error         string      `json:"error,omitempty"`
+	CorrelationID string      `json:"correlation_id,omitempty"`
+	Created       time.Time   `json:"created"`
+	Finished      *time.Time  `json:"finished,omitempty"`
+}
+
+// jobs runs the asynchronous jobs and keeps their status.
+type jobs struct {
+	once  sync.Once
+	opts  JobOptions
+	queue chan func()
+
+	mu       sync.Mutex
+	byID     map[string]*Job
+	finished []string // IDs of the finished jobs, oldest first
+}
+
+// EnableJobs starts the workers running asynchronous jobs and mounts the
+// job status routes:
+//
+//	GET {path}/:id         returns the job status
+//	GET {path}/:id/result  returns the job result once it has finished
+//
+// It is called with the default options by the first call to Async if it
+// has not been called before, and later calls only mount the routes if they
+// are missing, e.g. on a clone of a router created before jobs were
+// enabled. It returns an error if the routes cannot be added, see AddRoute.
+func (r *Router) EnableJobs(opts JobOptions) error {
+	r.jobs.once.Do(func() {
+		if opts.Path == "" {
+			opts.Path = "/jobs"
+		}
+		if opts.Workers <= 0 {
+			opts.Workers = 4
+		}
+		if opts.QueueSize <= 0 {
+			opts.QueueSize = 100
+		}
+		if opts.Retention <= 0 {
+			opts.Retention = time.Hour
+		}
+		if opts.MaxBodySize <= 0 {
+			opts.MaxBodySize = 1 << 20
+		}
+		r.jobs.opts = opts
+		r.jobs.queue = make(chan func(), opts.QueueSize)
+		r.jobs.byID = make(map[string]*Job)
+		for i := 0; i < opts.Workers; i++ {
+			go func() {
+				for run := range r.jobs.queue {
+					run()
+				}
+			}()
+		}
+
+	})
+
+	// The runner is shared with clones, which may already have the routes
+	path := r.jobs.opts.Path
+	if len(r.routes.routes["GET"][path+"/:id"]) > 0 {
+		return nil
+	}
+	if err := r.validateRoutes(routeKey{"GET", path + "/:id"}, routeKey{"GET", path + "/:id/result"}); err != nil {
+		return err
+	}
+	r.MustAddRoute("GET", path+"/:id", r.jobStatusHandler)
+	r.MustAddRoute("GET", path+"/:id/result", r.jobResultHandler)
+	return nil
+}
+
+// Async adds a route running the work as an asynchronous job. Requests are
+// answered immediately with 202 Accepted and the job status URL in the
+// Location header. It returns an error if the route or the job status
+// routes cannot be added, see AddRoute.
+func (r *Router) Async(method string, path string, work AsyncFunc) (*Route, error) {
+	if err := r.validateRoutes(routeKey{method, path}); err != nil {
+		return nil, err
+	}
+	if err := r.EnableJobs(JobOptions{}); err != nil {
+		return nil, err
+	}
+	return r.AddRoute(method, path, func(w http.ResponseWriter, req *http.Request) {
+		if req.Body != nil {
+			limit := r.jobs.opts.MaxBodySize
+			body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, limit))
+			if err != nil && int64(len(body)) >= limit {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if err != nil {
+				http.Error(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		req = req.WithContext(detachStore(detachedContext{req.Context()}))
+
+		job := &Job{
+			ID:            uuid.New().String(),
+			Status:        JobPending,
+			CorrelationID: r.GetCorrelationID(req),
+			Created:       time.Now(),
+		}
+		run := func() {
+			r.jobs.update(job.ID, func(j *Job) { j.Status = JobRunning })
+			var result interface{}
+			var err error
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					err = fmt.Errorf("panic: %v", recovered)
+					r.logger.Errorf("Job %s panicked (correlation ID %s): %v\n%s", job.ID, job.CorrelationID, recovered, debug.Stack())
+				} else if err != nil {
+					r.logger.Errorf("Failed to run job %s (correlation ID %s): %v", job.ID, job.CorrelationID, err)
+				}
+				r.jobs.finish(job.ID, result, err)
+			}()
+			result, err = work(req)
+		}
+
+		r.jobs.add(job)
+		select {
+		case r.jobs.queue <- run:
+		default:
+			r.jobs.remove(job.ID)
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+
+		statusURL := r.jobs.opts.Path + "/" + job.ID
+		w.Header().Set("Location", statusURL)
+		writeJSON(w, http.StatusAccepted, map[string]string{"id": job.ID, "status_url": statusURL})
+	})
+}
+
+// Job returns the status of a job.
+func (r *Router) Job(id string) (Job, bool) {
+	r.jobs.mu.Lock()
+	defer r.jobs.mu.Unlock()
+	job, ok := r.jobs.byID[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// jobStatusHandler serves the status of a job.
+func (r *Router) jobStatusHandler(w http.ResponseWriter, req *http.Request) {
+	job, ok := r.Job(r.GetPathParam(req, "id"))
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// jobResultHandler serves the result of a finished job, or its status
+// with 202 Accepted while it has not finished.
+func (r *Router) jobResultHandler(w http.ResponseWriter, req *http.Request) {
+	job, ok := r.Job(r.GetPathParam(req, "id"))
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	switch job.Status {
+	case JobSucceeded:
+		writeJSON(w, http.StatusOK, job.Result)
+	case JobFailed:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": job.Error})
+	default:
+		w.Header().Set("Location", r.jobs.opts.Path+"/"+job.ID)
+		writeJSON(w, http.StatusAccepted, job)
+	}
+}
+
+// add stores a new job and drops the expired ones.
+func (j *jobs) add(job *Job) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	expiry := time.Now().Add(-j.opts.Retention)
+	n := 0
+	for ; n < len(j.finished); n++ {
+		old, ok := j.byID[j.finished[n]]
+		if ok && !old.Finished.Before(expiry) {
+			break
+		}
+		delete(j.byID, j.finished[n])
+	}
+	j.finished = j.finished[n:]
+	j.byID[job.ID] = job
+}
+
+// finish records the outcome of a job.
+func (j *jobs) finish(id string, result interface{}, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.byID[id]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	job.Finished = &now
+	if err != nil {
+		job.Status, job.Error = JobFailed, err.Error()
+	} else {
+		job.Status, job.Result = JobSucceeded, result
+	}
+	j.finished = append(j.finished, id)
+}
+
+// remove deletes a job.
+func (j *jobs) remove(id string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	delete(j.byID, id)
+}
+
+// update modifies a job.
+func (j *jobs) update(id string, fn func(job *Job)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if job, ok := j.byID[id]; ok {
+		fn(job)
+	}
+}
+
+// detachedContext keeps the values of its parent but is never canceled,
+// letting work outlive the request.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
@@ -0,0 +1,180 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAsync(t *testing.T) {
+	router := NewRouter()
+	router.EnableJobs(JobOptions{Path: "/jobs", Workers: 1, MaxBodySize: 16})
+
+	release := make(chan struct{})
+	router.Async("POST", "/reports/:name", func(req *http.Request) (interface{}, error) {
+		<-release
+		if req.Context().Err() != nil {
+			return nil, req.Context().Err()
+		}
+		body, _ := io.ReadAll(req.Body)
+		return map[string]string{
+			"name":           router.GetPathParam(req, "name"),
+			"body":           string(body),
+			"correlation_id": router.GetCorrelationID(req),
+		}, nil
+	})
+	router.Async("POST", "/fail", func(req *http.Request) (interface{}, error) {
+		return nil, errors.New("out of paper")
+	})
+	router.Async("POST", "/panic", func(req *http.Request) (interface{}, error) {
+		panic("paper jam")
+	})
+
+	submit := func(t *testing.T, path string) string {
+		req, err := http.NewRequest("POST", path, strings.NewReader("payload"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Check the response status code
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("Expected status code %d, but got %d", http.StatusAccepted, rr.Code)
+		}
+		return rr.Header().Get("Location")
+	}
+
+	get := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	wait := func(t *testing.T, location string) Job {
+		for i := 0; i < 100; i++ {
+			var job Job
+			json.Unmarshal(get(t, location).Body.Bytes(), &job)
+			if job.Status == JobSucceeded || job.Status == JobFailed {
+				return job
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("Job %s did not finish", location)
+		return Job{}
+	}
+
+	t.Run("Successful job", func(t *testing.T) {
+		location := submit(t, "/reports/sales")
+
+		// The result is not available while the job runs
+		if rr := get(t, location+"/result"); rr.Code != http.StatusAccepted {
+			t.Errorf("Expected status code %d, but got %d", http.StatusAccepted, rr.Code)
+		}
+
+		close(release)
+		job := wait(t, location)
+		if job.Status != JobSucceeded {
+			t.Fatalf("Expected status %q, but got %q", JobSucceeded, job.Status)
+		}
+
+		var result map[string]string
+		rr := get(t, location+"/result")
+		json.Unmarshal(rr.Body.Bytes(), &result)
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+		if result["name"] != "sales" || result["body"] != "payload" {
+			t.Errorf("Expected the path parameter and body, but got %v", result)
+		}
+		if result["correlation_id"] == "" || result["correlation_id"] != job.CorrelationID {
+			t.Errorf("Expected correlation ID %q, but got %q", job.CorrelationID, result["correlation_id"])
+		}
+	})
+
+	t.Run("Failed job", func(t *testing.T) {
+		location := submit(t, "/fail")
+		job := wait(t, location)
+		if job.Status != JobFailed || job.Error != "out of paper" {
+			t.Errorf("Expected a failed job, but got %+v", job)
+		}
+		if rr := get(t, location+"/result"); rr.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status code %d, but got %d", http.StatusInternalServerError, rr.Code)
+		}
+	})
+
+	t.Run("Panicking job", func(t *testing.T) {
+		location := submit(t, "/panic")
+		job := wait(t, location)
+		if job.Status != JobFailed || job.Error != "panic: paper jam" {
+			t.Errorf("Expected a failed job, but got %+v", job)
+		}
+	})
+
+	t.Run("Expired jobs", func(t *testing.T) {
+		router := NewRouter()
+		router.EnableJobs(JobOptions{Retention: 10 * time.Millisecond})
+		done := make(chan struct{}, 2)
+		router.Async("POST", "/work", func(req *http.Request) (interface{}, error) {
+			defer func() { done <- struct{}{} }()
+			return nil, nil
+		})
+		submit := func() string {
+			req, err := http.NewRequest("POST", "/work", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return strings.TrimPrefix(rr.Header().Get("Location"), "/jobs/")
+		}
+
+		first := submit()
+		<-done
+		waitFor(t, func() bool {
+			job, _ := router.Job(first)
+			return job.Status == JobSucceeded
+		})
+		time.Sleep(20 * time.Millisecond)
+		second := submit()
+		<-done
+
+		// Check that the first job expired when the second was added
+		if _, ok := router.Job(first); ok {
+			t.Errorf("Expected job %s to have expired", first)
+		}
+		if _, ok := router.Job(second); !ok {
+			t.Errorf("Expected job %s to be kept", second)
+		}
+	})
+
+	t.Run("Unknown job", func(t *testing.T) {
+		if rr := get(t, "/jobs/unknown"); rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d, but got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+
+	t.Run("Body too large", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/fail", strings.NewReader(strings.Repeat("x", 17)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Check the response status code
+		if rr.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status code %d, but got %d", http.StatusRequestEntityTooLarge, rr.Code)
+		}
+	})
+}
@@ -0,0 +1,176 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/sdpsagarpawar/logger"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	JSONRPCParseError     = -32700
+	JSONRPCInvalidRequest = -32600
+	JSONRPCMethodNotFound = -32601
+	JSONRPCInvalidParams  = -32602
+	JSONRPCInternalError  = -32603
+)
+
+// JSONRPCError is a JSON-RPC error. Methods return it to control the error
+// code reported to the client; other errors are reported as internal
+// errors.
+type JSONRPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *JSONRPCError) Error() string {
+	return e.Message
+}
+
+// JSONRPCMethod handles a JSON-RPC method call. The context is the one of
+// the HTTP request, carrying the correlation ID and the values set by
+// middleware.
+type JSONRPCMethod func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+// JSONRPC is a JSON-RPC 2.0 endpoint.
+type JSONRPC struct {
+	route   *Route
+	logger  *logger.Logger
+	mu      sync.RWMutex
+	methods map[string]JSONRPCMethod
+}
+
+// jsonrpcRequest is a JSON-RPC request object.
+type jsonrpcRequest struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// jsonrpcResponse is a JSON-RPC response object.
+type jsonrpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// JSONRPC mounts a JSON-RPC 2.0 endpoint on POST requests to the path. The
+// endpoint goes through the router's middleware, and middleware for the
+// endpoint alone can be added to its route. It returns an error if the
+// route cannot be added, see AddRoute.
+func (r *Router) JSONRPC(path string) (*JSONRPC, error) {
+	endpoint := &JSONRPC{
+		logger:  r.logger,
+		methods: make(map[string]JSONRPCMethod),
+	}
+	route, err := r.AddRoute("POST", path, endpoint.ServeHTTP)
+	if err != nil {
+		return nil, err
+	}
+	endpoint.route = route
+	return endpoint, nil
+}
+
+// Route returns the route of the endpoint.
+func (e *JSONRPC) Route() *Route {
+	return e.route
+}
+
+// Register registers a method.
+func (e *JSONRPC) Register(name string, method JSONRPCMethod) *JSONRPC {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.methods[name] = method
+	return e
+}
+
+// ServeHTTP handles a single or batch JSON-RPC request.
+func (e *JSONRPC) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var body json.RawMessage
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusOK, jsonrpcFailure(nil, JSONRPCParseError, "Parse error"))
+		return
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '[' {
+		if resp := e.call(req.Context(), body); resp != nil {
+			writeJSON(w, http.StatusOK, resp)
+		} else {
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil || len(batch) == 0 {
+		writeJSON(w, http.StatusOK, jsonrpcFailure(nil, JSONRPCInvalidRequest, "Invalid Request"))
+		return
+	}
+	responses := make([]*jsonrpcResponse, 0, len(batch))
+	for _, raw := range batch {
+		if resp := e.call(req.Context(), raw); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusOK, responses)
+}
+
+// call handles a single request object. It returns nil for notifications.
+func (e *JSONRPC) call(ctx context.Context, raw json.RawMessage) *jsonrpcResponse {
+	var r jsonrpcRequest
+	if err := json.Unmarshal(raw, &r); err != nil || r.Version != "2.0" || r.Method == "" {
+		return jsonrpcFailure(nil, JSONRPCInvalidRequest, "Invalid Request")
+	}
+
+	e.mu.RLock()
+	method, ok := e.methods[r.Method]
+	e.mu.RUnlock()
+
+	var resp *jsonrpcResponse
+	if !ok {
+		resp = jsonrpcFailure(r.ID, JSONRPCMethodNotFound, "Method not found")
+	} else if result, err := method(ctx, r.Params); err != nil {
+		var rpcErr *JSONRPCError
+		if !errors.As(err, &rpcErr) {
+			e.logger.Errorf("Failed to call JSON-RPC method %s: %v", r.Method, err)
+			rpcErr = &JSONRPCError{Code: JSONRPCInternalError, Message: "Internal error"}
+		}
+		resp = &jsonrpcResponse{Version: "2.0", Error: rpcErr, ID: r.ID}
+	} else {
+		if result == nil {
+			result = json.RawMessage("null")
+		}
+		resp = &jsonrpcResponse{Version: "2.0", Result: result, ID: r.ID}
+	}
+
+	if r.ID == nil {
+		return nil
+	}
+	return resp
+}
+
+// jsonrpcFailure returns an error response.
+func jsonrpcFailure(id json.RawMessage, code int, message string) *jsonrpcResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &jsonrpcResponse{
+		Version: "2.0",
+		Error:   &JSONRPCError{Code: code, Message: message},
+		ID:      id,
+	}
+}
@@ -0,0 +1,82 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestJSONRPC(t *testing.T) {
+	router := NewRouter()
+
+	endpoint, err := router.JSONRPC("/rpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint.
+		Register("add", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			var args []int
+			if err := json.Unmarshal(params, &args); err != nil {
+				return nil, &JSONRPCError{Code: JSONRPCInvalidParams, Message: "Invalid params"}
+			}
+			sum := 0
+			for _, n := range args {
+				sum += n
+			}
+			return sum, nil
+		}).
+		Register("fail", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			return nil, errors.New("database unavailable")
+		})
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Call", `{"jsonrpc":"2.0","method":"add","params":[1,2],"id":1}`, http.StatusOK,
+			`{"jsonrpc":"2.0","result":3,"id":1}`},
+		{"Invalid params", `{"jsonrpc":"2.0","method":"add","params":"x","id":2}`, http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":2}`},
+		{"Method not found", `{"jsonrpc":"2.0","method":"sub","id":"a"}`, http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":"a"}`},
+		{"Internal error", `{"jsonrpc":"2.0","method":"fail","id":3}`, http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":3}`},
+		{"Parse error", `{"jsonrpc"`, http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`},
+		{"Invalid request", `{"jsonrpc":"1.0","method":"add","id":4}`, http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`},
+		{"Notification", `{"jsonrpc":"2.0","method":"add","params":[1]}`, http.StatusNoContent, ""},
+		{"Batch", `[{"jsonrpc":"2.0","method":"add","params":[1],"id":1},{"jsonrpc":"2.0","method":"add","params":[2]},{"jsonrpc":"2.0","method":"add","params":[3],"id":2}]`, http.StatusOK,
+			`[{"jsonrpc":"2.0","result":1,"id":1},{"jsonrpc":"2.0","result":3,"id":2}]`},
+		{"Empty batch", `[]`, http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/rpc", strings.NewReader(test.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if rr.Code != test.expectedStatus {
+				t.Errorf("Expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+
+			// Check the response body
+			if body := strings.TrimSpace(rr.Body.String()); body != test.expectedBody {
+				t.Errorf("Expected response body %s, but got %s", test.expectedBody, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType is the type of a field that can be filtered or sorted on.
+type FieldType int
+
+// Field types. Time values are parsed as RFC 3339.
+const (
+	StringField FieldType = iota
+	IntField
+	FloatField
+	BoolField
+	TimeField
+)
+
+// ListFields lists the fields clients may filter and sort on, with their
+// types. Fields not listed are rejected, so that only known columns reach
+// the database.
+type ListFields map[string]FieldType
+
+// FilterCondition is a parsed filter condition such as "age>30". Value
+// holds a string, int64, float64, bool or time.Time according to the type
+// of the field.
+type FilterCondition struct {
+	Field string
+	// Op is one of "=", "!=", ">", ">=", "<", "<=" and "~=", which matches
+	// strings containing the value.
+	Op    string
+	Value interface{}
+}
+
+// SortField is a parsed sort key such as "-created_at".
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ListQuery is a parsed filter and sort query.
+type ListQuery struct {
+	Filters []FilterCondition
+	Sort    []SortField
+}
+
+// filterOps lists the filter operators, longest first.
+var filterOps = []string{">=", "<=", "!=", "~=", ">", "<", "="}
+
+// ParseListQuery parses the filter and sort query parameters, e.g.
+// "filter=age>30,name~=jo&sort=-created_at,name". Conditions are separated
+// by commas and values containing commas can be double-quoted. Sort keys
+// starting with "-" sort in descending order.
+func ParseListQuery(query url.Values, fields ListFields) (*ListQuery, error) {
+	q := &ListQuery{}
+	for _, filter := range query["filter"] {
+		for _, cond := range splitFilter(filter) {
+			c, err := parseCondition(cond, fields)
+			if err != nil {
+				return nil, err
+			}
+			q.Filters = append(q.Filters, c)
+		}
+	}
+	for _, sort := range query["sort"] {
+		for _, key := range strings.Split(sort, ",") {
+			key = strings.TrimSpace(key)
+			if key == "" {
+				continue
+			}
+			s := SortField{Field: key}
+			if strings.HasPrefix(key, "-") {
+				s = SortField{Field: key[1:], Desc: true}
+			} else if strings.HasPrefix(key, "+") {
+				s.Field = key[1:]
+			}
+			if _, ok := fields[s.Field]; !ok {
+				return nil, fmt.Errorf("router: cannot sort on field %q", s.Field)
+			}
+			q.Sort = append(q.Sort, s)
+		}
+	}
+	return q, nil
+}
+
+// ListQuery parses the filter and sort query parameters of the request.
+func (r *Router) ListQuery(req *http.Request, fields ListFields) (*ListQuery, error) {
+	return ParseListQuery(r.GetQueryParams(req), fields)
+}
+
+// splitFilter splits a filter on the commas outside double quotes.
+func splitFilter(filter string) []string {
+	var conds []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(filter); i++ {
+		switch filter[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				conds = append(conds, filter[start:i])
+				start = i + 1
+			}
+		}
+	}
+	conds = append(conds, filter[start:])
+
+	nonEmpty := conds[:0]
+	for _, cond := range conds {
+		if strings.TrimSpace(cond) != "" {
+			nonEmpty = append(nonEmpty, cond)
+		}
+	}
+	return nonEmpty
+}
+
+// parseCondition parses a single filter condition.
+func parseCondition(cond string, fields ListFields) (FilterCondition, error) {
+	cond = strings.TrimSpace(cond)
+	end := 0
+	for end < len(cond) && isFieldChar(cond[end]) {
+		end++
+	}
+	field := cond[:end]
+	if field == "" {
+		return FilterCondition{}, fmt.Errorf("router: invalid filter %q: missing field", cond)
+	}
+	typ, ok := fields[field]
+	if !ok {
+		return FilterCondition{}, fmt.Errorf("router: cannot filter on field %q", field)
+	}
+
+	rest := strings.TrimLeft(cond[end:], " ")
+	op := ""
+	for _, candidate := range filterOps {
+		if strings.HasPrefix(rest, candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return FilterCondition{}, fmt.Errorf("router: invalid filter %q: missing operator", cond)
+	}
+
+	raw := strings.TrimSpace(rest[len(op):])
+	if strings.HasPrefix(raw, `"`) {
+		unquoted, err := strconv.Unquote(raw)
+		if err != nil {
+			return FilterCondition{}, fmt.Errorf("router: invalid filter %q: bad quoted value", cond)
+		}
+		raw = unquoted
+	}
+
+	value, err := parseFieldValue(raw, typ)
+	if err != nil {
+		return FilterCondition{}, fmt.Errorf("router: invalid value %q for field %q: %v", raw, field, err)
+	}
+	if op == "~=" && typ != StringField {
+		return FilterCondition{}, fmt.Errorf("router: operator ~= requires a string field, not %q", field)
+	}
+	if typ == BoolField && op != "=" && op != "!=" {
+		return FilterCondition{}, fmt.Errorf("router: operator %s cannot be used on boolean field %q", op, field)
+	}
+	return FilterCondition{Field: field, Op: op, Value: value}, nil
+}
+
+// parseFieldValue converts a filter value to the type of its field.
+func parseFieldValue(raw string, typ FieldType) (interface{}, error) {
+	switch typ {
+	case IntField:
+		return strconv.ParseInt(raw, 10, 64)
+	case FloatField:
+		return strconv.ParseFloat(raw, 64)
+	case BoolField:
+		return strconv.ParseBool(raw)
+	case TimeField:
+		return time.Parse(time.RFC3339, raw)
+	}
+	return raw, nil
+}
+
+// isFieldChar reports whether c can appear in a field name.
+func isFieldChar(c byte) bool {
+	return c == '_' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
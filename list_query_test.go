@@ -0,0 +1,87 @@
+package router
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListQuery(t *testing.T) {
+	fields := ListFields{
+		"age":        IntField,
+		"name":       StringField,
+		"score":      FloatField,
+		"active":     BoolField,
+		"created_at": TimeField,
+	}
+
+	t.Run("Filters and sort", func(t *testing.T) {
+		query := url.Values{
+			"filter": {`age>30,name~=jo,active=true`, `score<=9.5,created_at>=2024-01-02T03:04:05Z,name="a,b"`},
+			"sort":   {"-created_at,name"},
+		}
+		q, err := ParseListQuery(query, fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedFilters := []FilterCondition{
+			{Field: "age", Op: ">", Value: int64(30)},
+			{Field: "name", Op: "~=", Value: "jo"},
+			{Field: "active", Op: "=", Value: true},
+			{Field: "score", Op: "<=", Value: 9.5},
+			{Field: "created_at", Op: ">=", Value: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+			{Field: "name", Op: "=", Value: "a,b"},
+		}
+		if !reflect.DeepEqual(q.Filters, expectedFilters) {
+			t.Errorf("Expected filters %v, but got %v", expectedFilters, q.Filters)
+		}
+
+		expectedSort := []SortField{{Field: "created_at", Desc: true}, {Field: "name"}}
+		if !reflect.DeepEqual(q.Sort, expectedSort) {
+			t.Errorf("Expected sort %v, but got %v", expectedSort, q.Sort)
+		}
+	})
+
+	t.Run("Invalid queries", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			query url.Values
+		}{
+			{"Unknown filter field", url.Values{"filter": {"password=x"}}},
+			{"Injection attempt", url.Values{"filter": {"name;drop table=1"}}},
+			{"Unknown sort field", url.Values{"sort": {"-password"}}},
+			{"Missing operator", url.Values{"filter": {"age"}}},
+			{"Invalid value", url.Values{"filter": {"age>old"}}},
+			{"Contains on number", url.Values{"filter": {"age~=3"}}},
+			{"Ordering booleans", url.Values{"filter": {"active>true"}}},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				if _, err := ParseListQuery(test.query, fields); err == nil {
+					t.Errorf("Expected an error for %v", test.query)
+				}
+			})
+		}
+	})
+
+	t.Run("Request helper", func(t *testing.T) {
+		router := NewRouter()
+		var q *ListQuery
+		router.MustAddRoute("GET", "/users", func(w http.ResponseWriter, req *http.Request) {
+			q, _ = router.ListQuery(req, fields)
+		})
+
+		req, err := http.NewRequest("GET", "/users?filter=age%3E%3D18&sort=name", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		router.ServeHTTP(nil, req)
+
+		if q == nil || len(q.Filters) != 1 || q.Filters[0].Op != ">=" || len(q.Sort) != 1 {
+			t.Errorf("Expected one filter and one sort key, but got %+v", q)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Translator translates a message key into the given locale.
+type Translator func(locale string, key string, args ...interface{}) string
+
+// LocaleOptions configures the LocaleNegotiation middleware.
+type LocaleOptions struct {
+	// Supported lists the supported locales, e.g. "en", "fr-CA". The first
+	// locale is the default.
+	Supported []string
+	// QueryParam is the query parameter overriding the negotiated locale.
+	QueryParam string
+	// Cookie is the cookie overriding the negotiated locale.
+	Cookie string
+	// Translator translates messages for the chosen locale.
+	Translator Translator
+}
+
+// LocaleNegotiation returns middleware that chooses a locale for each
+// request from the query parameter, the cookie and the Accept-Language
+// header, in that order, and stores it in the request context.
+func LocaleNegotiation(opts LocaleOptions) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			locale := negotiateLocale(req, opts)
+
+			ctx := req.Context()
+			ctx = context.WithValue(ctx, "locale", locale)
+			if opts.Translator != nil {
+				ctx = context.WithValue(ctx, "translator", opts.Translator)
+			}
+			req = req.WithContext(ctx)
+
+			w.Header().Add("Vary", "Accept-Language")
+			next(w, req)
+		}
+	}
+}
+
+// negotiateLocale chooses the best supported locale for the request.
+func negotiateLocale(req *http.Request, opts LocaleOptions) string {
+	if len(opts.Supported) == 0 {
+		return ""
+	}
+
+	if opts.QueryParam != "" {
+		if locale, ok := matchLocale(req.URL.Query().Get(opts.QueryParam), opts.Supported); ok {
+			return locale
+		}
+	}
+	if opts.Cookie != "" {
+		if cookie, err := req.Cookie(opts.Cookie); err == nil {
+			if locale, ok := matchLocale(cookie.Value, opts.Supported); ok {
+				return locale
+			}
+		}
+	}
+	for _, value := range parseQualityValues(req.Header.Get("Accept-Language")) {
+		if locale, ok := matchLocale(value.value, opts.Supported); ok {
+			return locale
+		}
+	}
+	return opts.Supported[0]
+}
+
+// matchLocale matches a requested language tag against the supported
+// locales, falling back to the base language when there is no exact match.
+func matchLocale(tag string, supported []string) (string, bool) {
+	if tag == "" {
+		return "", false
+	}
+	if tag == "*" {
+		return supported[0], true
+	}
+	for _, locale := range supported {
+		if strings.EqualFold(tag, locale) {
+			return locale, true
+		}
+	}
+	base := baseLanguage(tag)
+	for _, locale := range supported {
+		if strings.EqualFold(base, baseLanguage(locale)) {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// baseLanguage returns the primary language subtag, e.g. "en" for "en-US".
+func baseLanguage(tag string) string {
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
+
+// Locale retrieves the locale negotiated for the request.
+func (r *Router) Locale(req *http.Request) string {
+	locale, ok := req.Context().Value("locale").(string)
+	if !ok {
+		return ""
+	}
+	return locale
+}
+
+// Translate translates the message key into the request's locale. Without
+// a translator the key is formatted with the arguments.
+func (r *Router) Translate(req *http.Request, key string, args ...interface{}) string {
+	translator, ok := req.Context().Value("translator").(Translator)
+	if !ok {
+		if len(args) == 0 {
+			return key
+		}
+		return fmt.Sprintf(key, args...)
+	}
+	return translator(r.Locale(req), key, args...)
+}
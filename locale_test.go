@@ -0,0 +1,64 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLocale(t *testing.T) {
+	messages := map[string]map[string]string{
+		"en":    {"greeting": "Hello"},
+		"fr-FR": {"greeting": "Bonjour"},
+		"de":    {"greeting": "Hallo"},
+	}
+
+	router := NewRouter()
+	router.Use(LocaleNegotiation(LocaleOptions{
+		Supported:  []string{"en", "fr-FR", "de"},
+		QueryParam: "lang",
+		Cookie:     "lang",
+		Translator: func(locale string, key string, args ...interface{}) string {
+			return messages[locale][key]
+		},
+	}))
+	router.MustAddRoute("GET", "/hello", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(router.Locale(req) + " " + router.Translate(req, "greeting")))
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptLanguage string
+		cookie         string
+		expectedBody   string
+	}{
+		{"Default locale", "/hello", "", "", "en Hello"},
+		{"Accept-Language with q-values", "/hello", "es;q=1, de;q=0.5, fr;q=0.8", "", "fr-FR Bonjour"},
+		{"Cookie override", "/hello", "fr", "de", "de Hallo"},
+		{"Query override", "/hello?lang=fr-fr", "de", "de", "fr-FR Bonjour"},
+		{"Unsupported language", "/hello", "ja", "", "en Hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "lang", Value: tt.cookie})
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected response body %q, but got %q", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
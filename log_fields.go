@@ -0,0 +1,123 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sdpsagarpawar/logger"
+)
+
+// LogField is a structured field of the log lines of a request.
+type LogField struct {
+	Key   string
+	Value string
+}
+
+// LogFieldsOptions configures the LogFields middleware.
+type LogFieldsOptions struct {
+	// Principal identifies who made the request, e.g. from a verified
+	// token set in the context by authentication middleware.
+	Principal func(req *http.Request) string
+	// Tenant identifies the tenant of the request.
+	Tenant func(req *http.Request) string
+	// TrustedProxies are the proxies whose forwarding headers are used to
+	// determine the client address. It defaults to the proxies set with
+	// TrustForwardedHeaders.
+	TrustedProxies *TrustedProxies
+}
+
+// LogFields returns middleware enriching the loggers returned by Logger with
+// the fields of the request: its route pattern, principal, tenant and client
+// IP. Fields are resolved when the logger is requested, so the principal set
+// by authentication middleware running after LogFields is included.
+func (r *Router) LogFields(opts LogFieldsOptions) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			if s := storeOf(req); s != nil {
+				s.logFields = &opts
+				s.mu.Unlock()
+			}
+			next(w, req)
+		}
+	}
+}
+
+// Logger returns the logger of the request, whose lines are prefixed with
+// the fields of the request when the LogFields middleware applies to it.
+func (r *Router) Logger(req *http.Request) *RequestLogger {
+	l := &RequestLogger{logger: r.logger}
+	s := storeOf(req)
+	if s == nil {
+		return l
+	}
+	opts, route := s.logFields, s.route
+	s.mu.Unlock()
+	if opts == nil {
+		return l
+	}
+
+	if route != nil && route.Path != "" {
+		l = l.With("route", route.Path)
+	}
+	if opts.Principal != nil {
+		l = l.With("principal", opts.Principal(req))
+	}
+	if opts.Tenant != nil {
+		l = l.With("tenant", opts.Tenant(req))
+	}
+	proxies := opts.TrustedProxies
+	if proxies == nil {
+		proxies = r.forwardedTrust
+	}
+	if ip := proxies.ClientIP(req); ip != nil {
+		l = l.With("client_ip", ip.String())
+	}
+	return l
+}
+
+// RequestLogger logs the lines of a request with its fields, formatted as
+// "key=value" pairs before the message.
+type RequestLogger struct {
+	logger *logger.Logger
+	fields []LogField
+	prefix string
+}
+
+// With returns a logger with the field added. Empty values are omitted.
+func (l *RequestLogger) With(key string, value string) *RequestLogger {
+	if value == "" {
+		return l
+	}
+	fields := append(append([]LogField(nil), l.fields...), LogField{key, value})
+	if strings.ContainsAny(value, " \"=") {
+		value = strconv.Quote(value)
+	}
+	return &RequestLogger{logger: l.logger, fields: fields, prefix: l.prefix + key + "=" + value + " "}
+}
+
+// Fields returns the fields of the logger.
+func (l *RequestLogger) Fields() []LogField {
+	return append([]LogField(nil), l.fields...)
+}
+
+// Debugf logs a debug message with the fields.
+func (l *RequestLogger) Debugf(format string, args ...interface{}) {
+	l.logger.Debugf("%s", l.prefix+fmt.Sprintf(format, args...))
+}
+
+// Infof logs an info message with the fields.
+func (l *RequestLogger) Infof(format string, args ...interface{}) {
+	l.logger.Infof("%s", l.prefix+fmt.Sprintf(format, args...))
+}
+
+// Warningf logs a warning with the fields.
+func (l *RequestLogger) Warningf(format string, args ...interface{}) {
+	l.logger.Warningf("%s", l.prefix+fmt.Sprintf(format, args...))
+}
+
+// Errorf logs an error with the fields.
+func (l *RequestLogger) Errorf(format string, args ...interface{}) {
+	l.logger.Errorf("%s", l.prefix+fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,70 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestLogFields(t *testing.T) {
+	proxies, err := NewTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter()
+	router.Use(router.LogFields(LogFieldsOptions{
+		Principal:      func(req *http.Request) string { s, _ := req.Context().Value("user").(string); return s },
+		Tenant:         func(req *http.Request) string { return req.Header.Get("X-Tenant") },
+		TrustedProxies: proxies,
+	}))
+	auth := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			next(w, req.WithContext(context.WithValue(req.Context(), "user", "alice")))
+		}
+	}
+
+	var fields []LogField
+	router.MustGET("/orders/:id", func(w http.ResponseWriter, req *http.Request) {
+		l := router.Logger(req).With("order", router.GetPathParam(req, "id"))
+		l.Infof("Order viewed")
+		fields = l.Fields()
+	}).Use(auth)
+
+	t.Run("Request fields", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/orders/7", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", "203.0.113.9")
+		req.Header.Set("X-Tenant", "acme")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		// Check the fields, including the principal set after LogFields ran
+		expected := []LogField{
+			{"route", "/orders/:id"},
+			{"principal", "alice"},
+			{"tenant", "acme"},
+			{"client_ip", "203.0.113.9"},
+			{"order", "7"},
+		}
+		if !reflect.DeepEqual(fields, expected) {
+			t.Errorf("Expected fields %v, but got %v", expected, fields)
+		}
+	})
+
+	t.Run("Without the middleware", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/orders/7", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Check that requests not served by the router have no fields
+		if got := router.Logger(req).Fields(); len(got) != 0 {
+			t.Errorf("Expected no fields, but got %v", got)
+		}
+	})
+}
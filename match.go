@@ -0,0 +1,95 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// RouteMatch describes how the router would route a request.
+type RouteMatch struct {
+	// Route is the matched route, or nil if there is none.
+	Route *Route
+	// Params are the captured path parameters.
+	Params map[string]string
+	// Middleware lists the names of the middleware the request would go
+	// through, outermost first. Middleware registered with
+	// RegisterMiddleware is listed under its registered name, other
+	// middleware under its function name.
+	Middleware []string
+	// Status is the status code the router would respond with when no
+	// route matches, such as 404 or 415, or the code of a redirect rule.
+	Status int
+	// Candidates lists the routes whose path matches the request, in the
+	// order they are tried, explaining why Route was chosen over them.
+	Candidates []MatchCandidate
+}
+
+// Match reports how the router would route a request with the method and
+// target, which may include a query, without running any handler.
+func (r *Router) Match(method string, target string) *RouteMatch {
+	u, err := url.ParseRequestURI(target)
+	if err != nil {
+		return &RouteMatch{Status: http.StatusNotFound}
+	}
+	req := &http.Request{Method: method, URL: u, Host: u.Host, Header: http.Header{}}
+	return r.MatchRequest(req.WithContext(context.Background()))
+}
+
+// MatchRequest reports how the router would route the request, taking its
+// headers into account, without running any handler.
+func (r *Router) MatchRequest(req *http.Request) *RouteMatch {
+	w := &discardWriter{header: http.Header{}}
+	req, ok := r.applyRewrites(w, req)
+	if !ok {
+		return &RouteMatch{Status: w.status}
+	}
+
+	route, params, status := r.lookup(req)
+	unmatched := "no route matches the request"
+	candidates := r.routes.explain(req, route, unmatched)
+	if config, ok := r.config.Load().(*routeTable); ok {
+		if route != nil && route.table == r.routes {
+			unmatched = "routes added in code take precedence over routes loaded from a configuration"
+		}
+		candidates = append(candidates, config.explain(req, route, unmatched)...)
+	}
+	if route == nil {
+		return &RouteMatch{Status: status, Candidates: candidates}
+	}
+
+	match := &RouteMatch{Route: route, Params: params, Candidates: candidates}
+	for _, mw := range r.middlewareChain(route) {
+		match.Middleware = append(match.Middleware, r.middlewareName(mw))
+	}
+	return match
+}
+
+// middlewareName returns the registered name of the middleware, or its
+// function name.
+func (r *Router) middlewareName(mw func(http.HandlerFunc) http.HandlerFunc) string {
+	pc := reflect.ValueOf(mw).Pointer()
+	for name, named := range r.namedMiddleware {
+		if reflect.ValueOf(named).Pointer() == pc {
+			return name
+		}
+	}
+	name := runtime.FuncForPC(pc).Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// discardWriter is a response writer recording only the status code.
+type discardWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(status int)      { w.status = status }
@@ -0,0 +1,141 @@
+package router
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func requestLogger(next http.HandlerFunc) http.HandlerFunc {
+	return next
+}
+
+func TestMatch(t *testing.T) {
+	router := NewRouter()
+	router.Use(requestLogger)
+	router.RegisterMiddleware("auth", func(next http.HandlerFunc) http.HandlerFunc {
+		return next
+	})
+
+	called := false
+	handler := func(w http.ResponseWriter, req *http.Request) { called = true }
+	router.MustAddRoute("GET", "/users/:id", handler).Use(router.namedMiddleware["auth"])
+	router.MustAddRoute("GET", "/users/me", handler)
+	router.MustAddRoute("POST", "/upload", handler).ContentType("application/json")
+	router.Rewrite("/api/*path", "/*path")
+	router.AddRewriteRule(RewriteRule{Pattern: "/old/*path", Target: "/*path", RedirectCode: http.StatusFound})
+
+	t.Run("Pattern route", func(t *testing.T) {
+		match := router.Match("GET", "/users/42?verbose=1")
+		if match.Route == nil || match.Route.Path != "/users/:id" {
+			t.Fatalf("Expected route %q, but got %+v", "/users/:id", match.Route)
+		}
+		if match.Params["id"] != "42" {
+			t.Errorf("Expected parameter id %q, but got %q", "42", match.Params["id"])
+		}
+		expected := "router.requestLogger,auth"
+		if got := strings.Join(match.Middleware, ","); got != expected {
+			t.Errorf("Expected middleware %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("Static route wins", func(t *testing.T) {
+		match := router.Match("GET", "/users/me")
+		if match.Route == nil || match.Route.Path != "/users/me" {
+			t.Errorf("Expected route %q, but got %+v", "/users/me", match.Route)
+		}
+	})
+
+	t.Run("Explained precedence", func(t *testing.T) {
+		router := NewRouter()
+		router.MustAddRoute("GET", "/files/*path", handler)
+		router.MustAddRoute("GET", "/files/:name/raw", handler).Header("X-Beta", "1")
+		router.MustAddRoute("GET", "/files/:name/raw", handler)
+		router.MustAddRoute("GET", "/users/:id", handler)
+		router.MustAddRoute("GET", "/users/me", handler)
+
+		tests := []struct {
+			target   string
+			expected []MatchCandidate
+		}{
+			{"/files/a/raw", []MatchCandidate{
+				{Method: "GET", Path: "/files/:name/raw", Reason: "its conditions do not match the request"},
+				{Method: "GET", Path: "/files/:name/raw", Selected: true, Reason: "selected"},
+				{Method: "GET", Path: "/files/*path", Reason: `GET /files/:name/raw takes precedence: its parameter ":name" is more specific than the wildcard "*path"`},
+			}},
+			{"/users/me", []MatchCandidate{
+				{Method: "GET", Path: "/users/me", Selected: true, Reason: "selected"},
+				{Method: "GET", Path: "/users/:id", Reason: "GET /users/me takes precedence: static paths take precedence over patterns"},
+			}},
+		}
+		for _, tt := range tests {
+			match := router.Match("GET", tt.target)
+
+			// Check the candidates and their explanations
+			if !reflect.DeepEqual(match.Candidates, tt.expected) {
+				t.Errorf("Expected candidates %+v, but got %+v", tt.expected, match.Candidates)
+			}
+		}
+	})
+
+	t.Run("Group and scoped middleware", func(t *testing.T) {
+		router := NewRouter()
+		router.SetMiddlewareScope(MiddlewareLaterRoutes)
+		router.RegisterMiddleware("auth", func(next http.HandlerFunc) http.HandlerFunc { return next })
+		router.RegisterMiddleware("api", func(next http.HandlerFunc) http.HandlerFunc { return next })
+		router.RegisterMiddleware("late", func(next http.HandlerFunc) http.HandlerFunc { return next })
+		router.Use(router.namedMiddleware["auth"])
+		api := router.Group("/api")
+		api.Use(router.namedMiddleware["api"])
+		api.MustAddRoute("GET", "/items", handler)
+		router.Use(router.namedMiddleware["late"])
+
+		// Check that the group middleware is listed and the later middleware is not
+		match := router.Match("GET", "/api/items")
+		expected := "auth,api"
+		if got := strings.Join(match.Middleware, ","); got != expected {
+			t.Errorf("Expected middleware %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("Rewritten path", func(t *testing.T) {
+		match := router.Match("GET", "/api/users/7")
+		if match.Route == nil || match.Params["id"] != "7" {
+			t.Errorf("Expected the rewritten path to match user 7, but got %+v", match)
+		}
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		tests := []struct {
+			method         string
+			target         string
+			expectedStatus int
+		}{
+			{"GET", "/missing", http.StatusNotFound},
+			{"POST", "/upload", http.StatusUnsupportedMediaType},
+			{"GET", "/old/users/1", http.StatusFound},
+		}
+		for _, test := range tests {
+			match := router.Match(test.method, test.target)
+			if match.Route != nil || match.Status != test.expectedStatus {
+				t.Errorf("Expected status code %d for %s %s, but got %+v", test.expectedStatus, test.method, test.target, match)
+			}
+		}
+	})
+
+	t.Run("Request headers", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/upload", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if match := router.MatchRequest(req); match.Route == nil {
+			t.Errorf("Expected the upload route to match, but got %+v", match)
+		}
+	})
+
+	if called {
+		t.Errorf("Expected no handler to run")
+	}
+}
@@ -0,0 +1,117 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Header restricts the route to requests with the header set to value, or
+// with the header present when value is empty. Requests that do not match
+// fall through to the other routes registered for the same method and path.
+func (route *Route) Header(name string, value string) *Route {
+	route.matchers = append(route.matchers, func(req *http.Request) bool {
+		values := req.Header.Values(name)
+		if value == "" {
+			return len(values) > 0
+		}
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	})
+	return route
+}
+
+// HeaderRegexp restricts the route to requests with a value of the header
+// matching the regular expression.
+func (route *Route) HeaderRegexp(name string, re *regexp.Regexp) *Route {
+	route.matchers = append(route.matchers, func(req *http.Request) bool {
+		for _, v := range req.Header.Values(name) {
+			if re.MatchString(v) {
+				return true
+			}
+		}
+		return false
+	})
+	return route
+}
+
+// Query restricts the route to requests with the query parameter set to
+// value, or with the parameter present when value is empty.
+func (route *Route) Query(name string, value string) *Route {
+	route.matchers = append(route.matchers, func(req *http.Request) bool {
+		values, ok := queryValues(req)[name]
+		if value == "" {
+			return ok
+		}
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	})
+	return route
+}
+
+// QueryRegexp restricts the route to requests with a value of the query
+// parameter matching the regular expression.
+func (route *Route) QueryRegexp(name string, re *regexp.Regexp) *Route {
+	route.matchers = append(route.matchers, func(req *http.Request) bool {
+		for _, v := range queryValues(req)[name] {
+			if re.MatchString(v) {
+				return true
+			}
+		}
+		return false
+	})
+	return route
+}
+
+// Scheme restricts the route to requests received over the scheme, "http"
+// or "https". The Forwarded and X-Forwarded-Proto headers are honoured for
+// requests from the trusted proxies, which may be nil.
+func (route *Route) Scheme(scheme string, proxies *TrustedProxies) *Route {
+	https := strings.EqualFold(scheme, "https")
+	route.matchers = append(route.matchers, func(req *http.Request) bool {
+		return isHTTPS(req, proxies) == https
+	})
+	return route
+}
+
+// Port restricts the route to requests accepted by a listener on the port,
+// so that a router serving several listeners can keep their routes apart.
+func (route *Route) Port(port int) *Route {
+	route.matchers = append(route.matchers, func(req *http.Request) bool {
+		return localPort(req) == port
+	})
+	return route
+}
+
+// localPort returns the port of the listener that accepted the request, or
+// 0 if it is unknown.
+func localPort(req *http.Request) int {
+	addr, ok := req.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	if !ok {
+		return 0
+	}
+	_, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(port)
+	return n
+}
+
+// queryValues returns the query parameters of the request, ignoring
+// malformed ones.
+func queryValues(req *http.Request) url.Values {
+	queryParams, _ := parseQuery(req)
+	return queryParams
+}
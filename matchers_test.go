@@ -0,0 +1,157 @@
+package router
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestMatchers(t *testing.T) {
+	t.Run("Header matchers", func(t *testing.T) {
+		router := NewRouter()
+		router.MustAddRoute("GET", "/users/:id", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("v2"))
+		}).Header("X-Api-Version", "2")
+		router.MustAddRoute("GET", "/users/:id", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("v3"))
+		}).HeaderRegexp("Accept", regexp.MustCompile(`version=3`))
+		router.MustAddRoute("GET", "/users/:id", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("v1"))
+		})
+		router.MustAddRoute("GET", "/debug", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("debug"))
+		}).Header("X-Debug", "")
+
+		tests := []struct {
+			name         string
+			path         string
+			header       string
+			value        string
+			expectedCode int
+			expectedBody string
+		}{
+			{"Exact header value", "/users/42", "X-Api-Version", "2", http.StatusOK, "v2"},
+			{"Header pattern", "/users/42", "Accept", "application/json; version=3", http.StatusOK, "v3"},
+			{"Fall through", "/users/42", "X-Api-Version", "9", http.StatusOK, "v1"},
+			{"Header present", "/debug", "X-Debug", "1", http.StatusOK, "debug"},
+			{"Header missing", "/debug", "X-Other", "1", http.StatusNotFound, "404 page not found\n"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req, err := http.NewRequest("GET", tt.path, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set(tt.header, tt.value)
+
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+
+				if rr.Code != tt.expectedCode {
+					t.Errorf("Expected status code %d, but got %d", tt.expectedCode, rr.Code)
+				}
+				if rr.Body.String() != tt.expectedBody {
+					t.Errorf("Expected response body %q, but got %q", tt.expectedBody, rr.Body.String())
+				}
+			})
+		}
+	})
+
+	t.Run("Query matchers", func(t *testing.T) {
+		router := NewRouter()
+		router.MustAddRoute("GET", "/export", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("csv"))
+		}).Query("format", "csv")
+		router.MustAddRoute("GET", "/export", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("json"))
+		}).QueryRegexp("format", regexp.MustCompile(`^json(lines)?$`))
+		router.MustAddRoute("GET", "/export", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("default"))
+		})
+		router.MustAddRoute("GET", "/search", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("search"))
+		}).Query("q", "")
+
+		tests := []struct {
+			name         string
+			path         string
+			expectedCode int
+			expectedBody string
+		}{
+			{"Exact query value", "/export?format=csv", http.StatusOK, "csv"},
+			{"Query pattern", "/export?format=jsonlines", http.StatusOK, "json"},
+			{"Fall through", "/export?format=xml", http.StatusOK, "default"},
+			{"Parameter present", "/search?q=", http.StatusOK, "search"},
+			{"Parameter missing", "/search", http.StatusNotFound, "404 page not found\n"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req, err := http.NewRequest("GET", tt.path, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+
+				if rr.Code != tt.expectedCode {
+					t.Errorf("Expected status code %d, but got %d", tt.expectedCode, rr.Code)
+				}
+				if rr.Body.String() != tt.expectedBody {
+					t.Errorf("Expected response body %q, but got %q", tt.expectedBody, rr.Body.String())
+				}
+			})
+		}
+	})
+	t.Run("Scheme and port matchers", func(t *testing.T) {
+		router := NewRouter()
+		router.MustAddRoute("GET", "/", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("public"))
+		}).Scheme("https", nil)
+		router.MustAddRoute("GET", "/", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("admin"))
+		}).Scheme("http", nil).Port(9090)
+
+		tests := []struct {
+			name         string
+			tls          bool
+			port         int
+			expectedCode int
+			expectedBody string
+		}{
+			{"TLS listener", true, 443, http.StatusOK, "public"},
+			{"Admin listener", false, 9090, http.StatusOK, "admin"},
+			{"Other listener", false, 8080, http.StatusNotFound, "404 page not found\n"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req, err := http.NewRequest("GET", "/", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tt.tls {
+					req.TLS = &tls.ConnectionState{}
+				}
+				addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: tt.port}
+				req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, addr))
+
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+
+				if rr.Code != tt.expectedCode {
+					t.Errorf("Expected status code %d, but got %d", tt.expectedCode, rr.Code)
+				}
+				if rr.Body.String() != tt.expectedBody {
+					t.Errorf("Expected response body %q, but got %q", tt.expectedBody, rr.Body.String())
+				}
+			})
+		}
+	})
+}
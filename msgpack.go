@@ -0,0 +1,274 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// MarshalMsgpack encodes v as MessagePack. Values are encoded as they
+// would be encoded to JSON, following their json struct tags.
+func MarshalMsgpack(v interface{}) ([]byte, error) {
+	g, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := encodeMsgpack(&buf, g); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalMsgpack decodes MessagePack into v, as if it were decoded from
+// JSON. Binary values decode into []byte fields.
+func UnmarshalMsgpack(data []byte, v interface{}) error {
+	g, err := decodeMsgpack(&binaryReader{data: data}, 0)
+	if err != nil {
+		return fmt.Errorf("router: invalid MessagePack: %v", err)
+	}
+	return fromGeneric(g, v)
+}
+
+// encodeMsgpack appends the generic value to the buffer.
+func encodeMsgpack(buf *bytes.Buffer, g interface{}) error {
+	switch v := g.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		u, i, ok := genericInt(v)
+		switch {
+		case !ok:
+			f, err := v.Float64()
+			if err != nil {
+				return err
+			}
+			buf.WriteByte(0xcb)
+			putUint(buf, math.Float64bits(f), 8)
+		case i < 0 && i >= -32:
+			buf.WriteByte(byte(i))
+		case i < 0:
+			writeMsgpackInt(buf, i)
+		default:
+			writeMsgpackUint(buf, u)
+		}
+	case string:
+		writeMsgpackHeader(buf, len(v), 0xa0, 32, 0xd9, 0xda)
+		buf.WriteString(v)
+	case []interface{}:
+		writeMsgpackHeader(buf, len(v), 0x90, 16, 0, 0xdc)
+		for _, elem := range v {
+			if err := encodeMsgpack(buf, elem); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		writeMsgpackHeader(buf, len(v), 0x80, 16, 0, 0xde)
+		for _, key := range sortedKeys(v) {
+			encodeMsgpack(buf, key)
+			if err := encodeMsgpack(buf, v[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("router: cannot encode %T as MessagePack", g)
+	}
+	return nil
+}
+
+// writeMsgpackUint writes an unsigned integer in its shortest form.
+func writeMsgpackUint(buf *bytes.Buffer, u uint64) {
+	switch {
+	case u < 128:
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		buf.WriteByte(0xcc)
+		putUint(buf, u, 1)
+	case u <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		putUint(buf, u, 2)
+	case u <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		putUint(buf, u, 4)
+	default:
+		buf.WriteByte(0xcf)
+		putUint(buf, u, 8)
+	}
+}
+
+// writeMsgpackInt writes a negative integer below -32 in its shortest
+// form.
+func writeMsgpackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= math.MinInt8:
+		buf.WriteByte(0xd0)
+		putUint(buf, uint64(i), 1)
+	case i >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		putUint(buf, uint64(i), 2)
+	case i >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		putUint(buf, uint64(i), 4)
+	default:
+		buf.WriteByte(0xd3)
+		putUint(buf, uint64(i), 8)
+	}
+}
+
+// writeMsgpackHeader writes the header of a string, array or map of n
+// elements: the fix format when n is below fixMax, and otherwise the 8-bit
+// format, if code8 is not 0, or the 16-bit format, followed by the 32-bit
+// format's code.
+func writeMsgpackHeader(buf *bytes.Buffer, n int, fix byte, fixMax int, code8 byte, code16 byte) {
+	switch {
+	case n < fixMax:
+		buf.WriteByte(fix | byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		buf.WriteByte(code8)
+		putUint(buf, uint64(n), 1)
+	case n <= math.MaxUint16:
+		buf.WriteByte(code16)
+		putUint(buf, uint64(n), 2)
+	default:
+		buf.WriteByte(code16 + 1)
+		putUint(buf, uint64(n), 4)
+	}
+}
+
+// decodeMsgpack decodes the next MessagePack value as a generic value.
+func decodeMsgpack(r *binaryReader, depth int) (interface{}, error) {
+	if depth > maxCodecDepth {
+		return nil, fmt.Errorf("nesting too deep")
+	}
+	b, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+	switch {
+	case c <= 0x7f:
+		return json.Number(strconv.Itoa(int(c))), nil
+	case c >= 0xe0:
+		return json.Number(strconv.Itoa(int(int8(c)))), nil
+	case c&0xe0 == 0xa0:
+		return msgpackString(r, uint64(c&0x1f))
+	case c&0xf0 == 0x90:
+		return decodeMsgpackArray(r, uint64(c&0x0f), depth)
+	case c&0xf0 == 0x80:
+		return decodeMsgpackMap(r, uint64(c&0x0f), depth)
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		data, err := r.next(n)
+		return append([]byte(nil), data...), err
+	case 0xca:
+		bits, err := r.uint(4)
+		return float64(math.Float32frombits(uint32(bits))), err
+	case 0xcb:
+		bits, err := r.uint(8)
+		return math.Float64frombits(bits), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := r.uint(1 << (c - 0xcc))
+		return json.Number(strconv.FormatUint(u, 10)), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		u, err := r.uint(size)
+		shift := uint(64 - 8*size)
+		return json.Number(strconv.FormatInt(int64(u<<shift)>>shift, 10)), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackString(r, n)
+	case 0xdc, 0xdd:
+		n, err := r.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgpackArray(r, n, depth)
+	case 0xde, 0xdf:
+		n, err := r.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgpackMap(r, n, depth)
+	}
+	return nil, fmt.Errorf("unsupported type 0x%02x", c)
+}
+
+// msgpackString reads a string of n bytes.
+func msgpackString(r *binaryReader, n uint64) (interface{}, error) {
+	b, err := r.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// decodeMsgpackArray decodes an array of n elements.
+func decodeMsgpackArray(r *binaryReader, n uint64, depth int) (interface{}, error) {
+	count, err := r.count(n)
+	if err != nil {
+		return nil, err
+	}
+	a := make([]interface{}, count)
+	for i := range a {
+		if a[i], err = decodeMsgpack(r, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// decodeMsgpackMap decodes a map of n entries.
+func decodeMsgpackMap(r *binaryReader, n uint64, depth int) (interface{}, error) {
+	count, err := r.count(n)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{}, count)
+	for i := 0; i < count; i++ {
+		k, err := decodeMsgpack(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		key, err := mapKey(k)
+		if err != nil {
+			return nil, err
+		}
+		if m[key], err = decodeMsgpack(r, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// msgpackBinder decodes MessagePack request bodies.
+func msgpackBinder(body io.Reader, dst interface{}) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	return UnmarshalMsgpack(data, dst)
+}
@@ -0,0 +1,75 @@
+package router
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMsgpack(t *testing.T) {
+	t.Run("Encode", func(t *testing.T) {
+		tests := []struct {
+			value interface{}
+			hex   string
+		}{
+			{0, "00"},
+			{127, "7f"},
+			{128, "cc80"},
+			{65536, "ce00010000"},
+			{-1, "ff"},
+			{-33, "d0df"},
+			{-40000, "d2ffff63c0"},
+			{1.5, "cb3ff8000000000000"},
+			{"a", "a161"},
+			{strings.Repeat("x", 32), "d920" + strings.Repeat("78", 32)},
+			{[]int{1, 2, 3}, "93010203"},
+			{map[string]interface{}{"b": false, "a": nil}, "82a161c0a162c2"},
+		}
+		for _, tt := range tests {
+			b, err := MarshalMsgpack(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(b); got != tt.hex {
+				t.Errorf("Expected %v to encode to %s, but got %s", tt.value, tt.hex, got)
+			}
+		}
+	})
+
+	t.Run("Decode", func(t *testing.T) {
+		tests := []struct {
+			hex  string
+			json string
+		}{
+			{"cd0100", `256`},
+			{"d1ff00", `-256`},
+			{"d3ffffffffffffffff", `-1`},
+			{"ca3fc00000", `1.5`},
+			{"c40201ff", `"Af8="`},
+			{"dc0002c3c2", `[true,false]`},
+			{"81a26964d90461626364", `{"id":"abcd"}`},
+			{"8101a36f6e65", `{"1":"one"}`},
+		}
+		for _, tt := range tests {
+			data, _ := hex.DecodeString(tt.hex)
+			var v interface{}
+			if err := UnmarshalMsgpack(data, &v); err != nil {
+				t.Fatalf("Expected %s to decode, but got %v", tt.hex, err)
+			}
+			if got, _ := json.Marshal(v); string(got) != tt.json {
+				t.Errorf("Expected %s to decode to %s, but got %s", tt.hex, tt.json, got)
+			}
+		}
+	})
+
+	t.Run("Invalid input", func(t *testing.T) {
+		for _, h := range []string{"", "cd01", "dd7fffffff", "a3616263ff"[:6], "c1", "d4010a"} {
+			data, _ := hex.DecodeString(h)
+			var v interface{}
+			if err := UnmarshalMsgpack(data, &v); err == nil {
+				t.Errorf("Expected an error decoding %q", h)
+			}
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package router
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// NDJSONOptions configures StreamNDJSON.
+type NDJSONOptions struct {
+	// FlushInterval is how long written lines may wait before they are
+	// flushed to the client, even while the next value is awaited. It
+	// defaults to one second, and a negative interval flushes every line.
+	FlushInterval time.Duration
+}
+
+// StreamNDJSON answers the request with the values returned by next, one
+// JSON value per line (application/x-ndjson), flushing them periodically so
+// that large exports reach the client as they are produced. next returns
+// io.EOF after the last value.
+//
+// Streaming stops when the client goes away, returning the context's
+// error. If next fails before the first value, the request is answered with
+// 500; later errors end the response early and are returned, as the status
+// has already been sent.
+func (r *Router) StreamNDJSON(w http.ResponseWriter, req *http.Request, next func() (interface{}, error), opts NDJSONOptions) error {
+	enc := json.NewEncoder(w)
+	return r.stream(w, req, "application/x-ndjson", func(func()) (interface{}, error) { return next() }, enc.Encode, opts.FlushInterval)
+}
+
+// stream answers the request with the values returned by next, written
+// with write, as described by StreamNDJSON. Written values are flushed by a
+// timer at most interval later, so that they reach the client even while
+// next blocks, or when next calls idle.
+func (r *Router) stream(w http.ResponseWriter, req *http.Request, contentType string, next func(idle func()) (interface{}, error), write func(v interface{}) error, interval time.Duration) error {
+	if interval == 0 {
+		interval = time.Second
+	}
+	flusher, _ := w.(http.Flusher)
+	var mu sync.Mutex // guards w between the handler and the flush timer
+	var timer *time.Timer
+	pending, done := false, false
+	flush := func() {
+		pending = false
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	idle := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if pending && !done {
+			flush()
+		}
+	}
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		done = true
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	start := func() {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+	}
+
+	ctx := req.Context()
+	started := false
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		v, err := next(idle)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if !started {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+			r.logger.Errorf("Error streaming %s %s: %v", req.Method, req.URL.Path, err)
+			return err
+		}
+
+		mu.Lock()
+		if !started {
+			started = true
+			start()
+		}
+		err = write(v)
+		if err == nil && interval < 0 {
+			flush()
+		} else if err == nil && !pending {
+			pending = true
+			timer = time.AfterFunc(interval, idle)
+		}
+		mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !started {
+		start()
+	}
+	flush()
+	return nil
+}
+
+// StreamNDJSONChannel answers the request with the values received from
+// ch, which must be a channel, like StreamNDJSON, until it is closed.
+// Lines are flushed whenever the channel has no value ready, so slow
+// producers are not held back by the flush interval.
+func (r *Router) StreamNDJSONChannel(w http.ResponseWriter, req *http.Request, ch interface{}, opts NDJSONOptions) error {
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(req.Context().Done())},
+		{Dir: reflect.SelectDefault},
+	}
+	next := func(idle func()) (interface{}, error) {
+		chosen, v, ok := reflect.Select(cases)
+		if chosen == 2 {
+			idle()
+			chosen, v, ok = reflect.Select(cases[:2])
+		}
+		switch {
+		case chosen == 1:
+			return nil, req.Context().Err()
+		case !ok:
+			return nil, io.EOF
+		}
+		return v.Interface(), nil
+	}
+	enc := json.NewEncoder(w)
+	return r.stream(w, req, "application/x-ndjson", next, enc.Encode, opts.FlushInterval)
+}
@@ -0,0 +1,130 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStreamNDJSON(t *testing.T) {
+	router := NewRouter()
+
+	t.Run("Iterator", func(t *testing.T) {
+		i := 0
+		next := func() (interface{}, error) {
+			if i == 3 {
+				return nil, io.EOF
+			}
+			i++
+			return map[string]int{"n": i}, nil
+		}
+		req := httptest.NewRequest("GET", "/export", nil)
+		rr := httptest.NewRecorder()
+		if err := router.StreamNDJSON(rr, req, next, NDJSONOptions{FlushInterval: -1}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Check the response status code, content type and lines
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, status)
+		}
+		if contentType := rr.Header().Get("Content-Type"); contentType != "application/x-ndjson" {
+			t.Errorf("Expected content type %q, but got %q", "application/x-ndjson", contentType)
+		}
+		if want := "{\"n\":1}\n{\"n\":2}\n{\"n\":3}\n"; rr.Body.String() != want {
+			t.Errorf("Expected response body %q, but got %q", want, rr.Body.String())
+		}
+		if !rr.Flushed {
+			t.Errorf("Expected the response to be flushed")
+		}
+	})
+
+	t.Run("Slow iterator", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/export", nil)
+		w := &countingFlusher{ResponseRecorder: httptest.NewRecorder()}
+		i := 0
+		next := func() (interface{}, error) {
+			if i++; i == 1 {
+				return map[string]int{"n": 1}, nil
+			}
+			// Check that the first line is flushed while the next one is awaited
+			waitFor(t, func() bool { return atomic.LoadInt32(&w.flushes) > 0 })
+			return nil, io.EOF
+		}
+		if err := router.StreamNDJSON(w, req, next, NDJSONOptions{FlushInterval: 10 * time.Millisecond}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Error before the first value", func(t *testing.T) {
+		failure := errors.New("database unavailable")
+		req := httptest.NewRequest("GET", "/export", nil)
+		rr := httptest.NewRecorder()
+		err := router.StreamNDJSON(rr, req, func() (interface{}, error) { return nil, failure }, NDJSONOptions{})
+
+		// Check the error and the response status code
+		if err != failure {
+			t.Errorf("Expected error %v, but got %v", failure, err)
+		}
+		if status := rr.Code; status != http.StatusInternalServerError {
+			t.Errorf("Expected status code %d, but got %d", http.StatusInternalServerError, status)
+		}
+	})
+
+	t.Run("Channel", func(t *testing.T) {
+		ch := make(chan string)
+		go func() {
+			defer close(ch)
+			for _, s := range []string{"a", "b"} {
+				ch <- s
+			}
+		}()
+		req := httptest.NewRequest("GET", "/export", nil)
+		rr := httptest.NewRecorder()
+		if err := router.StreamNDJSONChannel(rr, req, ch, NDJSONOptions{}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Check the lines
+		if want := "\"a\"\n\"b\"\n"; rr.Body.String() != want {
+			t.Errorf("Expected response body %q, but got %q", want, rr.Body.String())
+		}
+	})
+
+	t.Run("Client gone", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := make(chan int)
+		go func() {
+			ch <- 1
+			cancel()
+		}()
+		req := httptest.NewRequest("GET", "/export", nil).WithContext(ctx)
+		rr := httptest.NewRecorder()
+		err := router.StreamNDJSONChannel(rr, req, ch, NDJSONOptions{})
+
+		// Check that streaming stopped with the context's error
+		if err != context.Canceled {
+			t.Errorf("Expected error %v, but got %v", context.Canceled, err)
+		}
+		if want := "1\n"; rr.Body.String() != want {
+			t.Errorf("Expected response body %q, but got %q", want, rr.Body.String())
+		}
+	})
+}
+
+// countingFlusher counts the flushes of a recorder.
+type countingFlusher struct {
+	*httptest.ResponseRecorder
+	flushes int32 // accessed atomically
+}
+
+// Flush counts the flush and flushes the recorder.
+func (w *countingFlusher) Flush() {
+	atomic.AddInt32(&w.flushes, 1)
+	w.ResponseRecorder.Flush()
+}
@@ -0,0 +1,98 @@
+package router
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ContentType restricts the route to requests whose Content-Type is one of
+// the given media types, e.g. "application/json" or "image/*". Requests for
+// the same method and path that match no route because of their content
+// type are answered with 415 Unsupported Media Type.
+func (route *Route) ContentType(types ...string) *Route {
+	for _, t := range types {
+		route.consumes = append(route.consumes, strings.ToLower(t))
+	}
+	return route
+}
+
+// consumesRequest reports whether the request's Content-Type is accepted
+// by the route.
+func (route *Route) consumesRequest(req *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, t := range route.consumes {
+		if mediaTypeMatches(t, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaTypeMatches reports whether the media type matches the pattern,
+// which may use a "*" wildcard for the type or subtype.
+func mediaTypeMatches(pattern string, mediaType string) bool {
+	if pattern == "*/*" || pattern == mediaType {
+		return true
+	}
+	if strings.HasSuffix(pattern, "/*") {
+		return strings.HasPrefix(mediaType, pattern[:len(pattern)-1])
+	}
+	return false
+}
+
+// Produces restricts the route to requests whose Accept header allows one
+// of the given media types. When several routes for the same method and
+// path match, the one with the highest quality value is used. Requests
+// accepting none of the routes' media types are answered with 406 Not
+// Acceptable.
+func (route *Route) Produces(types ...string) *Route {
+	for _, t := range types {
+		route.produces = append(route.produces, strings.ToLower(t))
+	}
+	return route
+}
+
+// acceptQuality returns the highest quality with which the request's
+// Accept header allows one of the media types produced by the route. A
+// missing Accept header allows any media type.
+func (route *Route) acceptQuality(req *http.Request) float64 {
+	header := req.Header.Get("Accept")
+	if header == "" {
+		return 1
+	}
+	ranges := parseQualityHeader(strings.ToLower(header))
+
+	var best float64
+	for _, t := range route.produces {
+		if q := mediaRangeQuality(ranges, t); q > best {
+			best = q
+		}
+	}
+	return best
+}
+
+// mediaRangeQuality returns the quality of the most specific media range
+// matching the media type, or 0 if there is none.
+func mediaRangeQuality(ranges []qualityValue, mediaType string) float64 {
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		if !mediaTypeMatches(r.value, mediaType) {
+			continue
+		}
+		s := 2
+		switch {
+		case r.value == "*/*":
+			s = 0
+		case strings.HasSuffix(r.value, "/*"):
+			s = 1
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
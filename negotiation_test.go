@@ -0,0 +1,119 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestContentTypeRouting(t *testing.T) {
+	router := NewRouter()
+
+	router.MustAddRoute("POST", "/upload", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("json"))
+	}).ContentType("application/json")
+	router.MustAddRoute("POST", "/upload", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("image"))
+	}).ContentType("image/*")
+
+	tests := []struct {
+		contentType    string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"application/json; charset=utf-8", http.StatusOK, "json"},
+		{"image/png", http.StatusOK, "image"},
+		{"text/plain", http.StatusUnsupportedMediaType, ""},
+		{"", http.StatusUnsupportedMediaType, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.contentType, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/upload", strings.NewReader("body"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.contentType != "" {
+				req.Header.Set("Content-Type", test.contentType)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if rr.Code != test.expectedStatus {
+				t.Errorf("Expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+
+			// Check the response body
+			if test.expectedBody != "" && rr.Body.String() != test.expectedBody {
+				t.Errorf("Expected response body %q, but got %q", test.expectedBody, rr.Body.String())
+			}
+		})
+	}
+
+	t.Run("Unknown path", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/other", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Check the response status code
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d, but got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
+
+func TestAcceptRouting(t *testing.T) {
+	router := NewRouter()
+
+	router.MustAddRoute("GET", "/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("html"))
+	}).Produces("text/html")
+	router.MustAddRoute("GET", "/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("json"))
+	}).Produces("application/json")
+
+	tests := []struct {
+		accept         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"", http.StatusOK, "html"},
+		{"application/json", http.StatusOK, "json"},
+		{"text/html;q=0.5, application/json;q=0.9", http.StatusOK, "json"},
+		{"text/*;q=0.8, */*;q=0.1", http.StatusOK, "html"},
+		{"text/html;q=0, */*", http.StatusOK, "json"},
+		{"image/png", http.StatusNotAcceptable, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.accept, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/users/42", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if rr.Code != test.expectedStatus {
+				t.Errorf("Expected status code %d, but got %d", test.expectedStatus, rr.Code)
+			}
+
+			// Check the response body
+			if test.expectedBody != "" && rr.Body.String() != test.expectedBody {
+				t.Errorf("Expected response body %q, but got %q", test.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package router
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// OutlierDetection configures the passive outlier detection of an upstream
+// pool, which ejects backends failing live requests, complementing the
+// active health checks for failures probes cannot see.
+type OutlierDetection struct {
+	// ConsecutiveErrors is the number of consecutive failed requests
+	// ejecting a backend. Requests fail when they are answered with a 5xx
+	// status, including the 502 and 504 of unreachable backends. It
+	// defaults to 5.
+	ConsecutiveErrors int
+	// SlowRequest, if set, also counts requests taking longer as failed.
+	SlowRequest time.Duration
+	// BaseEjection is how long a backend is first ejected. Each ejection
+	// following the previous one within MaxEjection doubles it. It defaults
+	// to 30 seconds.
+	BaseEjection time.Duration
+	// MaxEjection caps the ejection time. It defaults to 5 minutes.
+	MaxEjection time.Duration
+	// MaxEjectedPercent caps the share of the backends ejected at once. It
+	// defaults to 50.
+	MaxEjectedPercent int
+}
+
+// withDefaults returns the options with their defaults set.
+func (o OutlierDetection) withDefaults() OutlierDetection {
+	if o.ConsecutiveErrors <= 0 {
+		o.ConsecutiveErrors = 5
+	}
+	if o.BaseEjection <= 0 {
+		o.BaseEjection = 30 * time.Second
+	}
+	if o.MaxEjection <= 0 {
+		o.MaxEjection = 5 * time.Minute
+	}
+	if o.MaxEjectedPercent <= 0 {
+		o.MaxEjectedPercent = 50
+	}
+	return o
+}
+
+// ejected reports whether the backend is ejected as an outlier at the time.
+func (b *backend) ejected(now time.Time) bool {
+	return now.UnixNano() < atomic.LoadInt64(&b.ejectedUntil)
+}
+
+// record records the outcome of a request served by the backend, ejecting
+// it once it fails ConsecutiveErrors requests in a row.
+func (p *UpstreamPool) record(b *backend, status int, duration time.Duration) {
+	opts := p.opts.Outliers.withDefaults()
+	failed := status >= 500 || (opts.SlowRequest > 0 && duration > opts.SlowRequest)
+
+	p.outlierMu.Lock()
+	defer p.outlierMu.Unlock()
+	if !failed {
+		b.errors = 0
+		return
+	}
+	b.errors++
+	now := time.Now()
+	if b.errors < opts.ConsecutiveErrors || b.ejected(now) || !p.canEject(now, opts.MaxEjectedPercent) {
+		return
+	}
+
+	// Backends staying healthy for MaxEjection start over from BaseEjection
+	if until := atomic.LoadInt64(&b.ejectedUntil); until > 0 && now.Sub(time.Unix(0, until)) > opts.MaxEjection {
+		b.ejections = 0
+	}
+	ejection := opts.BaseEjection
+	for i := 0; i < b.ejections && ejection < opts.MaxEjection; i++ {
+		ejection *= 2
+	}
+	if ejection > opts.MaxEjection {
+		ejection = opts.MaxEjection
+	}
+	b.ejections++
+	b.errors = 0
+	atomic.StoreInt64(&b.ejectedUntil, now.Add(ejection).UnixNano())
+}
+
+// canEject reports whether another backend can be ejected without
+// exceeding the maximum share of ejected backends.
+func (p *UpstreamPool) canEject(now time.Time, maxPercent int) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	ejected := 1
+	for _, b := range p.backends {
+		if b.ejected(now) {
+			ejected++
+		}
+	}
+	return ejected*100 <= maxPercent*len(p.backends)
+}
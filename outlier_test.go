@@ -0,0 +1,82 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOutlierDetection(t *testing.T) {
+	t.Run("Failing backend ejected", func(t *testing.T) {
+		good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			io.WriteString(w, "good")
+		}))
+		defer good.Close()
+		bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			http.Error(w, "broken", http.StatusInternalServerError)
+		}))
+		defer bad.Close()
+		goodURL, _ := url.Parse(good.URL)
+		badURL, _ := url.Parse(bad.URL)
+
+		router := NewRouter()
+		pool := NewUpstreamPool(UpstreamPoolOptions{Outliers: &OutlierDetection{ConsecutiveErrors: 2, BaseEjection: time.Minute}}, goodURL, badURL)
+		router.ProxyPool("GET", "/app", pool)
+
+		var failures int
+		for i := 0; i < 10; i++ {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", "/app", nil))
+			if rr.Code != http.StatusOK {
+				failures++
+			}
+		}
+
+		// Check that the failing backend stopped receiving requests
+		if failures != 2 {
+			t.Errorf("Expected %d failed requests before the ejection, but got %d", 2, failures)
+		}
+		if pool.backends[1].available() {
+			t.Errorf("Expected the failing backend to be ejected")
+		}
+	})
+
+	t.Run("Exponential backoff", func(t *testing.T) {
+		pool := NewUpstreamPool(UpstreamPoolOptions{Outliers: &OutlierDetection{ConsecutiveErrors: 1, BaseEjection: time.Second, MaxEjection: 3 * time.Second}},
+			&url.URL{Scheme: "http", Host: "a"}, &url.URL{Scheme: "http", Host: "b"})
+		b := pool.backends[0]
+
+		for _, want := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+			// Expire the previous ejection
+			atomic.StoreInt64(&b.ejectedUntil, time.Now().Add(-time.Millisecond).UnixNano())
+			pool.record(b, http.StatusBadGateway, 0)
+
+			// Check the ejection time
+			got := time.Until(time.Unix(0, atomic.LoadInt64(&b.ejectedUntil)))
+			if got < want-100*time.Millisecond || got > want {
+				t.Errorf("Expected an ejection of %v, but got %v", want, got)
+			}
+		}
+	})
+
+	t.Run("Slow requests and ejection cap", func(t *testing.T) {
+		pool := NewUpstreamPool(UpstreamPoolOptions{Outliers: &OutlierDetection{ConsecutiveErrors: 1, SlowRequest: time.Second}},
+			&url.URL{Scheme: "http", Host: "a"}, &url.URL{Scheme: "http", Host: "b"})
+
+		// Check that slow requests count as failures
+		pool.record(pool.backends[0], http.StatusOK, 2*time.Second)
+		if pool.backends[0].available() {
+			t.Errorf("Expected the slow backend to be ejected")
+		}
+
+		// Check that no more than half of the backends are ejected
+		pool.record(pool.backends[1], http.StatusServiceUnavailable, 0)
+		if !pool.backends[1].available() {
+			t.Errorf("Expected the last backend to stay available")
+		}
+	})
+}
@@ -0,0 +1,411 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ParamError reports a missing or invalid request parameter.
+type ParamError struct {
+	// Source is where the parameter comes from, "path", "query", "header"
+	// or "cookie".
+	Source string `json:"source"`
+	Name   string `json:"name"`
+	Value  string `json:"value,omitempty"`
+	// Type is the expected type, such as "integer" or "UUID".
+	Type string `json:"type"`
+	// Message describes the error in the client's language, see
+	// Router.WriteParamError.
+	Message string `json:"message,omitempty"`
+	Err     error  `json:"-"`
+}
+
+// Error implements the error interface.
+func (e *ParamError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("router: missing %s parameter %q", e.Source, e.Name)
+	}
+	return fmt.Sprintf("router: invalid %s parameter %q: %q is not a valid %s", e.Source, e.Name, e.Value, e.Type)
+}
+
+// Unwrap returns the parse error.
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// ParamErrors reports several missing or invalid parameters, see Bind.
+type ParamErrors []*ParamError
+
+// Error implements the error interface.
+func (e ParamErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, pe := range e {
+		messages[i] = strings.TrimPrefix(pe.Error(), "router: ")
+	}
+	return "router: " + strings.Join(messages, "; ")
+}
+
+// WriteParamError answers the request with 400 Bad Request and a JSON body
+// describing the parameter error, such as
+//
+//	{"error": "invalid path parameter", "param": {"source": "path", "name": "id", "message": "...", ...}}
+//
+// ParamErrors are listed under "params" instead, and ErrUnsupportedMediaType
+// is answered with 415. Other errors are answered with their message.
+func WriteParamError(w http.ResponseWriter, err error) {
+	writeParamError(w, err, fmt.Sprintf)
+}
+
+// WriteParamError answers the request like the WriteParamError function,
+// translating the messages into the request's locale, see
+// LocaleNegotiation. The messages are the translation keys, such as
+// "invalid %s parameter" and "%q is not a valid %s".
+func (r *Router) WriteParamError(w http.ResponseWriter, req *http.Request, err error) {
+	if locale := r.Locale(req); locale != "" {
+		w.Header().Set("Content-Language", locale)
+	}
+	writeParamError(w, err, r.translator(req))
+}
+
+// translator returns a function translating messages into the request's
+// locale.
+func (r *Router) translator(req *http.Request) func(key string, args ...interface{}) string {
+	return func(key string, args ...interface{}) string {
+		return r.Translate(req, key, args...)
+	}
+}
+
+// writeParamError answers the request with the parameter error, its
+// messages translated with translate.
+func writeParamError(w http.ResponseWriter, err error, translate func(key string, args ...interface{}) string) {
+	if errs, ok := err.(ParamErrors); ok {
+		writeParamErrors(w, translate("invalid parameters"), errs, translate)
+		return
+	}
+	if errors.Is(err, ErrUnsupportedMediaType) {
+		http.Error(w, translate("unsupported media type"), http.StatusUnsupportedMediaType)
+		return
+	}
+	pe, ok := err.(*ParamError)
+	if !ok {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	message := translate("invalid %s parameter", pe.Source)
+	if pe.Value == "" {
+		message = translate("missing %s parameter", pe.Source)
+	}
+	writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": message, "param": pe.localize(translate)})
+}
+
+// writeParamErrors answers the request with 400 and the parameter errors
+// listed under "params".
+func writeParamErrors(w http.ResponseWriter, message string, errs []*ParamError, translate func(key string, args ...interface{}) string) {
+	params := make([]*ParamError, len(errs))
+	for i, pe := range errs {
+		params[i] = pe.localize(translate)
+	}
+	writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": message, "params": params})
+}
+
+// localize returns a copy of the error with its message translated.
+func (e *ParamError) localize(translate func(key string, args ...interface{}) string) *ParamError {
+	c := *e
+	if e.Value == "" {
+		c.Message = translate("missing %s parameter %q", e.Source, e.Name)
+	} else {
+		c.Message = translate("%q is not a valid %s", e.Value, e.Type)
+	}
+	return &c
+}
+
+// pathParam returns the value of the path parameter, or a *ParamError if
+// it is missing.
+func (r *Router) pathParam(req *http.Request, name string, typ string) (string, error) {
+	value := r.GetPathParam(req, name)
+	if value == "" {
+		return "", &ParamError{Source: "path", Name: name, Type: typ}
+	}
+	return value, nil
+}
+
+// ParamInt returns the path parameter as an int. It returns a *ParamError
+// if the parameter is missing or invalid, see WriteParamError.
+func (r *Router) ParamInt(req *http.Request, name string) (int, error) {
+	value, err := r.pathParam(req, name, "integer")
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &ParamError{Source: "path", Name: name, Value: value, Type: "integer", Err: err}
+	}
+	return n, nil
+}
+
+// ParamInt64 returns the path parameter as an int64, like ParamInt.
+func (r *Router) ParamInt64(req *http.Request, name string) (int64, error) {
+	value, err := r.pathParam(req, name, "integer")
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, &ParamError{Source: "path", Name: name, Value: value, Type: "integer", Err: err}
+	}
+	return n, nil
+}
+
+// ParamFloat returns the path parameter as a float64, like ParamInt.
+func (r *Router) ParamFloat(req *http.Request, name string) (float64, error) {
+	value, err := r.pathParam(req, name, "number")
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, &ParamError{Source: "path", Name: name, Value: value, Type: "number", Err: err}
+	}
+	return f, nil
+}
+
+// ParamBool returns the path parameter as a bool, like ParamInt. It
+// accepts the values accepted by strconv.ParseBool.
+func (r *Router) ParamBool(req *http.Request, name string) (bool, error) {
+	value, err := r.pathParam(req, name, "boolean")
+	if err != nil {
+		return false, err
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, &ParamError{Source: "path", Name: name, Value: value, Type: "boolean", Err: err}
+	}
+	return b, nil
+}
+
+// ParamUUID returns the path parameter as a UUID, like ParamInt.
+func (r *Router) ParamUUID(req *http.Request, name string) (uuid.UUID, error) {
+	value, err := r.pathParam(req, name, "UUID")
+	if err != nil {
+		return uuid.Nil, err
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, &ParamError{Source: "path", Name: name, Value: value, Type: "UUID", Err: err}
+	}
+	return id, nil
+}
+
+// ParamTime returns the path parameter as a time parsed with the layout,
+// such as "2006-01-02", like ParamInt.
+func (r *Router) ParamTime(req *http.Request, name string, layout string) (time.Time, error) {
+	value, err := r.pathParam(req, name, "time")
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, &ParamError{Source: "path", Name: name, Value: value, Type: "time", Err: err}
+	}
+	return t, nil
+}
+
+// queryParam returns the last value of the query parameter, and whether it
+// is present and not empty.
+func (r *Router) queryParam(req *http.Request, name string) (string, bool) {
+	values := r.GetQueryParams(req)[name]
+	if len(values) == 0 || values[len(values)-1] == "" {
+		return "", false
+	}
+	return values[len(values)-1], true
+}
+
+// QueryString returns the query parameter, or def if it is absent or
+// empty.
+func (r *Router) QueryString(req *http.Request, name string, def string) string {
+	if value, ok := r.queryParam(req, name); ok {
+		return value
+	}
+	return def
+}
+
+// QueryInt returns the query parameter as an int, or def if it is absent
+// or empty. It returns a *ParamError if the parameter is invalid, see
+// WriteParamError.
+func (r *Router) QueryInt(req *http.Request, name string, def int) (int, error) {
+	value, ok := r.queryParam(req, name)
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return def, &ParamError{Source: "query", Name: name, Value: value, Type: "integer", Err: err}
+	}
+	return n, nil
+}
+
+// QueryFloat returns the query parameter as a float64, like QueryInt.
+func (r *Router) QueryFloat(req *http.Request, name string, def float64) (float64, error) {
+	value, ok := r.queryParam(req, name)
+	if !ok {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return def, &ParamError{Source: "query", Name: name, Value: value, Type: "number", Err: err}
+	}
+	return f, nil
+}
+
+// QueryBool returns the query parameter as a bool, like QueryInt. It
+// accepts the values accepted by strconv.ParseBool.
+func (r *Router) QueryBool(req *http.Request, name string, def bool) (bool, error) {
+	value, ok := r.queryParam(req, name)
+	if !ok {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return def, &ParamError{Source: "query", Name: name, Value: value, Type: "boolean", Err: err}
+	}
+	return b, nil
+}
+
+// QueryTime returns the query parameter as a time parsed with the layout,
+// like QueryInt.
+func (r *Router) QueryTime(req *http.Request, name string, layout string, def time.Time) (time.Time, error) {
+	value, ok := r.queryParam(req, name)
+	if !ok {
+		return def, nil
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return def, &ParamError{Source: "query", Name: name, Value: value, Type: "time", Err: err}
+	}
+	return t, nil
+}
+
+// QueryStringSlice returns the values of the query parameter, which may be
+// repeated, as in "?tag=a&tag=b", or comma-separated, as in "?tag=a,b".
+// Empty values are dropped. It returns def if there are none.
+func (r *Router) QueryStringSlice(req *http.Request, name string, def []string) []string {
+	var values []string
+	for _, value := range r.GetQueryParams(req)[name] {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	if len(values) == 0 {
+		return def
+	}
+	return values
+}
+
+// HeaderOr returns the request header, or def if it is absent or blank.
+func (r *Router) HeaderOr(req *http.Request, name string, def string) string {
+	if value := strings.TrimSpace(req.Header.Get(name)); value != "" {
+		return value
+	}
+	return def
+}
+
+// RequiredHeader returns the request header. It returns a *ParamError if
+// the header is absent or blank, see WriteParamError.
+func (r *Router) RequiredHeader(req *http.Request, name string) (string, error) {
+	value := r.HeaderOr(req, name, "")
+	if value == "" {
+		return "", &ParamError{Source: "header", Name: http.CanonicalHeaderKey(name), Type: "string"}
+	}
+	return value, nil
+}
+
+// HeaderInt returns the request header as an int, or def if it is absent
+// or blank. It returns a *ParamError if the header is invalid.
+func (r *Router) HeaderInt(req *http.Request, name string, def int) (int, error) {
+	value := r.HeaderOr(req, name, "")
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return def, &ParamError{Source: "header", Name: http.CanonicalHeaderKey(name), Value: value, Type: "integer", Err: err}
+	}
+	return n, nil
+}
+
+// cookieValue returns the value of the cookie, and whether it is present
+// and not empty.
+func cookieValue(req *http.Request, name string) (string, bool) {
+	cookie, err := req.Cookie(name)
+	if err != nil || cookie.Value == "" {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+// CookieString returns the value of the cookie, or def if it is absent or
+// empty.
+func (r *Router) CookieString(req *http.Request, name string, def string) string {
+	if value, ok := cookieValue(req, name); ok {
+		return value
+	}
+	return def
+}
+
+// RequiredCookie returns the value of the cookie. It returns a *ParamError
+// if the cookie is absent or empty, see WriteParamError.
+func (r *Router) RequiredCookie(req *http.Request, name string) (string, error) {
+	value, ok := cookieValue(req, name)
+	if !ok {
+		return "", &ParamError{Source: "cookie", Name: name, Type: "string"}
+	}
+	return value, nil
+}
+
+// CookieInt returns the value of the cookie as an int, like QueryInt.
+func (r *Router) CookieInt(req *http.Request, name string, def int) (int, error) {
+	value, ok := cookieValue(req, name)
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return def, &ParamError{Source: "cookie", Name: name, Value: value, Type: "integer", Err: err}
+	}
+	return n, nil
+}
+
+// CookieBool returns the value of the cookie as a bool, like QueryBool.
+func (r *Router) CookieBool(req *http.Request, name string, def bool) (bool, error) {
+	value, ok := cookieValue(req, name)
+	if !ok {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return def, &ParamError{Source: "cookie", Name: name, Value: value, Type: "boolean", Err: err}
+	}
+	return b, nil
+}
+
+// CookieUUID returns the value of the cookie as a UUID. It returns a
+// *ParamError if the cookie is absent or invalid.
+func (r *Router) CookieUUID(req *http.Request, name string) (uuid.UUID, error) {
+	value, ok := cookieValue(req, name)
+	if !ok {
+		return uuid.Nil, &ParamError{Source: "cookie", Name: name, Type: "UUID"}
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, &ParamError{Source: "cookie", Name: name, Value: value, Type: "UUID", Err: err}
+	}
+	return id, nil
+}
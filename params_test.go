@@ -0,0 +1,298 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPathParamGetters(t *testing.T) {
+	router := NewRouter()
+	router.MustAddRoute("GET", "/orders/:id/:placed/:ref/:express", func(w http.ResponseWriter, req *http.Request) {
+		id, err := router.ParamInt(req, "id")
+		if err != nil {
+			WriteParamError(w, err)
+			return
+		}
+		placed, err := router.ParamTime(req, "placed", "2006-01-02")
+		if err != nil {
+			WriteParamError(w, err)
+			return
+		}
+		ref, err := router.ParamUUID(req, "ref")
+		if err != nil {
+			WriteParamError(w, err)
+			return
+		}
+		express, err := router.ParamBool(req, "express")
+		if err != nil {
+			WriteParamError(w, err)
+			return
+		}
+		fmt.Fprintf(w, "%d %s %s %t", id, placed.Format(time.RFC3339), ref, express)
+	})
+
+	const ref = "1b4e28ba-2fa1-11d2-883f-0016d3cca427"
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		body   string
+		param  string
+	}{
+		{"Valid parameters", "/orders/42/2024-05-17/" + ref + "/true", http.StatusOK, "42 2024-05-17T00:00:00Z " + ref + " true", ""},
+		{"Invalid integer", "/orders/abc/2024-05-17/" + ref + "/true", http.StatusBadRequest, "", "id"},
+		{"Invalid time", "/orders/42/17-05-2024/" + ref + "/true", http.StatusBadRequest, "", "placed"},
+		{"Invalid UUID", "/orders/42/2024-05-17/not-a-uuid/true", http.StatusBadRequest, "", "ref"},
+		{"Invalid boolean", "/orders/42/2024-05-17/" + ref + "/maybe", http.StatusBadRequest, "", "express"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if status := rr.Code; status != tt.status {
+				t.Errorf("Expected status code %d, but got %d", tt.status, status)
+			}
+			if tt.status == http.StatusOK {
+				if body := rr.Body.String(); body != tt.body {
+					t.Errorf("Expected response body %q, but got %q", tt.body, body)
+				}
+				return
+			}
+
+			// Check the parameter named in the error
+			var body struct {
+				Error string     `json:"error"`
+				Param ParamError `json:"param"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error != "invalid path parameter" || body.Param.Name != tt.param {
+				t.Errorf("Expected an error for parameter %q, but got %s", tt.param, rr.Body.String())
+			}
+		})
+	}
+
+	t.Run("Missing parameter", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/orders", nil)
+		_, err := router.ParamInt64(req, "id")
+		var pe *ParamError
+		if !errors.As(err, &pe) || pe.Value != "" {
+			t.Errorf("Expected a missing parameter error, but got %v", err)
+		}
+	})
+}
+
+func TestQueryGetters(t *testing.T) {
+	router := NewRouter()
+	var limit int
+	var verbose bool
+	var since time.Time
+	var tags []string
+	var sort string
+	var errs []error
+	router.MustAddRoute("GET", "/items", func(w http.ResponseWriter, req *http.Request) {
+		errs = nil
+		var err error
+		if limit, err = router.QueryInt(req, "limit", 20); err != nil {
+			errs = append(errs, err)
+		}
+		if verbose, err = router.QueryBool(req, "verbose", false); err != nil {
+			errs = append(errs, err)
+		}
+		if since, err = router.QueryTime(req, "since", "2006-01-02", time.Time{}); err != nil {
+			errs = append(errs, err)
+		}
+		tags = router.QueryStringSlice(req, "tag", nil)
+		sort = router.QueryString(req, "sort", "name")
+	})
+
+	serve := func(target string) {
+		req := httptest.NewRequest("GET", target, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	t.Run("Defaults", func(t *testing.T) {
+		serve("/items?limit=")
+
+		// Check that absent and empty values use the defaults
+		if limit != 20 || verbose || !since.IsZero() || tags != nil || sort != "name" || len(errs) != 0 {
+			t.Errorf("Expected the defaults, but got %d %t %v %v %q %v", limit, verbose, since, tags, sort, errs)
+		}
+	})
+
+	t.Run("Values", func(t *testing.T) {
+		serve("/items?limit=5&verbose=1&since=2024-05-17&tag=a,b&tag=c&sort=price")
+
+		// Check the parsed values
+		want := time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC)
+		if limit != 5 || !verbose || !since.Equal(want) || fmt.Sprint(tags) != "[a b c]" || sort != "price" || len(errs) != 0 {
+			t.Errorf("Expected the query values, but got %d %t %v %v %q %v", limit, verbose, since, tags, sort, errs)
+		}
+	})
+
+	t.Run("Invalid values", func(t *testing.T) {
+		serve("/items?limit=ten&verbose=maybe")
+
+		// Check that invalid values report errors and fall back to the defaults
+		if len(errs) != 2 || limit != 20 || verbose {
+			t.Fatalf("Expected 2 errors and the defaults, but got %v, %d and %t", errs, limit, verbose)
+		}
+		var pe *ParamError
+		if !errors.As(errs[0], &pe) || pe.Source != "query" || pe.Name != "limit" {
+			t.Errorf("Expected a query parameter error for limit, but got %v", errs[0])
+		}
+	})
+}
+
+func TestHeaderAndCookieGetters(t *testing.T) {
+	router := NewRouter()
+	const session = "1b4e28ba-2fa1-11d2-883f-0016d3cca427"
+
+	t.Run("Values", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Tenant", " acme ")
+		req.Header.Set("X-Retries", "3")
+		req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		req.AddCookie(&http.Cookie{Name: "visits", Value: "7"})
+		req.AddCookie(&http.Cookie{Name: "beta", Value: "true"})
+
+		// Check the parsed values
+		tenant, err := router.RequiredHeader(req, "x-tenant")
+		if err != nil || tenant != "acme" {
+			t.Errorf("Expected header %q, but got %q %v", "acme", tenant, err)
+		}
+		if retries, err := router.HeaderInt(req, "X-Retries", 0); err != nil || retries != 3 {
+			t.Errorf("Expected header %d, but got %d %v", 3, retries, err)
+		}
+		if id, err := router.CookieUUID(req, "session"); err != nil || id.String() != session {
+			t.Errorf("Expected cookie %q, but got %q %v", session, id, err)
+		}
+		if visits, err := router.CookieInt(req, "visits", 0); err != nil || visits != 7 {
+			t.Errorf("Expected cookie %d, but got %d %v", 7, visits, err)
+		}
+		if beta, err := router.CookieBool(req, "beta", false); err != nil || !beta {
+			t.Errorf("Expected cookie %t, but got %t %v", true, beta, err)
+		}
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Tenant", "  ")
+
+		// Check that absent and blank values use the defaults
+		if tenant := router.HeaderOr(req, "X-Tenant", "default"); tenant != "default" {
+			t.Errorf("Expected header %q, but got %q", "default", tenant)
+		}
+		if theme := router.CookieString(req, "theme", "light"); theme != "light" {
+			t.Errorf("Expected cookie %q, but got %q", "light", theme)
+		}
+		if visits, err := router.CookieInt(req, "visits", 1); err != nil || visits != 1 {
+			t.Errorf("Expected cookie %d, but got %d %v", 1, visits, err)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Retries", "many")
+		req.AddCookie(&http.Cookie{Name: "session", Value: "nope"})
+
+		// Check the errors and the response written for them
+		_, err := router.RequiredHeader(req, "x-tenant")
+		var pe *ParamError
+		if !errors.As(err, &pe) || pe.Source != "header" || pe.Name != "X-Tenant" || pe.Value != "" {
+			t.Errorf("Expected a missing header error, but got %v", err)
+		}
+		if _, err := router.HeaderInt(req, "X-Retries", 0); !errors.As(err, &pe) || pe.Value != "many" {
+			t.Errorf("Expected an invalid header error, but got %v", err)
+		}
+		_, err = router.CookieUUID(req, "session")
+		if !errors.As(err, &pe) || pe.Source != "cookie" || pe.Type != "UUID" {
+			t.Errorf("Expected an invalid cookie error, but got %v", err)
+		}
+		if _, err := router.RequiredCookie(req, "csrf"); !errors.As(err, &pe) || pe.Value != "" {
+			t.Errorf("Expected a missing cookie error, but got %v", err)
+		}
+
+		rr := httptest.NewRecorder()
+		WriteParamError(rr, err)
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, but got %d", http.StatusBadRequest, status)
+		}
+		var body struct {
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil || body.Error != "invalid cookie parameter" {
+			t.Errorf("Expected an invalid cookie parameter error, but got %s", rr.Body.String())
+		}
+	})
+}
+
+func TestLocalizedParamErrors(t *testing.T) {
+	router := NewRouter()
+	french := map[string]string{
+		"invalid %s parameter":    "paramètre %s invalide",
+		"%q is not a valid %s":    "%q n'est pas un %s valide",
+		"invalid parameters":      "paramètres invalides",
+		"missing %s parameter %q": "paramètre %s %q manquant",
+	}
+	router.Use(LocaleNegotiation(LocaleOptions{
+		Supported: []string{"en", "fr"},
+		Translator: func(locale string, key string, args ...interface{}) string {
+			if translated, ok := french[key]; ok && locale == "fr" {
+				key = translated
+			}
+			return fmt.Sprintf(key, args...)
+		},
+	}))
+	router.MustAddRoute("GET", "/items/:id", func(w http.ResponseWriter, req *http.Request) {
+		if _, err := router.ParamInt(req, "id"); err != nil {
+			router.WriteParamError(w, req, err)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		language string
+		error    string
+		message  string
+	}{
+		{"English", "en", "invalid path parameter", `"abc" is not a valid integer`},
+		{"French", "fr-CA, en;q=0.5", "paramètre path invalide", `"abc" n'est pas un integer valide`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/items/abc", nil)
+			req.Header.Set("Accept-Language", tt.language)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("Expected status code %d, but got %d", http.StatusBadRequest, status)
+			}
+
+			// Check the translated messages
+			var body struct {
+				Error string     `json:"error"`
+				Param ParamError `json:"param"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error != tt.error || body.Param.Message != tt.message {
+				t.Errorf("Expected %q and %q, but got %s", tt.error, tt.message, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package router
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// pattern is a compiled path pattern. Segments starting with ':' match a
+// single path segment and segments starting with '*' match the rest of
+// the path, e.g. "/users/:id" or "/static/*filepath". Trailing parameter
+// segments ending with '?' are optional.
+type pattern struct {
+	raw      string
+	segments []string
+	required int // number of segments before the optional ones
+}
+
+// compilePattern compiles a path pattern. Trailing parameter segments may
+// be optional, as in "/reports/:year/:month?". It panics if the pattern is
+// invalid, see validatePattern.
+func compilePattern(raw string) *pattern {
+	if err := validatePattern(raw); err != nil {
+		panic(err.Error())
+	}
+	p := &pattern{
+		raw:      raw,
+		segments: strings.Split(raw, "/"),
+	}
+	p.required = len(p.segments)
+	for p.required > 0 && isOptional(p.segments[p.required-1]) {
+		p.required--
+	}
+	return p
+}
+
+// validatePattern checks that the path pattern starts with a slash, that
+// its parameters are named uniquely, that only its trailing segments are
+// optional and that a wildcard segment comes last.
+func validatePattern(raw string) error {
+	if !strings.HasPrefix(raw, "/") {
+		return fmt.Errorf("router: path %q must start with a slash", raw)
+	}
+	optional := ""
+	names := make(map[string]bool)
+	segments := strings.Split(raw, "/")
+	for i, segment := range segments {
+		var name string
+		switch {
+		case strings.HasPrefix(segment, "*"):
+			if i != len(segments)-1 {
+				return fmt.Errorf("router: wildcard segment %s of %s must be the last segment", segment, raw)
+			}
+			name = wildcardName(segment)
+		case strings.HasPrefix(segment, ":"):
+			name = paramName(segment)
+			if name == "" {
+				return fmt.Errorf("router: parameter segment %s of %s must be named", segment, raw)
+			}
+		}
+		if name != "" {
+			if names[name] {
+				return fmt.Errorf("router: parameter %s of %s is used twice", name, raw)
+			}
+			names[name] = true
+		}
+
+		if isOptional(segment) {
+			optional = segment
+		} else if optional != "" {
+			return fmt.Errorf("router: optional segment %s of %s must be followed by optional segments only", optional, raw)
+		}
+	}
+	return nil
+}
+
+// isOptional reports whether the segment is an optional parameter.
+func isOptional(segment string) bool {
+	return len(segment) > 2 && segment[0] == ':' && strings.HasSuffix(segment, "?")
+}
+
+// paramName returns the name of a parameter segment.
+func paramName(segment string) string {
+	return strings.TrimSuffix(segment[1:], "?")
+}
+
+// isPattern reports whether the path contains parameter or wildcard segments.
+func isPattern(path string) bool {
+	return strings.Contains(path, "/:") || strings.Contains(path, "/*")
+}
+
+// match matches the path against the pattern and returns the captured parameters.
+func (p *pattern) match(path string) (map[string]string, bool) {
+	parts := strings.Split(path, "/")
+	var params map[string]string
+
+	for i, segment := range p.segments {
+		if strings.HasPrefix(segment, "*") {
+			if params == nil {
+				params = make(map[string]string)
+			}
+			if i < len(parts) {
+				params[wildcardName(segment)] = strings.Join(parts[i:], "/")
+			} else {
+				params[wildcardName(segment)] = ""
+			}
+			return params, true
+		}
+		if i >= len(parts) {
+			// The path may stop before the optional segments
+			return params, i >= p.required
+		}
+		if len(segment) > 1 && segment[0] == ':' {
+			if parts[i] == "" {
+				return nil, false
+			}
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[paramName(segment)] = parts[i]
+			continue
+		}
+		if segment != parts[i] {
+			return nil, false
+		}
+	}
+
+	if len(parts) != len(p.segments) {
+		return nil, false
+	}
+	return params, true
+}
+
+// expand replaces the parameter and wildcard segments of target with the
+// captured parameter values. Optional segments without a value are
+// removed.
+func expand(target string, params map[string]string) string {
+	return expandWith(target, params, false)
+}
+
+// expandWith is like expand, path escaping the values if escape is set.
+// The slashes of wildcard values are kept, those of parameters escaped.
+func expandWith(target string, params map[string]string, escape bool) string {
+	segments := strings.Split(target, "/")
+	expanded := segments[:0]
+	for _, segment := range segments {
+		switch {
+		case len(segment) > 1 && segment[0] == ':':
+			if value, ok := params[paramName(segment)]; ok {
+				segment = value
+				if escape {
+					segment = url.PathEscape(value)
+				}
+			} else if isOptional(segment) {
+				continue
+			}
+		case strings.HasPrefix(segment, "*"):
+			if value, ok := params[wildcardName(segment)]; ok {
+				segment = value
+				if escape {
+					parts := strings.Split(value, "/")
+					for i, part := range parts {
+						parts[i] = url.PathEscape(part)
+					}
+					segment = strings.Join(parts, "/")
+				}
+			}
+		}
+		expanded = append(expanded, segment)
+	}
+	return strings.Join(expanded, "/")
+}
+
+// wildcardName returns the parameter name of a wildcard segment. An
+// unnamed wildcard is stored under "*".
+func wildcardName(segment string) string {
+	if segment == "*" {
+		return "*"
+	}
+	return segment[1:]
+}
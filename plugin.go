@@ -0,0 +1,147 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Plugin is an optional extension that can be activated by name from a
+// configuration.
+type Plugin interface {
+	// Name returns the name the plugin is activated by.
+	Name() string
+	// NewConfig returns a pointer to a new value of the plugin's
+	// configuration type. The plugin configuration is decoded into it.
+	NewConfig() interface{}
+	// Activate contributes the plugin's middleware and routes.
+	Activate(ext *Extension, config interface{}) error
+}
+
+// PluginConfig activates a plugin from a configuration.
+type PluginConfig struct {
+	Name   string                 `json:"name"`
+	Config map[string]interface{} `json:"config"`
+}
+
+// Extension collects the middleware and routes contributed by plugins to a
+// configuration. The middleware applies to all the configuration's routes.
+type Extension struct {
+	router     *Router
+	middleware []func(http.HandlerFunc) http.HandlerFunc
+	routes     []*Route
+	err        error // first route that could not be added
+}
+
+// Router returns the router the plugin is activated on.
+func (e *Extension) Router() *Router {
+	return e.router
+}
+
+// Use contributes middleware.
+func (e *Extension) Use(middleware ...func(http.HandlerFunc) http.HandlerFunc) {
+	e.middleware = append(e.middleware, middleware...)
+}
+
+// AddRoute contributes a route. It returns an error, like Router.AddRoute,
+// if the route is invalid or duplicates a route of the plugins; the plugin
+// then fails to activate.
+func (e *Extension) AddRoute(method string, path string, handler http.HandlerFunc) (*Route, error) {
+	var existing []*Route
+	for _, route := range e.routes {
+		if route.Method == method && route.Path == path {
+			existing = append(existing, route)
+		}
+	}
+	if err := validateRoute(method, path, existing); err != nil {
+		if e.err == nil {
+			e.err = err
+		}
+		return nil, err
+	}
+	route := newRoute(method, path, handler)
+	e.routes = append(e.routes, route)
+	return route, nil
+}
+
+var (
+	pluginsMu sync.RWMutex
+	plugins   = make(map[string]Plugin)
+)
+
+// RegisterPlugin makes a plugin available by name. It is typically called
+// from the init function of the package providing the plugin and panics if
+// a plugin with the same name is already registered.
+func RegisterPlugin(plugin Plugin) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	name := plugin.Name()
+	if _, ok := plugins[name]; ok {
+		panic("router: plugin " + name + " registered twice")
+	}
+	plugins[name] = plugin
+}
+
+// Plugins returns the names of the registered plugins, sorted.
+func Plugins() []string {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+
+	var names []string
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupPlugin returns the plugin registered under the name.
+func lookupPlugin(name string) (Plugin, bool) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+
+	plugin, ok := plugins[name]
+	return plugin, ok
+}
+
+// decodePluginConfig decodes the plugin configuration into the plugin's
+// configuration type.
+func decodePluginConfig(plugin Plugin, config map[string]interface{}) (interface{}, error) {
+	value := plugin.NewConfig()
+	if value == nil || config == nil {
+		return value, nil
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// activatePlugins activates the plugins of the configuration.
+func (r *Router) activatePlugins(configs []PluginConfig) (*Extension, error) {
+	ext := &Extension{router: r}
+	for _, pc := range configs {
+		plugin, ok := lookupPlugin(pc.Name)
+		if !ok {
+			return nil, fmt.Errorf("router: unknown plugin %q", pc.Name)
+		}
+		config, err := decodePluginConfig(plugin, pc.Config)
+		if err != nil {
+			return nil, fmt.Errorf("router: invalid configuration for plugin %q: %v", pc.Name, err)
+		}
+		if err := plugin.Activate(ext, config); err != nil {
+			return nil, fmt.Errorf("router: failed to activate plugin %q: %v", pc.Name, err)
+		}
+		if ext.err != nil {
+			return nil, fmt.Errorf("router: failed to activate plugin %q: %v", pc.Name, ext.err)
+		}
+	}
+	return ext, nil
+}
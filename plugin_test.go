@@ -0,0 +1,129 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// headerPlugin sets a configurable response header and serves a ping route.
+type headerPlugin struct{}
+
+type headerPluginConfig struct {
+	Header string `json:"header"`
+	Value  string `json:"value"`
+}
+
+func (headerPlugin) Name() string { return "test-header" }
+
+func (headerPlugin) NewConfig() interface{} { return &headerPluginConfig{} }
+
+func (headerPlugin) Activate(ext *Extension, config interface{}) error {
+	cfg := config.(*headerPluginConfig)
+	if cfg.Header == "" {
+		return errors.New("header is required")
+	}
+	ext.Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set(cfg.Header, cfg.Value)
+			next(w, req)
+		}
+	})
+	ext.AddRoute("GET", "/ping", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("pong"))
+	})
+	return nil
+}
+
+// badRoutePlugin contributes a route with an invalid path.
+type badRoutePlugin struct{}
+
+func (badRoutePlugin) Name() string { return "test-bad-route" }
+
+func (badRoutePlugin) NewConfig() interface{} { return nil }
+
+func (badRoutePlugin) Activate(ext *Extension, config interface{}) error {
+	ext.AddRoute("GET", "/files/*path/raw", func(w http.ResponseWriter, req *http.Request) {})
+	return nil
+}
+
+func init() {
+	RegisterPlugin(headerPlugin{})
+	RegisterPlugin(badRoutePlugin{})
+}
+
+func TestPlugins(t *testing.T) {
+	router := NewRouter()
+	router.RegisterHandler("hello", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("Hello, World!"))
+	})
+
+	t.Run("Activate from configuration", func(t *testing.T) {
+		err := router.LoadConfig(&Config{
+			Routes: []RouteConfig{{Method: "GET", Path: "/hello", Handler: "hello"}},
+			Plugins: []PluginConfig{{
+				Name:   "test-header",
+				Config: map[string]interface{}{"header": "X-Plugin", "value": "on"},
+			}},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		for path, expectedBody := range map[string]string{"/hello": "Hello, World!", "/ping": "pong"} {
+			req, err := http.NewRequest("GET", path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Body.String() != expectedBody {
+				t.Errorf("Expected response body %q, but got %q", expectedBody, rr.Body.String())
+			}
+			if rr.Header().Get("X-Plugin") != "on" {
+				t.Errorf("Expected the plugin middleware to run for %s", path)
+			}
+		}
+	})
+
+	t.Run("Invalid plugin configuration", func(t *testing.T) {
+		tests := []PluginConfig{
+			{Name: "missing"},
+			{Name: "test-header", Config: map[string]interface{}{"header": 42}},
+			{Name: "test-header", Config: map[string]interface{}{}},
+			{Name: "test-bad-route"},
+		}
+		for _, pc := range tests {
+			if err := router.LoadConfig(&Config{Plugins: []PluginConfig{pc}}); err == nil {
+				t.Errorf("Expected an error for %v, but got nil", pc)
+			}
+		}
+	})
+
+	t.Run("Plugin route duplicating a configured route", func(t *testing.T) {
+		err := router.LoadConfig(&Config{
+			Routes: []RouteConfig{{Method: "GET", Path: "/ping", Handler: "hello"}},
+			Plugins: []PluginConfig{{
+				Name:   "test-header",
+				Config: map[string]interface{}{"header": "X-Plugin", "value": "on"},
+			}},
+		})
+		if err == nil {
+			t.Errorf("Expected an error for the duplicate route, but got nil")
+		}
+	})
+
+	t.Run("Registered plugins", func(t *testing.T) {
+		found := false
+		for _, name := range Plugins() {
+			if name == "test-header" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected test-header in %v", Plugins())
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+)
+
+// Proxy adds a route forwarding matching requests to the target URL. The
+// request path is appended to the target path. It returns an error if the
+// route cannot be added, see AddRoute.
+func (r *Router) Proxy(method string, path string, target *url.URL) (*Route, error) {
+	return r.AddRoute(method, path, r.proxyHandler(target, nil))
+}
+
+// ProxyWithTransport is like Proxy, but connects to the target with the
+// transport, see NewTransport.
+func (r *Router) ProxyWithTransport(method string, path string, target *url.URL, transport http.RoundTripper) (*Route, error) {
+	return r.AddRoute(method, path, r.proxyHandler(target, transport))
+}
+
+// TrustForwardedHeaders sets the proxies whose forwarding headers, such as
+// X-Forwarded-For and Forwarded, proxy routes pass on to upstreams. The
+// forwarding headers of other clients are dropped, so they cannot spoof
+// their address.
+func (r *Router) TrustForwardedHeaders(proxies *TrustedProxies) {
+	r.forwardedTrust = proxies
+}
+
+// proxyHandler returns a handler forwarding requests to the target URL. The
+// time left before the request deadline, the trace context and the
+// correlation ID are passed on to the target, along with the forwarding
+// headers describing the client. Hop-by-hop headers are not forwarded. A nil
+// transport means http.DefaultTransport.
+func (r *Router) proxyHandler(target *url.URL, transport http.RoundTripper) http.HandlerFunc {
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = transport
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		host := req.Host
+		director(req)
+		r.setForwardedHeaders(req, host)
+		propagateDeadline(req)
+		r.InjectTrace(req, req.Header)
+		if correlationID := r.GetCorrelationID(req); correlationID != "" {
+			req.Header.Set("X-Correlation-ID", correlationID)
+		}
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		r.logger.Errorf("Failed to proxy request to %s: %v", target, err)
+		if errors.Is(err, context.DeadlineExceeded) {
+			http.Error(w, http.StatusText(http.StatusGatewayTimeout), http.StatusGatewayTimeout)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+	}
+	return proxy.ServeHTTP
+}
+
+// setForwardedHeaders describes the client of the outgoing request to the
+// upstream: X-Forwarded-Proto, X-Forwarded-Host and a Forwarded element
+// are added, and the X-Forwarded-For address is appended by the reverse
+// proxy. host is the host the client requested.
+func (r *Router) setForwardedHeaders(req *http.Request, host string) {
+	if !r.forwardedTrust.Trusted(req) {
+		for _, name := range []string{"Forwarded", "X-Forwarded-For", "X-Forwarded-Proto", "X-Forwarded-Host"} {
+			req.Header.Del(name)
+		}
+	}
+
+	proto := "http"
+	if req.TLS != nil {
+		proto = "https"
+	}
+	if req.Header.Get("X-Forwarded-Proto") == "" {
+		req.Header.Set("X-Forwarded-Proto", proto)
+	}
+	if req.Header.Get("X-Forwarded-Host") == "" {
+		req.Header.Set("X-Forwarded-Host", host)
+	}
+
+	node := "unknown"
+	if ip := remoteIP(req); ip != nil {
+		node = ip.String()
+		if ip.To4() == nil {
+			node = `"[` + node + `]"`
+		}
+	}
+	req.Header.Add("Forwarded", "for="+node+";host="+forwardedValue(host)+";proto="+proto)
+}
+
+// forwardedValue quotes a Forwarded parameter value unless it is a token.
+func forwardedValue(value string) string {
+	for _, c := range value {
+		if !(c == '-' || c == '.' || c == '_' || c == '~' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return strconv.Quote(value)
+		}
+	}
+	return value
+}
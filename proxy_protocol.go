@@ -0,0 +1,220 @@
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// proxyV2Signature starts PROXY protocol version 2 headers.
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// ProxyProtocolOptions configures the PROXY protocol listener.
+type ProxyProtocolOptions struct {
+	// Trusted lists the load balancers allowed to send PROXY protocol
+	// headers. Headers from other peers are not parsed, so that clients
+	// connecting directly cannot spoof their address. It is required.
+	Trusted *TrustedProxies
+	// Required rejects trusted connections without a header.
+	Required bool
+	// HeaderTimeout is how long to wait for the header. It defaults to 5
+	// seconds.
+	HeaderTimeout time.Duration
+}
+
+// ProxyProtocol makes the router's listeners parse HAProxy PROXY protocol
+// version 1 and 2 headers, so that the RemoteAddr of requests is the
+// original client address, as seen by ClientIP, logging and rate limiting,
+// rather than that of the load balancer. It applies to the listeners
+// served after it is called. It returns an error if no trusted load
+// balancers are given.
+func (r *Router) ProxyProtocol(opts ProxyProtocolOptions) error {
+	if opts.Trusted == nil {
+		return errMissingProxyProtocolTrust
+	}
+	r.server.mu.Lock()
+	defer r.server.mu.Unlock()
+	r.server.proxyProtocol = &opts
+	return nil
+}
+
+// ProxyProtocolListener wraps the listener to parse PROXY protocol
+// headers, for servers not started by the router. It returns an error if
+// no trusted load balancers are given.
+func ProxyProtocolListener(l net.Listener, opts ProxyProtocolOptions) (net.Listener, error) {
+	if opts.Trusted == nil {
+		return nil, errMissingProxyProtocolTrust
+	}
+	if opts.HeaderTimeout <= 0 {
+		opts.HeaderTimeout = 5 * time.Second
+	}
+	return &proxyListener{Listener: l, opts: opts}, nil
+}
+
+// errMissingProxyProtocolTrust is returned for options without trusted
+// load balancers.
+var errMissingProxyProtocolTrust = errors.New("router: PROXY protocol requires trusted load balancers")
+
+// proxyListener accepts connections starting with a PROXY protocol header.
+type proxyListener struct {
+	net.Listener
+	opts ProxyProtocolOptions
+}
+
+// Accept accepts a connection. Its header is read by the connection's
+// first Read or RemoteAddr call, so that slow clients do not block Accept.
+func (l *proxyListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &proxyConn{Conn: conn, opts: l.opts, reader: bufio.NewReader(conn)}, nil
+}
+
+// proxyConn is a connection whose remote address is read from its PROXY
+// protocol header.
+type proxyConn struct {
+	net.Conn
+	opts   ProxyProtocolOptions
+	reader *bufio.Reader
+	once   sync.Once
+	source net.Addr
+	err    error
+}
+
+// Read reads after the header.
+func (c *proxyConn) Read(b []byte) (int, error) {
+	c.once.Do(c.readHeader)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(b)
+}
+
+// RemoteAddr returns the client address of the header, or the address of
+// the peer if there is none.
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.source != nil {
+		return c.source
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// readHeader reads the header, if the peer is trusted to send one.
+func (c *proxyConn) readHeader() {
+	host, _, _ := net.SplitHostPort(c.Conn.RemoteAddr().String())
+	if !c.opts.Trusted.Contains(net.ParseIP(host)) {
+		return
+	}
+
+	c.Conn.SetReadDeadline(time.Now().Add(c.opts.HeaderTimeout))
+	defer c.Conn.SetReadDeadline(time.Time{})
+	var found bool
+	c.source, found, c.err = readProxyHeader(c.reader)
+	if c.err == nil && !found && c.opts.Required {
+		c.err = errors.New("router: missing PROXY protocol header")
+	}
+	if c.err != nil {
+		c.Conn.Close()
+	}
+}
+
+// readProxyHeader reads a version 1 or 2 header. It returns the source
+// address, nil for local or unknown connections, and whether there was a
+// header.
+func readProxyHeader(r *bufio.Reader) (net.Addr, bool, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return nil, false, err
+	}
+	switch first[0] {
+	case 'P':
+		if b, err := r.Peek(6); err != nil || string(b) != "PROXY " {
+			return nil, false, nil
+		}
+		addr, err := readProxyV1(r)
+		return addr, true, err
+	case '\r':
+		if b, err := r.Peek(len(proxyV2Signature)); err != nil || !bytes.Equal(b, proxyV2Signature) {
+			return nil, false, nil
+		}
+		addr, err := readProxyV2(r)
+		return addr, true, err
+	}
+	return nil, false, nil
+}
+
+// readProxyV1 reads a version 1 header, such as
+// "PROXY TCP4 203.0.113.7 10.0.0.1 56324 443\r\n".
+func readProxyV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) < 107 {
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, c)
+		if c == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errors.New("router: invalid PROXY protocol header")
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("router: invalid PROXY protocol header %q", line)
+	}
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if ip == nil || err != nil || (fields[1] == "TCP4") != (ip.To4() != nil) {
+		return nil, fmt.Errorf("router: invalid PROXY protocol header %q", line)
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readProxyV2 reads a version 2 header.
+func readProxyV2(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if header[12]>>4 != 2 {
+		return nil, errors.New("router: unsupported PROXY protocol version")
+	}
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	// Local connections, such as health checks, keep the peer address
+	if header[12]&0x0f == 0 {
+		return nil, nil
+	}
+	switch header[13] {
+	case 0x11, 0x12:
+		if len(payload) < 12 {
+			return nil, errors.New("router: invalid PROXY protocol header")
+		}
+		return &net.TCPAddr{IP: net.IP(append([]byte(nil), payload[0:4]...)), Port: int(binary.BigEndian.Uint16(payload[8:10]))}, nil
+	case 0x21, 0x22:
+		if len(payload) < 36 {
+			return nil, errors.New("router: invalid PROXY protocol header")
+		}
+		return &net.TCPAddr{IP: net.IP(append([]byte(nil), payload[0:16]...)), Port: int(binary.BigEndian.Uint16(payload[32:34]))}, nil
+	}
+	return nil, nil
+}
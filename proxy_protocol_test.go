@@ -0,0 +1,137 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProxyProtocol(t *testing.T) {
+	router := NewRouter()
+	trusted, _ := NewTrustedProxies("127.0.0.1")
+	if err := router.ProxyProtocol(ProxyProtocolOptions{Trusted: trusted, HeaderTimeout: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	router.MustAddRoute("GET", "/ip", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, req.RemoteAddr)
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go router.Serve(l)
+	defer router.Shutdown(context.Background())
+
+	v2 := func(cmd byte, family byte, addr []byte) string {
+		header := append([]byte(nil), proxyV2Signature...)
+		header = append(header, 0x20|cmd, family, 0, 0)
+		binary.BigEndian.PutUint16(header[14:], uint16(len(addr)))
+		return string(append(header, addr...))
+	}
+	ipv4 := []byte{203, 0, 113, 7, 10, 0, 0, 1, 0xdc, 0x04, 0x01, 0xbb}
+
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"Version 1", "PROXY TCP4 203.0.113.7 10.0.0.1 56324 443\r\n", "203.0.113.7:56324"},
+		{"Version 1 IPv6", "PROXY TCP6 2001:db8::1 2001:db8::2 4000 443\r\n", "[2001:db8::1]:4000"},
+		{"Version 1 unknown", "PROXY UNKNOWN\r\n", "127.0.0.1:"},
+		{"Version 2", v2(1, 0x11, ipv4), "203.0.113.7:56324"},
+		{"Version 2 local", v2(0, 0x00, nil), "127.0.0.1:"},
+		{"No header", "", "127.0.0.1:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", l.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			io.WriteString(conn, tt.header+"GET /ip HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n")
+			resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+
+			// Check the response status code
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("Expected status code %d, but got %d", http.StatusOK, resp.StatusCode)
+			}
+
+			// Check the remote address seen by the handler
+			if !strings.HasPrefix(string(body), tt.want) {
+				t.Errorf("Expected remote address %q, but got %q", tt.want, body)
+			}
+		})
+	}
+
+	t.Run("Invalid header", func(t *testing.T) {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		io.WriteString(conn, "PROXY TCP4 nonsense\r\nGET /ip HTTP/1.1\r\nHost: example.com\r\n\r\n")
+
+		// Check that the connection is closed without a response
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		if n, err := conn.Read(make([]byte, 1)); err == nil {
+			t.Errorf("Expected the connection to be closed, but read %d bytes", n)
+		}
+	})
+}
+
+func TestProxyProtocolUntrustedPeer(t *testing.T) {
+	trusted, _ := NewTrustedProxies("10.0.0.0/8")
+	client, server := net.Pipe()
+	defer client.Close()
+	conn := &proxyConn{Conn: &addrConn{Conn: server, remote: &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5000}}, opts: ProxyProtocolOptions{Trusted: trusted, Required: true, HeaderTimeout: time.Second}}
+	conn.reader = bufio.NewReader(conn.Conn)
+
+	// Check that headers from untrusted peers are not parsed
+	go io.WriteString(client, "PROXY TCP4 203.0.113.7 10.0.0.1 56324 443\r\n")
+	if addr := conn.RemoteAddr().String(); addr != "192.0.2.1:5000" {
+		t.Errorf("Expected remote address %q, but got %q", "192.0.2.1:5000", addr)
+	}
+	line, _ := conn.reader.ReadString('\n')
+	if !strings.HasPrefix(line, "PROXY") {
+		t.Errorf("Expected the header to be passed through, but got %q", line)
+	}
+}
+
+func TestProxyProtocolWithoutTrust(t *testing.T) {
+	// Check that the load balancers must be listed
+	if err := NewRouter().ProxyProtocol(ProxyProtocolOptions{}); err == nil {
+		t.Errorf("Expected an error without trusted load balancers")
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if _, err := ProxyProtocolListener(l, ProxyProtocolOptions{}); err == nil {
+		t.Errorf("Expected an error without trusted load balancers")
+	}
+}
+
+// addrConn is a connection with a fixed remote address.
+type addrConn struct {
+	net.Conn
+	remote net.Addr
+}
+
+// RemoteAddr returns the fixed remote address.
+func (c *addrConn) RemoteAddr() net.Addr {
+	return c.remote
+}
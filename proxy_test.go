@@ -0,0 +1,132 @@
+package router
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("upstream " + req.URL.Path))
+	}))
+	defer upstream.Close()
+
+	target, err := url.Parse(upstream.URL + "/v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter()
+	router.Proxy("GET", "/users/*path", target)
+
+	t.Run("Forwarded request", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/users/42", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		expectedBody := "upstream /v1/users/42"
+		body, _ := io.ReadAll(rr.Body)
+		if string(body) != expectedBody {
+			t.Errorf("Expected response body %q, but got %q", expectedBody, body)
+		}
+	})
+
+	t.Run("Unreachable upstream", func(t *testing.T) {
+		unreachable := NewRouter()
+		unreachable.Proxy("GET", "/", &url.URL{Scheme: "http", Host: "127.0.0.1:1"})
+
+		req, err := http.NewRequest("GET", "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		unreachable.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadGateway {
+			t.Errorf("Expected status code %d, but got %d", http.StatusBadGateway, rr.Code)
+		}
+	})
+}
+
+func TestProxyForwardedHeaders(t *testing.T) {
+	var received http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received = req.Header.Clone()
+	}))
+	defer upstream.Close()
+	target, _ := url.Parse(upstream.URL)
+
+	router := NewRouter()
+	proxies, _ := NewTrustedProxies("10.0.0.0/8")
+	router.TrustForwardedHeaders(proxies)
+	router.Proxy("GET", "/api", target)
+
+	tests := []struct {
+		name      string
+		remote    string
+		headers   map[string]string
+		xff       string
+		proto     string
+		host      string
+		forwarded []string
+	}{
+		{"Direct client", "203.0.113.7:4000", map[string]string{"X-Forwarded-For": "1.2.3.4", "X-Forwarded-Host": "evil.example", "Forwarded": "for=1.2.3.4"},
+			"203.0.113.7", "http", "shop.example.com", []string{"for=203.0.113.7;host=shop.example.com;proto=http"}},
+		{"Trusted proxy", "10.0.0.2:4000", map[string]string{"X-Forwarded-For": "203.0.113.7", "X-Forwarded-Proto": "https", "Forwarded": "for=203.0.113.7;proto=https"},
+			"203.0.113.7, 10.0.0.2", "https", "shop.example.com", []string{"for=203.0.113.7;proto=https", "for=10.0.0.2;host=shop.example.com;proto=http"}},
+		{"IPv6 client", "[2001:db8::1]:4000", nil,
+			"2001:db8::1", "http", "shop.example.com", []string{`for="[2001:db8::1]";host=shop.example.com;proto=http`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://shop.example.com/api", nil)
+			req.RemoteAddr = tt.remote
+			req.Header.Set("Connection", "X-Secret")
+			req.Header.Set("X-Secret", "hop")
+			req.Header.Set("Keep-Alive", "timeout=5")
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+			}
+
+			// Check the forwarding headers received by the upstream
+			if xff := received.Get("X-Forwarded-For"); xff != tt.xff {
+				t.Errorf("Expected X-Forwarded-For %q, but got %q", tt.xff, xff)
+			}
+			if proto := received.Get("X-Forwarded-Proto"); proto != tt.proto {
+				t.Errorf("Expected X-Forwarded-Proto %q, but got %q", tt.proto, proto)
+			}
+			if host := received.Get("X-Forwarded-Host"); host != tt.host {
+				t.Errorf("Expected X-Forwarded-Host %q, but got %q", tt.host, host)
+			}
+			if forwarded := received.Values("Forwarded"); fmt.Sprint(forwarded) != fmt.Sprint(tt.forwarded) {
+				t.Errorf("Expected Forwarded %q, but got %q", tt.forwarded, forwarded)
+			}
+
+			// Check that hop-by-hop headers are stripped and the correlation
+			// ID is propagated
+			if received.Get("X-Secret") != "" || received.Get("Keep-Alive") != "" {
+				t.Errorf("Expected hop-by-hop headers to be stripped, but got %v", received)
+			}
+			if received.Get("X-Correlation-ID") == "" {
+				t.Errorf("Expected a correlation ID")
+			}
+		})
+	}
+}
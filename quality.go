@@ -0,0 +1,60 @@
+package router
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// qualityValue is a single entry of an Accept-style header.
+type qualityValue struct {
+	value string
+	q     float64
+}
+
+// parseQualityValues parses an Accept-style header such as
+// "en-US,en;q=0.9,*;q=0.1" into its values ordered by descending quality.
+// Values with a quality of zero are dropped.
+func parseQualityValues(header string) []qualityValue {
+	var values []qualityValue
+	for _, value := range parseQualityHeader(header) {
+		if value.q > 0 {
+			values = append(values, value)
+		}
+	}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].q > values[j].q
+	})
+	return values
+}
+
+// parseQualityHeader parses an Accept-style header into its values in
+// header order, including those with a quality of zero.
+func parseQualityHeader(header string) []qualityValue {
+	var values []qualityValue
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		value := qualityValue{value: part, q: 1}
+		if i := strings.IndexByte(part, ';'); i >= 0 {
+			value.value = strings.TrimSpace(part[:i])
+			for _, param := range strings.Split(part[i+1:], ";") {
+				param = strings.TrimSpace(param)
+				if !strings.HasPrefix(param, "q=") {
+					continue
+				}
+				q, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil || q < 0 || q > 1 {
+					q = 0
+				}
+				value.q = q
+			}
+		}
+		values = append(values, value)
+	}
+	return values
+}
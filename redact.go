@@ -0,0 +1,246 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// redacted replaces the values hidden by a Redactor.
+const redacted = "[REDACTED]"
+
+// Redactor hides personal data and secrets from what the router logs. The
+// same redactor is shared by the dump middleware and the router's own
+// logging, and can be configured per deployment through Config.
+type Redactor struct {
+	// Headers lists the headers whose values are hidden.
+	Headers []string `json:"headers"`
+	// Cookies lists the cookies whose values are hidden in the Cookie and
+	// Set-Cookie headers.
+	Cookies []string `json:"cookies"`
+	// Fields lists the JSON object keys and query parameters whose values
+	// are hidden wherever they appear, compared case-insensitively.
+	Fields []string `json:"fields"`
+	// Paths lists dotted JSON paths whose values are hidden, e.g.
+	// "user.email" or "cards.*.number" where "*" matches any key or index.
+	Paths []string `json:"paths"`
+	// Patterns lists regular expressions whose matches are hidden in
+	// header values, bodies and messages, e.g. card numbers or emails.
+	Patterns []string `json:"patterns"`
+
+	once     sync.Once
+	err      error
+	fields   map[string]bool
+	paths    [][]string
+	patterns []*regexp.Regexp
+	rawField *regexp.Regexp
+}
+
+// DefaultRedactor returns a redactor hiding credentials: the Authorization,
+// Proxy-Authorization, Cookie, Set-Cookie and X-Api-Key headers and the
+// password, token, secret, access_token, refresh_token and api_key fields.
+func DefaultRedactor() *Redactor {
+	return &Redactor{
+		Headers: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"},
+		Fields:  []string{"password", "token", "secret", "access_token", "refresh_token", "api_key"},
+	}
+}
+
+// SetRedactor sets the redactor used by the router. It returns an error if
+// one of its patterns is invalid.
+func (r *Router) SetRedactor(rd *Redactor) error {
+	if err := rd.compile(); err != nil {
+		return err
+	}
+	r.redactor.Store(rd)
+	return nil
+}
+
+// Redactor returns the redactor used by the router, DefaultRedactor if none
+// has been set.
+func (r *Router) Redactor() *Redactor {
+	if rd, ok := r.redactor.Load().(*Redactor); ok {
+		return rd
+	}
+	return defaultRedactor
+}
+
+// defaultRedactor is used by routers without a redactor.
+var defaultRedactor = DefaultRedactor()
+
+// compile prepares the redactor for use.
+func (rd *Redactor) compile() error {
+	rd.once.Do(func() {
+		rd.fields = make(map[string]bool, len(rd.Fields))
+		for _, field := range rd.Fields {
+			rd.fields[strings.ToLower(field)] = true
+		}
+		for _, path := range rd.Paths {
+			rd.paths = append(rd.paths, strings.Split(path, "."))
+		}
+		for _, pattern := range rd.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				rd.err = fmt.Errorf("router: invalid redaction pattern %q: %v", pattern, err)
+				return
+			}
+			rd.patterns = append(rd.patterns, re)
+		}
+		if len(rd.Fields) > 0 {
+			quoted := make([]string, len(rd.Fields))
+			for i, field := range rd.Fields {
+				quoted[i] = regexp.QuoteMeta(field)
+			}
+			rd.rawField = regexp.MustCompile(`(?i)("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)"(?:[^"\\]|\\.)*"?`)
+		}
+	})
+	return rd.err
+}
+
+// String hides the matches of the patterns in s.
+func (rd *Redactor) String(s string) string {
+	if rd.compile() != nil {
+		return redacted
+	}
+	for _, re := range rd.patterns {
+		s = re.ReplaceAllString(s, redacted)
+	}
+	return s
+}
+
+// Header returns a copy of the header with the values of the headers and
+// cookies hidden.
+func (rd *Redactor) Header(header http.Header) http.Header {
+	header = header.Clone()
+	for _, name := range rd.Headers {
+		if len(header.Values(name)) > 0 {
+			header.Set(name, redacted)
+		}
+	}
+	if len(rd.Cookies) > 0 {
+		for _, name := range []string{"Cookie", "Set-Cookie"} {
+			values := header.Values(name)
+			for i, v := range values {
+				values[i] = rd.cookies(v)
+			}
+		}
+	}
+	for name, values := range header {
+		for i, v := range values {
+			values[i] = rd.String(v)
+		}
+		header[name] = values
+	}
+	return header
+}
+
+// cookies hides the values of the cookies in a Cookie or Set-Cookie header
+// value.
+func (rd *Redactor) cookies(value string) string {
+	pairs := strings.Split(value, ";")
+	for i, pair := range pairs {
+		j := strings.IndexByte(pair, '=')
+		if j < 0 {
+			continue
+		}
+		name := strings.TrimSpace(pair[:j])
+		for _, cookie := range rd.Cookies {
+			if name == cookie {
+				pairs[i] = pair[:j+1] + redacted
+				break
+			}
+		}
+	}
+	return strings.Join(pairs, ";")
+}
+
+// Query returns a copy of the query parameters with the values of the
+// fields hidden.
+func (rd *Redactor) Query(values url.Values) url.Values {
+	rd.compile()
+	out := make(url.Values, len(values))
+	for name, vs := range values {
+		vs = append([]string(nil), vs...)
+		for i, v := range vs {
+			if rd.fields[strings.ToLower(name)] {
+				vs[i] = redacted
+			} else {
+				vs[i] = rd.String(v)
+			}
+		}
+		out[name] = vs
+	}
+	return out
+}
+
+// Body hides the values of the fields and paths in a JSON body and the
+// matches of the patterns in any body. Bodies that cannot be decoded as
+// JSON, such as truncated ones, have the string values of the fields
+// hidden textually instead.
+func (rd *Redactor) Body(body []byte) []byte {
+	if rd.compile() != nil {
+		return []byte(redacted)
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		if rd.rawField != nil {
+			body = rd.rawField.ReplaceAll(body, []byte(`$1"`+redacted+`"`))
+		}
+		return []byte(rd.String(string(body)))
+	}
+	v = rd.value(v, nil)
+	out, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// value hides the fields, paths and patterns of a decoded JSON value found
+// at the path.
+func (rd *Redactor) value(v interface{}, path []string) interface{} {
+	if rd.matchesPath(path) {
+		return redacted
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if rd.fields[strings.ToLower(key)] {
+				v[key] = redacted
+			} else {
+				v[key] = rd.value(value, append(path, key))
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = rd.value(value, append(path, fmt.Sprint(i)))
+		}
+	case string:
+		return rd.String(v)
+	}
+	return v
+}
+
+// matchesPath reports whether the path is one of the redacted paths.
+func (rd *Redactor) matchesPath(path []string) bool {
+	if len(path) == 0 {
+		return false
+	}
+next:
+	for _, p := range rd.paths {
+		if len(p) != len(path) {
+			continue
+		}
+		for i := range p {
+			if p[i] != "*" && p[i] != path[i] {
+				continue next
+			}
+		}
+		return true
+	}
+	return false
+}
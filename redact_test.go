@@ -0,0 +1,91 @@
+package router
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	rd := &Redactor{
+		Headers:  []string{"Authorization"},
+		Cookies:  []string{"session"},
+		Fields:   []string{"password"},
+		Paths:    []string{"user.email", "cards.*.number"},
+		Patterns: []string{`\d{3}-\d{2}-\d{4}`},
+	}
+
+	t.Run("Headers and cookies", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Authorization", "Bearer s3cret")
+		header.Set("Cookie", "session=abc123; theme=dark")
+		header.Set("X-Ssn", "123-45-6789")
+
+		got := rd.Header(header)
+		if got.Get("Authorization") != redacted {
+			t.Errorf("Expected Authorization %q, but got %q", redacted, got.Get("Authorization"))
+		}
+		if expected := "session=" + redacted + "; theme=dark"; got.Get("Cookie") != expected {
+			t.Errorf("Expected Cookie %q, but got %q", expected, got.Get("Cookie"))
+		}
+		if got.Get("X-Ssn") != redacted {
+			t.Errorf("Expected X-Ssn %q, but got %q", redacted, got.Get("X-Ssn"))
+		}
+
+		// The original header must not be modified
+		if header.Get("Authorization") != "Bearer s3cret" {
+			t.Errorf("Expected the original header to be kept, but got %q", header.Get("Authorization"))
+		}
+	})
+
+	t.Run("JSON body", func(t *testing.T) {
+		body := `{"user":{"email":"a@b.c","name":"gopher","password":"hunter2"},"cards":[{"number":"4111","brand":"visa"}],"note":"ssn 123-45-6789"}`
+		got := string(rd.Body([]byte(body)))
+		for _, secret := range []string{"a@b.c", "hunter2", "4111", "123-45-6789"} {
+			if strings.Contains(got, secret) {
+				t.Errorf("Expected %q to be redacted, but got %s", secret, got)
+			}
+		}
+		for _, kept := range []string{"gopher", "visa"} {
+			if !strings.Contains(got, kept) {
+				t.Errorf("Expected %q to be kept, but got %s", kept, got)
+			}
+		}
+	})
+
+	t.Run("Truncated body", func(t *testing.T) {
+		got := string(rd.Body([]byte(`{"password": "hunter2", "name": "gop`)))
+		if strings.Contains(got, "hunter2") {
+			t.Errorf("Expected the password to be redacted, but got %s", got)
+		}
+	})
+
+	t.Run("Query parameters", func(t *testing.T) {
+		got := rd.Query(url.Values{"password": {"hunter2"}, "q": {"go"}})
+		if got.Get("password") != redacted || got.Get("q") != "go" {
+			t.Errorf("Expected only the password to be redacted, but got %v", got)
+		}
+	})
+
+	t.Run("Invalid pattern", func(t *testing.T) {
+		router := NewRouter()
+		if err := router.SetRedactor(&Redactor{Patterns: []string{"("}}); err == nil {
+			t.Errorf("Expected an error for an invalid pattern")
+		}
+		cfg := &Config{Redaction: &Redactor{Patterns: []string{"("}}}
+		if err := router.LoadConfig(cfg); err == nil {
+			t.Errorf("Expected an invalid configuration error")
+		}
+	})
+
+	t.Run("Configured redactor", func(t *testing.T) {
+		router := NewRouter()
+		if err := router.LoadConfig(&Config{Redaction: rd}); err != nil {
+			t.Fatal(err)
+		}
+		if router.Redactor() != rd {
+			t.Errorf("Expected the configured redactor to be used")
+		}
+	})
+}
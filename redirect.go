@@ -0,0 +1,40 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Redirect adds a route redirecting requests matching the from pattern to
+// the to URL with the given status code. Path parameters captured by the
+// pattern are interpolated into the target, e.g.
+// Redirect("GET", "/old/:id", "/new/:id", http.StatusMovedPermanently).
+// It returns an error if the route cannot be added, see AddRoute.
+func (r *Router) Redirect(method string, from string, to string, code int) (*Route, error) {
+	return r.AddRoute(method, from, r.redirectHandler(to, code))
+}
+
+// redirectHandler returns a handler redirecting to the target URL.
+func (r *Router) redirectHandler(to string, code int) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		target, ok := redirectTarget(expandWith(to, r.GetPathParams(req), true))
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		if req.URL.RawQuery != "" && !strings.Contains(target, "?") {
+			target += "?" + req.URL.RawQuery
+		}
+		http.Redirect(w, req, target, code)
+	}
+}
+
+// redirectTarget checks a redirect target built from request values. It
+// returns false for scheme-relative targets such as "//evil.com" or
+// "/\evil.com", which browsers follow to another host.
+func redirectTarget(target string) (string, bool) {
+	if strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return "", false
+	}
+	return target, true
+}
@@ -0,0 +1,51 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirect(t *testing.T) {
+	router := NewRouter()
+
+	router.Redirect("GET", "/old", "/new", http.StatusMovedPermanently)
+	router.Redirect("GET", "/users/:id/profile", "/profiles/:id", http.StatusFound)
+	router.Redirect("GET", "/o/*rest", "/*rest", http.StatusMovedPermanently)
+	router.Redirect("GET", "/u/:name", "/users/:name", http.StatusMovedPermanently)
+
+	tests := []struct {
+		name             string
+		path             string
+		expectedCode     int
+		expectedLocation string
+	}{
+		{"Static redirect", "/old", http.StatusMovedPermanently, "/new"},
+		{"Parameter interpolation", "/users/42/profile", http.StatusFound, "/profiles/42"},
+		{"Query preserved", "/old?page=2", http.StatusMovedPermanently, "/new?page=2"},
+		{"Wildcard interpolation", "/o/docs/a b", http.StatusMovedPermanently, "/docs/a%20b"},
+		{"Scheme-relative target", "/o//evil.com/x", http.StatusBadRequest, ""},
+		{"Escaped scheme-relative target", "/o/%2Fevil.com", http.StatusBadRequest, ""},
+		{"Escaped backslash", "/o/%5Cevil.com", http.StatusMovedPermanently, "/%5Cevil.com"},
+		{"Query injection", "/u/a%3Fx=1", http.StatusMovedPermanently, "/users/a%3Fx=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, but got %d", tt.expectedCode, rr.Code)
+			}
+			if location := rr.Header().Get("Location"); location != tt.expectedLocation {
+				t.Errorf("Expected location %q, but got %q", tt.expectedLocation, location)
+			}
+		})
+	}
+}
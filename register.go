@@ -0,0 +1,143 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrDuplicateRoute is returned by AddRoute for a route whose method and
+// path are already taken by an unconditional route. Routes sharing a path
+// but with matchers or content type constraints are registered before the
+// unconditional route serving the other requests.
+var ErrDuplicateRoute = errors.New("router: duplicate route")
+
+// MethodAny is the method of the fallback routes serving any method that
+// has no route of its own for the path, see Any.
+const MethodAny = "*"
+
+// validateRoute checks that a route can be added for the method and path
+// next to the routes already registered for them.
+func validateRoute(method string, path string, existing []*Route) error {
+	if !isToken(method) {
+		return fmt.Errorf("router: invalid method %q", method)
+	}
+	if err := validatePattern(path); err != nil {
+		return err
+	}
+	for _, route := range existing {
+		if !route.conditional() {
+			return fmt.Errorf("%w %s %s", ErrDuplicateRoute, method, path)
+		}
+	}
+	return nil
+}
+
+// routeKey is the method and path of a route.
+type routeKey struct {
+	method string
+	path   string
+}
+
+// validateRoutes checks that all the routes can be added, so that helpers
+// adding several routes add either all or none of them.
+func (r *Router) validateRoutes(keys ...routeKey) error {
+	for i, key := range keys {
+		if err := validateRoute(key.method, key.path, r.routes.routes[key.method][key.path]); err != nil {
+			return err
+		}
+		for _, other := range keys[:i] {
+			if other == key {
+				return fmt.Errorf("%w %s %s", ErrDuplicateRoute, key.method, key.path)
+			}
+		}
+	}
+	return nil
+}
+
+// isToken reports whether s is a non-empty HTTP token, as methods are.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c < 0x80 && c > ' ' && !isSeparator(byte(c)):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isSeparator reports whether c is a separator, which tokens exclude.
+func isSeparator(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '@', ',', ';', ':', '\\', '"', '/', '[', ']', '?', '=', '{', '}', 0x7f:
+		return true
+	}
+	return false
+}
+
+// GET adds a route for GET requests, see AddRoute.
+func (r *Router) GET(path string, handler http.HandlerFunc) (*Route, error) {
+	return r.AddRoute(http.MethodGet, path, handler)
+}
+
+// POST adds a route for POST requests, see AddRoute.
+func (r *Router) POST(path string, handler http.HandlerFunc) (*Route, error) {
+	return r.AddRoute(http.MethodPost, path, handler)
+}
+
+// PUT adds a route for PUT requests, see AddRoute.
+func (r *Router) PUT(path string, handler http.HandlerFunc) (*Route, error) {
+	return r.AddRoute(http.MethodPut, path, handler)
+}
+
+// PATCH adds a route for PATCH requests, see AddRoute.
+func (r *Router) PATCH(path string, handler http.HandlerFunc) (*Route, error) {
+	return r.AddRoute(http.MethodPatch, path, handler)
+}
+
+// DELETE adds a route for DELETE requests, see AddRoute.
+func (r *Router) DELETE(path string, handler http.HandlerFunc) (*Route, error) {
+	return r.AddRoute(http.MethodDelete, path, handler)
+}
+
+// MustGET is like GET but panics if the route cannot be added.
+func (r *Router) MustGET(path string, handler http.HandlerFunc) *Route {
+	return r.MustAddRoute(http.MethodGet, path, handler)
+}
+
+// MustPOST is like POST but panics if the route cannot be added.
+func (r *Router) MustPOST(path string, handler http.HandlerFunc) *Route {
+	return r.MustAddRoute(http.MethodPost, path, handler)
+}
+
+// MustPUT is like PUT but panics if the route cannot be added.
+func (r *Router) MustPUT(path string, handler http.HandlerFunc) *Route {
+	return r.MustAddRoute(http.MethodPut, path, handler)
+}
+
+// MustPATCH is like PATCH but panics if the route cannot be added.
+func (r *Router) MustPATCH(path string, handler http.HandlerFunc) *Route {
+	return r.MustAddRoute(http.MethodPatch, path, handler)
+}
+
+// MustDELETE is like DELETE but panics if the route cannot be added.
+func (r *Router) MustDELETE(path string, handler http.HandlerFunc) *Route {
+	return r.MustAddRoute(http.MethodDelete, path, handler)
+}
+
+// Any adds a fallback route for the requests to the path whose method has
+// no route of its own, e.g. for proxy catch-alls or WebDAV endpoints. Use
+// GetRoute or req.Method to tell the methods apart.
+func (r *Router) Any(path string, handler http.HandlerFunc) (*Route, error) {
+	return r.AddRoute(MethodAny, path, handler)
+}
+
+// MustAny is like Any but panics if the route cannot be added.
+func (r *Router) MustAny(path string, handler http.HandlerFunc) *Route {
+	return r.MustAddRoute(MethodAny, path, handler)
+}
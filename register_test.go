@@ -0,0 +1,130 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestAddRouteErrors(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {}
+
+	t.Run("Invalid routes", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			method string
+			path   string
+		}{
+			{"Empty method", "", "/users"},
+			{"Invalid method", "GET /", "/users"},
+			{"Relative path", "GET", "users"},
+			{"Unnamed parameter", "GET", "/users/:"},
+			{"Repeated parameter", "GET", "/users/:id/friends/:id"},
+			{"Wildcard before segments", "GET", "/files/*path/raw"},
+			{"Optional segment before required", "GET", "/reports/:year?/summary"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				router := NewRouter()
+				route, err := router.AddRoute(tt.method, tt.path, handler)
+
+				// Check that the route was rejected
+				if err == nil || route != nil {
+					t.Errorf("Expected an error for %s %s, but got nil", tt.method, tt.path)
+				}
+			})
+		}
+	})
+
+	t.Run("Duplicate route", func(t *testing.T) {
+		router := NewRouter()
+		router.MustGET("/feed", handler).Header("X-Beta", "1")
+		router.MustGET("/feed", handler)
+		_, err := router.GET("/feed", handler)
+
+		// Check that only the second unconditional route was rejected
+		if !errors.Is(err, ErrDuplicateRoute) {
+			t.Errorf("Expected error %v, but got %v", ErrDuplicateRoute, err)
+		}
+		if n := len(router.routes.routes["GET"]["/feed"]); n != 2 {
+			t.Errorf("Expected %d routes, but got %d", 2, n)
+		}
+	})
+
+	t.Run("Helpers", func(t *testing.T) {
+		router := NewRouter()
+		router.MustPOST("/graphql", handler)
+		router.MustGET("/old", handler)
+
+		// Check that helpers return the errors instead of panicking
+		if _, err := router.Redirect("GET", "/old", "/new", http.StatusFound); !errors.Is(err, ErrDuplicateRoute) {
+			t.Errorf("Expected error %v, but got %v", ErrDuplicateRoute, err)
+		}
+		if _, err := router.Proxy("GET", "/files/*path/raw", &url.URL{Scheme: "http", Host: "localhost"}); err == nil {
+			t.Error("Expected an error for the invalid path, but got nil")
+		}
+
+		// Check that helpers adding several routes add none on error
+		if _, err := router.GraphQL("/graphql", nil, GraphQLOptions{}); !errors.Is(err, ErrDuplicateRoute) {
+			t.Errorf("Expected error %v, but got %v", ErrDuplicateRoute, err)
+		}
+		if n := len(router.routes.routes["GET"]["/graphql"]); n != 0 {
+			t.Errorf("Expected %d routes, but got %d", 0, n)
+		}
+	})
+
+	t.Run("Must variants", func(t *testing.T) {
+		router := NewRouter()
+		router.MustPOST("/users", handler)
+		defer func() {
+			// Check that the duplicate route panicked
+			if recover() == nil {
+				t.Error("Expected a panic for the duplicate route")
+			}
+		}()
+		router.MustPOST("/users", handler)
+	})
+}
+
+func TestMethodFallback(t *testing.T) {
+	router := NewRouter()
+	router.MustGET("/dav/:name", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("get"))
+	})
+	router.MustAny("/dav/:name", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("fallback " + req.Method))
+	})
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Registered method", "GET", "/dav/a", http.StatusOK, "get"},
+		{"Other method", "PROPFIND", "/dav/a", http.StatusOK, "fallback PROPFIND"},
+		{"Other path", "PROPFIND", "/files/a", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, but got %d", tt.expectedStatus, rr.Code)
+			}
+			if tt.expectedBody != "" && rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected response body %q, but got %q", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package router
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ReloadListener is called after each configuration reload with the path of
+// the configuration file and the error that prevented the reload, if any.
+type ReloadListener func(path string, err error)
+
+// OnReload subscribes a listener to configuration reloads.
+func (r *Router) OnReload(listener ReloadListener) {
+	r.events.reload = append(r.events.reload, listener)
+}
+
+// ReloadConfigFile reads the configuration file and atomically swaps in its
+// routes. The current routes are kept if the file is invalid.
+func (r *Router) ReloadConfigFile(path string) error {
+	err := r.LoadConfigFile(path)
+	if err != nil {
+		r.logger.Errorf("Failed to reload configuration %s: %v", path, err)
+	}
+	for _, listener := range r.events.reload {
+		listener(path, err)
+	}
+	return err
+}
+
+// WatchConfigFile polls the configuration file at the given interval and
+// reloads it whenever it changes. Call the returned function to stop
+// watching.
+func (r *Router) WatchConfigFile(path string, interval time.Duration) (stop func()) {
+	var modTime time.Time
+	var size int64
+	if info, err := os.Stat(path); err == nil {
+		modTime, size = info.ModTime(), info.Size()
+	}
+
+	return r.watch(func(done <-chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				r.logger.Errorf("Failed to stat configuration %s: %v", path, err)
+				continue
+			}
+			if info.ModTime().Equal(modTime) && info.Size() == size {
+				continue
+			}
+			modTime, size = info.ModTime(), info.Size()
+			r.ReloadConfigFile(path)
+		}
+	})
+}
+
+// ReloadOnSignal reloads the configuration file whenever the process
+// receives one of the signals, SIGHUP by default. Call the returned
+// function to stop listening.
+func (r *Router) ReloadOnSignal(path string, signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+
+	// Subscribe before returning, so that no signal is missed meanwhile
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, signals...)
+	return r.watch(func(done <-chan struct{}) {
+		defer signal.Stop(c)
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-c:
+				r.ReloadConfigFile(path)
+			}
+		}
+	})
+}
+
+// watch runs the watcher in a goroutine and returns a function stopping it.
+func (r *Router) watch(watcher func(done <-chan struct{})) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		watcher(done)
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	router := NewRouter()
+	router.RegisterHandler("v1", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("v1"))
+	})
+	router.RegisterHandler("v2", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("v2"))
+	})
+
+	reloads := make(chan error, 10)
+	router.OnReload(func(path string, err error) {
+		reloads <- err
+	})
+
+	path := filepath.Join(t.TempDir(), "routes.json")
+	writeConfig := func(handler string) {
+		config := `{"routes": [{"method": "GET", "path": "/version", "handler": "` + handler + `"}]}`
+		if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	serve := func() string {
+		req, err := http.NewRequest("GET", "/version", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Body.String()
+	}
+
+	writeConfig("v1")
+	if err := router.LoadConfigFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Watch file", func(t *testing.T) {
+		stop := router.WatchConfigFile(path, 5*time.Millisecond)
+		defer stop()
+
+		// Make sure the modification time changes on coarse file systems
+		time.Sleep(10 * time.Millisecond)
+		writeConfig("v2")
+		os.Chtimes(path, time.Now(), time.Now().Add(time.Second))
+
+		select {
+		case err := <-reloads:
+			if err != nil {
+				t.Fatalf("Expected no reload error, but got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the configuration to be reloaded")
+		}
+
+		if body := serve(); body != "v2" {
+			t.Errorf("Expected response body %q, but got %q", "v2", body)
+		}
+	})
+
+	t.Run("Reload on signal", func(t *testing.T) {
+		writeConfig("v1")
+		stop := router.ReloadOnSignal(path)
+		defer stop()
+
+		// The signal is sent right away, before the watcher goroutine runs
+		process, err := os.FindProcess(os.Getpid())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := process.Signal(syscall.SIGHUP); err != nil {
+			t.Skipf("Signals are not supported: %v", err)
+		}
+
+		select {
+		case err := <-reloads:
+			if err != nil {
+				t.Fatalf("Expected no reload error, but got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the configuration to be reloaded")
+		}
+
+		if body := serve(); body != "v1" {
+			t.Errorf("Expected response body %q, but got %q", "v1", body)
+		}
+		writeConfig("v2")
+		if err := router.ReloadConfigFile(path); err != nil {
+			t.Fatal(err)
+		}
+		<-reloads
+	})
+
+	t.Run("Rollback on invalid configuration", func(t *testing.T) {
+		writeConfig("missing")
+		if err := router.ReloadConfigFile(path); err == nil {
+			t.Fatal("Expected an error, but got nil")
+		}
+		if err := <-reloads; err == nil {
+			t.Errorf("Expected the reload listener to receive the error")
+		}
+
+		// Check the previous routes are still served
+		if body := serve(); body != "v2" {
+			t.Errorf("Expected response body %q, but got %q", "v2", body)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Encoder encodes a response body.
+type Encoder func(w io.Writer, v interface{}) error
+
+// registeredEncoder is an encoder and the media type it produces.
+type registeredEncoder struct {
+	mediaType string
+	encoder   Encoder
+	// supports, if set, reports whether the encoder can encode a value.
+	supports func(v interface{}) bool
+}
+
+// defaultEncoders are the encoders used unless overridden, in order of
+// preference when the client accepts several equally.
+var defaultEncoders = []registeredEncoder{
+	{mediaType: "application/json", encoder: func(w io.Writer, v interface{}) error {
+		return json.NewEncoder(w).Encode(v)
+	}},
+	{mediaType: "application/cbor", encoder: func(w io.Writer, v interface{}) error {
+		b, err := MarshalCBOR(v)
+		if err == nil {
+			_, err = w.Write(b)
+		}
+		return err
+	}},
+	{mediaType: "application/msgpack", encoder: msgpackEncoder},
+	{mediaType: "application/x-msgpack", encoder: msgpackEncoder},
+	{mediaType: "application/vnd.msgpack", encoder: msgpackEncoder},
+	{mediaType: "text/csv", encoder: encodeCSV, supports: isCSVEncodable},
+}
+
+// msgpackEncoder encodes MessagePack response bodies.
+func msgpackEncoder(w io.Writer, v interface{}) error {
+	b, err := MarshalMsgpack(v)
+	if err == nil {
+		_, err = w.Write(b)
+	}
+	return err
+}
+
+// RegisterEncoder registers the encoder Render uses for responses of the
+// media type, e.g. "application/xml". It replaces the encoder previously
+// registered for the media type, including the built-in JSON, CBOR and
+// MessagePack encoders.
+func (r *Router) RegisterEncoder(mediaType string, encoder Encoder) {
+	r.encoders = append(r.encoders, registeredEncoder{mediaType: strings.ToLower(mediaType), encoder: encoder})
+}
+
+// Render answers the request with the status and v, encoded in the media
+// type the request's Accept header prefers among those of the registered
+// encoders, JSON by default. The built-in text/csv encoder is only chosen
+// for rows of strings and slices of structs. Requests accepting none of them are answered
+// with 406 Not Acceptable, and values that cannot be encoded with 500.
+func (r *Router) Render(w http.ResponseWriter, req *http.Request, status int, v interface{}) {
+	encoders := r.encodersByPreference()
+	var chosen *registeredEncoder
+	best := 0.0
+	if header := req.Header.Get("Accept"); header == "" {
+		chosen = &encoders[0]
+	} else {
+		ranges := parseQualityHeader(strings.ToLower(header))
+		for i := range encoders {
+			if encoders[i].supports != nil && !encoders[i].supports(v) {
+				continue
+			}
+			if q := mediaRangeQuality(ranges, encoders[i].mediaType); q > best {
+				chosen, best = &encoders[i], q
+			}
+		}
+	}
+	w.Header().Add("Vary", "Accept")
+	if chosen == nil {
+		http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := chosen.encoder(&buf, v); err != nil {
+		r.logger.Errorf("Error encoding %s response for %s %s: %v", chosen.mediaType, req.Method, req.URL.Path, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", chosen.mediaType)
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// encodersByPreference returns the built-in encoders, replaced by the
+// registered ones for the same media type, followed by the other
+// registered encoders.
+func (r *Router) encodersByPreference() []registeredEncoder {
+	encoders := append([]registeredEncoder(nil), defaultEncoders...)
+	for _, registered := range r.encoders {
+		replaced := false
+		for i := range encoders {
+			if encoders[i].mediaType == registered.mediaType {
+				encoders[i], replaced = registered, true
+			}
+		}
+		if !replaced {
+			encoders = append(encoders, registered)
+		}
+	}
+	return encoders
+}
@@ -0,0 +1,80 @@
+package router
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	type reading struct {
+		Sensor string  `json:"sensor"`
+		Value  float64 `json:"value"`
+	}
+	router := NewRouter()
+	router.RegisterEncoder("text/plain", func(w io.Writer, v interface{}) error {
+		_, err := io.WriteString(w, v.(reading).Sensor)
+		return err
+	})
+	router.MustAddRoute("POST", "/readings", func(w http.ResponseWriter, req *http.Request) {
+		var in reading
+		if err := router.Bind(req, &in); err != nil {
+			WriteParamError(w, err)
+			return
+		}
+		router.Render(w, req, http.StatusCreated, in)
+	})
+
+	cbor, _ := hex.DecodeString("a26673656e736f726274316576616c756502") // {"sensor": "t1", "value": 2}
+	msgpack, _ := MarshalMsgpack(reading{Sensor: "t1", Value: 2})
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		accept      string
+		status      int
+		mediaType   string
+		response    string
+	}{
+		{"JSON by default", "application/json", []byte(`{"sensor":"t1","value":2}`), "", http.StatusCreated, "application/json", `{"sensor":"t1","value":2}` + "\n"},
+		{"CBOR", "application/msgpack", msgpack, "application/cbor", http.StatusCreated, "application/cbor", "\xa2fsensorbt1evalue\x02"},
+		{"MessagePack", "application/cbor", cbor, "application/json;q=0.5, application/x-msgpack", http.StatusCreated, "application/x-msgpack", "\x82\xa6sensor\xa2t1\xa5value\x02"},
+		{"Registered encoder", "application/json", []byte(`{"sensor":"t2"}`), "text/*", http.StatusCreated, "text/plain", "t2"},
+		{"Not acceptable", "application/json", []byte(`{}`), "image/png", http.StatusNotAcceptable, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/readings", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if status := rr.Code; status != tt.status {
+				t.Fatalf("Expected status code %d, but got %d: %s", tt.status, status, rr.Body.String())
+			}
+			if tt.mediaType == "" {
+				return
+			}
+
+			// Check the negotiated media type and body
+			if contentType := rr.Header().Get("Content-Type"); contentType != tt.mediaType {
+				t.Errorf("Expected content type %q, but got %q", tt.mediaType, contentType)
+			}
+			if body := rr.Body.String(); body != tt.response {
+				t.Errorf("Expected response body %q, but got %q", tt.response, body)
+			}
+			if vary := strings.Join(rr.Header().Values("Vary"), ","); !strings.Contains(vary, "Accept") {
+				t.Errorf("Expected Vary to contain Accept, but got %q", vary)
+			}
+		})
+	}
+}
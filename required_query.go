@@ -0,0 +1,76 @@
+package router
+
+import (
+	"net/http"
+	"regexp"
+)
+
+// QueryRequirement declares a query parameter a route requires.
+type QueryRequirement struct {
+	Name string
+	// Type is the type of the value. It defaults to StringField, which
+	// accepts any value.
+	Type FieldType
+	// Pattern, if set, must match the value.
+	Pattern *regexp.Regexp
+}
+
+// fieldTypeNames names the field types in parameter errors.
+var fieldTypeNames = map[FieldType]string{
+	StringField: "string",
+	IntField:    "integer",
+	FloatField:  "number",
+	BoolField:   "boolean",
+	TimeField:   "RFC 3339 time",
+}
+
+// RequireQuery declares query parameters the route requires. Requests
+// missing any of them, or with invalid values, are answered with 400 Bad
+// Request before the route's middleware and handler run, with a JSON body
+// listing every problem in the request's locale:
+//
+//	{"error": "invalid query parameters", "params": [{"source": "query", "name": "q", ...}]}
+func (route *Route) RequireQuery(requirements ...QueryRequirement) *Route {
+	route.requiredQuery = append(route.requiredQuery, requirements...)
+	route.invalidate()
+	return route
+}
+
+// withRequiredQuery wraps the handler to check the route's required query
+// parameters.
+func (route *Route) withRequiredQuery(r *Router, handler http.HandlerFunc) http.HandlerFunc {
+	if len(route.requiredQuery) == 0 {
+		return handler
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		var problems []*ParamError
+		for _, requirement := range route.requiredQuery {
+			if pe := requirement.check(r.QueryString(req, requirement.Name, "")); pe != nil {
+				problems = append(problems, pe)
+			}
+		}
+		if len(problems) > 0 {
+			translate := r.translator(req)
+			writeParamErrors(w, translate("invalid query parameters"), problems, translate)
+			return
+		}
+		handler(w, req)
+	}
+}
+
+// check returns the error of the value, or nil if it is valid.
+func (q QueryRequirement) check(value string) *ParamError {
+	pe := &ParamError{Source: "query", Name: q.Name, Value: value, Type: fieldTypeNames[q.Type]}
+	if value == "" {
+		return pe
+	}
+	if _, err := parseFieldValue(value, q.Type); err != nil {
+		pe.Err = err
+		return pe
+	}
+	if q.Pattern != nil && !q.Pattern.MatchString(value) {
+		pe.Type = "value matching " + q.Pattern.String()
+		return pe
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestRequireQuery(t *testing.T) {
+	router := NewRouter()
+	called := false
+	router.MustAddRoute("GET", "/search", func(w http.ResponseWriter, req *http.Request) {
+		called = true
+		w.Write([]byte("results"))
+	}).RequireQuery(
+		QueryRequirement{Name: "q"},
+		QueryRequirement{Name: "page", Type: IntField},
+		QueryRequirement{Name: "sort", Pattern: regexp.MustCompile(`^(asc|desc)$`)},
+	)
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		params []string
+	}{
+		{"All present", "/search?q=go&page=2&sort=asc", http.StatusOK, nil},
+		{"Missing parameters", "/search?page=2", http.StatusBadRequest, []string{"q", "sort"}},
+		{"Invalid type", "/search?q=go&page=two&sort=desc", http.StatusBadRequest, []string{"page"}},
+		{"Pattern mismatch", "/search?q=go&page=1&sort=up", http.StatusBadRequest, []string{"sort"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called = false
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if rr.Code != tt.status {
+				t.Fatalf("Expected status code %d, but got %d", tt.status, rr.Code)
+			}
+			if tt.status == http.StatusOK {
+				if !called {
+					t.Errorf("Expected the handler to be called")
+				}
+				return
+			}
+
+			// Check the handler did not run
+			if called {
+				t.Errorf("Expected the handler not to be called")
+			}
+
+			// Check the listed parameters
+			var body struct {
+				Error  string       `json:"error"`
+				Params []ParamError `json:"params"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Expected a JSON body, but got %q", rr.Body.String())
+			}
+			if len(body.Params) != len(tt.params) {
+				t.Fatalf("Expected %d parameters, but got %+v", len(tt.params), body.Params)
+			}
+			for i, name := range tt.params {
+				if body.Params[i].Name != name || body.Params[i].Source != "query" {
+					t.Errorf("Expected query parameter %q, but got %+v", name, body.Params[i])
+				}
+			}
+		})
+	}
+}
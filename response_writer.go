@@ -0,0 +1,138 @@
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// responseWriter wraps http.ResponseWriter to capture the status code and
+// the number of bytes written by the handler.
+type responseWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+// newResponseWriter wraps w in a responseWriter.
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	return &responseWriter{ResponseWriter: w}
+}
+
+// WriteHeader records the status code and forwards it to the wrapped writer.
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the number of bytes written and forwards them to the wrapped writer.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+// Status returns the captured status code, defaulting to 200 when the
+// handler did not write anything.
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Written reports whether the response header has been sent.
+func (w *responseWriter) Written() bool {
+	return w.status != 0
+}
+
+// Flush implements http.Flusher if the wrapped writer supports it.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker if the wrapped writer supports it.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("router: response writer does not support hijacking")
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the wrapped http.ResponseWriter.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// bufferedWriter holds the response of a handler so that middleware can
+// transform it before writing it to the wrapped writer.
+type bufferedWriter struct {
+	http.ResponseWriter
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// newBufferedWriter wraps w in a bufferedWriter.
+func newBufferedWriter(w http.ResponseWriter) *bufferedWriter {
+	return &bufferedWriter{ResponseWriter: w, header: http.Header{}}
+}
+
+// Header returns the buffered header.
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader records the status code.
+func (w *bufferedWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write buffers the body.
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// Status returns the status code, defaulting to 200.
+func (w *bufferedWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// flush writes the buffered response with the body replaced.
+func (w *bufferedWriter) flush(body []byte) {
+	header := w.ResponseWriter.Header()
+	for name, values := range w.header {
+		header[name] = values
+	}
+	if header.Get("Content-Length") != "" {
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	// Trailers are only sent with chunked responses
+	if hasTrailers(header) {
+		header.Del("Content-Length")
+	}
+	w.ResponseWriter.WriteHeader(w.Status())
+	w.ResponseWriter.Write(body)
+}
@@ -0,0 +1,312 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy configures the retries of an upstream pool. Only requests
+// with an idempotent method, and without a body or with a replayable one
+// (see BufferBody), are retried, on another backend when there is one.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first.
+	// It defaults to 2.
+	MaxAttempts int
+	// PerTryTimeout, if set, limits the time to the response header of each
+	// attempt. Attempts timing out count as 504 Gateway Timeout.
+	PerTryTimeout time.Duration
+	// RetryOn lists the retryable status codes. It defaults to 502, 503 and
+	// 504, which include unreachable backends.
+	RetryOn []int
+	// Budget limits the retries, and may be shared by several pools. It
+	// defaults to a budget of the pool created with NewRetryBudget(0.2, 10).
+	Budget *RetryBudget
+}
+
+// replayable reports whether the request may be sent again: it has an
+// idempotent method, and no body or a body that can be read again.
+func replayable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// retryOn reports whether the status is retryable.
+func (p *RetryPolicy) retryOn(status int) bool {
+	for _, s := range p.RetryOn {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryBudget limits retries to a share of the requests, so that retries
+// cannot amplify an outage.
+type RetryBudget struct {
+	ratio      float64
+	perSecond  float64
+	mu         sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+}
+
+// NewRetryBudget creates a budget allowing retries for the ratio of the
+// requests, e.g. 0.2 for 20%, plus minPerSecond retries per second so that
+// low traffic can still be retried. Unused budget accumulates up to ten
+// seconds of minimum retries.
+func NewRetryBudget(ratio float64, minPerSecond int) *RetryBudget {
+	return &RetryBudget{ratio: ratio, perSecond: float64(minPerSecond), tokens: float64(minPerSecond), lastRefill: time.Now()}
+}
+
+// deposit adds the share of a request to the budget.
+func (b *RetryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.tokens += b.ratio
+	b.cap()
+}
+
+// withdraw reports whether a retry is allowed, consuming it.
+func (b *RetryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// refill adds the minimum retries since the last refill.
+func (b *RetryBudget) refill() {
+	now := time.Now()
+	b.tokens += now.Sub(b.lastRefill).Seconds() * b.perSecond
+	b.lastRefill = now
+	b.cap()
+}
+
+// cap limits the accumulated budget.
+func (b *RetryBudget) cap() {
+	max := 10 * b.perSecond
+	if max < 1 {
+		max = 1
+	}
+	if b.tokens > max {
+		b.tokens = max
+	}
+}
+
+// serve forwards the request to a backend of the pool, retrying failed
+// attempts as allowed by the retry policy.
+func (p *UpstreamPool) serve(r *Router, w http.ResponseWriter, req *http.Request) {
+	policy := p.opts.Retry
+	maxAttempts := 1
+	if policy != nil && replayable(req) {
+		maxAttempts = policy.MaxAttempts
+		p.budget.deposit()
+	}
+
+	var tried []*backend
+	for attempt := 1; ; attempt++ {
+		b := p.pick(req, tried)
+		if b == nil {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		tried = append(tried, b)
+
+		attemptReq := req
+		if attempt > 1 {
+			var err error
+			if attemptReq, err = replay(req); err != nil {
+				http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+				return
+			}
+		}
+
+		aw := &attemptWriter{w: w, header: http.Header{}}
+		last := attempt >= maxAttempts
+		aw.retry = func(status int) bool {
+			return !last && policy.retryOn(status) && req.Context().Err() == nil && p.budget.withdraw()
+		}
+		if p.opts.Affinity == AffinityCookie {
+			aw.onCommit = func() {
+				if cookie, err := req.Cookie(p.opts.CookieName); err != nil || cookie.Value != b.id {
+					r.SetCookie(w, &http.Cookie{Name: p.opts.CookieName, Value: b.id, Path: "/", HttpOnly: true})
+				}
+			}
+		}
+
+		cancel := func() {}
+		if policy != nil && policy.PerTryTimeout > 0 {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(attemptReq.Context())
+			timer := time.AfterFunc(policy.PerTryTimeout, func() {
+				if atomic.CompareAndSwapInt32(&aw.state, attemptPending, attemptTimedOut) {
+					cancel()
+				}
+			})
+			defer timer.Stop()
+			attemptReq = attemptReq.WithContext(ctx)
+		}
+
+		start := time.Now()
+		if p.opts.Hedge != nil && replayable(req) {
+			p.hedge(r, aw, attemptReq, b, &tried)
+			aw.finish()
+		} else {
+			b.serve(r, aw, attemptReq)
+			aw.finish()
+			if p.opts.Outliers != nil {
+				p.record(b, aw.status, time.Since(start))
+			}
+		}
+		cancel()
+		if !aw.discarded {
+			return
+		}
+	}
+}
+
+// replay returns a copy of the request with a new body, if any.
+func replay(req *http.Request) (*http.Request, error) {
+	if req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(req.Context())
+	req.Body = body
+	return req, nil
+}
+
+// serve forwards the request to the backend.
+func (b *backend) serve(r *Router, w http.ResponseWriter, req *http.Request) {
+	b.once.Do(func() {
+		b.handler = r.proxyHandler(b.url, b.transport)
+	})
+	b.handler(w, req)
+}
+
+// Attempt states.
+const (
+	attemptPending int32 = iota
+	attemptCommitted
+	attemptTimedOut
+)
+
+// attemptWriter holds back the response of an attempt until its status is
+// known, discarding it if the attempt is retried.
+type attemptWriter struct {
+	w        http.ResponseWriter
+	header   http.Header
+	retry    func(status int) bool
+	onCommit func()
+	state    int32 // accessed atomically
+
+	status    int
+	committed bool
+	discarded bool
+	noRetry   bool
+}
+
+// Header returns the header of the attempt, or of the response once it is
+// committed.
+func (w *attemptWriter) Header() http.Header {
+	if w.committed {
+		return w.w.Header()
+	}
+	return w.header
+}
+
+// WriteHeader commits the response, unless the attempt is retried.
+func (w *attemptWriter) WriteHeader(status int) {
+	if w.committed || w.discarded {
+		return
+	}
+	if status < 200 {
+		// Informational responses are passed on, so the attempt can no
+		// longer be retried
+		w.noRetry = true
+		copyHeader(w.w.Header(), w.header)
+		w.w.WriteHeader(status)
+		for name := range w.header {
+			delete(w.header, name)
+		}
+		return
+	}
+
+	timedOut := !atomic.CompareAndSwapInt32(&w.state, attemptPending, attemptCommitted)
+	if timedOut {
+		status = http.StatusGatewayTimeout
+	}
+	w.status = status
+	if !w.noRetry && w.retry(status) {
+		w.discarded = true
+		return
+	}
+	if timedOut {
+		// Replace the error of the cancelled attempt
+		w.discarded = true
+		http.Error(w.w, http.StatusText(status), status)
+		return
+	}
+
+	w.committed = true
+	copyHeader(w.w.Header(), w.header)
+	if w.onCommit != nil {
+		w.onCommit()
+	}
+	w.w.WriteHeader(status)
+}
+
+// Write writes the body of a committed response.
+func (w *attemptWriter) Write(b []byte) (int, error) {
+	if !w.committed && !w.discarded {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.discarded {
+		return len(b), nil
+	}
+	return w.w.Write(b)
+}
+
+// Flush implements http.Flusher if the wrapped writer supports it.
+func (w *attemptWriter) Flush() {
+	if !w.committed {
+		return
+	}
+	if f, ok := w.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter.
+func (w *attemptWriter) Unwrap() http.ResponseWriter {
+	return w.w
+}
+
+// finish commits the response if the attempt wrote nothing.
+func (w *attemptWriter) finish() {
+	if !w.committed && !w.discarded {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// copyHeader adds the values of src to dst.
+func copyHeader(dst http.Header, src http.Header) {
+	for name, values := range src {
+		dst[name] = append(dst[name], values...)
+	}
+}
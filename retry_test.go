@@ -0,0 +1,124 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProxyPoolRetries(t *testing.T) {
+	// startBackend starts a backend running the handler
+	startBackend := func(handler http.HandlerFunc) *url.URL {
+		backend := httptest.NewServer(handler)
+		t.Cleanup(backend.Close)
+		u, _ := url.Parse(backend.URL)
+		return u
+	}
+	failing := startBackend(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	healthy := startBackend(func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "ok")
+	})
+
+	serve := func(router *Router, method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/app", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Retryable status", func(t *testing.T) {
+		router := NewRouter()
+		router.ProxyPool("GET", "/app", NewUpstreamPool(UpstreamPoolOptions{Retry: &RetryPolicy{}}, failing, healthy))
+
+		rr := serve(router, "GET")
+
+		// Check that the request was retried on the healthy backend
+		if rr.Code != http.StatusOK || rr.Body.String() != "ok" {
+			t.Errorf("Expected status code %d and body %q, but got %d and %q", http.StatusOK, "ok", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("Non-idempotent method", func(t *testing.T) {
+		router := NewRouter()
+		router.ProxyPool("POST", "/app", NewUpstreamPool(UpstreamPoolOptions{Retry: &RetryPolicy{}}, failing, healthy))
+
+		rr := serve(router, "POST")
+
+		// Check the response status code
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status code %d, but got %d", http.StatusServiceUnavailable, rr.Code)
+		}
+	})
+
+	t.Run("Exhausted budget", func(t *testing.T) {
+		router := NewRouter()
+		retry := &RetryPolicy{Budget: NewRetryBudget(0, 0)}
+		router.ProxyPool("GET", "/app", NewUpstreamPool(UpstreamPoolOptions{Retry: retry}, failing, healthy))
+
+		rr := serve(router, "GET")
+
+		// Check that the request was not retried
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status code %d, but got %d", http.StatusServiceUnavailable, rr.Code)
+		}
+		if !strings.Contains(rr.Body.String(), "unavailable") {
+			t.Errorf("Expected the response of the backend, but got %q", rr.Body.String())
+		}
+	})
+
+	t.Run("Per-try timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		slow := startBackend(func(w http.ResponseWriter, req *http.Request) {
+			select {
+			case <-release:
+			case <-req.Context().Done():
+			}
+		})
+		router := NewRouter()
+		retry := &RetryPolicy{PerTryTimeout: 50 * time.Millisecond}
+		router.ProxyPool("GET", "/app", NewUpstreamPool(UpstreamPoolOptions{Retry: retry}, slow, healthy))
+
+		rr := serve(router, "GET")
+
+		// Check that the slow attempt was retried on the healthy backend
+		if rr.Code != http.StatusOK || rr.Body.String() != "ok" {
+			t.Errorf("Expected status code %d and body %q, but got %d and %q", http.StatusOK, "ok", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("Last attempt", func(t *testing.T) {
+		router := NewRouter()
+		retry := &RetryPolicy{MaxAttempts: 2}
+		router.ProxyPool("GET", "/app", NewUpstreamPool(UpstreamPoolOptions{Retry: retry}, failing, failing))
+
+		rr := serve(router, "GET")
+
+		// Check that the response of the last attempt is sent
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status code %d, but got %d", http.StatusServiceUnavailable, rr.Code)
+		}
+	})
+}
+
+func TestRetryBudget(t *testing.T) {
+	budget := NewRetryBudget(0.5, 0)
+
+	// Check that retries are limited to the ratio of the requests
+	retries := 0
+	for i := 0; i < 10; i++ {
+		budget.deposit()
+		if budget.withdraw() {
+			retries++
+		}
+	}
+	if retries > 5 {
+		t.Errorf("Expected at most %d retries, but got %d", 5, retries)
+	}
+}
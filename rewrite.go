@@ -0,0 +1,99 @@
+package router
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+)
+
+// RewriteRule rewrites the path of matching requests before routing.
+type RewriteRule struct {
+	// Pattern is a path pattern such as "/api/*path". It is ignored when
+	// Regexp is set.
+	Pattern string
+	// Regexp matches the path instead of Pattern when set.
+	Regexp *regexp.Regexp
+	// Target is the new path. Parameters captured by Pattern are
+	// interpolated with ":name" or "*name"; Regexp captures are expanded
+	// with "$1" or "${name}".
+	Target string
+	// RedirectCode, when non-zero, makes the router respond with a redirect
+	// to the new path instead of rewriting the request internally.
+	RedirectCode int
+
+	pattern *pattern
+}
+
+// AddRewriteRule adds a rewrite rule. Rules are evaluated in the order they
+// were added and only the first matching rule is applied.
+func (r *Router) AddRewriteRule(rule RewriteRule) {
+	if rule.Regexp == nil {
+		rule.pattern = compilePattern(rule.Pattern)
+	}
+	r.rewrites = append(r.rewrites, &rule)
+}
+
+// Rewrite adds a rule internally rewriting paths matching the from pattern
+// to the to pattern, e.g. Rewrite("/api/*path", "/*path").
+func (r *Router) Rewrite(from string, to string) {
+	r.AddRewriteRule(RewriteRule{Pattern: from, Target: to})
+}
+
+// rewrite returns the rewritten path if the rule matches it.
+func (rule *RewriteRule) rewrite(path string) (string, bool) {
+	if rule.Regexp != nil {
+		if !rule.Regexp.MatchString(path) {
+			return "", false
+		}
+		return rule.Regexp.ReplaceAllString(path, rule.Target), true
+	}
+	params, ok := rule.pattern.match(path)
+	if !ok {
+		return "", false
+	}
+	return expand(rule.Target, params), true
+}
+
+// redirectPath returns the escaped path to redirect to, given the request
+// path and the path it was rewritten to.
+func (rule *RewriteRule) redirectPath(path string, rewritten string) string {
+	if rule.Regexp != nil {
+		return (&url.URL{Path: rewritten}).EscapedPath()
+	}
+	params, _ := rule.pattern.match(path)
+	return expandWith(rule.Target, params, true)
+}
+
+// applyRewrites rewrites the request path using the rewrite rules. It
+// returns false if a redirect has been written instead.
+func (r *Router) applyRewrites(w http.ResponseWriter, req *http.Request) (*http.Request, bool) {
+	for _, rule := range r.rewrites {
+		path, ok := rule.rewrite(req.URL.Path)
+		if !ok {
+			continue
+		}
+
+		if rule.RedirectCode != 0 {
+			target, ok := redirectTarget(rule.redirectPath(req.URL.Path, path))
+			if !ok {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return req, false
+			}
+			if req.URL.RawQuery != "" {
+				target += "?" + req.URL.RawQuery
+			}
+			http.Redirect(w, req, target, rule.RedirectCode)
+			return req, false
+		}
+
+		u := new(url.URL)
+		*u = *req.URL
+		u.Path = path
+		u.RawPath = ""
+
+		req = req.WithContext(req.Context())
+		req.URL = u
+		return req, true
+	}
+	return req, true
+}
@@ -0,0 +1,122 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestRewrite(t *testing.T) {
+	router := NewRouter()
+
+	router.MustAddRoute("GET", "/users/42", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("user " + req.URL.Path))
+	})
+	router.MustAddRoute("GET", "/new/page", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("new page"))
+	})
+
+	router.Rewrite("/api/*path", "/*path")
+	router.AddRewriteRule(RewriteRule{
+		Regexp: regexp.MustCompile(`^/legacy/(\w+)$`),
+		Target: "/new/$1",
+	})
+	router.AddRewriteRule(RewriteRule{
+		Pattern:      "/old/:name",
+		Target:       "/new/:name",
+		RedirectCode: http.StatusMovedPermanently,
+	})
+	router.AddRewriteRule(RewriteRule{
+		Pattern:      "/moved/*rest",
+		Target:       "/*rest",
+		RedirectCode: http.StatusFound,
+	})
+
+	t.Run("Pattern rewrite", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/users/42", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		expectedBody := "user /users/42"
+		if rr.Body.String() != expectedBody {
+			t.Errorf("Expected response body %q, but got %q", expectedBody, rr.Body.String())
+		}
+
+		// The original request must not be modified
+		if req.URL.Path != "/api/users/42" {
+			t.Errorf("Expected original path %q, but got %q", "/api/users/42", req.URL.Path)
+		}
+	})
+
+	t.Run("Regexp rewrite", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/legacy/page", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		expectedBody := "new page"
+		if rr.Body.String() != expectedBody {
+			t.Errorf("Expected response body %q, but got %q", expectedBody, rr.Body.String())
+		}
+	})
+
+	t.Run("Redirect rule", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/old/page?ref=mail", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusMovedPermanently {
+			t.Errorf("Expected status code %d, but got %d", http.StatusMovedPermanently, rr.Code)
+		}
+
+		expectedLocation := "/new/page?ref=mail"
+		if location := rr.Header().Get("Location"); location != expectedLocation {
+			t.Errorf("Expected location %q, but got %q", expectedLocation, location)
+		}
+	})
+
+	t.Run("Redirect escaping", func(t *testing.T) {
+		tests := []struct {
+			name             string
+			path             string
+			expectedCode     int
+			expectedLocation string
+		}{
+			{"Query injection", "/old/a%3Fx=1", http.StatusMovedPermanently, "/new/a%3Fx=1"},
+			{"Scheme-relative target", "/moved//evil.com/x", http.StatusBadRequest, ""},
+			{"Escaped scheme-relative target", "/moved/%2Fevil.com", http.StatusBadRequest, ""},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req, err := http.NewRequest("GET", tt.path, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+
+				// Check the response status code
+				if rr.Code != tt.expectedCode {
+					t.Errorf("Expected status code %d, but got %d", tt.expectedCode, rr.Code)
+				}
+				if location := rr.Header().Get("Location"); location != tt.expectedLocation {
+					t.Errorf("Expected location %q, but got %q", tt.expectedLocation, location)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package router
+
+import (
+	"net/http"
+	"sort"
+)
+
+// routeTable holds routes indexed by method and path. Several routes may be
+// registered for the same method and path when they have matchers.
+type routeTable struct {
+	routes   map[string]map[string][]*Route
+	patterns map[string][]string // in precedence order, see sortPatterns
+	seq      int
+}
+
+// newRouteTable creates an empty route table.
+func newRouteTable() *routeTable {
+	return &routeTable{
+		routes:   make(map[string]map[string][]*Route),
+		patterns: make(map[string][]string),
+	}
+}
+
+// newRoute creates a route, compiling its path if it is a pattern.
+func newRoute(method string, path string, handler http.HandlerFunc) *Route {
+	route := &Route{
+		Method:      method,
+		Path:        path,
+		HandlerFunc: handler,
+	}
+	if isPattern(path) {
+		route.pattern = compilePattern(path)
+	}
+	return route
+}
+
+// add adds the route to the table.
+func (t *routeTable) add(route *Route) {
+	if t.routes[route.Method] == nil {
+		t.routes[route.Method] = make(map[string][]*Route)
+	}
+	t.seq++
+	route.table, route.seq = t, t.seq
+	candidates := t.routes[route.Method][route.Path]
+	if route.pattern != nil && len(candidates) == 0 {
+		t.patterns[route.Method] = append(t.patterns[route.Method], route.Path)
+	}
+	t.routes[route.Method][route.Path] = append(candidates, route)
+	if route.pattern != nil {
+		t.sortPatterns(route.Method)
+	}
+}
+
+// Priority sets the precedence of the route over overlapping routes, e.g.
+// to have "/users/:id" win over "/users/new". Routes with a higher priority
+// are tried first. At equal priority, which defaults to 0, static paths
+// are tried before patterns, and the most specific patterns first, see
+// sortPatterns.
+func (route *Route) Priority(priority int) *Route {
+	route.precedence = priority
+	if route.table != nil && route.pattern != nil {
+		route.table.sortPatterns(route.Method)
+	}
+	return route
+}
+
+// sortPatterns orders the patterns of the method by descending priority,
+// then from the most to the least specific, see compareSpecificity, then
+// by registration.
+func (t *routeTable) sortPatterns(method string) {
+	paths := t.patterns[method]
+	byPath := t.routes[method]
+	sort.SliceStable(paths, func(i, j int) bool {
+		a, b := byPath[paths[i]][0], byPath[paths[j]][0]
+		pi, pj := pathPriority(byPath[paths[i]]), pathPriority(byPath[paths[j]])
+		if pi != pj {
+			return pi > pj
+		}
+		if cmp, _ := compareSpecificity(a.pattern, b.pattern); cmp != 0 {
+			return cmp > 0
+		}
+		return a.seq < b.seq
+	})
+}
+
+// pathPriority returns the highest priority of the routes registered for a
+// path.
+func pathPriority(candidates []*Route) int {
+	priority := candidates[0].precedence
+	for _, route := range candidates[1:] {
+		if route.precedence > priority {
+			priority = route.precedence
+		}
+	}
+	return priority
+}
+
+// get returns the route most recently registered for the method and path.
+func (t *routeTable) get(method string, path string) *Route {
+	candidates := t.routes[method][path]
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[len(candidates)-1]
+}
+
+// lookup returns the route matching the request's method and path along
+// with the captured path parameters. Static paths take precedence over
+// patterns of the same priority, and patterns are tried in the order of
+// sortPatterns. If no route matches, it returns the
+// status code explaining why: 415 or 406 when a route only failed on its
+// content type constraints, 404 otherwise.
+func (t *routeTable) lookup(req *http.Request) (*Route, map[string]string, int) {
+	return t.lookupMethod(req, req.Method)
+}
+
+// lookupMethod is like lookup but looks up the routes of the method rather
+// than of the request's method.
+func (t *routeTable) lookupMethod(req *http.Request, method string) (*Route, map[string]string, int) {
+	status := http.StatusNotFound
+	byPath := t.routes[method]
+	static, s := pick(byPath[req.URL.Path], req)
+	if static != nil && static.pattern != nil {
+		static = nil
+	} else if s != http.StatusNotFound {
+		status = s
+	}
+	for _, path := range t.patterns[method] {
+		candidates := byPath[path]
+		if static != nil && pathPriority(candidates) <= static.precedence {
+			break
+		}
+		params, ok := candidates[0].pattern.match(req.URL.Path)
+		if !ok {
+			continue
+		}
+		route, s := pick(candidates, req)
+		if route != nil {
+			return route, params, 0
+		}
+		if status == http.StatusNotFound {
+			status = s
+		}
+	}
+	if static != nil {
+		return static, nil, 0
+	}
+	return nil, nil, status
+}
+
+// pick selects among the routes registered for the same method and path.
+// The conditional route best matching the request's Accept header is used,
+// earlier routes winning ties, then the most recently registered
+// unconditional route. If no route matches, it returns the status code
+// explaining why.
+func pick(candidates []*Route, req *http.Request) (*Route, int) {
+	var best, fallback *Route
+	var bestQ float64
+	status := http.StatusNotFound
+	for _, route := range candidates {
+		if !route.conditional() {
+			fallback = route
+			continue
+		}
+		q, s := route.check(req)
+		if s != 0 {
+			if status == http.StatusNotFound {
+				status = s
+			}
+			continue
+		}
+		if q > bestQ {
+			best, bestQ = route, q
+		}
+	}
+	if best != nil {
+		return best, 0
+	}
+	if fallback != nil {
+		return fallback, 0
+	}
+	return nil, status
+}
@@ -0,0 +1,180 @@
+package router
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Meta sets metadata on the route, e.g. its owner or its authentication
+// scheme, shown in the route tree.
+func (route *Route) Meta(key string, value string) *Route {
+	if route.meta == nil {
+		route.meta = make(map[string]string)
+	}
+	route.meta[key] = value
+	return route
+}
+
+// RouteNode describes a route in the route tree.
+type RouteNode struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Middleware lists the middleware of the route, outermost first, as in
+	// RouteMatch.
+	Middleware  []string          `json:"middleware,omitempty"`
+	Enabled     bool              `json:"enabled"`
+	Conditional bool              `json:"conditional,omitempty"`
+	Consumes    []string          `json:"consumes,omitempty"`
+	Produces    []string          `json:"produces,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// RouteTree groups routes by path segment.
+type RouteTree struct {
+	Segment  string       `json:"segment"`
+	Routes   []RouteNode  `json:"routes,omitempty"`
+	Children []*RouteTree `json:"children,omitempty"`
+}
+
+// RouteTree returns the registered routes, including the routes loaded
+// from a configuration, as a tree of path segments.
+func (r *Router) RouteTree() *RouteTree {
+	root := &RouteTree{Segment: "/"}
+	for _, route := range r.allRoutes() {
+		node := RouteNode{
+			Method:      route.Method,
+			Path:        route.Path,
+			Enabled:     atomic.LoadInt32(&route.disabled) == 0,
+			Conditional: len(route.matchers) > 0,
+			Consumes:    route.consumes,
+			Produces:    route.produces,
+			Metadata:    route.meta,
+		}
+		for _, mw := range r.middleware {
+			node.Middleware = append(node.Middleware, r.middlewareName(mw))
+		}
+		for _, mw := range route.middleware {
+			node.Middleware = append(node.Middleware, r.middlewareName(mw))
+		}
+
+		tree := root
+		for _, segment := range strings.Split(strings.Trim(route.Path, "/"), "/") {
+			if segment != "" {
+				tree = tree.child(segment)
+			}
+		}
+		tree.Routes = append(tree.Routes, node)
+	}
+	root.sort()
+	return root
+}
+
+// child returns the child tree of the segment, adding it if needed.
+func (t *RouteTree) child(segment string) *RouteTree {
+	for _, child := range t.Children {
+		if child.Segment == segment {
+			return child
+		}
+	}
+	child := &RouteTree{Segment: segment}
+	t.Children = append(t.Children, child)
+	return child
+}
+
+// sort orders the routes by method and the children by segment.
+func (t *RouteTree) sort() {
+	sort.Slice(t.Routes, func(i, j int) bool { return t.Routes[i].Method < t.Routes[j].Method })
+	sort.Slice(t.Children, func(i, j int) bool { return t.Children[i].Segment < t.Children[j].Segment })
+	for _, child := range t.Children {
+		child.sort()
+	}
+}
+
+// EnableRouteTree adds a GET route at path showing the route tree as an
+// HTML page, or as JSON or a Graphviz graph with format=json or
+// format=dot. The middleware, typically checking that the client is an
+// operator, is applied to the route. It returns an error if the route
+// cannot be added, see AddRoute.
+func (r *Router) EnableRouteTree(path string, middleware ...func(http.HandlerFunc) http.HandlerFunc) (*Route, error) {
+	route, err := r.AddRoute("GET", path, func(w http.ResponseWriter, req *http.Request) {
+		tree := r.RouteTree()
+		switch req.URL.Query().Get("format") {
+		case "json":
+			writeJSON(w, http.StatusOK, tree)
+		case "dot":
+			w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+			writeRouteTreeDot(w, tree)
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			if err := routeTreePage.Execute(w, tree); err != nil {
+				r.logger.Errorf("Failed to render route tree: %v", err)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return route.Use(middleware...), nil
+}
+
+// writeRouteTreeDot writes the tree as a Graphviz graph.
+func writeRouteTreeDot(w io.Writer, tree *RouteTree) {
+	fmt.Fprintln(w, "digraph routes {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	id := 0
+	var walk func(t *RouteTree) int
+	walk = func(t *RouteTree) int {
+		node := id
+		id++
+		label := t.Segment
+		for _, route := range t.Routes {
+			label += "\n" + route.Method
+			if len(route.Middleware) > 0 {
+				label += " [" + strings.Join(route.Middleware, ", ") + "]"
+			}
+		}
+		fmt.Fprintf(w, "  n%d [shape=box, label=%s];\n", node, strconv.Quote(label))
+		for _, child := range t.Children {
+			fmt.Fprintf(w, "  n%d -> n%d;\n", node, walk(child))
+		}
+		return node
+	}
+	walk(tree)
+	fmt.Fprintln(w, "}")
+}
+
+// routeTreePage renders the route tree as nested lists.
+var routeTreePage = template.Must(template.New("tree").Parse(`{{define "node"}}<li><strong>{{.Segment}}</strong>
+  {{range .Routes}}<div class="route{{if not .Enabled}} disabled{{end}}"><code>{{.Method}} {{.Path}}</code>
+    {{if .Middleware}}<span class="mw">{{range $i, $m := .Middleware}}{{if $i}} → {{end}}{{$m}}{{end}}</span>{{end}}
+    {{if .Conditional}}<span class="meta">conditional</span>{{end}}
+    {{if .Consumes}}<span class="meta">consumes {{range .Consumes}}{{.}} {{end}}</span>{{end}}
+    {{if .Produces}}<span class="meta">produces {{range .Produces}}{{.}} {{end}}</span>{{end}}
+    {{range $k, $v := .Metadata}}<span class="meta">{{$k}}={{$v}}</span>{{end}}
+    {{if not .Enabled}}<span class="meta">disabled</span>{{end}}</div>{{end}}
+  {{if .Children}}<ul>{{range .Children}}{{template "node" .}}{{end}}</ul>{{end}}
+</li>{{end}}<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>Routes</title>
+  <style>
+    body { font-family: sans-serif; margin: 2em; }
+    ul { list-style: none; border-left: 1px solid #ccc; padding-left: 1.5em; }
+    .route { margin: 0.2em 0; }
+    .disabled code { text-decoration: line-through; }
+    .mw, .meta { color: #666; font-size: 0.9em; margin-left: 0.8em; }
+  </style>
+</head>
+<body>
+  <h1>Routes</h1>
+  <ul>{{template "node" .}}</ul>
+</body>
+</html>
+`))
@@ -0,0 +1,95 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouteTree(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {}
+	router := NewRouter()
+	router.MustAddRoute("GET", "/users", handler)
+	router.MustAddRoute("GET", "/users/:id", handler).Meta("auth", "bearer")
+	router.MustAddRoute("DELETE", "/users/:id", handler).Produces("application/json")
+	router.MustAddRoute("GET", "/health", handler)
+
+	authorized := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("X-Operator") == "" {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			next(w, req)
+		}
+	}
+	router.EnableRouteTree("/debug/routes", authorized)
+
+	serve := func(target string, operator bool) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", target, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if operator {
+			req.Header.Set("X-Operator", "alice")
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Guarded", func(t *testing.T) {
+		if rr := serve("/debug/routes", false); rr.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status code %d, but got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		rr := serve("/debug/routes?format=json", true)
+
+		var tree RouteTree
+		if err := json.Unmarshal(rr.Body.Bytes(), &tree); err != nil {
+			t.Fatal(err)
+		}
+
+		// Check the grouping by path segment
+		var segments []string
+		for _, child := range tree.Children {
+			segments = append(segments, child.Segment)
+		}
+		if expected := "debug,health,users"; strings.Join(segments, ",") != expected {
+			t.Fatalf("Expected top-level segments %s, but got %v", expected, segments)
+		}
+		users := tree.Children[2]
+		if len(users.Routes) != 1 || len(users.Children) != 1 || users.Children[0].Segment != ":id" {
+			t.Fatalf("Unexpected users subtree %+v", users)
+		}
+
+		// Check the route details
+		routes := users.Children[0].Routes
+		if len(routes) != 2 || routes[0].Method != "DELETE" || routes[1].Method != "GET" {
+			t.Fatalf("Unexpected routes %+v", routes)
+		}
+		if len(routes[0].Produces) != 1 || routes[1].Metadata["auth"] != "bearer" {
+			t.Errorf("Expected the content types and metadata, but got %+v", routes)
+		}
+		debug := tree.Children[0].Children[0].Routes[0]
+		if len(debug.Middleware) != 1 || !strings.Contains(debug.Middleware[0], "TestRouteTree") {
+			t.Errorf("Expected the guard middleware, but got %v", debug.Middleware)
+		}
+	})
+
+	t.Run("Graphviz and HTML", func(t *testing.T) {
+		rr := serve("/debug/routes?format=dot", true)
+		if body := rr.Body.String(); !strings.HasPrefix(body, "digraph routes {") || !strings.Contains(body, `":id\nDELETE\nGET"`) {
+			t.Errorf("Unexpected graph %s", body)
+		}
+
+		rr = serve("/debug/routes", true)
+		if body := rr.Body.String(); !strings.Contains(body, "<code>GET /users/:id</code>") || !strings.Contains(body, "auth=bearer") {
+			t.Errorf("Unexpected page %s", body)
+		}
+	})
+}
@@ -4,45 +4,131 @@ import (
 	"context"
 	"net/http"
 	"net/url"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sdpsagarpawar/logger"
 )
 
 type Router struct {
-	routes          map[string]map[string]*Route
+	routes          *routeTable
+	config          atomic.Value // *routeTable loaded from a Config
+	configMu        sync.Mutex   // serializes LoadConfig
+	baseRedactor    *Redactor    // in place before a Config set one, guarded by configMu
 	notFoundHandler http.HandlerFunc
 	middleware      []func(http.HandlerFunc) http.HandlerFunc
 	logger          *logger.Logger
+	beforeHooks     []BeforeHook
+	afterHooks      []AfterHook
+	events          events
+	rewrites        []*RewriteRule
+	admission       admission
+	server          server
+	handlers        map[string]http.HandlerFunc
+	namedMiddleware map[string]func(http.HandlerFunc) http.HandlerFunc
+	maintenance     int32 // accessed atomically
+	webhooksOnce    sync.Once
+	webhooks        atomic.Value   // *Webhooks
+	jobs            *jobs          // shared with clones
+	auditPending    sync.WaitGroup // queued audit records
+	redactor        atomic.Value   // *Redactor
+	stubs           stubs
+	serverTiming    int32 // accessed atomically
+	traceFormats    []TraceFormat
+	errorReporters  []ErrorReporter
+	errorPages      int32 // accessed atomically
+	startupSummary  *RouteSummaryOptions
+	summaryOnce     sync.Once
+	cookiePolicy    *CookiePolicy
+	cors            *CORSPolicy
+	binders         []registeredBinder
+	encoders        []registeredEncoder
+	forwardedTrust  *TrustedProxies
+	middlewareGen   uint64 // bumped by Use, accessed atomically
+	middlewareScope MiddlewareScope
+	groups          []*Group
+	errorHandlers   errorHandlers
+
+	skipPreflightMiddleware bool
 }
 
 type Route struct {
+	Method      string
+	Path        string
 	HandlerFunc http.HandlerFunc
-	Response    http.HandlerFunc
+
+	pattern    *pattern
+	matchers   []func(req *http.Request) bool
+	middleware []func(http.HandlerFunc) http.HandlerFunc
+	queue      int
+	disabled   int32 // status of a disabled route, accessed atomically
+	admin      bool
+	consumes   []string
+	produces   []string
+	postHooks  []PostHook
+	meta       map[string]string
+
+	continueChecks  []ContinueCheck
+	cors            *CORSPolicy
+	preflightMaxAge time.Duration
+	precedence      int
+	table           *routeTable
+	seq             int
+	requiredQuery   []QueryRequirement
+	compiled        atomic.Value // *compiledHandler
+	useCount        int          // router middleware when the route was registered
+	groups          []groupMembership
 }
 
 // NewRouter creates a new instance of Router.
 func NewRouter() *Router {
 	return &Router{
-		routes: make(map[string]map[string]*Route),
+		routes: newRouteTable(),
 		logger: logger.NewLogger(), // Create a new logger instance
+		jobs:   &jobs{},
 	}
 }
 
 // AddRoute adds a new route to the router with the specified HTTP method.
-func (r *Router) AddRoute(method string, path string, handler http.HandlerFunc) {
-	if r.routes[method] == nil {
-		r.routes[method] = make(map[string]*Route)
+// The path may contain parameter segments such as "/users/:id", optional
+// trailing parameter segments such as "/reports/:year/:month?", and a
+// trailing wildcard segment such as "/static/*filepath". The returned route
+// can be used to configure it further. The method may be MethodAny to
+// register a fallback for the requests whose method has no route for the
+// path, see Any.
+//
+// It returns an error, registering nothing, if the method is not a valid
+// token, the path is an invalid pattern, or an unconditional route is
+// already registered for the method and path, see ErrDuplicateRoute.
+func (r *Router) AddRoute(method string, path string, handler http.HandlerFunc) (*Route, error) {
+	if err := validateRoute(method, path, r.routes.routes[method][path]); err != nil {
+		return nil, err
 	}
-	r.routes[method][path] = &Route{
-		HandlerFunc: handler,
+	route := newRoute(method, path, handler)
+	route.useCount = len(r.middleware)
+	r.routes.add(route)
+	return route, nil
+}
+
+// MustAddRoute is like AddRoute but panics if the route cannot be added,
+// for routes registered at initialization.
+func (r *Router) MustAddRoute(method string, path string, handler http.HandlerFunc) *Route {
+	route, err := r.AddRoute(method, path, handler)
+	if err != nil {
+		panic(err.Error())
 	}
+	return route
 }
 
-// SetResponse sets the response for a specific route.
-func (r *Router) SetResponse(method string, path string, response http.HandlerFunc) {
-	if r.routes[method] != nil && r.routes[method][path] != nil {
-		r.routes[method][path].Response = response
+// SetResponse adds a post-hook to a specific route.
+//
+// Deprecated: Use Route.After.
+func (r *Router) SetResponse(method string, path string, hook PostHook) {
+	if route := r.routes.get(method, path); route != nil {
+		route.After(hook)
 	}
 }
 
@@ -51,28 +137,270 @@ func (r *Router) SetNotFoundHandler(handler http.HandlerFunc) {
 	r.notFoundHandler = handler
 }
 
-// Use adds middleware to the router.
+// Use adds middleware to the router. By default it applies to all routes,
+// including those registered before, see SetMiddlewareScope.
 func (r *Router) Use(middleware ...func(http.HandlerFunc) http.HandlerFunc) {
 	r.middleware = append(r.middleware, middleware...)
+	atomic.AddUint64(&r.middlewareGen, 1)
+}
+
+// MiddlewareScope tells which routes the middleware added with Use applies
+// to.
+type MiddlewareScope int
+
+const (
+	// MiddlewareAllRoutes applies middleware to all routes, including those
+	// registered before it was added. It is the default.
+	MiddlewareAllRoutes MiddlewareScope = iota
+	// MiddlewareLaterRoutes applies middleware only to the routes registered
+	// after it was added, so that the middleware of a route can be read from
+	// the registration code above it.
+	MiddlewareLaterRoutes
+)
+
+// SetMiddlewareScope sets which routes the middleware added with Use
+// applies to. Requests matching no route run all the router middleware
+// either way.
+func (r *Router) SetMiddlewareScope(scope MiddlewareScope) {
+	r.middlewareScope = scope
+	atomic.AddUint64(&r.middlewareGen, 1)
 }
 
 // ServeHTTP handles the incoming HTTP requests.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+
+	// Generate correlation ID using UUID, or use the trace ID of the caller
+	correlationID := uuid.New().String()
+	ctx := req.Context()
+	if tc := r.extractTrace(req.Header); tc != nil {
+		correlationID = tc.TraceID
+		ctx = context.WithValue(ctx, "traceContext", tc)
+	}
+	if baggage := parseBaggage(req.Header.Values("Baggage")); baggage != nil {
+		ctx = context.WithValue(ctx, "baggage", baggage)
+	}
+
+	// Attach a pooled store holding the correlation ID, the matched route
+	// and the values set with Set, released once the request has been served
+	store := acquireStore()
+	defer store.release()
+	store.store.correlationID = correlationID
+	ctx = context.WithValue(ctx, "store", store)
+	req = req.WithContext(ctx)
+
+	// Run the before hooks prior to routing
+	for _, hook := range r.beforeHooks {
+		hook(req)
+	}
+
+	// Capture the response so the after hooks and listeners can inspect it
+	rw := newResponseWriter(w)
+	var out http.ResponseWriter = rw
+
+	// Hold back 5xx responses when error pages are enabled
+	var pages *errorPageWriter
+	if atomic.LoadInt32(&r.errorPages) != errorPagesOff {
+		pages = &errorPageWriter{ResponseWriter: rw}
+		out = pages
+	}
+
 	var route *Route
+	defer func() {
+		recovered := recover()
+		if recovered == http.ErrAbortHandler {
+			// Aborted responses, e.g. by httputil.ReverseProxy, are not
+			// errors of the handler and must stay aborted
+			panic(recovered)
+		}
+		var stack []byte
+		if recovered != nil {
+			stack = debug.Stack()
+		}
+		if pages != nil {
+			r.writeErrorPage(pages, req, recovered, stack)
+		}
+		r.runAfterHooks(req, rw, start, recovered)
+
+		event := Event{Request: req, Route: route, Status: rw.Status(), Duration: time.Since(start)}
+		if r.ClientGone(req) {
+			event.Status = StatusClientClosedRequest
+		}
+		if recovered != nil {
+			event.Status = http.StatusInternalServerError
+			event.Panic = recovered
+			r.reportError(event, stack)
+			r.events.emit(r.events.panic, event)
+			if pages == nil {
+				panic(recovered)
+			}
+			return
+		}
+		if event.Status >= 500 {
+			r.reportError(event, nil)
+		}
+		r.events.emit(r.events.response, event)
+	}()
 
-	// Determine the appropriate route based on the requested method and path
-	if routes, ok := r.routes[req.Method]; ok {
-		if r, ok := routes[req.URL.Path]; ok {
-			route = r
+	// Turn requests away once the router is shutting down
+	if r.rejectClosing(out) {
+		return
+	}
+
+	// Apply the rewrite rules before matching
+	req, ok := r.applyRewrites(out, req)
+	if !ok {
+		return
+	}
+
+	// Time the routing when the ServerTiming middleware is in use
+	var timing *Timing
+	if atomic.LoadInt32(&r.serverTiming) != 0 {
+		timing = &Timing{start: start}
+		ctx = req.Context()
+		ctx = context.WithValue(ctx, "timing", timing)
+		req = req.WithContext(ctx)
+	}
+
+	// Determine the appropriate route based on the requested method and path,
+	// answering CORS preflight requests for the route they are sent for
+	route, params, status := r.lookup(req)
+	if route == nil && isPreflight(req) {
+		if preflight := r.lookupPreflight(req); preflight != nil {
+			if r.skipPreflightMiddleware {
+				route = preflight
+				preflight.HandlerFunc(out, req)
+				return
+			}
+			route, params, status = preflight, nil, 0
 		}
 	}
+	if timing != nil {
+		timing.routed = time.Now()
+	}
+	if s := storeOf(req); s != nil {
+		s.pathParams = params
+		s.route = route
+		s.mu.Unlock()
+	}
+	if route != nil {
+		r.events.emit(r.events.routeMatched, Event{Request: req, Route: route})
+	} else if status == http.StatusNotFound {
+		r.events.emit(r.events.notFound, Event{Request: req})
+	}
 
-	// If no route found, use the not found handler or default to http.NotFound
+	// Allow cross-origin requests according to the route's CORS policy
+	if route != nil && req.Method != http.MethodOptions && req.Header.Get("Origin") != "" {
+		if policy := r.corsPolicy(route); policy != nil {
+			policy.apply(out, req)
+		}
+	}
+
+	// Queue or shed the request when too many requests are in flight
+	if !r.admission.admit(req, route.priority()) {
+		out.Header().Set("Retry-After", "1")
+		http.Error(out, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	defer r.admission.release()
+
+	// Tell the listeners as soon as the client goes away
+	defer r.watchClient(req, route)()
+
+	r.serve(out, req, route, status)
+}
+
+// lookup returns the route matching the request's method and path along
+// with the captured path parameters, or the status code to respond with if
+// there is none. Routes added in code take precedence over routes loaded
+// from a configuration, and routes registered for the request's method over
+// the fallback routes registered for any method.
+func (r *Router) lookup(req *http.Request) (*Route, map[string]string, int) {
+	route, params, status := r.lookupMethod(req, req.Method)
+	if route == nil && status == http.StatusNotFound && req.Method != MethodAny {
+		return r.lookupMethod(req, MethodAny)
+	}
+	return route, params, status
+}
+
+// lookupMethod looks up the routes of the method in the route tables.
+func (r *Router) lookupMethod(req *http.Request, method string) (*Route, map[string]string, int) {
+	route, params, status := r.routes.lookupMethod(req, method)
+	if route != nil {
+		return route, params, 0
+	}
+	if config, ok := r.config.Load().(*routeTable); ok {
+		route, params, s := config.lookupMethod(req, method)
+		if route != nil || status == http.StatusNotFound {
+			return route, params, s
+		}
+	}
+	return nil, nil, status
+}
+
+// Use adds middleware to the route. It runs after the router middleware.
+func (route *Route) Use(middleware ...func(http.HandlerFunc) http.HandlerFunc) *Route {
+	route.middleware = append(route.middleware, middleware...)
+	route.invalidate()
+	return route
+}
+
+// conditional reports whether the route only matches some requests.
+func (route *Route) conditional() bool {
+	return len(route.matchers) > 0 || len(route.consumes) > 0 || len(route.produces) > 0
+}
+
+// check returns the quality with which the route matches the request's
+// Accept header, or the status code explaining why it does not match.
+func (route *Route) check(req *http.Request) (float64, int) {
+	for _, match := range route.matchers {
+		if !match(req) {
+			return 0, http.StatusNotFound
+		}
+	}
+	if len(route.consumes) > 0 && !route.consumesRequest(req) {
+		return 0, http.StatusUnsupportedMediaType
+	}
+	if len(route.produces) == 0 {
+		return 1, 0
+	}
+	q := route.acceptQuality(req)
+	if q == 0 {
+		return 0, http.StatusNotAcceptable
+	}
+	return q, 0
+}
+
+// serve calls the route's handler wrapped in the router middleware.
+func (r *Router) serve(w http.ResponseWriter, req *http.Request, route *Route, status int) {
+	// If a route only failed on its content type constraints, say so
+	if route == nil && status != 0 && status != http.StatusNotFound {
+		route = &Route{
+			HandlerFunc: func(w http.ResponseWriter, req *http.Request) {
+				http.Error(w, http.StatusText(status), status)
+			},
+		}
+	}
+
+	// Routes disabled with 404 look like missing routes
+	if route != nil && route.disabledAsNotFound() && !r.Maintenance() {
+		route = nil
+	}
+
+	// If no route found, use the not found handler of the request's group or
+	// of the router, or default to http.NotFound, suggesting near-miss routes
+	// in development mode
 	if route == nil {
-		if r.notFoundHandler != nil {
+		if notFound := r.groupNotFound(req); notFound != nil {
+			route = notFound
+		} else if r.notFoundHandler != nil {
 			route = &Route{
 				HandlerFunc: r.notFoundHandler,
 			}
+		} else if r.devMode() {
+			route = &Route{
+				HandlerFunc: r.devNotFound,
+			}
 		} else {
 			route = &Route{
 				HandlerFunc: http.NotFound,
@@ -80,49 +408,125 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 
-	// Apply middleware in reverse order
-	handler := route.HandlerFunc
-	for i := len(r.middleware) - 1; i >= 0; i-- {
-		handler = r.middleware[i](handler)
+	// Answer with 503 for disabled routes and during maintenance
+	if r.unavailable(w, route) {
+		return
 	}
 
-	// Generate correlation ID using UUID
-	correlationID := uuid.New().String()
+	// Call the handler with the modified request, composing the fallback
+	// routes created above on every request
+	if route.table == nil {
+		r.compose(route)(w, req)
+		return
+	}
+	r.handler(route)(w, req)
+}
 
-	// Set correlation ID in request context
-	ctx := req.Context()
-	ctx = context.WithValue(ctx, "correlationID", correlationID)
-	req = req.WithContext(ctx)
+// compiledHandler is the handler of a route wrapped in its middleware, as
+// composed for a generation of the router middleware.
+type compiledHandler struct {
+	router  *Router
+	gen     uint64
+	handler http.HandlerFunc
+}
 
-	// Parse query parameters
-	queryParams, err := url.ParseQuery(req.URL.RawQuery)
-	if err != nil {
-		r.logger.Errorf("Failed to parse query parameters: %v", err)
+// Compile composes the handlers of the routes with their middleware ahead
+// of the first requests. Handlers are otherwise composed on first use, and
+// again once middleware is added to the router or the route.
+func (r *Router) Compile() {
+	for _, route := range r.allRoutes() {
+		r.handler(route)
 	}
+}
 
-	// Add query parameters to the request context
-	ctx = req.Context()
-	ctx = context.WithValue(ctx, "queryParams", queryParams)
-	req = req.WithContext(ctx)
+// handler returns the route's handler wrapped in its middleware.
+func (r *Router) handler(route *Route) http.HandlerFunc {
+	gen := atomic.LoadUint64(&r.middlewareGen)
+	if c, ok := route.compiled.Load().(*compiledHandler); ok && c.handler != nil && c.router == r && c.gen == gen {
+		return c.handler
+	}
+	handler := r.compose(route)
+	route.compiled.Store(&compiledHandler{router: r, gen: gen, handler: handler})
+	return handler
+}
+
+// invalidate discards the composed handler of the route.
+func (route *Route) invalidate() {
+	route.compiled.Store(&compiledHandler{})
+}
+
+// compose wraps the route's handler in its middleware.
+func (r *Router) compose(route *Route) http.HandlerFunc {
+	// The stubbed handler and the timing depend on the request
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		handler := r.stubHandler(route)
+		if timing := r.Timing(req); timing != nil {
+			handler = timing.wrap(handler)
+		}
+		handler(w, req)
+	}
 
-	// Call the handler with the modified request
-	handler(w, req)
+	// Apply the middleware chain in reverse order
+	chain := r.middlewareChain(route)
+	for i := len(chain) - 1; i >= 0; i-- {
+		handler = chain[i](handler)
+	}
+
+	// Check the required query parameters, then run the route's continue
+	// checks before anything reads the body
+	handler = route.withRequiredQuery(r, handler)
+	handler = route.withContinueChecks(handler)
+
+	// Run the route's post-hooks once the response has been written
+	return route.withPostHooks(handler)
+}
+
+// middlewareChain returns the middleware the requests to the route go
+// through, outermost first: the router middleware, then the group
+// middleware from the outermost group, then the route middleware.
+func (r *Router) middlewareChain(route *Route) []func(http.HandlerFunc) http.HandlerFunc {
+	chain := r.scoped(r.middleware, route.useCount, route)
+	for _, g := range route.groups {
+		chain = append(chain, r.scoped(g.group.middleware, g.useCount, route)...)
+	}
+	return append(chain, route.middleware...)
+}
 
-	// Set the response for the route
-	if route.Response != nil {
-		route.Response(w, req)
+// scoped returns the middleware applying to the route. Only the first
+// useCount middleware apply to routes registered with the
+// MiddlewareLaterRoutes scope.
+func (r *Router) scoped(middleware []func(http.HandlerFunc) http.HandlerFunc, useCount int, route *Route) []func(http.HandlerFunc) http.HandlerFunc {
+	if r.middlewareScope == MiddlewareLaterRoutes && route.table != nil && useCount < len(middleware) {
+		middleware = middleware[:useCount]
 	}
+	return append([]func(http.HandlerFunc) http.HandlerFunc(nil), middleware...)
 }
 
-// GetQueryParams retrieves the query parameters from the request.
+// GetQueryParams retrieves the query parameters from the request. They are
+// parsed on first use and cached for the rest of the request.
 func (r *Router) GetQueryParams(req *http.Request) url.Values {
-	queryParams, ok := req.Context().Value("queryParams").(url.Values)
-	if !ok {
-		return nil
+	queryParams, err := parseQuery(req)
+	if err != nil {
+		r.logger.Errorf("Failed to parse query parameters: %s", r.Redactor().String(err.Error()))
 	}
 	return queryParams
 }
 
+// GetPathParams retrieves the path parameters captured by the matched route.
+func (r *Router) GetPathParams(req *http.Request) map[string]string {
+	s := storeOf(req)
+	if s == nil {
+		return nil
+	}
+	defer s.mu.Unlock()
+	return s.pathParams
+}
+
+// GetPathParam retrieves a single path parameter captured by the matched route.
+func (r *Router) GetPathParam(req *http.Request, name string) string {
+	return r.GetPathParams(req)[name]
+}
+
 // GetFormParams retrieves the form parameters from the request.
 func (r *Router) GetFormParams(req *http.Request) (url.Values, error) {
 	err := req.ParseForm()
@@ -132,11 +536,22 @@ func (r *Router) GetFormParams(req *http.Request) (url.Values, error) {
 	return req.Form, nil
 }
 
+// GetRoute returns the route matched by the request, or nil if there is none.
+func (r *Router) GetRoute(req *http.Request) *Route {
+	s := storeOf(req)
+	if s == nil {
+		return nil
+	}
+	defer s.mu.Unlock()
+	return s.route
+}
+
 // GetCorrelationID retrieves the correlation ID from the request.
 func (r *Router) GetCorrelationID(req *http.Request) string {
-	correlationID, ok := req.Context().Value("correlationID").(string)
-	if !ok {
+	s := storeOf(req)
+	if s == nil {
 		return ""
 	}
-	return correlationID
+	defer s.mu.Unlock()
+	return s.correlationID
 }
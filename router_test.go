@@ -3,6 +3,8 @@ package router
 import (
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -32,8 +34,8 @@ func TestRouter(t *testing.T) {
 	}
 
 	// Add routes to the router
-	router.AddRoute("GET", "/hello", helloHandler)
-	router.AddRoute("POST", "/users", createUserHandler)
+	router.MustAddRoute("GET", "/hello", helloHandler)
+	router.MustAddRoute("POST", "/users", createUserHandler)
 	router.SetNotFoundHandler(notFoundHandler)
 
 	// Test cases
@@ -120,7 +122,7 @@ func TestRouter(t *testing.T) {
 		}
 
 		// Add route with middleware
-		router.AddRoute("GET", "/middleware", handlerWithMiddleware)
+		router.MustAddRoute("GET", "/middleware", handlerWithMiddleware)
 
 		req, err := http.NewRequest("GET", "/middleware", nil)
 		if err != nil {
@@ -157,7 +159,7 @@ func TestRouter(t *testing.T) {
 		}
 
 		// Add route with correlation ID
-		router.AddRoute("GET", "/correlation", handlerWithCorrelationID)
+		router.MustAddRoute("GET", "/correlation", handlerWithCorrelationID)
 
 		req, err := http.NewRequest("GET", "/correlation", nil)
 		if err != nil {
@@ -188,7 +190,7 @@ func TestRouter(t *testing.T) {
 		}
 
 		// Add route with query parameters
-		router.AddRoute("GET", "/query", handlerWithQueryParams)
+		router.MustAddRoute("GET", "/query", handlerWithQueryParams)
 
 		req, err := http.NewRequest("GET", "/query?name=John", nil)
 		if err != nil {
@@ -210,6 +212,36 @@ func TestRouter(t *testing.T) {
 		}
 	})
 
+	t.Run("Path Parameters", func(t *testing.T) {
+		// Test handler with path parameters
+		handlerWithPathParams := func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(router.GetPathParam(req, "id") + " " + router.GetPathParam(req, "filepath")))
+		}
+
+		// Add routes with a parameter and a wildcard segment
+		router.MustAddRoute("GET", "/users/:id/files/*filepath", handlerWithPathParams)
+
+		req, err := http.NewRequest("GET", "/users/42/files/docs/readme.md", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Check the response status code
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+
+		// Check the response body (path parameters)
+		expectedPathParams := "42 docs/readme.md"
+		if rr.Body.String() != expectedPathParams {
+			t.Errorf("Expected response body %q, but got %q", expectedPathParams, rr.Body.String())
+		}
+	})
+
 	t.Run("Form Parameters", func(t *testing.T) {
 		// Test handler with form parameters
 		handlerWithFormParams := func(w http.ResponseWriter, req *http.Request) {
@@ -224,7 +256,7 @@ func TestRouter(t *testing.T) {
 		}
 
 		// Add route with form parameters
-		router.AddRoute("POST", "/form", handlerWithFormParams)
+		router.MustAddRoute("POST", "/form", handlerWithFormParams)
 
 		// Create a test request with form data
 		req, err := http.NewRequest("POST", "/form", nil)
@@ -251,3 +283,219 @@ func TestRouter(t *testing.T) {
 		}
 	})
 }
+
+func TestRoutePriority(t *testing.T) {
+	named := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte(name))
+		}
+	}
+	router := NewRouter()
+	router.MustAddRoute("GET", "/users/new", named("new"))
+	router.MustAddRoute("GET", "/users/:id", named("user")).Priority(10)
+	router.MustAddRoute("GET", "/files/*path", named("files"))
+	router.MustAddRoute("GET", "/files/:name", named("file"))
+	router.MustAddRoute("GET", "/docs/:page", named("page")).Priority(-1)
+	router.MustAddRoute("GET", "/docs/*path", named("docs"))
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		// A pattern with a higher priority wins over a static path
+		{"/users/new", "user"},
+		{"/users/42", "user"},
+		// The most specific pattern wins by default
+		{"/files/report.pdf", "file"},
+		{"/files/2024/report.pdf", "files"},
+		// A negative priority moves a pattern after the others
+		{"/docs/intro", "docs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the route serving the request
+			if body := rr.Body.String(); body != tt.want {
+				t.Errorf("Expected route %q, but got %q", tt.want, body)
+			}
+		})
+	}
+
+	t.Run("Warnings follow priorities", func(t *testing.T) {
+		warnings := strings.Join(router.RouteWarnings(), "\n")
+		for _, warning := range []string{
+			"route GET /users/new is shadowed by GET /users/:id",
+			"route GET /docs/:page is shadowed by GET /docs/*path",
+		} {
+			if !strings.Contains(warnings, warning) {
+				t.Errorf("Expected warning %q, but got %q", warning, warnings)
+			}
+		}
+	})
+}
+
+func TestOptionalSegments(t *testing.T) {
+	router := NewRouter()
+	router.MustAddRoute("GET", "/reports/:year/:month?/:day?", func(w http.ResponseWriter, req *http.Request) {
+		params := router.GetPathParams(req)
+		_, hasMonth := params["month"]
+		w.Write([]byte(params["year"] + "|" + params["month"] + "|" + params["day"] + "|" + strconv.FormatBool(hasMonth)))
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/reports/2024", http.StatusOK, "2024|||false"},
+		{"/reports/2024/05", http.StatusOK, "2024|05||true"},
+		{"/reports/2024/05/17", http.StatusOK, "2024|05|17|true"},
+		{"/reports", http.StatusNotFound, ""},
+		{"/reports/2024/05/17/extra", http.StatusNotFound, ""},
+		{"/reports/2024//17", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if status := rr.Code; status != tt.status {
+				t.Errorf("Expected status code %d, but got %d", tt.status, status)
+			}
+
+			// Check the captured parameters
+			if tt.status == http.StatusOK && rr.Body.String() != tt.body {
+				t.Errorf("Expected response body %q, but got %q", tt.body, rr.Body.String())
+			}
+		})
+	}
+
+	t.Run("Optional segment not trailing", func(t *testing.T) {
+		if err := validatePattern("/reports/:year?/summary"); err == nil {
+			t.Error("Expected an error for an optional segment followed by a required one")
+		}
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected AddRoute to panic")
+			}
+		}()
+		router.MustAddRoute("GET", "/reports/:year?/summary", func(w http.ResponseWriter, req *http.Request) {})
+	})
+
+	t.Run("Rewrite without the optional segment", func(t *testing.T) {
+		if got := expand("/r/:year/:month?", map[string]string{"year": "2024"}); got != "/r/2024" {
+			t.Errorf("Expected %q, but got %q", "/r/2024", got)
+		}
+	})
+}
+
+func TestCompiledMiddleware(t *testing.T) {
+	// counting returns middleware counting how often it wraps a handler and
+	// adding its name to the X-Chain header
+	counting := func(name string, wraps *int) func(http.HandlerFunc) http.HandlerFunc {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			*wraps++
+			return func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Add("X-Chain", name)
+				next(w, req)
+			}
+		}
+	}
+	serve := func(router *Router) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/users", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	router := NewRouter()
+	var outer, inner, late int
+	router.Use(counting("outer", &outer))
+	route := router.MustAddRoute("GET", "/users", func(w http.ResponseWriter, req *http.Request) {})
+	router.Compile()
+
+	t.Run("Composed once", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			serve(router)
+		}
+
+		// Check that the middleware wrapped the handler once
+		if outer != 1 {
+			t.Errorf("Expected the middleware to wrap the handler %d time, but got %d", 1, outer)
+		}
+	})
+
+	t.Run("Middleware added later", func(t *testing.T) {
+		route.Use(counting("inner", &inner))
+		router.Use(counting("late", &late))
+		serve(router)
+		rr := serve(router)
+
+		// Check that the handler was composed again with the new middleware
+		if chain := rr.Header().Values("X-Chain"); len(chain) != 3 || chain[0] != "outer" || chain[1] != "late" || chain[2] != "inner" {
+			t.Errorf("Expected middleware chain %v, but got %v", []string{"outer", "late", "inner"}, chain)
+		}
+		if inner != 1 || late != 1 {
+			t.Errorf("Expected the new middleware to wrap the handler once, but got %d and %d", inner, late)
+		}
+	})
+}
+
+func TestMiddlewareScope(t *testing.T) {
+	tag := func(name string) func(http.HandlerFunc) http.HandlerFunc {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Add("X-Chain", name)
+				next(w, req)
+			}
+		}
+	}
+	chain := func(router *Router, path string) string {
+		req := httptest.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return strings.Join(rr.Header().Values("X-Chain"), ",")
+	}
+	handler := func(w http.ResponseWriter, req *http.Request) {}
+
+	t.Run("All routes", func(t *testing.T) {
+		router := NewRouter()
+		router.MustAddRoute("GET", "/public", handler)
+		router.Use(tag("auth"))
+		router.MustAddRoute("GET", "/private", handler)
+
+		// Check that the middleware applies to the routes registered before
+		if got := chain(router, "/public"); got != "auth" {
+			t.Errorf("Expected middleware %q, but got %q", "auth", got)
+		}
+	})
+
+	t.Run("Later routes", func(t *testing.T) {
+		router := NewRouter()
+		router.SetMiddlewareScope(MiddlewareLaterRoutes)
+		router.Use(tag("log"))
+		router.MustAddRoute("GET", "/public", handler)
+		router.Use(tag("auth"))
+		router.MustAddRoute("GET", "/private", handler)
+
+		// Check that the middleware only applies to the routes registered after
+		if got := chain(router, "/public"); got != "log" {
+			t.Errorf("Expected middleware %q, but got %q", "log", got)
+		}
+		if got := chain(router, "/private"); got != "log,auth" {
+			t.Errorf("Expected middleware %q, but got %q", "log,auth", got)
+		}
+
+		// Check that unmatched requests run all the middleware
+		if got := chain(router, "/missing"); got != "log,auth" {
+			t.Errorf("Expected middleware %q, but got %q", "log,auth", got)
+		}
+	})
+}
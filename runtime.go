@@ -0,0 +1,140 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Routes returns the registered routes, including the routes loaded from a
+// configuration, sorted by path and method.
+func (r *Router) Routes() []RouteInfo {
+	var infos []RouteInfo
+	for _, route := range r.allRoutes() {
+		infos = append(infos, RouteInfo{
+			Method:  route.Method,
+			Path:    route.Path,
+			Enabled: atomic.LoadInt32(&route.disabled) == 0,
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Path != infos[j].Path {
+			return infos[i].Path < infos[j].Path
+		}
+		return infos[i].Method < infos[j].Method
+	})
+	return infos
+}
+
+// allRoutes returns the routes added in code and loaded from a configuration.
+func (r *Router) allRoutes() []*Route {
+	var routes []*Route
+	tables := []*routeTable{r.routes}
+	if config, ok := r.config.Load().(*routeTable); ok {
+		tables = append(tables, config)
+	}
+	for _, table := range tables {
+		for _, byPath := range table.routes {
+			for _, candidates := range byPath {
+				routes = append(routes, candidates...)
+			}
+		}
+	}
+	return routes
+}
+
+// Disable makes the routes matching the method and path respond with 503
+// Service Unavailable without removing them. The method may be "*" to match
+// any method, and a path ending with "*" matches the routes whose path
+// starts with the rest, e.g. Disable("GET", "/beta/*"). Admin routes cannot
+// be disabled. It returns the number of routes disabled.
+func (r *Router) Disable(method string, path string) int {
+	return r.setDisabled(method, path, http.StatusServiceUnavailable)
+}
+
+// DisableWithStatus is like Disable, but the routes respond with the
+// status, which must be 404 Not Found or 503 Service Unavailable. With 404
+// the routes look like they do not exist.
+func (r *Router) DisableWithStatus(method string, path string, status int) (int, error) {
+	if status != http.StatusNotFound && status != http.StatusServiceUnavailable {
+		return 0, fmt.Errorf("router: cannot disable routes with status %d", status)
+	}
+	return r.setDisabled(method, path, int32(status)), nil
+}
+
+// Enable re-enables the routes disabled with Disable, matching the method
+// and path as Disable does. It returns the number of routes enabled.
+func (r *Router) Enable(method string, path string) int {
+	return r.setDisabled(method, path, 0)
+}
+
+// setDisabled sets the status of the routes matching method and path.
+func (r *Router) setDisabled(method string, path string, status int32) int {
+	n := 0
+	for _, route := range r.allRoutes() {
+		if route.admin || !routeSelected(route, method, path) {
+			continue
+		}
+		atomic.StoreInt32(&route.disabled, status)
+		n++
+	}
+	return n
+}
+
+// routeSelected reports whether the route matches the method, or "*", and
+// the path, or the path prefix before a trailing "*".
+func routeSelected(route *Route, method string, path string) bool {
+	if method != "*" && route.Method != method {
+		return false
+	}
+	if route.Path == path {
+		return true
+	}
+	prefix := strings.TrimSuffix(path, "*")
+	return prefix != path && strings.HasPrefix(route.Path, prefix)
+}
+
+// SetMaintenance turns maintenance mode on or off. In maintenance mode all
+// routes except the admin routes respond with 503 Service Unavailable.
+func (r *Router) SetMaintenance(enabled bool) {
+	var maintenance int32
+	if enabled {
+		maintenance = 1
+	}
+	atomic.StoreInt32(&r.maintenance, maintenance)
+}
+
+// Maintenance reports whether maintenance mode is on.
+func (r *Router) Maintenance() bool {
+	return atomic.LoadInt32(&r.maintenance) == 1
+}
+
+// disabledAsNotFound reports whether the route is disabled with 404 Not
+// Found, so that it is served by the not found handler.
+func (route *Route) disabledAsNotFound() bool {
+	return !route.admin && atomic.LoadInt32(&route.disabled) == http.StatusNotFound
+}
+
+// unavailable answers with 503 Service Unavailable if the route is disabled
+// or the router is in maintenance mode. It reports whether it did.
+func (r *Router) unavailable(w http.ResponseWriter, route *Route) bool {
+	if route.admin {
+		return false
+	}
+	if atomic.LoadInt32(&route.disabled) == 0 && !r.Maintenance() {
+		return false
+	}
+	w.Header().Set("Retry-After", "60")
+	http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	return true
+}
@@ -0,0 +1,68 @@
+package router
+
+import (
+	"net/http"
+	"time"
+)
+
+// timeNow returns the current time. It is replaced in tests.
+var timeNow = time.Now
+
+// AvailableBetween restricts the route to the time window between start
+// and end. Before start the route does not match and the request falls
+// through to the not found handler; after end it responds with 410 Gone.
+// A zero start or end leaves the window open on that side.
+func (route *Route) AvailableBetween(start time.Time, end time.Time) *Route {
+	if !start.IsZero() {
+		route.matchers = append(route.matchers, func(req *http.Request) bool {
+			return !timeNow().Before(start)
+		})
+	}
+
+	if !end.IsZero() {
+		handler := route.HandlerFunc
+		route.HandlerFunc = func(w http.ResponseWriter, req *http.Request) {
+			if !timeNow().Before(end) {
+				http.Error(w, http.StatusText(http.StatusGone), http.StatusGone)
+				return
+			}
+			handler(w, req)
+		}
+	}
+	return route
+}
+
+// AvailableDaily restricts the route to a recurring daily window, given as
+// offsets from midnight in loc, on the given weekdays (every day when none
+// are given). Outside the window the route does not match. A window whose
+// end is before its start spans midnight.
+func (route *Route) AvailableDaily(from time.Duration, to time.Duration, loc *time.Location, days ...time.Weekday) *Route {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	route.matchers = append(route.matchers, func(req *http.Request) bool {
+		now := timeNow().In(loc)
+		if len(days) > 0 && !containsWeekday(days, now.Weekday()) {
+			return false
+		}
+
+		midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		offset := now.Sub(midnight)
+		if from <= to {
+			return offset >= from && offset < to
+		}
+		return offset >= from || offset < to
+	})
+	return route
+}
+
+// containsWeekday reports whether day is one of days.
+func containsWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
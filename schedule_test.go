@@ -0,0 +1,54 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+
+	start := time.Date(2024, time.November, 29, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	router := NewRouter()
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("available"))
+	}
+	router.MustAddRoute("GET", "/promo", handler).AvailableBetween(start, end)
+	router.MustAddRoute("GET", "/maintenance", handler).AvailableDaily(22*time.Hour, 2*time.Hour, time.UTC, time.Saturday)
+
+	tests := []struct {
+		name         string
+		path         string
+		now          time.Time
+		expectedCode int
+	}{
+		{"Before the window", "/promo", start.Add(-time.Minute), http.StatusNotFound},
+		{"Inside the window", "/promo", start.Add(time.Hour), http.StatusOK},
+		{"After the window", "/promo", end, http.StatusGone},
+		{"Inside the daily window", "/maintenance", time.Date(2024, time.November, 30, 23, 0, 0, 0, time.UTC), http.StatusOK},
+		{"Outside the daily window", "/maintenance", time.Date(2024, time.November, 30, 12, 0, 0, 0, time.UTC), http.StatusNotFound},
+		{"Wrong weekday", "/maintenance", time.Date(2024, time.November, 29, 23, 0, 0, 0, time.UTC), http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeNow = func() time.Time { return tt.now }
+
+			req, err := http.NewRequest("GET", tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, but got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Router lifecycle states.
+const (
+	stateServing int32 = iota
+	stateDraining
+	stateClosing
+)
+
+// server holds the HTTP servers started by the router.
+type server struct {
+	mu          sync.Mutex
+	servers     []*http.Server
+	drainPeriod time.Duration
+	state       int32        // accessed atomically
+	readiness   atomic.Value // readiness
+
+	proxyProtocol *ProxyProtocolOptions
+}
+
+// readiness holds the reason the application is not ready, nil if it is.
+type readiness struct {
+	err error
+}
+
+// ListenAndServe listens on the TCP address and serves the router. It
+// returns http.ErrServerClosed after Shutdown.
+func (r *Router) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return r.Serve(l)
+}
+
+// Serve serves the router on the listener. It returns http.ErrServerClosed
+// after Shutdown.
+func (r *Router) Serve(l net.Listener) error {
+	if opts := r.startupSummary; opts != nil {
+		r.summaryOnce.Do(func() {
+			r.LogRouteSummary(*opts)
+		})
+	}
+	srv := &http.Server{Handler: r}
+
+	r.server.mu.Lock()
+	if atomic.LoadInt32(&r.server.state) != stateServing {
+		r.server.mu.Unlock()
+		l.Close()
+		return http.ErrServerClosed
+	}
+	r.server.servers = append(r.server.servers, srv)
+	if opts := r.server.proxyProtocol; opts != nil {
+		// The options were validated by ProxyProtocol
+		l, _ = ProxyProtocolListener(l, *opts)
+	}
+	r.server.mu.Unlock()
+
+	return srv.Serve(l)
+}
+
+// SetDrainPeriod sets how long Shutdown keeps serving requests after the
+// readiness endpoint starts failing, giving load balancers time to take the
+// instance out of rotation.
+func (r *Router) SetDrainPeriod(d time.Duration) {
+	r.server.mu.Lock()
+	defer r.server.mu.Unlock()
+	r.server.drainPeriod = d
+}
+
+// Shutdown gracefully stops the servers started with Serve. It flips the
+// readiness endpoint to failing, waits for the drain period, answers new
+// requests with 503 Service Unavailable and a Retry-After header, then
+// closes the listeners and waits for in-flight requests to complete and
+// their audit records to be stored, or ctx to be done.
+func (r *Router) Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&r.server.state, stateDraining)
+
+	r.server.mu.Lock()
+	drainPeriod := r.server.drainPeriod
+	r.server.mu.Unlock()
+
+	if drainPeriod > 0 {
+		timer := time.NewTimer(drainPeriod)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	atomic.StoreInt32(&r.server.state, stateClosing)
+
+	r.server.mu.Lock()
+	servers := r.server.servers
+	r.server.servers = nil
+	r.server.mu.Unlock()
+
+	var firstErr error
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if err := r.FlushAudit(ctx); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// rejectClosing answers the request with 503 Service Unavailable if the
+// router is shutting down. It reports whether the request was rejected.
+func (r *Router) rejectClosing(w http.ResponseWriter) bool {
+	if atomic.LoadInt32(&r.server.state) != stateClosing {
+		return false
+	}
+	w.Header().Set("Connection", "close")
+	w.Header().Set("Retry-After", "5")
+	http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	return true
+}
+
+// Ready marks the application as ready to receive traffic or not, e.g.
+// while warming up caches. The router is ready initially.
+func (r *Router) Ready(ready bool) {
+	if ready {
+		r.ReadyError(nil)
+	} else {
+		r.ReadyError(errors.New("not ready"))
+	}
+}
+
+// ReadyError marks the application as not ready to receive traffic because
+// of err, e.g. a dependency outage, or as ready again if err is nil.
+func (r *Router) ReadyError(err error) {
+	r.server.readiness.Store(readiness{err: err})
+}
+
+// ReadinessHandler returns a handler reporting whether the router is ready
+// to receive traffic. It responds with 503 Service Unavailable once
+// Shutdown has started or while the application is marked as not ready,
+// with the reason in the body.
+func (r *Router) ReadinessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if atomic.LoadInt32(&r.server.state) != stateServing {
+			http.Error(w, "shutting down", http.StatusServiceUnavailable)
+			return
+		}
+		if state, _ := r.server.readiness.Load().(readiness); state.err != nil {
+			http.Error(w, state.err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ready"))
+	}
+}
@@ -0,0 +1,119 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestShutdown(t *testing.T) {
+	router := NewRouter()
+	router.SetDrainPeriod(100 * time.Millisecond)
+	router.MustAddRoute("GET", "/readyz", router.ReadinessHandler())
+	router.MustAddRoute("GET", "/hello", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("Hello, World!"))
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- router.Serve(l)
+	}()
+
+	// Check the router is ready before shutting down
+	if rr := serve("/readyz"); rr.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+	}
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- router.Shutdown(context.Background())
+	}()
+
+	// Wait for the readiness endpoint to start failing
+	deadline := time.Now().Add(time.Second)
+	for serve("/readyz").Code != http.StatusServiceUnavailable {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the readiness endpoint to fail during shutdown")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Check requests are still served during the drain period
+	if rr := serve("/hello"); rr.Code != http.StatusOK {
+		t.Errorf("Expected status code %d during the drain period, but got %d", http.StatusOK, rr.Code)
+	}
+
+	if err := <-shutdown; err != nil {
+		t.Errorf("Expected no shutdown error, but got %v", err)
+	}
+	if err := <-served; err != http.ErrServerClosed {
+		t.Errorf("Expected %v, but got %v", http.ErrServerClosed, err)
+	}
+
+	// Check new requests are turned away after the drain period
+	rr := serve("/hello")
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status code %d, but got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected a Retry-After header")
+	}
+}
+
+func TestReadinessGate(t *testing.T) {
+	router := NewRouter()
+	router.MustAddRoute("GET", "/readyz", router.ReadinessHandler())
+
+	tests := []struct {
+		name   string
+		set    func()
+		status int
+		body   string
+	}{
+		{"Ready initially", func() {}, http.StatusOK, "ready"},
+		{"Not ready", func() { router.Ready(false) }, http.StatusServiceUnavailable, "not ready\n"},
+		{"Ready again", func() { router.Ready(true) }, http.StatusOK, "ready"},
+		{"Not ready with error", func() { router.ReadyError(errors.New("database unreachable")) }, http.StatusServiceUnavailable, "database unreachable\n"},
+		{"Error cleared", func() { router.ReadyError(nil) }, http.StatusOK, "ready"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.set()
+
+			req, err := http.NewRequest("GET", "/readyz", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if rr.Code != test.status {
+				t.Errorf("Expected status code %d, but got %d", test.status, rr.Code)
+			}
+
+			// Check the response body
+			if rr.Body.String() != test.body {
+				t.Errorf("Expected response body %q, but got %q", test.body, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Segment kinds, from the least to the most specific.
+const (
+	wildcardSegment = iota
+	paramSegment
+	staticSegment
+)
+
+// segmentKind returns the kind of a pattern segment.
+func segmentKind(segment string) int {
+	switch {
+	case strings.HasPrefix(segment, "*"):
+		return wildcardSegment
+	case len(segment) > 1 && segment[0] == ':':
+		return paramSegment
+	}
+	return staticSegment
+}
+
+// segmentKindNames names the segment kinds in explanations.
+var segmentKindNames = [...]string{"wildcard", "parameter", "static segment"}
+
+// compareSpecificity compares the patterns segment by segment: at the first
+// segment of a different kind, a static segment beats a parameter, which
+// beats a wildcard, so the longest static prefix wins. It returns a
+// positive number if a is more specific than b, a negative number if it is
+// less specific, and 0 otherwise, along with the index of the deciding
+// segment.
+func compareSpecificity(a *pattern, b *pattern) (int, int) {
+	for i := 0; i < len(a.segments) && i < len(b.segments); i++ {
+		ka, kb := segmentKind(a.segments[i]), segmentKind(b.segments[i])
+		if ka != kb {
+			return ka - kb, i
+		}
+		if ka == wildcardSegment {
+			break
+		}
+	}
+	return 0, -1
+}
+
+// MatchCandidate describes a route whose path matches a request, see
+// RouteMatch.
+type MatchCandidate struct {
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	Priority int    `json:"priority,omitempty"`
+	Selected bool   `json:"selected"`
+	// Reason explains why the route was selected or not.
+	Reason string `json:"reason"`
+}
+
+// explain lists the routes of the table whose path matches the request, in
+// the order they are tried, explaining why selected was chosen over them.
+// inherited is the reason given when selected is nil or in another table.
+func (t *routeTable) explain(req *http.Request, selected *Route, inherited string) []MatchCandidate {
+	byPath := t.routes[req.Method]
+	var routes []*Route
+	for _, route := range byPath[req.URL.Path] {
+		if route.pattern == nil {
+			routes = append(routes, route)
+		}
+	}
+	for _, path := range t.patterns[req.Method] {
+		if _, ok := byPath[path][0].pattern.match(req.URL.Path); ok {
+			routes = append(routes, byPath[path]...)
+		}
+	}
+
+	var candidates []MatchCandidate
+	for _, route := range routes {
+		candidate := MatchCandidate{
+			Method:   route.Method,
+			Path:     route.Path,
+			Priority: route.precedence,
+			Selected: route == selected,
+		}
+		switch {
+		case candidate.Selected:
+			candidate.Reason = "selected"
+			if route.conditional() {
+				candidate.Reason = "selected: its conditions match the request"
+			}
+		case route.conditional() && rejects(route, req):
+			candidate.Reason = "its conditions do not match the request"
+		case selected == nil || selected.table != t:
+			candidate.Reason = inherited
+		default:
+			candidate.Reason = precedenceReason(selected, route, byPath)
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// rejects reports whether the conditions of the route reject the request.
+func rejects(route *Route, req *http.Request) bool {
+	_, status := route.check(req)
+	return status != 0
+}
+
+// precedenceReason explains why selected takes precedence over route, both
+// matching the request.
+func precedenceReason(selected *Route, route *Route, byPath map[string][]*Route) string {
+	reason := func(format string, args ...interface{}) string {
+		return fmt.Sprintf("%s %s takes precedence: ", selected.Method, selected.Path) + fmt.Sprintf(format, args...)
+	}
+	if selected.Path == route.Path {
+		if selected.conditional() {
+			return reason("conditional routes take precedence over unconditional ones, and better Accept matches over worse ones")
+		}
+		return reason("the last route registered for a path is used")
+	}
+
+	selectedPriority, routePriority := selected.precedence, route.precedence
+	if selected.pattern != nil {
+		selectedPriority = pathPriority(byPath[selected.Path])
+	}
+	if route.pattern != nil {
+		routePriority = pathPriority(byPath[route.Path])
+	}
+	if selectedPriority != routePriority {
+		return reason("its priority %d is higher than %d", selectedPriority, routePriority)
+	}
+	if selected.pattern == nil {
+		return reason("static paths take precedence over patterns")
+	}
+	if cmp, i := compareSpecificity(selected.pattern, route.pattern); cmp > 0 {
+		return reason("its %s %q is more specific than the %s %q",
+			segmentKindNames[segmentKind(selected.pattern.segments[i])], selected.pattern.segments[i],
+			segmentKindNames[segmentKind(route.pattern.segments[i])], route.pattern.segments[i])
+	}
+	return reason("it was registered first")
+}
@@ -0,0 +1,181 @@
+package router
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatsDOptions configures the StatsD metrics emitter.
+type StatsDOptions struct {
+	// Addr is the address of the StatsD agent. It defaults to
+	// "127.0.0.1:8125".
+	Addr string
+	// Prefix is prepended to the metric names, e.g. "myapp.".
+	Prefix string
+	// DogStatsD sends the route, method and status as DogStatsD tags.
+	// Plain StatsD has no tags, so they are made part of the metric names
+	// otherwise.
+	DogStatsD bool
+	// Tags are DogStatsD tags added to every metric, e.g. "env:prod".
+	Tags []string
+	// FlushInterval is how often buffered metrics are sent. It defaults to
+	// one second.
+	FlushInterval time.Duration
+	// MaxPacketSize is the maximum size of a packet. It defaults to 1432
+	// bytes, which fits in an Ethernet frame.
+	MaxPacketSize int
+	// QueueSize is the number of metrics buffered before new ones are
+	// dropped. It defaults to 1024.
+	QueueSize int
+}
+
+// StatsD sends request metrics to a StatsD or DogStatsD agent over UDP.
+type StatsD struct {
+	opts      StatsDOptions
+	conn      net.Conn
+	lines     chan string
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// StatsD starts sending a count and a timing metric for every response,
+// http.requests and http.request.duration, with the route, method and
+// status code. Metrics are sent in batches and dropped when the agent
+// cannot keep up, so that requests are never slowed down.
+func (r *Router) StatsD(opts StatsDOptions) (*StatsD, error) {
+	if opts.Addr == "" {
+		opts.Addr = "127.0.0.1:8125"
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.MaxPacketSize <= 0 {
+		opts.MaxPacketSize = 1432
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1024
+	}
+	conn, err := net.Dial("udp", opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("router: connecting to StatsD agent: %v", err)
+	}
+
+	s := &StatsD{
+		opts:    opts,
+		conn:    conn,
+		lines:   make(chan string, opts.QueueSize),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go s.run()
+
+	r.OnResponse(func(event Event) {
+		route := "not_found"
+		if event.Route != nil {
+			route = event.Route.Path
+		}
+		status := strconv.Itoa(event.Status)
+		ms := strconv.FormatFloat(float64(event.Duration)/float64(time.Millisecond), 'f', 3, 64)
+		s.send("http.requests", "1|c", route, event.Request.Method, status)
+		s.send("http.request.duration", ms+"|ms", route, event.Request.Method, status)
+	})
+	return s, nil
+}
+
+// Close sends the buffered metrics and stops the emitter.
+func (s *StatsD) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		<-s.stopped
+		err = s.conn.Close()
+	})
+	return err
+}
+
+// send queues a metric, dropping it if the queue is full.
+func (s *StatsD) send(name, value, route, method, status string) {
+	var line string
+	if s.opts.DogStatsD {
+		tags := append([]string{"route:" + route, "method:" + method, "status:" + status}, s.opts.Tags...)
+		line = s.opts.Prefix + name + ":" + value + "|#" + strings.Join(tags, ",")
+	} else {
+		line = s.opts.Prefix + name + "." + statsDName(method) + "." + statsDName(route) + "." + status + ":" + value
+	}
+
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	select {
+	case s.lines <- line:
+	default:
+	}
+}
+
+// run batches the queued metrics into packets until the emitter is closed.
+func (s *StatsD) run() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+
+	var packet []byte
+	flush := func() {
+		if len(packet) > 0 {
+			s.conn.Write(packet)
+			packet = packet[:0]
+		}
+	}
+	add := func(line string) {
+		if len(packet) > 0 && len(packet)+1+len(line) > s.opts.MaxPacketSize {
+			flush()
+		}
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, line...)
+	}
+
+	for {
+		select {
+		case line := <-s.lines:
+			add(line)
+		case <-ticker.C:
+			flush()
+		case <-s.done:
+			for {
+				select {
+				case line := <-s.lines:
+					add(line)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// statsDName turns a route path or method into a metric name segment,
+// e.g. "/users/:id" into "users._id".
+func statsDName(s string) string {
+	s = strings.Trim(s, "/")
+	if s == "" {
+		return "root"
+	}
+	return strings.Map(func(c rune) rune {
+		switch {
+		case c == '/':
+			return '.'
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+			return c
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,72 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsD(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     StatsDOptions
+		expected []string
+	}{
+		{"StatsD", StatsDOptions{Prefix: "app."}, []string{
+			"app.http.requests.GET.users._id.200:1|c",
+			"app.http.request.duration.GET.users._id.200:",
+			"app.http.requests.GET.not_found.404:1|c",
+		}},
+		{"DogStatsD", StatsDOptions{DogStatsD: true, Tags: []string{"env:test"}}, []string{
+			"http.requests:1|c|#route:/users/:id,method:GET,status:200,env:test",
+			"http.request.duration:",
+			"http.requests:1|c|#route:not_found,method:GET,status:404,env:test",
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			agent, err := net.ListenPacket("udp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer agent.Close()
+
+			router := NewRouter()
+			test.opts.Addr = agent.LocalAddr().String()
+			statsd, err := router.StatsD(test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			router.MustAddRoute("GET", "/users/:id", func(w http.ResponseWriter, req *http.Request) {})
+
+			for _, path := range []string{"/users/42", "/missing"} {
+				req, err := http.NewRequest("GET", path, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				router.ServeHTTP(httptest.NewRecorder(), req)
+			}
+			statsd.Close()
+
+			// Check that the metrics are sent in a single packet
+			buf := make([]byte, 2048)
+			agent.SetReadDeadline(time.Now().Add(time.Second))
+			n, _, err := agent.ReadFrom(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(string(buf[:n]), "\n")
+			if len(lines) != 4 {
+				t.Fatalf("Expected 4 metrics, but got %q", lines)
+			}
+			for i, prefix := range test.expected {
+				if !strings.HasPrefix(lines[i], prefix) {
+					t.Errorf("Expected metric %q, but got %q", prefix, lines[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package router
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StatusPageOptions configures the status page.
+type StatusPageOptions struct {
+	// Title is the title of the HTML page. It defaults to "Status".
+	Title string
+	// Checks are health checks run each time the page is served, by name.
+	Checks map[string]func(ctx context.Context) error
+	// Window is the period over which the request and error rates are
+	// computed. It defaults to one minute.
+	Window time.Duration
+}
+
+// StatusReport is the summary shown on the status page.
+type StatusReport struct {
+	// Status is "ok", or "degraded" when the router is not ready, in
+	// maintenance, or a check fails.
+	Status      string        `json:"status"`
+	Uptime      float64       `json:"uptime"`
+	Requests    int64         `json:"requests"`
+	Errors      int64         `json:"errors"`
+	RequestRate float64       `json:"request_rate"`
+	ErrorRate   float64       `json:"error_rate"`
+	InFlight    int           `json:"in_flight"`
+	Maintenance bool          `json:"maintenance"`
+	Checks      []StatusCheck `json:"checks"`
+}
+
+// StatusCheck is the result of a health check.
+type StatusCheck struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// EnableStatusPage adds a GET route at path serving a status page for
+// internal dashboards: uptime, request rate, rate of 5xx responses over the
+// window, readiness and health checks. The page is served as JSON to
+// clients accepting application/json or passing format=json, and as HTML
+// otherwise. It stays available in maintenance mode. Check errors are
+// redacted with the router's redactor. It returns an error if the route
+// cannot be added, see AddRoute.
+func (r *Router) EnableStatusPage(path string, opts StatusPageOptions) (*Route, error) {
+	if opts.Title == "" {
+		opts.Title = "Status"
+	}
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+	start := time.Now()
+	counter := newRateCounter(int(opts.Window / time.Second))
+	var requests, errors int64
+	route, err := r.AddRoute("GET", path, func(w http.ResponseWriter, req *http.Request) {
+		report := StatusReport{
+			Status:      "ok",
+			Uptime:      time.Since(start).Seconds(),
+			Requests:    atomic.LoadInt64(&requests),
+			Errors:      atomic.LoadInt64(&errors),
+			InFlight:    r.InFlight(),
+			Maintenance: atomic.LoadInt32(&r.maintenance) != 0,
+		}
+		windowRequests, windowErrors := counter.sum(time.Now())
+		report.RequestRate = float64(windowRequests) / opts.Window.Seconds()
+		if windowRequests > 0 {
+			report.ErrorRate = float64(windowErrors) / float64(windowRequests)
+		}
+
+		ready := StatusCheck{Name: "readiness", OK: true}
+		if atomic.LoadInt32(&r.server.state) != stateServing {
+			ready = StatusCheck{Name: "readiness", Error: "shutting down"}
+		} else if state, _ := r.server.readiness.Load().(readiness); state.err != nil {
+			ready = StatusCheck{Name: "readiness", Error: r.Redactor().String(state.err.Error())}
+		}
+		report.Checks = append(report.Checks, ready)
+
+		names := make([]string, 0, len(opts.Checks))
+		for name := range opts.Checks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			check := StatusCheck{Name: name, OK: true}
+			if err := opts.Checks[name](req.Context()); err != nil {
+				check = StatusCheck{Name: name, Error: r.Redactor().String(err.Error())}
+			}
+			report.Checks = append(report.Checks, check)
+		}
+
+		for _, check := range report.Checks {
+			if !check.OK {
+				report.Status = "degraded"
+			}
+		}
+		if report.Maintenance {
+			report.Status = "degraded"
+		}
+
+		if req.URL.Query().Get("format") == "json" || strings.Contains(req.Header.Get("Accept"), "application/json") {
+			writeJSON(w, http.StatusOK, report)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := statusPage.Execute(w, struct {
+			Title string
+			StatusReport
+		}{opts.Title, report}); err != nil {
+			r.logger.Errorf("Failed to render status page: %v", err)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	route.admin = true
+
+	r.OnResponse(func(event Event) {
+		failed := event.Status >= 500
+		atomic.AddInt64(&requests, 1)
+		if failed {
+			atomic.AddInt64(&errors, 1)
+		}
+		counter.add(time.Now(), failed)
+	})
+	return route, nil
+}
+
+// rateCounter counts requests and errors in per-second buckets over a
+// sliding window.
+type rateCounter struct {
+	mu      sync.Mutex
+	buckets []rateBucket
+}
+
+// rateBucket holds the counts of one second.
+type rateBucket struct {
+	second   int64
+	requests int64
+	errors   int64
+}
+
+// newRateCounter returns a counter over a window of the number of seconds.
+func newRateCounter(seconds int) *rateCounter {
+	if seconds < 1 {
+		seconds = 1
+	}
+	return &rateCounter{buckets: make([]rateBucket, seconds)}
+}
+
+// add counts a request at the time.
+func (c *rateCounter) add(now time.Time, failed bool) {
+	second := now.Unix()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b := &c.buckets[second%int64(len(c.buckets))]
+	if b.second != second {
+		*b = rateBucket{second: second}
+	}
+	b.requests++
+	if failed {
+		b.errors++
+	}
+}
+
+// sum returns the counts over the window ending at the time.
+func (c *rateCounter) sum(now time.Time) (requests, errors int64) {
+	oldest := now.Unix() - int64(len(c.buckets))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, b := range c.buckets {
+		if b.second > oldest {
+			requests += b.requests
+			errors += b.errors
+		}
+	}
+	return requests, errors
+}
+
+// statusPage renders the status page.
+var statusPage = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>{{.Title}}</title>
+  <style>
+    body { font-family: sans-serif; margin: 2em; }
+    td, th { padding: 0.3em 1em; text-align: left; }
+    .ok { color: #2a7d2a; }
+    .degraded, .failing { color: #b02a2a; }
+  </style>
+</head>
+<body>
+  <h1>{{.Title}}: <span class="{{.Status}}">{{.Status}}</span></h1>
+  <table>
+    <tr><th>Uptime</th><td>{{printf "%.0f" .Uptime}} s</td></tr>
+    <tr><th>Requests</th><td>{{.Requests}}</td></tr>
+    <tr><th>Errors</th><td>{{.Errors}}</td></tr>
+    <tr><th>Request rate</th><td>{{printf "%.2f" .RequestRate}} /s</td></tr>
+    <tr><th>Error rate</th><td>{{printf "%.2f" .ErrorRate}}</td></tr>
+    <tr><th>In flight</th><td>{{.InFlight}}</td></tr>
+    <tr><th>Maintenance</th><td>{{.Maintenance}}</td></tr>
+  </table>
+  <h2>Checks</h2>
+  <table>
+    {{range .Checks}}<tr><th>{{.Name}}</th>{{if .OK}}<td class="ok">ok</td><td></td>{{else}}<td class="failing">failing</td><td>{{.Error}}</td>{{end}}</tr>
+    {{end}}
+  </table>
+</body>
+</html>
+`))
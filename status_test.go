@@ -0,0 +1,86 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStatusPage(t *testing.T) {
+	router := NewRouter()
+	router.EnableStatusPage("/status", StatusPageOptions{
+		Checks: map[string]func(ctx context.Context) error{
+			"cache":    func(ctx context.Context) error { return nil },
+			"database": func(ctx context.Context) error { return errors.New("connection refused") },
+		},
+	})
+	router.MustAddRoute("GET", "/ok", func(w http.ResponseWriter, req *http.Request) {})
+	router.MustAddRoute("GET", "/fail", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	serve := func(path string, accept string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", accept)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	for _, path := range []string{"/ok", "/ok", "/ok", "/fail"} {
+		serve(path, "")
+	}
+
+	t.Run("JSON report", func(t *testing.T) {
+		rr := serve("/status", "application/json")
+
+		var report StatusReport
+		if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+
+		// Check the request counts and rates
+		if report.Requests != 4 || report.Errors != 1 {
+			t.Errorf("Expected 4 requests and 1 error, but got %d and %d", report.Requests, report.Errors)
+		}
+		if report.ErrorRate != 0.25 {
+			t.Errorf("Expected error rate 0.25, but got %v", report.ErrorRate)
+		}
+
+		// Check the checks, readiness first and the others by name
+		expected := []StatusCheck{
+			{Name: "readiness", OK: true},
+			{Name: "cache", OK: true},
+			{Name: "database", Error: "connection refused"},
+		}
+		if len(report.Checks) != len(expected) {
+			t.Fatalf("Expected checks %v, but got %v", expected, report.Checks)
+		}
+		for i := range expected {
+			if report.Checks[i] != expected[i] {
+				t.Errorf("Expected check %v, but got %v", expected[i], report.Checks[i])
+			}
+		}
+		if report.Status != "degraded" {
+			t.Errorf("Expected status degraded, but got %q", report.Status)
+		}
+	})
+
+	t.Run("HTML page", func(t *testing.T) {
+		rr := serve("/status", "text/html")
+
+		// Check the content type and a failing check
+		if contentType := rr.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+			t.Errorf("Expected an HTML page, but got %q", contentType)
+		}
+		if !strings.Contains(rr.Body.String(), "connection refused") {
+			t.Errorf("Expected the failing check in the page, but got %q", rr.Body.String())
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// requestStore holds the values of a request: those the router sets, such
+// as the correlation ID and the matched route, and those set with Set. It is
+// the only value the router adds to the request context. Stores are pooled
+// and reused once their request has been served; gen tells the requests
+// apart.
+type requestStore struct {
+	mu     sync.Mutex
+	gen    uint64
+	values map[string]interface{}
+
+	correlationID string
+	pathParams    map[string]string
+	route         *Route
+	logFields     *LogFieldsOptions
+
+	// The query parameters, parsed on first use, and the raw query they
+	// were parsed from
+	query    url.Values
+	rawQuery string
+}
+
+// storeHandle refers to the store of one request.
+type storeHandle struct {
+	store *requestStore
+	gen   uint64
+}
+
+// storePool recycles request stores.
+var storePool = sync.Pool{
+	New: func() interface{} {
+		return &requestStore{values: make(map[string]interface{})}
+	},
+}
+
+// acquireStore returns a store for a new request.
+func acquireStore() storeHandle {
+	store := storePool.Get().(*requestStore)
+	store.mu.Lock()
+	gen := store.gen
+	store.mu.Unlock()
+	return storeHandle{store: store, gen: gen}
+}
+
+// release empties the store and returns it to the pool. Handles to it
+// then see no values.
+func (h storeHandle) release() {
+	h.store.mu.Lock()
+	h.store.gen++
+	for key := range h.store.values {
+		delete(h.store.values, key)
+	}
+	h.store.correlationID = ""
+	h.store.pathParams = nil
+	h.store.route = nil
+	h.store.logFields = nil
+	h.store.query = nil
+	h.store.rawQuery = ""
+	h.store.mu.Unlock()
+	storePool.Put(h.store)
+}
+
+// storeOf returns the store of the request, locked, or nil if the request
+// is not served by the router or has been served. Callers unlock the store.
+func storeOf(req *http.Request) *requestStore {
+	h, ok := req.Context().Value("store").(storeHandle)
+	if !ok {
+		return nil
+	}
+	h.store.mu.Lock()
+	if h.store.gen != h.gen {
+		h.store.mu.Unlock()
+		return nil
+	}
+	return h.store
+}
+
+// detachStore returns the context with a copy of the store of its request
+// that is never released, for work outliving the request.
+func detachStore(ctx context.Context) context.Context {
+	h, ok := ctx.Value("store").(storeHandle)
+	if !ok {
+		return ctx
+	}
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+	if h.store.gen != h.gen {
+		return ctx
+	}
+	detached := &requestStore{
+		values:        make(map[string]interface{}, len(h.store.values)),
+		correlationID: h.store.correlationID,
+		pathParams:    h.store.pathParams,
+		route:         h.store.route,
+		logFields:     h.store.logFields,
+		query:         h.store.query,
+		rawQuery:      h.store.rawQuery,
+	}
+	for key, value := range h.store.values {
+		detached.values[key] = value
+	}
+	return context.WithValue(ctx, "store", storeHandle{store: detached})
+}
+
+// Set stores a value on the request under the key, for middleware to pass
+// data to handlers without wrapping the request context. Values are
+// available until the request has been served; Set does nothing on
+// requests not served by the router.
+func (r *Router) Set(req *http.Request, key string, value interface{}) {
+	if s := storeOf(req); s != nil {
+		s.values[key] = value
+		s.mu.Unlock()
+	}
+}
+
+// Get returns the value stored on the request under the key.
+func (r *Router) Get(req *http.Request, key string) (interface{}, bool) {
+	s := storeOf(req)
+	if s == nil {
+		return nil, false
+	}
+	defer s.mu.Unlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// parseQuery returns the query parameters of the request. They are parsed
+// on first use and cached in the store until the query is rewritten, so
+// that requests never reading them do not pay for parsing. The error is
+// only returned by the call that parsed the query.
+func parseQuery(req *http.Request) (url.Values, error) {
+	s := storeOf(req)
+	if s == nil {
+		return url.ParseQuery(req.URL.RawQuery)
+	}
+	defer s.mu.Unlock()
+	if s.query != nil && s.rawQuery == req.URL.RawQuery {
+		return s.query, nil
+	}
+	query, err := url.ParseQuery(req.URL.RawQuery)
+	s.query = query
+	s.rawQuery = req.URL.RawQuery
+	return query, err
+}
@@ -0,0 +1,149 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestStore(t *testing.T) {
+	router := NewRouter()
+	router.Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			router.Set(req, "user", "alice")
+			next(w, req)
+		}
+	})
+
+	var served *http.Request
+	router.MustAddRoute("GET", "/profile", func(w http.ResponseWriter, req *http.Request) {
+		served = req
+		user, _ := router.Get(req, "user")
+		w.Write([]byte(user.(string)))
+	})
+
+	t.Run("Middleware to handler", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/profile", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Check the value set by the middleware
+		if body := rr.Body.String(); body != "alice" {
+			t.Errorf("Expected response body %q, but got %q", "alice", body)
+		}
+	})
+
+	t.Run("Released after serving", func(t *testing.T) {
+		if _, ok := router.Get(served, "user"); ok {
+			t.Error("Expected no value once the request has been served")
+		}
+		router.Set(served, "user", "mallory")
+
+		// Check that the recycled store does not leak into the next request
+		req, err := http.NewRequest("GET", "/other", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var leaked bool
+		router.MustAddRoute("GET", "/other", func(w http.ResponseWriter, req *http.Request) {
+			value, _ := router.Get(req, "user")
+			leaked = value == "mallory"
+		})
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		if leaked {
+			t.Error("Expected values set after serving to be dropped")
+		}
+	})
+
+	t.Run("Outside the router", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/profile", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		router.Set(req, "user", "alice")
+		if _, ok := router.Get(req, "user"); ok {
+			t.Error("Expected no value on a request not served by the router")
+		}
+	})
+}
+
+func TestLazyQueryParams(t *testing.T) {
+	router := NewRouter()
+	var first, second, rewritten string
+	router.MustAddRoute("GET", "/search", func(w http.ResponseWriter, req *http.Request) {
+		query := router.GetQueryParams(req)
+		first = query.Get("q")
+		query.Set("q", "cached")
+		second = router.GetQueryParams(req).Get("q")
+		req.URL.RawQuery = "q=rewritten"
+		rewritten = router.GetQueryParams(req).Get("q")
+	})
+
+	req, err := http.NewRequest("GET", "/search?q=router", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Check that the query is parsed once, then reparsed once rewritten
+	if first != "router" {
+		t.Errorf("Expected query parameter %q, but got %q", "router", first)
+	}
+	if second != "cached" {
+		t.Errorf("Expected the parsed query to be cached, but got %q", second)
+	}
+	if rewritten != "rewritten" {
+		t.Errorf("Expected query parameter %q, but got %q", "rewritten", rewritten)
+	}
+
+	t.Run("Outside the router", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/search?q=router", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Check that the query is parsed
+		if q := router.GetQueryParams(req).Get("q"); q != "router" {
+			t.Errorf("Expected query parameter %q, but got %q", "router", q)
+		}
+	})
+}
+
+func TestRequestState(t *testing.T) {
+	router := NewRouter()
+	var served, detached *http.Request
+	router.MustAddRoute("GET", "/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		served = req
+		detached = req.WithContext(detachStore(req.Context()))
+	})
+
+	req, err := http.NewRequest("GET", "/users/42", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Check that the values are released once the request has been served
+	if id := router.GetPathParam(served, "id"); id != "" {
+		t.Errorf("Expected no path parameter once served, but got %q", id)
+	}
+	if router.GetRoute(served) != nil {
+		t.Error("Expected no route once served")
+	}
+
+	// Check that a detached request keeps its values
+	if id := router.GetPathParam(detached, "id"); id != "42" {
+		t.Errorf("Expected path parameter %q, but got %q", "42", id)
+	}
+	if route := router.GetRoute(detached); route == nil || route.Path != "/users/:id" {
+		t.Errorf("Expected route %q, but got %v", "/users/:id", route)
+	}
+	if id := router.GetCorrelationID(detached); id != "0af7651916cd43dd8448eb211c80319c" {
+		t.Errorf("Expected correlation ID %q, but got %q", "0af7651916cd43dd8448eb211c80319c", id)
+	}
+}
@@ -0,0 +1,202 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StubMode selects how routes are served in stub mode.
+type StubMode int
+
+const (
+	// StubOff serves routes with their handlers.
+	StubOff StubMode = iota
+	// StubRecord serves routes with their handlers and records the
+	// responses.
+	StubRecord
+	// StubReplay serves the recorded responses without calling the
+	// handlers.
+	StubReplay
+)
+
+// StubOptions configures stub mode.
+type StubOptions struct {
+	Mode StubMode
+	// Dir is the directory holding one file of recorded responses per
+	// route.
+	Dir string
+	// Latency replays the responses after the time the handlers took to
+	// produce them.
+	Latency bool
+	// Fallthrough calls the handler when replaying a request with no
+	// recorded response for its route. Such requests are answered with 404
+	// otherwise.
+	Fallthrough bool
+}
+
+// StubResponse is a recorded response.
+type StubResponse struct {
+	Status  int           `json:"status"`
+	Header  http.Header   `json:"header"`
+	Body    []byte        `json:"body"`
+	Latency time.Duration `json:"latency"`
+}
+
+// stubs records and replays responses.
+type stubs struct {
+	mu    sync.Mutex
+	opts  StubOptions
+	files map[string]map[string]*StubResponse
+}
+
+// SetStubMode records the responses of the routes to disk or replays them,
+// so that clients can run against the router without real backends.
+// Responses are recorded per route and per request path and query; when
+// replaying a request that was not recorded, the last response recorded
+// for its route is used.
+func (r *Router) SetStubMode(opts StubOptions) error {
+	if opts.Mode != StubOff {
+		if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+			return fmt.Errorf("router: creating stub directory: %v", err)
+		}
+	}
+	r.stubs.mu.Lock()
+	defer r.stubs.mu.Unlock()
+	r.stubs.opts = opts
+	r.stubs.files = make(map[string]map[string]*StubResponse)
+	return nil
+}
+
+// stubHandler returns the handler serving the route in the current stub
+// mode.
+func (r *Router) stubHandler(route *Route) http.HandlerFunc {
+	r.stubs.mu.Lock()
+	opts := r.stubs.opts
+	r.stubs.mu.Unlock()
+
+	// Not found and other fallback responses are never stubbed
+	if route.Path == "" {
+		return route.HandlerFunc
+	}
+
+	switch opts.Mode {
+	case StubRecord:
+		return func(w http.ResponseWriter, req *http.Request) {
+			cw := &captureWriter{ResponseWriter: w, limit: -1}
+			start := time.Now()
+			route.HandlerFunc(cw, req)
+			resp := &StubResponse{
+				Status:  cw.Status(),
+				Header:  w.Header().Clone(),
+				Body:    cw.body.Bytes(),
+				Latency: time.Since(start),
+			}
+			if err := r.stubs.record(route, req, resp); err != nil {
+				r.logger.Errorf("Failed to record response for %s %s: %v", route.Method, route.Path, err)
+			}
+		}
+	case StubReplay:
+		return func(w http.ResponseWriter, req *http.Request) {
+			resp, err := r.stubs.lookup(route, req)
+			if err != nil {
+				r.logger.Errorf("Failed to read recorded responses for %s %s: %v", route.Method, route.Path, err)
+			}
+			if resp == nil {
+				if opts.Fallthrough {
+					route.HandlerFunc(w, req)
+				} else {
+					http.Error(w, "no recorded response", http.StatusNotFound)
+				}
+				return
+			}
+
+			if opts.Latency && resp.Latency > 0 {
+				timer := time.NewTimer(resp.Latency)
+				select {
+				case <-timer.C:
+				case <-req.Context().Done():
+					timer.Stop()
+					return
+				}
+			}
+			for name, values := range resp.Header {
+				w.Header()[name] = append([]string(nil), values...)
+			}
+			w.WriteHeader(resp.Status)
+			w.Write(resp.Body)
+		}
+	}
+	return route.HandlerFunc
+}
+
+// record stores the response to the request in the route's file.
+func (s *stubs) record(route *Route, req *http.Request, resp *StubResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	responses, err := s.load(route)
+	if err != nil {
+		return err
+	}
+	responses[req.URL.RequestURI()] = resp
+	responses[""] = resp
+
+	data, err := json.MarshalIndent(responses, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(route), data, 0644)
+}
+
+// lookup returns the recorded response to the request, or nil if the route
+// has none.
+func (s *stubs) lookup(route *Route, req *http.Request) (*StubResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	responses, err := s.load(route)
+	if err != nil {
+		return nil, err
+	}
+	if resp, ok := responses[req.URL.RequestURI()]; ok {
+		return resp, nil
+	}
+	return responses[""], nil
+}
+
+// load returns the recorded responses of the route, keyed by request URI
+// and with the last one under the empty key.
+func (s *stubs) load(route *Route) (map[string]*StubResponse, error) {
+	path := s.path(route)
+	if responses, ok := s.files[path]; ok {
+		return responses, nil
+	}
+	responses := make(map[string]*StubResponse)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &responses); err != nil {
+			return nil, fmt.Errorf("invalid stub file %s: %v", path, err)
+		}
+	}
+	s.files[path] = responses
+	return responses, nil
+}
+
+// path returns the file of the route's recorded responses.
+func (s *stubs) path(route *Route) string {
+	name := strings.Map(func(c rune) rune {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '.':
+			return c
+		}
+		return '_'
+	}, route.Method+" "+route.Path)
+	return filepath.Join(s.opts.Dir, name+".json")
+}
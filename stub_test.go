@@ -0,0 +1,97 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStubMode(t *testing.T) {
+	dir := t.TempDir()
+	calls := 0
+	router := NewRouter()
+	router.MustAddRoute("GET", "/users/:id", func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"` + router.GetPathParam(req, "id") + `"}`))
+	})
+	router.MustAddRoute("GET", "/other", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("live"))
+	})
+
+	get := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Record", func(t *testing.T) {
+		if err := router.SetStubMode(StubOptions{Mode: StubRecord, Dir: dir}); err != nil {
+			t.Fatal(err)
+		}
+		get(t, "/users/1")
+		get(t, "/users/2")
+		if calls != 2 {
+			t.Errorf("Expected the handler to be called twice, but got %d", calls)
+		}
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		// Changing the mode drops the cached recordings, so they are read back from disk
+		if err := router.SetStubMode(StubOptions{Mode: StubReplay, Dir: dir, Latency: true}); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			path         string
+			expectedBody string
+		}{
+			{"/users/1", `{"id":"1"}`},
+			{"/users/2", `{"id":"2"}`},
+			{"/users/3", `{"id":"2"}`},
+		}
+		for _, test := range tests {
+			start := time.Now()
+			rr := get(t, test.path)
+
+			// Check the response status code
+			if rr.Code != http.StatusCreated {
+				t.Errorf("Expected status code %d, but got %d", http.StatusCreated, rr.Code)
+			}
+
+			// Check the response body and headers
+			if rr.Body.String() != test.expectedBody {
+				t.Errorf("Expected response body %q, but got %q", test.expectedBody, rr.Body.String())
+			}
+			if rr.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("Expected content type %q, but got %q", "application/json", rr.Header().Get("Content-Type"))
+			}
+
+			// Check the recorded latency was simulated
+			if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+				t.Errorf("Expected a latency of at least 20ms, but got %v", elapsed)
+			}
+		}
+		if calls != 2 {
+			t.Errorf("Expected the handler not to be called, but got %d calls", calls)
+		}
+	})
+
+	t.Run("Missing recording", func(t *testing.T) {
+		if rr := get(t, "/other"); rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d, but got %d", http.StatusNotFound, rr.Code)
+		}
+
+		router.SetStubMode(StubOptions{Mode: StubReplay, Dir: dir, Fallthrough: true})
+		if rr := get(t, "/other"); rr.Body.String() != "live" {
+			t.Errorf("Expected response body %q, but got %q", "live", rr.Body.String())
+		}
+	})
+}
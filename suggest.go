@@ -0,0 +1,132 @@
+package router
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// maxSuggestionDistance is the maximum edit distance between a request
+// and the routes suggested for it. Shorter paths allow fewer edits, one per
+// three characters.
+const maxSuggestionDistance = 3
+
+// maxSuggestions is the maximum number of routes suggested.
+const maxSuggestions = 3
+
+// devNotFound answers requests matching no route in development mode,
+// suggesting the routes closest to the request.
+func (r *Router) devNotFound(w http.ResponseWriter, req *http.Request) {
+	suggestions := r.suggestRoutes(req)
+	if len(suggestions) == 0 {
+		http.NotFound(w, req)
+		return
+	}
+	http.Error(w, "404 page not found\n\nDid you mean "+strings.Join(suggestions, " or ")+"?", http.StatusNotFound)
+}
+
+// suggestRoutes returns the routes within a small edit distance of the
+// request's method and path, closest first.
+func (r *Router) suggestRoutes(req *http.Request) []string {
+	type suggestion struct {
+		route    string
+		distance int
+	}
+	limit := len(req.URL.Path) / 3
+	if limit < 1 {
+		limit = 1
+	} else if limit > maxSuggestionDistance {
+		limit = maxSuggestionDistance
+	}
+
+	parts := strings.Split(req.URL.Path, "/")
+	seen := make(map[string]bool)
+	var suggestions []suggestion
+	for _, route := range r.allRoutes() {
+		distance := pathDistance(strings.Split(route.Path, "/"), parts)
+		if route.Method != req.Method {
+			distance++
+		}
+		name := route.Method + " " + route.Path
+		if distance <= limit && !seen[name] {
+			seen[name] = true
+			suggestions = append(suggestions, suggestion{route: name, distance: distance})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].distance != suggestions[j].distance {
+			return suggestions[i].distance < suggestions[j].distance
+		}
+		return suggestions[i].route < suggestions[j].route
+	})
+	var routes []string
+	for i := 0; i < len(suggestions) && i < maxSuggestions; i++ {
+		routes = append(routes, suggestions[i].route)
+	}
+	return routes
+}
+
+// pathDistance returns the edit distance between the segments of a route
+// path and of a request path. Parameter segments match any non-empty
+// segment and wildcard segments match the rest of the path; missing or
+// extra segments count as many edits as their length, except missing
+// optional segments.
+func pathDistance(segments []string, parts []string) int {
+	distance := 0
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "*") {
+			return distance
+		}
+		if i >= len(parts) {
+			if !isOptional(segment) {
+				distance += segmentLength(segment)
+			}
+			continue
+		}
+		if len(segment) > 1 && segment[0] == ':' {
+			if parts[i] == "" {
+				distance++
+			}
+			continue
+		}
+		distance += levenshtein(segment, parts[i])
+	}
+	for i := len(segments); i < len(parts); i++ {
+		distance += segmentLength(parts[i])
+	}
+	return distance
+}
+
+// segmentLength returns the number of edits adding or removing a segment,
+// including its slash.
+func segmentLength(segment string) int {
+	return len(segment) + 1
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j] + 1
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+			if prev[j-1]+cost < cur[j] {
+				cur[j] = prev[j-1] + cost
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,72 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNotFoundSuggestions(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {}
+	router := NewRouter()
+	router.EnableErrorPages(true)
+	router.MustAddRoute("GET", "/users/:id", handler)
+	router.MustAddRoute("GET", "/users", handler)
+	router.MustAddRoute("POST", "/orders", handler)
+	router.MustAddRoute("GET", "/static/*filepath", handler)
+	router.MustAddRoute("GET", "/item", handler)
+	router.MustAddRoute("GET", "/items", handler)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"Typo in static segment", "GET", "/usres/42", "404 page not found\n\nDid you mean GET /users/:id?\n"},
+		{"Wrong method", "GET", "/orders", "404 page not found\n\nDid you mean POST /orders?\n"},
+		{"Trailing slash", "GET", "/users/42/", "404 page not found\n\nDid you mean GET /users/:id?\n"},
+		{"Several candidates", "GET", "/itemz", "404 page not found\n\nDid you mean GET /item or GET /items?\n"},
+		{"Wildcard", "GET", "/statik/css/site.css", "404 page not found\n\nDid you mean GET /static/*filepath?\n"},
+		{"Nothing close", "GET", "/completely/different", "404 page not found\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(test.method, test.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			// Check the response status code
+			if rr.Code != http.StatusNotFound {
+				t.Errorf("Expected status code %d, but got %d", http.StatusNotFound, rr.Code)
+			}
+
+			// Check the suggestions
+			if rr.Body.String() != test.body {
+				t.Errorf("Expected response body %q, but got %q", test.body, rr.Body.String())
+			}
+		})
+	}
+
+	t.Run("Production", func(t *testing.T) {
+		production := NewRouter()
+		production.MustAddRoute("GET", "/users/:id", handler)
+
+		req, err := http.NewRequest("GET", "/usres/42", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		production.ServeHTTP(rr, req)
+
+		// Check that no route is suggested
+		if expected := "404 page not found\n"; rr.Body.String() != expected {
+			t.Errorf("Expected response body %q, but got %q", expected, rr.Body.String())
+		}
+	})
+}
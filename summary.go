@@ -0,0 +1,228 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteSummaryOptions configures the route summary.
+type RouteSummaryOptions struct {
+	// RequireMeta lists metadata keys every route must have, e.g. "auth".
+	// Admin routes are exempt.
+	RequireMeta []string
+	// Logf writes the route table. It defaults to the router's info
+	// logger.
+	Logf func(format string, args ...interface{})
+	// Warnf writes the warnings. It defaults to the router's warning
+	// logger.
+	Warnf func(format string, args ...interface{})
+}
+
+// SetStartupSummary makes Serve and ListenAndServe log the route summary
+// once, so that misconfigurations surface at boot.
+func (r *Router) SetStartupSummary(opts RouteSummaryOptions) {
+	r.startupSummary = &opts
+}
+
+// LogRouteSummary logs a table of the routes followed by the warnings
+// returned by RouteWarnings.
+func (r *Router) LogRouteSummary(opts RouteSummaryOptions) {
+	if opts.Logf == nil {
+		opts.Logf = r.logger.Infof
+	}
+	if opts.Warnf == nil {
+		opts.Warnf = r.logger.Warningf
+	}
+
+	var table bytes.Buffer
+	tw := tabwriter.NewWriter(&table, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tMIDDLEWARE\tMETADATA")
+	for _, route := range r.sortedRoutes() {
+		var middleware, meta []string
+		for _, mw := range r.middleware {
+			middleware = append(middleware, r.middlewareName(mw))
+		}
+		for _, mw := range route.middleware {
+			middleware = append(middleware, r.middlewareName(mw))
+		}
+		for key, value := range route.meta {
+			meta = append(meta, key+"="+value)
+		}
+		sort.Strings(meta)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", route.Method, route.Path, strings.Join(middleware, ", "), strings.Join(meta, ", "))
+	}
+	tw.Flush()
+	opts.Logf("Routes:\n%s", table.String())
+
+	for _, warning := range r.RouteWarnings(opts.RequireMeta...) {
+		opts.Warnf("%s", warning)
+	}
+}
+
+// RouteWarnings reports likely misconfigurations: routes that can never be
+// served because an earlier route always matches first, named middleware
+// used by no route, and routes missing one of the required metadata keys.
+func (r *Router) RouteWarnings(requireMeta ...string) []string {
+	var warnings []string
+
+	// Routes in the order the router tries them: routes added in code
+	// before routes loaded from a configuration, then by priority, static
+	// paths before patterns, and patterns in the order of sortPatterns
+	type entry struct {
+		route *Route
+		table int
+	}
+	var ordered []entry
+	tables := []*routeTable{r.routes}
+	if config, ok := r.config.Load().(*routeTable); ok {
+		tables = append(tables, config)
+	}
+	for i, table := range tables {
+		var statics []*Route
+		for _, byPath := range table.routes {
+			for path, candidates := range byPath {
+				if !isPattern(path) {
+					statics = append(statics, candidates...)
+				}
+			}
+		}
+		sortRoutes(statics)
+		for _, route := range statics {
+			ordered = append(ordered, entry{route, i})
+		}
+		var methods []string
+		for method := range table.patterns {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			for _, path := range table.patterns[method] {
+				for _, route := range table.routes[method][path] {
+					ordered = append(ordered, entry{route, i})
+				}
+			}
+		}
+	}
+	priority := func(e entry) int {
+		if e.route.pattern == nil {
+			return e.route.precedence
+		}
+		return pathPriority(tables[e.table].routes[e.route.Method][e.route.Path])
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].table != ordered[j].table {
+			return ordered[i].table < ordered[j].table
+		}
+		return priority(ordered[i]) > priority(ordered[j])
+	})
+
+	for i, e := range ordered {
+		route := e.route
+		for _, later := range ordered[i+1:] {
+			// The last unconditional route registered for a path is used
+			if later.table == e.table && later.route.Path == route.Path && later.route.Method == route.Method &&
+				!route.conditional() && !later.route.conditional() {
+				warnings = append(warnings, fmt.Sprintf("route %s %s is registered again and never served", route.Method, route.Path))
+				break
+			}
+		}
+		for _, earlier := range ordered[:i] {
+			if earlier.route.Method != route.Method || earlier.route.conditional() ||
+				(earlier.table == e.table && earlier.route.Path == route.Path) || !covers(earlier.route, route) {
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("route %s %s is shadowed by %s %s", route.Method, route.Path, earlier.route.Method, earlier.route.Path))
+			break
+		}
+	}
+
+	// Registered middleware shares its code with the middleware it is
+	// applied as, see middlewareName
+	used := make(map[uintptr]bool)
+	for _, mw := range r.middleware {
+		used[reflect.ValueOf(mw).Pointer()] = true
+	}
+	for _, e := range ordered {
+		for _, mw := range e.route.middleware {
+			used[reflect.ValueOf(mw).Pointer()] = true
+		}
+	}
+	var names []string
+	for name, mw := range r.namedMiddleware {
+		if !used[reflect.ValueOf(mw).Pointer()] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		warnings = append(warnings, fmt.Sprintf("middleware %q is registered but used by no route", name))
+	}
+
+	for _, route := range r.sortedRoutes() {
+		if route.admin {
+			continue
+		}
+		for _, key := range requireMeta {
+			if route.meta[key] == "" {
+				warnings = append(warnings, fmt.Sprintf("route %s %s has no %q metadata", route.Method, route.Path, key))
+			}
+		}
+	}
+	return warnings
+}
+
+// sortedRoutes returns all the routes sorted by path and method.
+func (r *Router) sortedRoutes() []*Route {
+	routes := r.allRoutes()
+	sortRoutes(routes)
+	return routes
+}
+
+// sortRoutes sorts the routes by path and method, keeping the registration
+// order of routes with the same path and method.
+func sortRoutes(routes []*Route) {
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+}
+
+// covers reports whether every path matched by route is matched by
+// earlier.
+func covers(earlier *Route, route *Route) bool {
+	if earlier.pattern == nil {
+		return earlier.Path == route.Path
+	}
+	if route.pattern == nil {
+		_, ok := earlier.pattern.match(route.Path)
+		return ok
+	}
+	segments := route.pattern.segments
+	for i, segment := range earlier.pattern.segments {
+		if strings.HasPrefix(segment, "*") {
+			return true
+		}
+		if i >= len(segments) {
+			return i >= earlier.pattern.required
+		}
+		if strings.HasPrefix(segments[i], "*") || (i >= route.pattern.required && i < earlier.pattern.required) {
+			return false
+		}
+		if len(segment) > 1 && segment[0] == ':' {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != segments[i] {
+			return false
+		}
+	}
+	return len(segments) == len(earlier.pattern.segments)
+}
@@ -0,0 +1,65 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRouteSummary(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {}
+	auth := func(next http.HandlerFunc) http.HandlerFunc { return next }
+	unused := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) { next(w, req) }
+	}
+
+	router := NewRouter()
+	router.RegisterMiddleware("auth", auth)
+	router.RegisterMiddleware("unused", unused)
+	router.MustAddRoute("GET", "/users/:id", handler).Use(auth).Meta("auth", "bearer")
+	router.MustAddRoute("GET", "/users/:name", handler).Meta("auth", "bearer")
+	router.MustAddRoute("GET", "/users/me", handler).Meta("auth", "bearer")
+	router.MustAddRoute("GET", "/files/*path", handler).Meta("auth", "none")
+	router.MustAddRoute("GET", "/files/:name/raw", handler).Meta("auth", "none")
+	router.MustAddRoute("GET", "/health", handler)
+	router.routes.add(newRoute("GET", "/health", handler)) // AddRoute rejects duplicates
+	router.MustAddRoute("GET", "/reports", handler).Header("X-Beta", "1").Meta("auth", "bearer")
+	router.MustAddRoute("GET", "/reports", handler).Meta("auth", "bearer")
+
+	t.Run("Warnings", func(t *testing.T) {
+		expected := []string{
+			"route GET /health is registered again and never served",
+			"route GET /users/:name is shadowed by GET /users/:id",
+			`middleware "unused" is registered but used by no route`,
+			`route GET /health has no "auth" metadata`,
+			`route GET /health has no "auth" metadata`,
+		}
+		warnings := router.RouteWarnings("auth")
+		if !reflect.DeepEqual(warnings, expected) {
+			t.Errorf("Expected warnings:\n%s\nbut got:\n%s", strings.Join(expected, "\n"), strings.Join(warnings, "\n"))
+		}
+	})
+
+	t.Run("Logged table", func(t *testing.T) {
+		var logs, warnings []string
+		router.LogRouteSummary(RouteSummaryOptions{
+			Logf:  func(format string, args ...interface{}) { logs = append(logs, fmt.Sprintf(format, args...)) },
+			Warnf: func(format string, args ...interface{}) { warnings = append(warnings, fmt.Sprintf(format, args...)) },
+		})
+
+		// Check the table header and a row with middleware and metadata
+		if len(logs) != 1 || !strings.Contains(logs[0], "METHOD  PATH") {
+			t.Fatalf("Expected a route table, but got %q", logs)
+		}
+		if !strings.Contains(logs[0], "GET     /users/:id        auth        auth=bearer") {
+			t.Errorf("Expected the route's middleware and metadata, but got:\n%s", logs[0])
+		}
+
+		// Check that no metadata is required by default
+		if len(warnings) != 3 {
+			t.Errorf("Expected 3 warnings, but got %q", warnings)
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Timing collects the durations reported in the Server-Timing header of a
+// response. Its methods do nothing on a nil Timing, so handlers can report
+// durations whether or not the ServerTiming middleware is in use.
+type Timing struct {
+	mu           sync.Mutex
+	start        time.Time
+	routed       time.Time
+	handlerStart time.Time
+	handlerEnd   time.Time
+	metrics      []timingMetric
+}
+
+// timingMetric is a duration reported by a handler.
+type timingMetric struct {
+	name     string
+	duration time.Duration
+}
+
+// Measure reports a duration under the name, e.g. the time spent querying
+// the database. The name must be a valid HTTP token.
+func (t *Timing) Measure(name string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.metrics = append(t.metrics, timingMetric{name: name, duration: d})
+}
+
+// Start starts measuring a duration reported under the name when the
+// returned function is called, e.g. with
+// defer r.Timing(req).Start("db")().
+func (t *Timing) Start(name string) func() {
+	start := time.Now()
+	return func() {
+		t.Measure(name, time.Since(start))
+	}
+}
+
+// header returns the Server-Timing header value. Phases still in progress
+// are measured up to now.
+func (t *Timing) header() string {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	metrics := []timingMetric{{name: "routing", duration: t.routed.Sub(t.start)}}
+	if !t.handlerStart.IsZero() {
+		handlerEnd := t.handlerEnd
+		if handlerEnd.IsZero() {
+			handlerEnd = now
+		}
+		metrics = append(metrics,
+			timingMetric{name: "middleware", duration: t.handlerStart.Sub(t.routed)},
+			timingMetric{name: "handler", duration: handlerEnd.Sub(t.handlerStart)},
+		)
+	} else {
+		metrics = append(metrics, timingMetric{name: "middleware", duration: now.Sub(t.routed)})
+	}
+	metrics = append(metrics, t.metrics...)
+
+	parts := make([]string, len(metrics))
+	for i, m := range metrics {
+		ms := float64(m.duration) / float64(time.Millisecond)
+		parts[i] = m.name + ";dur=" + strconv.FormatFloat(ms, 'f', 3, 64)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// wrap returns the handler recording when the route's handler runs.
+func (t *Timing) wrap(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		t.mu.Lock()
+		t.handlerStart = time.Now()
+		t.mu.Unlock()
+		defer func() {
+			t.mu.Lock()
+			t.handlerEnd = time.Now()
+			t.mu.Unlock()
+		}()
+		handler(w, req)
+	}
+}
+
+// ServerTiming returns middleware adding a Server-Timing header with the
+// time spent routing the request, in middleware and in the route's handler,
+// followed by the durations reported with Timing.Measure. The header is
+// written with the response header, so only durations reported before the
+// handler starts writing its response are included.
+func (r *Router) ServerTiming() func(http.HandlerFunc) http.HandlerFunc {
+	atomic.StoreInt32(&r.serverTiming, 1)
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			timing := r.Timing(req)
+			if timing == nil {
+				now := time.Now()
+				timing = &Timing{start: now, routed: now}
+				req = req.WithContext(context.WithValue(req.Context(), "timing", timing))
+			}
+			next(&timingWriter{ResponseWriter: w, timing: timing}, req)
+		}
+	}
+}
+
+// Timing returns the timing of the request, or nil if the ServerTiming
+// middleware is not in use.
+func (r *Router) Timing(req *http.Request) *Timing {
+	timing, _ := req.Context().Value("timing").(*Timing)
+	return timing
+}
+
+// timingWriter adds the Server-Timing header to the response.
+type timingWriter struct {
+	http.ResponseWriter
+	timing      *Timing
+	wroteHeader bool
+}
+
+// WriteHeader adds the Server-Timing header and writes the response header.
+func (w *timingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.Header().Set("Server-Timing", w.timing.header())
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes the response header first if needed.
+func (w *timingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher if the wrapped writer supports it.
+func (w *timingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusOK)
+		}
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter.
+func (w *timingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
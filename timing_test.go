@@ -0,0 +1,68 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerTiming(t *testing.T) {
+	t.Run("Phases and measurements", func(t *testing.T) {
+		router := NewRouter()
+		router.Use(router.ServerTiming())
+		router.MustAddRoute("GET", "/users", func(w http.ResponseWriter, req *http.Request) {
+			router.Timing(req).Measure("db", 5*time.Millisecond)
+			w.Write([]byte("Users"))
+		})
+
+		req, err := http.NewRequest("GET", "/users", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Check the reported phases and measurements in order
+		header := rr.Header().Get("Server-Timing")
+		var names []string
+		for _, metric := range strings.Split(header, ", ") {
+			names = append(names, strings.SplitN(metric, ";", 2)[0])
+		}
+		expected := "routing,middleware,handler,db"
+		if strings.Join(names, ",") != expected {
+			t.Errorf("Expected metrics %s, but got %q", expected, header)
+		}
+		if !strings.HasSuffix(header, "db;dur=5.000") {
+			t.Errorf("Expected the db duration to be reported, but got %q", header)
+		}
+	})
+
+	t.Run("Without middleware", func(t *testing.T) {
+		router := NewRouter()
+		router.MustAddRoute("GET", "/users", func(w http.ResponseWriter, req *http.Request) {
+			defer router.Timing(req).Start("db")()
+			w.Write([]byte("Users"))
+		})
+
+		req, err := http.NewRequest("GET", "/users", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Check the response status code
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, status)
+		}
+
+		// Check that no header is added
+		if header := rr.Header().Get("Server-Timing"); header != "" {
+			t.Errorf("Expected no Server-Timing header, but got %q", header)
+		}
+	})
+}
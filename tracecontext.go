@@ -0,0 +1,222 @@
+package router
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// TraceFormat is a format of trace headers.
+type TraceFormat int
+
+const (
+	// TraceW3C is the W3C traceparent and tracestate headers.
+	TraceW3C TraceFormat = iota
+	// TraceB3 is the Zipkin X-B3-* headers.
+	TraceB3
+	// TraceB3Single is the Zipkin b3 header.
+	TraceB3Single
+)
+
+// SetTracePropagation selects the formats of trace headers read from
+// requests and sent on outgoing requests, W3C only by default. Incoming
+// headers are read in the order of the formats, the first valid ones
+// being used. When B3 is selected, requests without trace headers start a
+// new trace.
+func (r *Router) SetTracePropagation(formats ...TraceFormat) {
+	r.traceFormats = formats
+}
+
+// TraceContext is the trace context of a request, parsed from its
+// traceparent and tracestate headers or its B3 headers.
+type TraceContext struct {
+	// TraceID identifies the trace, as 32 lowercase hex digits, or 16 for
+	// some B3 traces.
+	TraceID string
+	// ParentID identifies the caller's span, as 16 lowercase hex digits.
+	ParentID string
+	// SpanID identifies the span of the request in this service. It is
+	// sent as the parent ID of outgoing requests.
+	SpanID string
+	// Flags holds the trace flags, e.g. 1 when the trace is sampled.
+	Flags byte
+	// State is the vendor-specific tracestate header, passed on as is.
+	State string
+
+	// deferred is set when the caller left the sampling decision to us.
+	deferred bool
+}
+
+// Sampled reports whether the caller records the trace.
+func (tc *TraceContext) Sampled() bool {
+	return tc.Flags&1 != 0
+}
+
+// Traceparent returns the traceparent header value for outgoing requests.
+func (tc *TraceContext) Traceparent() string {
+	traceID := strings.Repeat("0", 32-len(tc.TraceID)) + tc.TraceID
+	return "00-" + traceID + "-" + tc.SpanID + "-" + hex.EncodeToString([]byte{tc.Flags})
+}
+
+// TraceContext returns the trace context of the request, or nil if it has
+// none.
+func (r *Router) TraceContext(req *http.Request) *TraceContext {
+	tc, _ := req.Context().Value("traceContext").(*TraceContext)
+	return tc
+}
+
+// Baggage returns the entries of the request's baggage header, with their
+// values decoded and their properties dropped.
+func (r *Router) Baggage(req *http.Request) map[string]string {
+	baggage, _ := req.Context().Value("baggage").(map[string]string)
+	return baggage
+}
+
+// InjectTrace sets the trace headers of an outgoing request made while
+// serving req, in the selected formats, and its baggage header, so that
+// the trace continues in the called service.
+func (r *Router) InjectTrace(req *http.Request, header http.Header) {
+	if tc := r.TraceContext(req); tc != nil {
+		for _, format := range r.tracePropagation() {
+			switch format {
+			case TraceW3C:
+				header.Set("Traceparent", tc.Traceparent())
+				if tc.State != "" {
+					header.Set("Tracestate", tc.State)
+				} else {
+					header.Del("Tracestate")
+				}
+			case TraceB3:
+				tc.setB3Headers(header)
+			case TraceB3Single:
+				header.Set("B3", tc.b3())
+			}
+		}
+	}
+	if baggage := r.Baggage(req); len(baggage) > 0 {
+		header.Set("Baggage", formatBaggage(baggage))
+	}
+}
+
+// tracePropagation returns the selected trace formats.
+func (r *Router) tracePropagation() []TraceFormat {
+	if len(r.traceFormats) == 0 {
+		return []TraceFormat{TraceW3C}
+	}
+	return r.traceFormats
+}
+
+// extractTrace returns the trace context of the request's headers in the
+// selected formats, a new one when B3 is selected, or nil.
+func (r *Router) extractTrace(header http.Header) *TraceContext {
+	generate := false
+	for _, format := range r.tracePropagation() {
+		var tc *TraceContext
+		ok := false
+		switch format {
+		case TraceW3C:
+			if tc, ok = parseTraceparent(header.Get("Traceparent")); ok {
+				tc.State = header.Get("Tracestate")
+			}
+		case TraceB3:
+			tc, ok = parseB3Headers(header)
+			generate = true
+		case TraceB3Single:
+			tc, ok = parseB3(header.Get("B3"))
+			generate = true
+		}
+		if ok {
+			return tc
+		}
+	}
+	if generate {
+		var b [16]byte
+		rand.Read(b[:])
+		return &TraceContext{TraceID: hex.EncodeToString(b[:]), SpanID: newSpanID(), deferred: true}
+	}
+	return nil
+}
+
+// parseTraceparent parses a traceparent header value. Versions other than
+// 00 are parsed as version 00, as the specification requires.
+func parseTraceparent(value string) (*TraceContext, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) < 55 || (len(value) > 55 && value[55] != '-') {
+		return nil, false
+	}
+	version, traceID, parentID, flags := value[0:2], value[3:35], value[36:52], value[53:55]
+	if value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return nil, false
+	}
+	if !isLowerHex(version) || version == "ff" || (version == "00" && len(value) != 55) {
+		return nil, false
+	}
+	if !isLowerHex(traceID) || traceID == strings.Repeat("0", 32) {
+		return nil, false
+	}
+	if !isLowerHex(parentID) || parentID == strings.Repeat("0", 16) {
+		return nil, false
+	}
+	if !isLowerHex(flags) {
+		return nil, false
+	}
+	b, _ := hex.DecodeString(flags)
+	return &TraceContext{TraceID: traceID, ParentID: parentID, SpanID: newSpanID(), Flags: b[0]}, true
+}
+
+// isLowerHex reports whether s only contains lowercase hex digits.
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// newSpanID returns a random span ID.
+func newSpanID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// parseBaggage parses baggage header values, skipping invalid entries.
+func parseBaggage(values []string) map[string]string {
+	var baggage map[string]string
+	for _, value := range values {
+		for _, member := range strings.Split(value, ",") {
+			if i := strings.IndexByte(member, ';'); i >= 0 {
+				member = member[:i]
+			}
+			i := strings.IndexByte(member, '=')
+			if i < 0 {
+				continue
+			}
+			key := strings.TrimSpace(member[:i])
+			v, err := url.PathUnescape(strings.TrimSpace(member[i+1:]))
+			if key == "" || err != nil {
+				continue
+			}
+			if baggage == nil {
+				baggage = make(map[string]string)
+			}
+			baggage[key] = v
+		}
+	}
+	return baggage
+}
+
+// formatBaggage returns the baggage header value of the entries.
+func formatBaggage(baggage map[string]string) string {
+	members := make([]string, 0, len(baggage))
+	for key, value := range baggage {
+		members = append(members, key+"="+url.PathEscape(value))
+	}
+	sort.Strings(members)
+	return strings.Join(members, ",")
+}
@@ -0,0 +1,116 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestTraceContext(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	t.Run("Parsed headers", func(t *testing.T) {
+		router := NewRouter()
+		var tc *TraceContext
+		var baggage map[string]string
+		var correlationID string
+		router.MustAddRoute("GET", "/users", func(w http.ResponseWriter, req *http.Request) {
+			tc = router.TraceContext(req)
+			baggage = router.Baggage(req)
+			correlationID = router.GetCorrelationID(req)
+		})
+
+		req, err := http.NewRequest("GET", "/users", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Traceparent", traceparent)
+		req.Header.Set("Tracestate", "vendor=abc")
+		req.Header.Set("Baggage", "user=alice, region=eu%20west;ttl=60, invalid")
+
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		// Check the parsed trace context
+		if tc == nil {
+			t.Fatal("Expected a trace context")
+		}
+		if tc.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || tc.ParentID != "00f067aa0ba902b7" || !tc.Sampled() || tc.State != "vendor=abc" {
+			t.Errorf("Unexpected trace context %+v", tc)
+		}
+		if len(tc.SpanID) != 16 || tc.SpanID == tc.ParentID {
+			t.Errorf("Expected a new span ID, but got %q", tc.SpanID)
+		}
+
+		// Check that the correlation ID is the trace ID
+		if correlationID != tc.TraceID {
+			t.Errorf("Expected correlation ID %q, but got %q", tc.TraceID, correlationID)
+		}
+
+		// Check the parsed baggage
+		if len(baggage) != 2 || baggage["user"] != "alice" || baggage["region"] != "eu west" {
+			t.Errorf("Unexpected baggage %v", baggage)
+		}
+	})
+
+	t.Run("Invalid traceparent", func(t *testing.T) {
+		invalid := []string{
+			"",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+			"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+			"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+			"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			traceparent + "-extra",
+		}
+		for _, value := range invalid {
+			if _, ok := parseTraceparent(value); ok {
+				t.Errorf("Expected %q to be invalid", value)
+			}
+		}
+		if _, ok := parseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); !ok {
+			t.Error("Expected future versions with extra fields to be valid")
+		}
+	})
+
+	t.Run("Propagated to proxied requests", func(t *testing.T) {
+		var header http.Header
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			header = req.Header.Clone()
+		}))
+		defer upstream.Close()
+
+		target, err := url.Parse(upstream.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		router := NewRouter()
+		var spanID string
+		router.Use(func(next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, req *http.Request) {
+				spanID = router.TraceContext(req).SpanID
+				next(w, req)
+			}
+		})
+		router.Proxy("GET", "/users", target)
+
+		req, err := http.NewRequest("GET", "/users", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Traceparent", traceparent)
+		req.Header.Set("Baggage", "user=alice")
+
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		// Check that the upstream is called as a child span
+		expected := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + spanID + "-01"
+		if got := header.Get("Traceparent"); got != expected {
+			t.Errorf("Expected traceparent %q, but got %q", expected, got)
+		}
+		if got := header.Get("Baggage"); !strings.Contains(got, "user=alice") {
+			t.Errorf("Expected baggage to be forwarded, but got %q", got)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package router
+
+import (
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DeclareTrailers announces the trailers the response will carry in its
+// Trailer header, so that clients and proxies expecting them can prepare.
+// It must be called before the response header is written. Trailers are
+// only sent with chunked responses, so it removes any Content-Length.
+func DeclareTrailers(w http.ResponseWriter, names ...string) {
+	header := w.Header()
+	for _, name := range names {
+		header.Add("Trailer", http.CanonicalHeaderKey(name))
+	}
+	header.Del("Content-Length")
+}
+
+// SetTrailer sets a response trailer. Unlike the response header it can be
+// called after the body is written, until the handler returns, whether the
+// trailer was declared or not.
+func SetTrailer(w http.ResponseWriter, name string, value string) {
+	w.Header().Set(http.TrailerPrefix+http.CanonicalHeaderKey(name), value)
+}
+
+// RequestTrailers reads the rest of the request body and returns the
+// trailers sent after it. It returns nil if the request has no trailers.
+func (r *Router) RequestTrailers(req *http.Request) (http.Header, error) {
+	if req.Body == nil {
+		return req.Trailer, nil
+	}
+	if _, err := io.Copy(io.Discard, req.Body); err != nil {
+		return nil, err
+	}
+	return req.Trailer, nil
+}
+
+// ChecksumTrailer returns middleware sending the hex-encoded hash of the
+// response body in a trailer, e.g. ChecksumTrailer("X-Checksum-Sha256",
+// sha256.New), so that clients can verify streamed responses.
+func ChecksumTrailer(name string, newHash func() hash.Hash) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			DeclareTrailers(w, name)
+			cw := &checksumWriter{ResponseWriter: w, hash: newHash()}
+			next(cw, req)
+			SetTrailer(w, name, hex.EncodeToString(cw.hash.Sum(nil)))
+		}
+	}
+}
+
+// checksumWriter hashes the response body as it is written.
+type checksumWriter struct {
+	http.ResponseWriter
+	hash        hash.Hash
+	wroteHeader bool
+}
+
+// WriteHeader drops any Content-Length set by the handler, which would
+// keep the trailer from being sent.
+func (w *checksumWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.Header().Del("Content-Length")
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write hashes the bytes written to the wrapped writer.
+func (w *checksumWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.hash.Write(b[:n])
+	return n, err
+}
+
+// Flush implements http.Flusher if the wrapped writer supports it.
+func (w *checksumWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter.
+func (w *checksumWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// hasTrailers reports whether the response header declares or sets
+// trailers.
+func hasTrailers(header http.Header) bool {
+	if len(header["Trailer"]) > 0 {
+		return true
+	}
+	for name := range header {
+		if strings.HasPrefix(name, http.TrailerPrefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package router
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTrailers(t *testing.T) {
+	router := NewRouter()
+	router.MustAddRoute("GET", "/stream", func(w http.ResponseWriter, req *http.Request) {
+		DeclareTrailers(w, "X-Row-Count")
+		w.Write([]byte("a\nb\n"))
+		SetTrailer(w, "X-Row-Count", "2")
+		SetTrailer(w, "X-Undeclared", "yes")
+	})
+	router.MustAddRoute("GET", "/download", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Length", "5")
+		w.Write([]byte("hello"))
+	}).Use(ChecksumTrailer("X-Checksum-Sha256", sha256.New))
+	router.MustAddRoute("POST", "/upload", func(w http.ResponseWriter, req *http.Request) {
+		trailer, err := router.RequestTrailers(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(trailer.Get("X-Checksum")))
+	})
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	t.Run("Response trailers", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/stream")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		io.ReadAll(resp.Body)
+
+		// Check the declared and undeclared trailers
+		if value := resp.Trailer.Get("X-Row-Count"); value != "2" {
+			t.Errorf("Expected trailer %q, but got %q", "2", value)
+		}
+		if value := resp.Trailer.Get("X-Undeclared"); value != "yes" {
+			t.Errorf("Expected trailer %q, but got %q", "yes", value)
+		}
+	})
+
+	t.Run("Checksum trailer", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/download")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+
+		// Check the response body
+		if string(body) != "hello" {
+			t.Errorf("Expected response body %q, but got %q", "hello", body)
+		}
+
+		// Check the checksum sent despite the Content-Length of the handler
+		sum := sha256.Sum256([]byte("hello"))
+		if value := resp.Trailer.Get("X-Checksum-Sha256"); value != hex.EncodeToString(sum[:]) {
+			t.Errorf("Expected trailer %q, but got %q", hex.EncodeToString(sum[:]), value)
+		}
+	})
+
+	t.Run("Request trailers", func(t *testing.T) {
+		body := struct{ io.Reader }{strings.NewReader("payload")}
+		req, err := http.NewRequest("POST", server.URL+"/upload", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Trailer = http.Header{"X-Checksum": {"abc123"}}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		got, _ := io.ReadAll(resp.Body)
+
+		// Check the trailer read by the handler
+		if string(got) != "abc123" {
+			t.Errorf("Expected response body %q, but got %q", "abc123", got)
+		}
+	})
+}
+
+func TestBufferedWriterTrailers(t *testing.T) {
+	rr := httptest.NewRecorder()
+	bw := newBufferedWriter(rr)
+	bw.Header().Set("Content-Length", "5")
+	bw.Write([]byte("hello"))
+	SetTrailer(bw, "X-Checksum", "abc123")
+	bw.flush([]byte("hi"))
+
+	// Check that the Content-Length is dropped so the trailer is sent
+	if value := rr.Header().Get("Content-Length"); value != "" {
+		t.Errorf("Expected no Content-Length, but got %q", value)
+	}
+	if value := rr.Result().Trailer.Get("X-Checksum"); value != "abc123" {
+		t.Errorf("Expected trailer %q, but got %q", "abc123", value)
+	}
+}
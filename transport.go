@@ -0,0 +1,84 @@
+package router
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportOptions configures the connections to upstreams.
+type TransportOptions struct {
+	// MaxIdleConns is the maximum number of idle connections across all
+	// hosts. It defaults to 100.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost is the maximum number of idle connections per
+	// host. It defaults to 64, rather than the 2 of http.DefaultTransport
+	// which makes busy gateways open and close connections constantly.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost limits the connections per host, including those in
+	// use. Zero means no limit.
+	MaxConnsPerHost int
+	// IdleConnTimeout is how long idle connections are kept. It defaults to
+	// 90 seconds.
+	IdleConnTimeout time.Duration
+	// DialTimeout limits the time to connect. It defaults to 30 seconds.
+	DialTimeout time.Duration
+	// KeepAlive is the interval of TCP keep-alive probes. It defaults to 30
+	// seconds, and a negative value disables them.
+	KeepAlive time.Duration
+	// TLSHandshakeTimeout limits the time of TLS handshakes. It defaults to
+	// 10 seconds.
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout, if set, limits the time to the response header
+	// once the request is sent.
+	ResponseHeaderTimeout time.Duration
+	// TLSConfig configures TLS connections, e.g. with client certificates
+	// or the root CAs of the upstreams.
+	TLSConfig *tls.Config
+	// DisableHTTP2 makes TLS connections use HTTP/1.1 only.
+	DisableHTTP2 bool
+}
+
+// NewTransport creates a transport for ProxyWithTransport and upstream pools.
+// A transport holds its idle connections, so it should be created once per
+// upstream rather than per request.
+func NewTransport(opts TransportOptions) *http.Transport {
+	if opts.MaxIdleConns <= 0 {
+		opts.MaxIdleConns = 100
+	}
+	if opts.MaxIdleConnsPerHost <= 0 {
+		opts.MaxIdleConnsPerHost = 64
+	}
+	if opts.IdleConnTimeout <= 0 {
+		opts.IdleConnTimeout = 90 * time.Second
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 30 * time.Second
+	}
+	if opts.KeepAlive == 0 {
+		opts.KeepAlive = 30 * time.Second
+	}
+	if opts.TLSHandshakeTimeout <= 0 {
+		opts.TLSHandshakeTimeout = 10 * time.Second
+	}
+
+	dialer := &net.Dialer{Timeout: opts.DialTimeout, KeepAlive: opts.KeepAlive}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          opts.MaxIdleConns,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: opts.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+		TLSClientConfig:       opts.TLSConfig,
+		ForceAttemptHTTP2:     !opts.DisableHTTP2,
+	}
+	if opts.DisableHTTP2 {
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return transport
+}
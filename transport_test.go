@@ -0,0 +1,95 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingTransport counts the requests it sends.
+type countingTransport struct {
+	http.RoundTripper
+	requests int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.requests, 1)
+	return t.RoundTripper.RoundTrip(req)
+}
+
+func TestNewTransport(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		transport := NewTransport(TransportOptions{})
+
+		// Check the connection pool settings
+		if transport.MaxIdleConnsPerHost != 64 {
+			t.Errorf("Expected %d idle connections per host, but got %d", 64, transport.MaxIdleConnsPerHost)
+		}
+		if transport.IdleConnTimeout != 90*time.Second {
+			t.Errorf("Expected idle connection timeout %s, but got %s", 90*time.Second, transport.IdleConnTimeout)
+		}
+		if !transport.ForceAttemptHTTP2 {
+			t.Errorf("Expected HTTP/2 to be enabled")
+		}
+	})
+
+	t.Run("HTTP/2 disabled", func(t *testing.T) {
+		transport := NewTransport(TransportOptions{DisableHTTP2: true, MaxConnsPerHost: 8})
+
+		// Check that HTTP/2 is disabled
+		if transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+			t.Errorf("Expected HTTP/2 to be disabled")
+		}
+		if transport.MaxConnsPerHost != 8 {
+			t.Errorf("Expected %d connections per host, but got %d", 8, transport.MaxConnsPerHost)
+		}
+	})
+}
+
+func TestProxyTransport(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer backend.Close()
+	target, _ := url.Parse(backend.URL)
+
+	t.Run("Proxy route", func(t *testing.T) {
+		transport := &countingTransport{RoundTripper: NewTransport(TransportOptions{})}
+		router := NewRouter()
+		router.ProxyWithTransport("GET", "/app", target, transport)
+
+		req := httptest.NewRequest("GET", "/app", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Check that the request was sent with the transport
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+		if n := atomic.LoadInt32(&transport.requests); n != 1 {
+			t.Errorf("Expected %d request sent with the transport, but got %d", 1, n)
+		}
+	})
+
+	t.Run("Upstream pool", func(t *testing.T) {
+		transport := &countingTransport{RoundTripper: NewTransport(TransportOptions{})}
+		router := NewRouter()
+		router.ProxyPool("GET", "/app", NewUpstreamPool(UpstreamPoolOptions{Transport: transport}, target))
+
+		req := httptest.NewRequest("GET", "/app", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Check that the request was sent with the transport
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, rr.Code)
+		}
+		if n := atomic.LoadInt32(&transport.requests); n != 1 {
+			t.Errorf("Expected %d request sent with the transport, but got %d", 1, n)
+		}
+	})
+}
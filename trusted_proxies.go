@@ -0,0 +1,241 @@
+package router
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TrustedProxies is a set of networks whose forwarding headers, such as
+// X-Forwarded-Proto, are trusted. A nil *TrustedProxies trusts no one.
+type TrustedProxies struct {
+	networks []*net.IPNet
+}
+
+// NewTrustedProxies creates a set of trusted proxies from IP addresses and
+// CIDR ranges, e.g. "10.0.0.0/8" or "127.0.0.1".
+func NewTrustedProxies(addrs ...string) (*TrustedProxies, error) {
+	p := &TrustedProxies{}
+	for _, addr := range addrs {
+		if !strings.Contains(addr, "/") {
+			ip := net.ParseIP(addr)
+			if ip == nil {
+				return nil, fmt.Errorf("router: invalid trusted proxy address %q", addr)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			p.networks = append(p.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(addr)
+		if err != nil {
+			return nil, fmt.Errorf("router: invalid trusted proxy network %q: %v", addr, err)
+		}
+		p.networks = append(p.networks, network)
+	}
+	return p, nil
+}
+
+// Contains reports whether the IP address belongs to a trusted proxy.
+func (p *TrustedProxies) Contains(ip net.IP) bool {
+	if p == nil || ip == nil {
+		return false
+	}
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Trusted reports whether the request was received from a trusted proxy.
+func (p *TrustedProxies) Trusted(req *http.Request) bool {
+	return p.Contains(remoteIP(req))
+}
+
+// remoteIP returns the IP address of the request's immediate peer.
+func remoteIP(req *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// headerValues returns the comma-separated values of a header.
+func headerValues(req *http.Request, name string) []string {
+	var values []string
+	for _, value := range req.Header.Values(name) {
+		for _, v := range strings.Split(value, ",") {
+			values = append(values, strings.TrimSpace(v))
+		}
+	}
+	return values
+}
+
+// ClientInfo describes the client of a request, as reported by the
+// trusted proxies it went through.
+type ClientInfo struct {
+	// IP is the address of the client.
+	IP net.IP
+	// Proto is the scheme the client used, "http" or "https".
+	Proto string
+	// Host is the host the client requested.
+	Host string
+	// By is the interface of the proxy that received the request from the
+	// client, if it reported one.
+	By string
+}
+
+// ClientIP returns the IP address of the client, see ClientInfo.
+func (p *TrustedProxies) ClientIP(req *http.Request) net.IP {
+	return p.ClientInfo(req).IP
+}
+
+// ClientInfo returns the client of the request. When the request was
+// received from a trusted proxy, the chain of forwarding proxies is walked
+// from right to left and the first untrusted address is the client. The
+// chain is read from the standard Forwarded header when it is present,
+// and from the X-Forwarded-For, X-Forwarded-Proto and X-Forwarded-Host
+// headers otherwise, taking the protocol and host entries of the same hop as
+// the client. Requests not received from a trusted proxy are described by
+// their connection.
+func (p *TrustedProxies) ClientInfo(req *http.Request) ClientInfo {
+	info := ClientInfo{IP: remoteIP(req), Proto: "http", Host: req.Host}
+	if req.TLS != nil {
+		info.Proto = "https"
+	}
+	if !p.Contains(info.IP) {
+		return info
+	}
+
+	if values := req.Header.Values("Forwarded"); len(values) > 0 {
+		elements := parseForwarded(values)
+		for i := len(elements) - 1; i >= 0; i-- {
+			hop := forwardedNodeIP(elements[i]["for"])
+			if hop == nil {
+				break
+			}
+			info.IP, info.By = hop, elements[i]["by"]
+			if proto := elements[i]["proto"]; proto != "" {
+				info.Proto = strings.ToLower(proto)
+			}
+			if host := elements[i]["host"]; host != "" {
+				info.Host = host
+			}
+			if !p.Contains(hop) {
+				break
+			}
+		}
+		return info
+	}
+
+	// Each proxy appends to the headers, so the entries at the same distance
+	// from the right describe the same hop. Proxies that only set the
+	// protocol and host once leave the rightmost entries in place.
+	forwarded := headerValues(req, "X-Forwarded-For")
+	protos := headerValues(req, "X-Forwarded-Proto")
+	hosts := headerValues(req, "X-Forwarded-Host")
+	hop := func(list []string, k int) string {
+		if i := len(list) - 1 - k; i >= 0 {
+			return list[i]
+		}
+		return ""
+	}
+	for k := 0; ; k++ {
+		// The protocol and host set by the proxy itself apply without a hop
+		ip := net.ParseIP(hop(forwarded, k))
+		if ip == nil && k > 0 {
+			break
+		}
+		if proto := hop(protos, k); proto != "" {
+			info.Proto = strings.ToLower(proto)
+		}
+		if host := hop(hosts, k); host != "" {
+			info.Host = host
+		}
+		if ip == nil {
+			break
+		}
+		info.IP = ip
+		if !p.Contains(ip) {
+			break
+		}
+	}
+	return info
+}
+
+// parseForwarded parses Forwarded header values (RFC 7239) into their
+// elements, one per proxy, mapping lower-cased parameter names to their
+// unquoted values.
+func parseForwarded(values []string) []map[string]string {
+	var elements []map[string]string
+	for _, value := range values {
+		element := map[string]string{}
+		for len(value) > 0 {
+			// Read a name=value pair, where the value may be quoted
+			value = strings.TrimLeft(value, " \t")
+			eq := strings.IndexByte(value, '=')
+			if eq < 0 {
+				break
+			}
+			name := strings.ToLower(strings.TrimSpace(value[:eq]))
+			value = value[eq+1:]
+			var v string
+			if strings.HasPrefix(value, `"`) {
+				var b strings.Builder
+				i := 1
+				for ; i < len(value) && value[i] != '"'; i++ {
+					if value[i] == '\\' && i+1 < len(value) {
+						i++
+					}
+					b.WriteByte(value[i])
+				}
+				if i < len(value) {
+					i++
+				}
+				v, value = b.String(), value[i:]
+			} else {
+				end := strings.IndexAny(value, ";,")
+				if end < 0 {
+					end = len(value)
+				}
+				v, value = strings.TrimSpace(value[:end]), value[end:]
+			}
+			element[name] = v
+
+			value = strings.TrimLeft(value, " \t")
+			if strings.HasPrefix(value, ";") {
+				value = value[1:]
+			} else if strings.HasPrefix(value, ",") {
+				elements = append(elements, element)
+				element = map[string]string{}
+				value = value[1:]
+			}
+		}
+		if len(element) > 0 {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// forwardedNodeIP returns the IP address of a Forwarded node, such as
+// "192.0.2.43:47011" or "[2001:db8::17]", or nil for obfuscated and unknown
+// nodes.
+func forwardedNodeIP(node string) net.IP {
+	if strings.HasPrefix(node, "[") {
+		if end := strings.IndexByte(node, ']'); end > 0 {
+			return net.ParseIP(node[1:end])
+		}
+		return nil
+	}
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+	return net.ParseIP(node)
+}
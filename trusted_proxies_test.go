@@ -0,0 +1,49 @@
+package router
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientInfo(t *testing.T) {
+	proxies, err := NewTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string][]string
+		ip      string
+		proto   string
+		host    string
+	}{
+		{"Direct", "192.0.2.1:1234", map[string][]string{"Forwarded": {"for=203.0.113.7;proto=https"}}, "192.0.2.1", "http", "example.com"},
+		{"Forwarded", "10.0.0.2:1234", map[string][]string{"Forwarded": {`for=203.0.113.7;proto=https;host="shop.example.com"`}}, "203.0.113.7", "https", "shop.example.com"},
+		{"Forwarded chain", "10.0.0.2:1234", map[string][]string{"Forwarded": {`for=198.51.100.9, for="[2001:db8::17]:4711";proto=https`, "for=10.0.0.5:80"}}, "2001:db8::17", "https", "example.com"},
+		{"Spoofed Forwarded", "10.0.0.2:1234", map[string][]string{"Forwarded": {"for=10.0.0.1, for=203.0.113.7;proto=http"}}, "203.0.113.7", "http", "example.com"},
+		{"Obfuscated node", "10.0.0.2:1234", map[string][]string{"Forwarded": {"for=_hidden;proto=https"}}, "10.0.0.2", "http", "example.com"},
+		{"Forwarded takes precedence", "10.0.0.2:1234", map[string][]string{"Forwarded": {"for=203.0.113.7"}, "X-Forwarded-For": {"198.51.100.9"}}, "203.0.113.7", "http", "example.com"},
+		{"X-Forwarded headers", "10.0.0.2:1234", map[string][]string{"X-Forwarded-For": {"203.0.113.7, 10.0.0.3"}, "X-Forwarded-Proto": {"HTTPS"}, "X-Forwarded-Host": {"shop.example.com"}}, "203.0.113.7", "https", "shop.example.com"},
+		{"Spoofed X-Forwarded headers", "10.0.0.2:1234", map[string][]string{"X-Forwarded-For": {"203.0.113.7"}, "X-Forwarded-Proto": {"https, http"}, "X-Forwarded-Host": {"evil.example.com", "shop.example.com"}}, "203.0.113.7", "http", "shop.example.com"},
+		{"X-Forwarded headers per hop", "10.0.0.2:1234", map[string][]string{"X-Forwarded-For": {"203.0.113.7, 10.0.0.3"}, "X-Forwarded-Proto": {"https, http"}}, "203.0.113.7", "https", "example.com"},
+		{"X-Forwarded headers without hops", "10.0.0.2:1234", map[string][]string{"X-Forwarded-Proto": {"https"}}, "10.0.0.2", "https", "example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/", nil)
+			req.RemoteAddr = tt.remote
+			for name, values := range tt.headers {
+				req.Header[name] = values
+			}
+			info := proxies.ClientInfo(req)
+
+			// Check the reconciled client information
+			if info.IP.String() != tt.ip || info.Proto != tt.proto || info.Host != tt.host {
+				t.Errorf("Expected %s %s %s, but got %s %s %s", tt.ip, tt.proto, tt.host, info.IP, info.Proto, info.Host)
+			}
+		})
+	}
+}
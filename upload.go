@@ -0,0 +1,159 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// executableType is the media type detected for executables and scripts.
+const executableType = "application/x-executable"
+
+// UploadOptions configures the validation of uploaded files.
+type UploadOptions struct {
+	// Allowed lists the allowed media types, such as "image/png" or
+	// "image/*". Any type is allowed when it is empty, except executables
+	// which must be allowed explicitly as "application/x-executable".
+	Allowed []string
+}
+
+// UploadError reports an uploaded file whose content does not match its
+// declared type or whose type is not allowed.
+type UploadError struct {
+	Declared string
+	Detected string
+	Reason   string
+}
+
+// Error implements the error interface.
+func (e *UploadError) Error() string {
+	return fmt.Sprintf("router: upload declared as %q detected as %q: %s", e.Declared, e.Detected, e.Reason)
+}
+
+// SniffUpload detects the media type of an uploaded file from its first
+// 512 bytes and checks it against the declared Content-Type and the
+// allowed types. Text and unrecognised binary content cannot be told apart
+// further by sniffing, so the declared type is trusted for them when it is
+// compatible. It returns the media type of the file, or an *UploadError.
+func SniffUpload(head []byte, declared string, opts UploadOptions) (string, error) {
+	detected := sniffType(head)
+	declaredType, _, err := mime.ParseMediaType(declared)
+	if err != nil {
+		declaredType = ""
+	}
+
+	if detected == executableType {
+		for _, allowed := range opts.Allowed {
+			if allowed == executableType {
+				return detected, nil
+			}
+		}
+		return "", &UploadError{Declared: declared, Detected: detected, Reason: "executables are not allowed"}
+	}
+
+	mediaType := detected
+	switch {
+	case detected == declaredType || declaredType == "":
+	case detected == "text/plain" && isTextType(declaredType):
+		mediaType = declaredType
+	case detected == "text/xml" && (declaredType == "application/xml" || strings.HasSuffix(declaredType, "+xml")):
+		mediaType = declaredType
+	case detected == "application/zip" && isZipType(declaredType):
+		mediaType = declaredType
+	case detected == "application/octet-stream" && !hasSignature(declaredType):
+		mediaType = declaredType
+	default:
+		return "", &UploadError{Declared: declared, Detected: detected, Reason: "content does not match the declared type"}
+	}
+
+	if len(opts.Allowed) == 0 {
+		return mediaType, nil
+	}
+	for _, allowed := range opts.Allowed {
+		if mediaTypeMatches(strings.ToLower(allowed), mediaType) {
+			return mediaType, nil
+		}
+	}
+	return "", &UploadError{Declared: declared, Detected: detected, Reason: "type is not allowed"}
+}
+
+// FormFile returns the first file uploaded under the form key, like
+// http.Request.FormFile, after checking its content with SniffUpload. It
+// also returns the media type of the file.
+func (r *Router) FormFile(req *http.Request, key string, opts UploadOptions) (multipart.File, *multipart.FileHeader, string, error) {
+	file, header, err := req.FormFile(key)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		file.Close()
+		return nil, nil, "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, "", err
+	}
+	mediaType, err := SniffUpload(head[:n], header.Header.Get("Content-Type"), opts)
+	if err != nil {
+		file.Close()
+		return nil, nil, "", err
+	}
+	return file, header, mediaType, nil
+}
+
+// executableSignatures are the magic bytes of executables and scripts,
+// which http.DetectContentType reports as binary or text data.
+var executableSignatures = [][]byte{
+	[]byte("MZ"),               // Windows PE
+	[]byte("\x7fELF"),          // ELF
+	[]byte("\xfe\xed\xfa\xce"), // Mach-O 32-bit
+	[]byte("\xfe\xed\xfa\xcf"), // Mach-O 64-bit
+	[]byte("\xce\xfa\xed\xfe"), // Mach-O 32-bit, little endian
+	[]byte("\xcf\xfa\xed\xfe"), // Mach-O 64-bit, little endian
+	[]byte("\xca\xfe\xba\xbe"), // Mach-O universal binary or Java class
+	[]byte("#!"),               // script
+}
+
+// sniffType returns the media type of the content, without parameters.
+func sniffType(head []byte) string {
+	for _, signature := range executableSignatures {
+		if bytes.HasPrefix(head, signature) {
+			return executableType
+		}
+	}
+	detected, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	return detected
+}
+
+// isTextType reports whether content of the media type is plain text to
+// http.DetectContentType.
+func isTextType(mediaType string) bool {
+	switch mediaType {
+	case "application/json", "application/xml", "application/yaml", "application/x-ndjson":
+		return true
+	}
+	return strings.HasPrefix(mediaType, "text/") && mediaType != "text/html" ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
+
+// isZipType reports whether files of the media type are ZIP archives.
+func isZipType(mediaType string) bool {
+	return strings.HasSuffix(mediaType, "+zip") ||
+		strings.HasPrefix(mediaType, "application/vnd.openxmlformats-officedocument.") ||
+		strings.HasPrefix(mediaType, "application/vnd.oasis.opendocument.") ||
+		mediaType == "application/java-archive"
+}
+
+// hasSignature reports whether http.DetectContentType recognises files of
+// the media type by their magic bytes.
+func hasSignature(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "image/") || strings.HasPrefix(mediaType, "audio/") ||
+		strings.HasPrefix(mediaType, "video/") || mediaType == "application/pdf" ||
+		mediaType == "application/zip" || mediaType == "application/x-gzip" || mediaType == "text/html"
+}
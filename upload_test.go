@@ -0,0 +1,123 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+)
+
+func TestSniffUpload(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	tests := []struct {
+		name     string
+		head     []byte
+		declared string
+		opts     UploadOptions
+		want     string
+		reason   string
+	}{
+		{"Matching image", png, "image/png", UploadOptions{}, "image/png", ""},
+		{"HTML disguised as image", []byte("<html><script>alert(1)</script></html>"), "image/png", UploadOptions{}, "", "content does not match the declared type"},
+		{"Executable disguised as PDF", []byte("MZ\x90\x00\x03\x00\x00\x00"), "application/pdf", UploadOptions{}, "", "executables are not allowed"},
+		{"Executable as binary data", []byte("\x7fELF\x02\x01\x01"), "application/octet-stream", UploadOptions{}, "", "executables are not allowed"},
+		{"Executable allowed", []byte("\x7fELF\x02\x01\x01"), "application/octet-stream", UploadOptions{Allowed: []string{executableType}}, executableType, ""},
+		{"CSV", []byte("id,name\n1,alice\n"), "text/csv", UploadOptions{}, "text/csv", ""},
+		{"SVG", []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`), "image/svg+xml", UploadOptions{}, "image/svg+xml", ""},
+		{"Word document", []byte("PK\x03\x04\x14\x00\x06\x00"), "application/vnd.openxmlformats-officedocument.wordprocessingml.document", UploadOptions{}, "application/vnd.openxmlformats-officedocument.wordprocessingml.document", ""},
+		{"Unrecognised binary", []byte("\x00\x01\x02\x03"), "application/x-custom", UploadOptions{}, "application/x-custom", ""},
+		{"Binary declared as image", []byte("\x00\x01\x02\x03"), "image/png", UploadOptions{}, "", "content does not match the declared type"},
+		{"Allowed images", png, "image/png", UploadOptions{Allowed: []string{"image/*"}}, "image/png", ""},
+		{"Type not allowed", []byte("%PDF-1.7\n"), "application/pdf", UploadOptions{Allowed: []string{"image/*"}}, "", "type is not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SniffUpload(tt.head, tt.declared, tt.opts)
+
+			// Check the detected type or the rejection reason
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, but got %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("Expected media type %q, but got %q", tt.want, got)
+				}
+				return
+			}
+			var uploadErr *UploadError
+			if !errors.As(err, &uploadErr) {
+				t.Fatalf("Expected an upload error, but got %v", err)
+			}
+			if uploadErr.Reason != tt.reason {
+				t.Errorf("Expected reason %q, but got %q", tt.reason, uploadErr.Reason)
+			}
+		})
+	}
+}
+
+func TestFormFile(t *testing.T) {
+	router := NewRouter()
+	router.MustAddRoute("POST", "/avatar", func(w http.ResponseWriter, req *http.Request) {
+		file, _, mediaType, err := router.FormFile(req, "avatar", UploadOptions{Allowed: []string{"image/*"}})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+		defer file.Close()
+		content, _ := io.ReadAll(file)
+		w.Header().Set("Content-Type", mediaType)
+		w.Write(content)
+	})
+
+	upload := func(content []byte, declared string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", `form-data; name="avatar"; filename="avatar.png"`)
+		header.Set("Content-Type", declared)
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(content)
+		mw.Close()
+
+		req, err := http.NewRequest("POST", "/avatar", &body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Valid image", func(t *testing.T) {
+		png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+		rr := upload(png, "image/png")
+
+		// Check the response status code
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("Expected status code %d, but got %d", http.StatusOK, status)
+		}
+
+		// Check that the file is read from the start
+		if body := rr.Body.String(); body != string(png) {
+			t.Errorf("Expected response body %q, but got %q", png, body)
+		}
+	})
+
+	t.Run("Disguised executable", func(t *testing.T) {
+		rr := upload([]byte("MZ\x90\x00\x03\x00\x00\x00"), "image/png")
+
+		// Check the response status code
+		if status := rr.Code; status != http.StatusUnsupportedMediaType {
+			t.Errorf("Expected status code %d, but got %d", http.StatusUnsupportedMediaType, status)
+		}
+	})
+}
@@ -0,0 +1,294 @@
+package router
+
+import (
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Affinity modes of upstream pools.
+const (
+	// AffinityNone spreads requests over the backends in turn.
+	AffinityNone = iota
+	// AffinityCookie sends clients back to the backend named by a cookie
+	// set on their first response.
+	AffinityCookie
+	// AffinityHash sends requests with the same key, by default the client
+	// IP address, to the same backend using consistent hashing, so that
+	// only the keys of a leaving backend move.
+	AffinityHash
+)
+
+// hashReplicas is the number of points of each backend on the hash ring.
+const hashReplicas = 100
+
+// UpstreamPoolOptions configures an upstream pool.
+type UpstreamPoolOptions struct {
+	// Affinity is the affinity mode, AffinityNone by default.
+	Affinity int
+	// CookieName is the name of the affinity cookie. It defaults to
+	// "upstream".
+	CookieName string
+	// HashKey returns the key of the request for AffinityHash. It defaults
+	// to the client IP address.
+	HashKey func(req *http.Request) string
+	// Outliers, if set, ejects backends failing live requests.
+	Outliers *OutlierDetection
+	// Retry, if set, retries failed requests.
+	Retry *RetryPolicy
+	// Hedge, if set, hedges slow requests.
+	Hedge *HedgePolicy
+	// Transport connects to the backends, see NewTransport. It defaults to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+// UpstreamPool is a set of backends serving the same application, see
+// ProxyPool.
+type UpstreamPool struct {
+	opts UpstreamPoolOptions
+
+	mu       sync.RWMutex
+	backends []*backend
+	ring     []hashPoint
+	next     uint32 // accessed atomically
+
+	outlierMu sync.Mutex
+	budget    *RetryBudget
+	latencies latencies
+}
+
+// backend is an upstream of a pool.
+type backend struct {
+	url       *url.URL
+	id        string
+	transport http.RoundTripper
+	once      sync.Once
+	handler   http.HandlerFunc
+	down      int32 // set by failed health checks, accessed atomically
+
+	// Consecutive health check results, used by the health checker only
+	successes int
+	failures  int
+
+	// Outlier detection state, see OutlierDetection
+	ejectedUntil int64 // in Unix nanoseconds, accessed atomically
+	errors       int   // consecutive errors, guarded by the pool's outlierMu
+	ejections    int   // guarded by the pool's outlierMu
+}
+
+// available reports whether the backend may receive requests.
+func (b *backend) available() bool {
+	return atomic.LoadInt32(&b.down) == 0 && !b.ejected(time.Now())
+}
+
+// hashPoint is a point of a backend on the hash ring.
+type hashPoint struct {
+	hash    uint32
+	backend *backend
+}
+
+// NewUpstreamPool creates a pool of the target backends.
+func NewUpstreamPool(opts UpstreamPoolOptions, targets ...*url.URL) *UpstreamPool {
+	if opts.CookieName == "" {
+		opts.CookieName = "upstream"
+	}
+	if opts.HashKey == nil {
+		opts.HashKey = func(req *http.Request) string {
+			return remoteIP(req).String()
+		}
+	}
+	if opts.Retry != nil {
+		retry := *opts.Retry
+		if retry.MaxAttempts <= 0 {
+			retry.MaxAttempts = 2
+		}
+		if len(retry.RetryOn) == 0 {
+			retry.RetryOn = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+		}
+		opts.Retry = &retry
+	}
+	if opts.Hedge != nil {
+		hedge := *opts.Hedge
+		if hedge.Percentile <= 0 || hedge.Percentile > 100 {
+			hedge.Percentile = 95
+		}
+		if hedge.MinSamples <= 0 {
+			hedge.MinSamples = 20
+		}
+		opts.Hedge = &hedge
+	}
+	p := &UpstreamPool{opts: opts}
+	if opts.Retry != nil {
+		p.budget = opts.Retry.Budget
+		if p.budget == nil {
+			p.budget = NewRetryBudget(0.2, 10)
+		}
+	}
+	for _, target := range targets {
+		p.Add(target)
+	}
+	return p
+}
+
+// Add adds a backend to the pool.
+func (p *UpstreamPool) Add(target *url.URL) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id := strconv.FormatUint(hash64(target.String()), 16)
+	for _, b := range p.backends {
+		if b.id == id {
+			return
+		}
+	}
+	p.backends = append(p.backends, &backend{url: target, id: id, transport: p.opts.Transport})
+	p.rebuildRing()
+}
+
+// Remove removes a backend from the pool. Clients with affinity to it are
+// moved to the remaining backends.
+func (p *UpstreamPool) Remove(target *url.URL) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id := strconv.FormatUint(hash64(target.String()), 16)
+	for i, b := range p.backends {
+		if b.id == id {
+			p.backends = append(p.backends[:i:i], p.backends[i+1:]...)
+			p.rebuildRing()
+			return
+		}
+	}
+}
+
+// SetBackends replaces the backends of the pool with the targets. Backends
+// present before keep their health and outlier state.
+func (p *UpstreamPool) SetBackends(targets []*url.URL) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	current := make(map[string]*backend, len(p.backends))
+	for _, b := range p.backends {
+		current[b.id] = b
+	}
+	seen := make(map[string]bool, len(targets))
+	backends := make([]*backend, 0, len(targets))
+	for _, target := range targets {
+		id := strconv.FormatUint(hash64(target.String()), 16)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		b, ok := current[id]
+		if !ok {
+			b = &backend{url: target, id: id, transport: p.opts.Transport}
+		}
+		backends = append(backends, b)
+	}
+	p.backends = backends
+	p.rebuildRing()
+}
+
+// Backends returns the URLs of the backends of the pool.
+func (p *UpstreamPool) Backends() []*url.URL {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	urls := make([]*url.URL, len(p.backends))
+	for i, b := range p.backends {
+		urls[i] = b.url
+	}
+	return urls
+}
+
+// rebuildRing rebuilds the hash ring of the backends.
+func (p *UpstreamPool) rebuildRing() {
+	if p.opts.Affinity != AffinityHash {
+		return
+	}
+	p.ring = p.ring[:0]
+	for _, b := range p.backends {
+		for i := 0; i < hashReplicas; i++ {
+			p.ring = append(p.ring, hashPoint{uint32(hash64(b.id + "#" + strconv.Itoa(i))), b})
+		}
+	}
+	sort.Slice(p.ring, func(i, j int) bool {
+		return p.ring[i].hash < p.ring[j].hash
+	})
+}
+
+// pick chooses the backend of the request among the available ones, or
+// nil if there is none. Backends already tried by the request are avoided
+// unless no other backend is available.
+func (p *UpstreamPool) pick(req *http.Request, tried []*backend) *backend {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var available, untried []*backend
+	for _, b := range p.backends {
+		if b.available() {
+			available = append(available, b)
+			if !containsBackend(tried, b) {
+				untried = append(untried, b)
+			}
+		}
+	}
+	if len(untried) > 0 {
+		available = untried
+	}
+	if len(available) == 0 {
+		return nil
+	}
+
+	switch p.opts.Affinity {
+	case AffinityCookie:
+		if cookie, err := req.Cookie(p.opts.CookieName); err == nil {
+			for _, b := range available {
+				if b.id == cookie.Value {
+					return b
+				}
+			}
+		}
+	case AffinityHash:
+		// Walk the ring past unavailable backends, so that only their keys
+		// move
+		h := uint32(hash64(p.opts.HashKey(req)))
+		i := sort.Search(len(p.ring), func(i int) bool {
+			return p.ring[i].hash >= h
+		})
+		for j := 0; j < len(p.ring); j++ {
+			if b := p.ring[(i+j)%len(p.ring)].backend; containsBackend(available, b) {
+				return b
+			}
+		}
+	}
+	n := atomic.AddUint32(&p.next, 1)
+	return available[(n-1)%uint32(len(available))]
+}
+
+// containsBackend reports whether the backend is in the list.
+func containsBackend(backends []*backend, b *backend) bool {
+	for _, candidate := range backends {
+		if candidate == b {
+			return true
+		}
+	}
+	return false
+}
+
+// hash64 returns the FNV-1a hash of s.
+func hash64(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// ProxyPool adds a route forwarding matching requests to the backends of
+// the pool, like Proxy. Requests are answered with 503 Service Unavailable
+// when the pool has no available backend.
+func (r *Router) ProxyPool(method string, path string, pool *UpstreamPool) (*Route, error) {
+	return r.AddRoute(method, path, func(w http.ResponseWriter, req *http.Request) {
+		pool.serve(r, w, req)
+	})
+}
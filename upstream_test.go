@@ -0,0 +1,124 @@
+package router
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// startBackends starts n backends answering with their index.
+func startBackends(t *testing.T, n int) []*url.URL {
+	var urls []*url.URL
+	for i := 0; i < n; i++ {
+		name := fmt.Sprint(i)
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			io.WriteString(w, name)
+		}))
+		t.Cleanup(backend.Close)
+		u, _ := url.Parse(backend.URL)
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+func TestProxyPool(t *testing.T) {
+	backends := startBackends(t, 3)
+
+	serve := func(router *Router, remote string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/app", nil)
+		req.RemoteAddr = remote
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Round robin", func(t *testing.T) {
+		router := NewRouter()
+		router.ProxyPool("GET", "/app", NewUpstreamPool(UpstreamPoolOptions{}, backends...))
+
+		// Check that the backends take turns
+		var got string
+		for i := 0; i < 6; i++ {
+			got += serve(router, "192.0.2.1:1000", nil).Body.String()
+		}
+		if got != "012012" {
+			t.Errorf("Expected the backends to take turns, but got %q", got)
+		}
+	})
+
+	t.Run("Cookie affinity", func(t *testing.T) {
+		router := NewRouter()
+		pool := NewUpstreamPool(UpstreamPoolOptions{Affinity: AffinityCookie}, backends...)
+		router.ProxyPool("GET", "/app", pool)
+
+		rr := serve(router, "192.0.2.1:1000", nil)
+		first := rr.Body.String()
+		cookies := rr.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != "upstream" {
+			t.Fatalf("Expected an affinity cookie, but got %v", cookies)
+		}
+
+		// Check that the client sticks to its backend
+		for i := 0; i < 3; i++ {
+			rr := serve(router, "192.0.2.1:1000", cookies)
+			if body := rr.Body.String(); body != first {
+				t.Errorf("Expected backend %s, but got %s", first, body)
+			}
+			if len(rr.Result().Cookies()) != 0 {
+				t.Errorf("Expected the cookie not to be set again")
+			}
+		}
+
+		// Check that the client moves when its backend leaves
+		var index int
+		fmt.Sscan(first, &index)
+		pool.Remove(backends[index])
+		rr = serve(router, "192.0.2.1:1000", cookies)
+		if rr.Body.String() == first || len(rr.Result().Cookies()) != 1 {
+			t.Errorf("Expected the client to move to another backend, but got %s", rr.Body.String())
+		}
+	})
+
+	t.Run("Consistent hashing", func(t *testing.T) {
+		router := NewRouter()
+		pool := NewUpstreamPool(UpstreamPoolOptions{Affinity: AffinityHash}, backends...)
+		router.ProxyPool("GET", "/app", pool)
+
+		before := map[string]string{}
+		for i := 0; i < 50; i++ {
+			remote := fmt.Sprintf("198.51.100.%d:1000", i)
+			before[remote] = serve(router, remote, nil).Body.String()
+			if again := serve(router, remote, nil).Body.String(); again != before[remote] {
+				t.Errorf("Expected %s to stay on backend %s, but got %s", remote, before[remote], again)
+			}
+		}
+
+		// Check that only the clients of a leaving backend move
+		pool.Remove(backends[1])
+		for remote, was := range before {
+			now := serve(router, remote, nil).Body.String()
+			if was != "1" && now != was {
+				t.Errorf("Expected %s to stay on backend %s, but got %s", remote, was, now)
+			}
+			if now == "1" {
+				t.Errorf("Expected %s to leave the removed backend", remote)
+			}
+		}
+	})
+
+	t.Run("Empty pool", func(t *testing.T) {
+		router := NewRouter()
+		router.ProxyPool("GET", "/app", NewUpstreamPool(UpstreamPoolOptions{}))
+
+		// Check the response status code
+		if rr := serve(router, "192.0.2.1:1000", nil); rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status code %d, but got %d", http.StatusServiceUnavailable, rr.Code)
+		}
+	})
+}
@@ -0,0 +1,316 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sdpsagarpawar/logger"
+)
+
+// WebhookEndpoint is a receiver of webhook events.
+type WebhookEndpoint struct {
+	// URL is the address events are posted to.
+	URL string
+	// Secret signs the deliveries with HMAC-SHA256. The signature is sent
+	// in the X-Webhook-Signature header as "sha256=<hex>".
+	Secret string
+	// Events lists the events sent to the endpoint. All events are sent
+	// when it is empty.
+	Events []string
+}
+
+// WebhookOptions configures a webhook dispatcher.
+type WebhookOptions struct {
+	// Client sends the deliveries. It defaults to a client with a 10 second
+	// timeout.
+	Client *http.Client
+	// MaxAttempts is the number of attempts per delivery, 5 by default.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled for each further
+	// retry. It defaults to one second.
+	Backoff time.Duration
+	// QueueSize is the number of deliveries that can be pending, 1000 by
+	// default.
+	QueueSize int
+	// LogSize is the number of deliveries kept in the delivery log, 100 by
+	// default.
+	LogSize int
+}
+
+// WebhookDelivery records a delivery of an event to an endpoint.
+type WebhookDelivery struct {
+	ID        string    `json:"id"`
+	Event     string    `json:"event"`
+	URL       string    `json:"url"`
+	Attempts  int       `json:"attempts"`
+	Status    int       `json:"status,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Delivered bool      `json:"delivered"`
+	Time      time.Time `json:"time"`
+}
+
+// Webhooks dispatches events to webhook endpoints in the background.
+type Webhooks struct {
+	opts   WebhookOptions
+	logger *logger.Logger
+	queue  chan *webhookJob
+	ctx    context.Context // cancelled to abandon the pending deliveries
+	cancel context.CancelFunc
+	wg     sync.WaitGroup // the run loop and the pending retries
+
+	mu        sync.Mutex
+	endpoints []WebhookEndpoint
+	log       []WebhookDelivery
+	closed    bool
+}
+
+// webhookJob is a pending delivery.
+type webhookJob struct {
+	delivery WebhookDelivery
+	endpoint WebhookEndpoint
+	body     []byte
+	backoff  time.Duration // before the next retry
+}
+
+// Webhooks returns the router's webhook dispatcher, starting it with the
+// options on the first call. Its failed deliveries are listed by the admin
+// endpoints.
+func (r *Router) Webhooks(opts WebhookOptions) *Webhooks {
+	r.webhooksOnce.Do(func() {
+		if opts.Client == nil {
+			opts.Client = &http.Client{Timeout: 10 * time.Second}
+		}
+		if opts.MaxAttempts <= 0 {
+			opts.MaxAttempts = 5
+		}
+		if opts.Backoff <= 0 {
+			opts.Backoff = time.Second
+		}
+		if opts.QueueSize <= 0 {
+			opts.QueueSize = 1000
+		}
+		if opts.LogSize <= 0 {
+			opts.LogSize = 100
+		}
+		wh := &Webhooks{
+			opts:   opts,
+			logger: r.logger,
+			queue:  make(chan *webhookJob, opts.QueueSize),
+		}
+		wh.ctx, wh.cancel = context.WithCancel(context.Background())
+		wh.wg.Add(1)
+		go wh.run()
+		r.webhooks.Store(wh)
+	})
+	return r.webhooks.Load().(*Webhooks)
+}
+
+// AddEndpoint registers an endpoint.
+func (wh *Webhooks) AddEndpoint(endpoint WebhookEndpoint) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	wh.endpoints = append(wh.endpoints, endpoint)
+}
+
+// Send queues the event for delivery to the endpoints subscribed to it.
+// The payload is encoded as JSON. If the queue cannot take the deliveries to
+// all the endpoints, none is queued and an error is returned.
+func (wh *Webhooks) Send(event string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("router: encoding webhook payload: %v", err)
+	}
+
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	if wh.closed {
+		return fmt.Errorf("router: webhook dispatcher is closed")
+	}
+	var jobs []*webhookJob
+	for _, endpoint := range wh.endpoints {
+		if endpoint.subscribed(event) {
+			jobs = append(jobs, &webhookJob{
+				delivery: WebhookDelivery{ID: uuid.New().String(), Event: event, URL: endpoint.URL},
+				endpoint: endpoint,
+				body:     body,
+			})
+		}
+	}
+	// Only Send adds to the queue, under the lock, so the room cannot shrink
+	if len(jobs) > cap(wh.queue)-len(wh.queue) {
+		return fmt.Errorf("router: webhook queue is full")
+	}
+	for _, job := range jobs {
+		wh.queue <- job
+	}
+	return nil
+}
+
+// Deliveries returns the most recent deliveries, oldest first.
+func (wh *Webhooks) Deliveries() []WebhookDelivery {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	return append([]WebhookDelivery(nil), wh.log...)
+}
+
+// Failures returns the most recent deliveries that failed after all their
+// attempts, oldest first.
+func (wh *Webhooks) Failures() []WebhookDelivery {
+	failures := []WebhookDelivery{}
+	for _, d := range wh.Deliveries() {
+		if !d.Delivered {
+			failures = append(failures, d)
+		}
+	}
+	return failures
+}
+
+// Close stops accepting events and waits for the pending deliveries until
+// the context is done. Once it is done, the deliveries in progress are
+// cancelled and the queued deliveries and retries are abandoned.
+func (wh *Webhooks) Close(ctx context.Context) error {
+	wh.mu.Lock()
+	if !wh.closed {
+		wh.closed = true
+		close(wh.queue)
+	}
+	wh.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		wh.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		wh.cancel()
+		<-finished
+		return ctx.Err()
+	}
+}
+
+// subscribed reports whether the endpoint receives the event.
+func (endpoint WebhookEndpoint) subscribed(event string) bool {
+	if len(endpoint.Events) == 0 {
+		return true
+	}
+	for _, e := range endpoint.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// run makes the first attempt of the queued deliveries. Retries are
+// scheduled per delivery, so that failing endpoints do not hold up the
+// others.
+func (wh *Webhooks) run() {
+	defer wh.wg.Done()
+	for job := range wh.queue {
+		if wh.ctx.Err() != nil {
+			job.delivery.Error = "abandoned: dispatcher closed"
+			job.delivery.Time = time.Now()
+			wh.record(job.delivery)
+			continue
+		}
+		job.backoff = wh.opts.Backoff
+		wh.attempt(job)
+	}
+}
+
+// attempt makes a delivery attempt and schedules a retry if it failed and
+// attempts remain, or records the outcome in the delivery log.
+func (wh *Webhooks) attempt(job *webhookJob) {
+	d := &job.delivery
+	d.Attempts++
+	d.Time = time.Now()
+
+	status, err := wh.post(job)
+	d.Status = status
+	if err == nil {
+		d.Error = ""
+		d.Delivered = true
+		wh.record(*d)
+		return
+	}
+	d.Error = err.Error()
+
+	if d.Attempts >= wh.opts.MaxAttempts {
+		wh.logger.Errorf("Failed to deliver webhook %s to %s after %d attempts: %s", d.Event, d.URL, d.Attempts, d.Error)
+		wh.record(*d)
+		return
+	}
+	wh.wg.Add(1)
+	go wh.retry(job)
+}
+
+// retry makes the next attempt of a delivery after its backoff, doubling
+// the backoff, unless the dispatcher is closed in the meantime.
+func (wh *Webhooks) retry(job *webhookJob) {
+	defer wh.wg.Done()
+	timer := time.NewTimer(job.backoff)
+	select {
+	case <-timer.C:
+	case <-wh.ctx.Done():
+		timer.Stop()
+		job.delivery.Error = "abandoned: " + job.delivery.Error
+		wh.record(job.delivery)
+		return
+	}
+	job.backoff *= 2
+	wh.attempt(job)
+}
+
+// post makes a single delivery attempt.
+func (wh *Webhooks) post(job *webhookJob) (int, error) {
+	req, err := http.NewRequestWithContext(wh.ctx, "POST", job.endpoint.URL, bytes.NewReader(job.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-ID", job.delivery.ID)
+	req.Header.Set("X-Webhook-Event", job.delivery.Event)
+	if job.endpoint.Secret != "" {
+		req.Header.Set("X-Webhook-Signature", SignWebhook(job.endpoint.Secret, job.body))
+	}
+
+	resp, err := wh.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// record adds a delivery to the delivery log.
+func (wh *Webhooks) record(d WebhookDelivery) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	wh.log = append(wh.log, d)
+	if len(wh.log) > wh.opts.LogSize {
+		wh.log = wh.log[len(wh.log)-wh.opts.LogSize:]
+	}
+}
+
+// SignWebhook returns the signature of a webhook body as sent in the
+// X-Webhook-Signature header, so that receivers can verify deliveries.
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,202 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhooks(t *testing.T) {
+	var mu sync.Mutex
+	var received []*http.Request
+	var bodies [][]byte
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		mu.Lock()
+		received = append(received, req)
+		bodies = append(bodies, body)
+		mu.Unlock()
+	}))
+	defer ok.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	router := NewRouter()
+	router.EnableAdmin(AdminOptions{Prefix: "/admin", Authorize: func(req *http.Request) bool { return true }})
+
+	webhooks := router.Webhooks(WebhookOptions{MaxAttempts: 3, Backoff: time.Millisecond})
+	webhooks.AddEndpoint(WebhookEndpoint{URL: ok.URL, Secret: "s3cret", Events: []string{"user.created"}})
+	webhooks.AddEndpoint(WebhookEndpoint{URL: failing.URL})
+
+	if err := webhooks.Send("user.created", map[string]string{"id": "42"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := webhooks.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Signed delivery", func(t *testing.T) {
+		if len(received) != 1 {
+			t.Fatalf("Expected 1 delivery, but got %d", len(received))
+		}
+		req := received[0]
+		if req.Header.Get("X-Webhook-Event") != "user.created" {
+			t.Errorf("Expected event %q, but got %q", "user.created", req.Header.Get("X-Webhook-Event"))
+		}
+		expected := SignWebhook("s3cret", bodies[0])
+		if signature := req.Header.Get("X-Webhook-Signature"); signature != expected {
+			t.Errorf("Expected signature %q, but got %q", expected, signature)
+		}
+		if string(bodies[0]) != `{"id":"42"}` {
+			t.Errorf("Expected body %q, but got %q", `{"id":"42"}`, bodies[0])
+		}
+	})
+
+	t.Run("Failed delivery", func(t *testing.T) {
+		failures := webhooks.Failures()
+		if len(failures) != 1 {
+			t.Fatalf("Expected 1 failure, but got %d", len(failures))
+		}
+		if failures[0].Attempts != 3 || failures[0].Status != http.StatusInternalServerError {
+			t.Errorf("Expected 3 attempts with status 500, but got %+v", failures[0])
+		}
+		if len(webhooks.Deliveries()) != 2 {
+			t.Errorf("Expected 2 logged deliveries, but got %d", len(webhooks.Deliveries()))
+		}
+	})
+
+	t.Run("Admin failures", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/admin/webhooks/failures", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		var failures []WebhookDelivery
+		if err := json.Unmarshal(rr.Body.Bytes(), &failures); err != nil {
+			t.Fatal(err)
+		}
+		if len(failures) != 1 || failures[0].URL != failing.URL {
+			t.Errorf("Expected the failed delivery to %s, but got %+v", failing.URL, failures)
+		}
+	})
+
+	t.Run("Closed dispatcher", func(t *testing.T) {
+		if err := webhooks.Send("user.created", nil); err == nil {
+			t.Errorf("Expected an error sending to a closed dispatcher")
+		}
+	})
+}
+
+func TestWebhookRetries(t *testing.T) {
+	delivered := make(chan struct{}, 1)
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		delivered <- struct{}{}
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	router := NewRouter()
+	webhooks := router.Webhooks(WebhookOptions{MaxAttempts: 3, Backoff: time.Hour})
+	webhooks.AddEndpoint(WebhookEndpoint{URL: failing.URL, Events: []string{"down"}})
+	webhooks.AddEndpoint(WebhookEndpoint{URL: ok.URL, Events: []string{"up"}})
+
+	t.Run("Failing endpoint does not block others", func(t *testing.T) {
+		if err := webhooks.Send("down", nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := webhooks.Send("up", nil); err != nil {
+			t.Fatal(err)
+		}
+
+		// Check that the second event is delivered while the first waits to be retried
+		select {
+		case <-delivered:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the delivery not to wait for the retries of the failing endpoint")
+		}
+		waitFor(t, func() bool { return len(webhooks.Deliveries()) == 1 })
+	})
+
+	t.Run("Close twice", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// Check that retries are abandoned and closing again does not panic
+		if err := webhooks.Close(ctx); err != context.Canceled {
+			t.Errorf("Expected error %v, but got %v", context.Canceled, err)
+		}
+		if err := webhooks.Close(ctx); err != nil && err != context.Canceled {
+			t.Errorf("Expected no error or %v, but got %v", context.Canceled, err)
+		}
+		if failures := webhooks.Failures(); len(failures) != 1 {
+			t.Errorf("Expected 1 abandoned delivery, but got %+v", failures)
+		}
+	})
+}
+
+func TestWebhookShutdown(t *testing.T) {
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// The server notices the client is gone once the body is read
+		io.ReadAll(req.Body)
+		<-req.Context().Done()
+	}))
+	defer hanging.Close()
+
+	t.Run("Full queue", func(t *testing.T) {
+		router := NewRouter()
+		webhooks := router.Webhooks(WebhookOptions{QueueSize: 1})
+		webhooks.AddEndpoint(WebhookEndpoint{URL: hanging.URL})
+		webhooks.AddEndpoint(WebhookEndpoint{URL: hanging.URL})
+
+		// Check that no delivery is queued when not all of them fit
+		if err := webhooks.Send("user.created", nil); err == nil {
+			t.Errorf("Expected an error for a full queue")
+		}
+		if err := webhooks.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if deliveries := webhooks.Deliveries(); len(deliveries) != 0 {
+			t.Errorf("Expected no delivery, but got %+v", deliveries)
+		}
+	})
+
+	t.Run("Close cancels deliveries", func(t *testing.T) {
+		router := NewRouter()
+		webhooks := router.Webhooks(WebhookOptions{})
+		webhooks.AddEndpoint(WebhookEndpoint{URL: hanging.URL})
+		for i := 0; i < 2; i++ {
+			if err := webhooks.Send("user.created", nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		if err := webhooks.Close(ctx); err != context.DeadlineExceeded {
+			t.Errorf("Expected error %v, but got %v", context.DeadlineExceeded, err)
+		}
+
+		// Check that Close returned soon after the deadline
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected Close to return after the deadline, but it took %s", elapsed)
+		}
+		if failures := webhooks.Failures(); len(failures) != 2 {
+			t.Errorf("Expected 2 abandoned deliveries, but got %+v", failures)
+		}
+	})
+}